@@ -15,20 +15,35 @@ import (
 )
 
 type AdminController struct {
-	userService        service.UserService
-	applicationService service.ApplicationService
-	adminService       service.AdminService
+	userService         service.UserService
+	applicationService  service.ApplicationService
+	adminService        service.AdminService
+	packService         service.PackService
+	itemService         service.ItemService
+	shippingService     service.ShippingService
+	watermarkService    service.WatermarkService
+	notificationService service.NotificationService
 }
 
 func NewAdminController(
 	userService service.UserService,
 	applicationService service.ApplicationService,
 	adminService service.AdminService,
+	packService service.PackService,
+	itemService service.ItemService,
+	shippingService service.ShippingService,
+	watermarkService service.WatermarkService,
+	notificationService service.NotificationService,
 ) *AdminController {
 	return &AdminController{
-		userService:        userService,
-		applicationService: applicationService,
-		adminService:       adminService,
+		userService:         userService,
+		applicationService:  applicationService,
+		adminService:        adminService,
+		packService:         packService,
+		itemService:         itemService,
+		shippingService:     shippingService,
+		watermarkService:    watermarkService,
+		notificationService: notificationService,
 	}
 }
 
@@ -40,7 +55,24 @@ func (contr AdminController) Register(router *gin.Engine) {
 	router.PATCH("/admin/editFaq", contr.EditFaq)
 	router.DELETE("/admin/removeFaq", contr.RemoveFaq)
 	router.DELETE("/admin/removeCreator", contr.RemoveVendor)
+	router.POST("/admin/banUser", contr.BanUser)
+	router.GET("/admin/user/spendingLimit", contr.GetSpendingLimit)
+	router.PUT("/admin/user/spendingLimit", contr.SetSpendingLimit)
 	router.DELETE("/admin/cache/flush", contr.FlushCache)
+	router.GET("/admin/packs/submitted", contr.GetSubmittedPacks)
+	router.GET("/admin/pack/review/:id", contr.GetPackReview)
+	router.POST("/admin/pack/approve", contr.ApprovePack)
+	router.POST("/admin/pack/reject", contr.RejectPack)
+	router.GET("/admin/item/instance/:id", contr.GetItemInstance)
+	router.PUT("/admin/burnRate", contr.SetBurnRate)
+	router.POST("/admin/rarity", contr.CreateRarity)
+	router.PATCH("/admin/rarity/:id", contr.PatchRarity)
+	router.DELETE("/admin/rarity/:id", contr.DeleteRarity)
+	router.PUT("/admin/rarityOverride", contr.SetRarityOverride)
+	router.DELETE("/admin/rarityOverride", contr.DeleteRarityOverride)
+	router.GET("/admin/withdrawals", contr.GetWithdrawalQueue)
+	router.PATCH("/admin/withdrawal/status/:id", contr.UpdateWithdrawalStatus)
+	router.POST("/admin/watermark/identify", contr.IdentifyLeak)
 }
 
 // @Summary			Login as an admin
@@ -297,6 +329,127 @@ func (contr AdminController) RemoveVendor(c *gin.Context) {
 	return
 }
 
+// @Summary			Ban a user
+// @Description		Deactivates a user and removes all of their marketplace listings
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			uid query string true "uid of the user to ban"
+// @Accept			json
+// @Produce			json
+// @Tags			Admin
+// @Success			200 {} string
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/admin/banUser [POST]
+func (contr AdminController) BanUser(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	uid := c.Query("uid")
+	if uid == "" {
+		httputil.NewError(c, http.StatusBadRequest, &core.ErrorResp{Message: "a uid param must be present"})
+		return
+	}
+
+	if err := contr.adminService.BanUser(c.Request.Context(), uid); err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	core.AddLog(logrus.Fields{
+		"UID":      uid,
+		"AdminUid": authorizedUid,
+	}, c, db.LOG_USER_BAN)
+
+	c.JSON(http.StatusOK, "success")
+	return
+}
+
+// @Summary			Get a users spending limit
+// @Description		Get the daily token limit on packs bought by or gifted to a user. a null limit means no limit is set
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			uid query string true "uid of the user"
+// @Accept			json
+// @Produce			json
+// @Tags			Admin
+// @Success			200 {object} model.UserSpendingLimit
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/admin/user/spendingLimit [GET]
+func (contr AdminController) GetSpendingLimit(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	uid := c.Query("uid")
+	if uid == "" {
+		httputil.NewError(c, http.StatusBadRequest, &core.ErrorResp{Message: "a uid param must be present"})
+		return
+	}
+
+	spendingLimit, err := contr.userService.GetSpendingLimit(c.Request.Context(), uid)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, spendingLimit)
+	return
+}
+
+// @Summary			Set a users spending limit
+// @Description		Restricts the tokens a user can spend on packs, or have spent on packs gifted to them, in a day. users cannot change their own limit. a null limit removes it
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			uid query string true "uid of the user"
+// @Param			spendingLimit body model.UserSpendingLimit true "daily token limit"
+// @Accept			json
+// @Produce			json
+// @Tags			Admin
+// @Success			200 {object} model.UserSpendingLimit
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/admin/user/spendingLimit [PUT]
+func (contr AdminController) SetSpendingLimit(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	uid := c.Query("uid")
+	if uid == "" {
+		httputil.NewError(c, http.StatusBadRequest, &core.ErrorResp{Message: "a uid param must be present"})
+		return
+	}
+
+	spendingLimit := model.UserSpendingLimit{}
+	if err := c.BindJSON(&spendingLimit); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	updatedLimit, err := contr.userService.SetSpendingLimit(c.Request.Context(), uid, &spendingLimit)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	core.AddLog(logrus.Fields{
+		"UID":             uid,
+		"AdminUid":        authorizedUid,
+		"DailyTokenLimit": spendingLimit.DailyTokenLimit,
+	}, c, db.LOG_USER_SPENDING_LIMIT)
+
+	c.JSON(http.StatusOK, updatedLimit)
+	return
+}
+
 // @Summary			Add an faq
 // @Description		Adds an faq to the list of active faq
 // @Param			authorizedUid query string true "authorized uid"
@@ -426,3 +579,524 @@ func (contr AdminController) FlushCache(c *gin.Context) {
 	c.JSON(http.StatusOK, "success")
 	return
 }
+
+// @Summary			Get submitted packs
+// @Description		List the pack configs waiting for an admin review
+// @Param			authorizedUid query string true "authorized uid"
+// @Accept			json
+// @Produce			json
+// @Tags			Admin
+// @Success			200 {object} []model.PackConfig
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/admin/packs/submitted [GET]
+func (contr AdminController) GetSubmittedPacks(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	packConfigs, err := contr.packService.GetSubmittedPackConfigs(c.Request.Context())
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, packConfigs)
+	return
+}
+
+// @Summary			Inspect a pack for review
+// @Description		Get a pack config along with its items and odds for review
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			id path int true "pack config id"
+// @Accept			json
+// @Produce			json
+// @Tags			Admin
+// @Success			200 {object} model.PackReview
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/admin/pack/review/{id} [GET]
+func (contr AdminController) GetPackReview(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	packConfigId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	packReview, err := contr.packService.GetPackReview(c.Request.Context(), packConfigId, contr.itemService)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, packReview)
+	return
+}
+
+// @Summary			Inspect an item instance
+// @Description		Get a pulled item with its full ownership history, including uids and notes, for disputes
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			id path int true "user item id"
+// @Accept			json
+// @Produce			json
+// @Tags			Admin
+// @Success			200 {object} model.ItemInstance
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/admin/item/instance/{id} [GET]
+func (contr AdminController) GetItemInstance(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	userItemId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	itemInstance, err := contr.itemService.GetItemInstance(c.Request.Context(), userItemId, true)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, itemInstance)
+	return
+}
+
+// @Summary			Approve a pack
+// @Description		Approve a submitted pack config so the creator can activate it
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			packConfigId query int true "pack config id"
+// @Param			notes query string false "review notes"
+// @Accept			json
+// @Produce			json
+// @Tags			Admin
+// @Success			200 {object} model.PackConfig
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/admin/pack/approve [POST]
+func (contr AdminController) ApprovePack(c *gin.Context) {
+	contr.reviewPack(c, true)
+}
+
+// @Summary			Reject a pack
+// @Description		Reject a submitted pack config with notes for the creator
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			packConfigId query int true "pack config id"
+// @Param			notes query string true "review notes"
+// @Accept			json
+// @Produce			json
+// @Tags			Admin
+// @Success			200 {object} model.PackConfig
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/admin/pack/reject [POST]
+func (contr AdminController) RejectPack(c *gin.Context) {
+	contr.reviewPack(c, false)
+}
+
+func (contr AdminController) reviewPack(c *gin.Context, approve bool) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	packConfigId, err := strconv.ParseUint(c.Query("packConfigId"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	status := db.PACK_REVIEW_APPROVED
+	if !approve {
+		status = db.PACK_REVIEW_REJECTED
+	}
+
+	var notes *string
+	if rawNotes := c.Query("notes"); rawNotes != "" {
+		notes = &rawNotes
+	}
+
+	packConfig, err := contr.packService.ReviewPackConfig(c.Request.Context(), packConfigId, approve, notes, authorizedUid)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	// the pack has already been reviewed, so a failed notification is only printed
+	if err := contr.notificationService.NotifyPackReview(c.Request.Context(), packConfig); err != nil {
+		fmt.Println("Error could not notify creator of pack review: ", err)
+	}
+
+	// log pack review so the creator is emailed
+	vendor, err := contr.userService.GetUser(c.Request.Context(), *packConfig.VendorID, true)
+	if err != nil {
+		fmt.Println("Error could not get creator for pack review notification: ", err)
+	} else if vendor != nil && vendor.Uid != nil && vendor.Email != nil && vendor.Username != nil {
+		reviewLog := logrus.Fields{
+			"UID":          *vendor.Uid,
+			"AdminUid":     authorizedUid,
+			"Username":     *vendor.Username,
+			"Email":        *vendor.Email,
+			"PackConfigId": packConfigId,
+			"Status":       status,
+		}
+		if packConfig.Title != nil {
+			reviewLog["PackTitle"] = *packConfig.Title
+		}
+		if notes != nil {
+			reviewLog["Notes"] = *notes
+		}
+		core.AddLog(reviewLog, c, db.LOG_PACK_REVIEW_STATUS)
+	}
+
+	c.JSON(http.StatusOK, packConfig)
+	return
+}
+
+// @Summary			Set a platform burn rate
+// @Description		Sets what items of a rarity burn for when their creator has not set their own rate
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			rate body model.BurnRate true "rarity id, token rate and crafting rate"
+// @Accept			json
+// @Produce			json
+// @Tags			Admin
+// @Success			200 {object} model.BurnRate
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/admin/burnRate [PUT]
+func (contr AdminController) SetBurnRate(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	rate := model.BurnRate{}
+	if err := c.BindJSON(&rate); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	rate.VendorId = nil
+
+	updatedRate, err := contr.itemService.SetBurnRate(c.Request.Context(), &rate)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, updatedRate)
+	return
+}
+
+// @Summary			Create a rarity
+// @Description		Adds a rarity, its ranking places it among the others from most common to rarest
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			rarity body model.Rarity true "rarity name, ranking, label and color"
+// @Accept			json
+// @Produce			json
+// @Tags			Admin
+// @Success			201 {object} model.Rarity
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/admin/rarity [POST]
+func (contr AdminController) CreateRarity(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	rarity := model.Rarity{}
+	if err := c.BindJSON(&rarity); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	createdRarity, err := contr.itemService.CreateRarity(c.Request.Context(), &rarity)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusCreated, createdRarity)
+	return
+}
+
+// @Summary			Edit a rarity
+// @Description		Changes the name, ranking, label or color of a rarity
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			id path int true "rarity id"
+// @Param			rarity body model.Rarity true "the fields to change"
+// @Accept			json
+// @Produce			json
+// @Tags			Admin
+// @Success			200 {object} model.Rarity
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/admin/rarity/{id} [PATCH]
+func (contr AdminController) PatchRarity(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	rarityId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	rarityPatch := model.Rarity{}
+	if err := c.BindJSON(&rarityPatch); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	updatedRarity, err := contr.itemService.PatchRarity(c.Request.Context(), rarityId, &rarityPatch)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, updatedRarity)
+	return
+}
+
+// @Summary			Remove a rarity
+// @Description		Removes a rarity that no item uses anymore
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			id path int true "rarity id"
+// @Accept			json
+// @Produce			json
+// @Tags			Admin
+// @Success			200 {} string
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/admin/rarity/{id} [DELETE]
+func (contr AdminController) DeleteRarity(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	rarityId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := contr.itemService.DeleteRarity(c.Request.Context(), rarityId); err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, "success")
+	return
+}
+
+// @Summary			Set a rarity override for a creator
+// @Description		Sets the label and color a creators items of a rarity are shown with
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			override body model.RarityOverride true "vendor id, rarity id, label and color"
+// @Accept			json
+// @Produce			json
+// @Tags			Admin
+// @Success			200 {object} model.RarityOverride
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/admin/rarityOverride [PUT]
+func (contr AdminController) SetRarityOverride(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	override := model.RarityOverride{}
+	if err := c.BindJSON(&override); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	updatedOverride, err := contr.itemService.SetRarityOverride(c.Request.Context(), &override)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, updatedOverride)
+	return
+}
+
+// @Summary			Remove a rarity override for a creator
+// @Description		The creator goes back to showing the rarity with the platform label and color
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			vendorId query string true "vendor uid"
+// @Param			rarityId query int true "rarity id"
+// @Accept			json
+// @Produce			json
+// @Tags			Admin
+// @Success			200 {} string
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/admin/rarityOverride [DELETE]
+func (contr AdminController) DeleteRarityOverride(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	vendorId := c.Query("vendorId")
+	if vendorId == "" {
+		httputil.NewError(c, http.StatusBadRequest, &core.ErrorResp{Message: "vendorId param must be present"})
+		return
+	}
+
+	rarityId, err := strconv.ParseUint(c.Query("rarityId"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := contr.itemService.DeleteRarityOverride(c.Request.Context(), vendorId, rarityId); err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, "success")
+	return
+}
+
+// @Summary			Get the fulfillment queue
+// @Description		Get withdrawals across every creator, optionally filtered by status
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			status query string false "requested, packed, shipped, delivered or cancelled"
+// @Accept			json
+// @Produce			json
+// @Tags			Admin
+// @Success			200 {object} []model.ItemWithdrawalExpanded
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/admin/withdrawals [GET]
+func (contr AdminController) GetWithdrawalQueue(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	withdrawals, err := contr.shippingService.GetWithdrawalQueue(c.Request.Context(), "", c.Query("status"))
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, withdrawals)
+	return
+}
+
+// @Summary			Update a withdrawal status
+// @Description		Move any withdrawal along its fulfillment statuses, shipping needs a carrier and tracking number
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			id path int true "withdrawal id"
+// @Param			status body model.WithdrawalStatusReq true "status, carrier and tracking number"
+// @Accept			json
+// @Produce			json
+// @Tags			Admin
+// @Success			200 {object} model.ItemWithdrawal
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/admin/withdrawal/status/{id} [PATCH]
+func (contr AdminController) UpdateWithdrawalStatus(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	withdrawalId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	req := model.WithdrawalStatusReq{}
+	if err := c.BindJSON(&req); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	withdrawal, err := contr.shippingService.UpdateWithdrawalStatus(c.Request.Context(), withdrawalId, "", "", authorizedUid, &req)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	logWithdrawalStatus(c, authorizedUid, withdrawal)
+	c.JSON(http.StatusOK, withdrawal)
+	return
+}
+
+// @Summary			Identify a leaked image
+// @Description		Read the forensic watermark out of a leaked item image and find the user it was issued to. the image has to be at the size it was served at
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			image formData file true "leaked image"
+// @Accept			multipart/form-data
+// @Produce			json
+// @Tags			Admin
+// @Success			200 {object} model.ContentWatermark
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/admin/watermark/identify [POST]
+func (contr AdminController) IdentifyLeak(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	image, err := c.FormFile("image")
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	watermark, err := contr.watermarkService.IdentifyLeak(c.Request.Context(), image)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	// log the lookup
+	core.AddLog(logrus.Fields{
+		"AdminUid":    authorizedUid,
+		"WatermarkId": *watermark.ID,
+		"UID":         *watermark.Uid,
+		"ItemId":      *watermark.ItemId,
+	}, c, db.LOG_WATERMARK_IDENTIFY)
+
+	c.JSON(http.StatusOK, watermark)
+	return
+}
@@ -30,6 +30,8 @@ func (contr AnalyticsController) Register(router *gin.Engine) {
 	router.GET("/analytics/customers/:vendorId", contr.TopCustomers)
 	router.GET("/analytics/packSales/:vendorId", contr.PackSales)
 	router.GET("/analytics/packQtySold/:vendorId", contr.PackQtySold)
+	router.GET("/analytics/packWaitlist/:vendorId", contr.PackWaitlistSize)
+	router.GET("/analytics/buybacks/:vendorId", contr.ItemBuybacks)
 }
 
 // @Summary			Get total vendor packs sold
@@ -311,3 +313,55 @@ func (contr AnalyticsController) PackQtySold(c *gin.Context) {
 	return
 
 }
+
+// @Summary			Get pack waitlist sizes
+// @Description		Get the amount of users waiting on each sold out or upcoming pack of this vendor
+// @Param			vendorId path string true "vendor uid"
+// @Accept			json
+// @Produce			json
+// @Tags			Analytics
+// @Success			200 {object} []model.PackWaitlistAnalytic
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/analytics/packWaitlist/:vendorId [get]
+func (contr AnalyticsController) PackWaitlistSize(c *gin.Context) {
+	vendorId := c.Param("vendorId")
+	if vendorId == "" {
+		httputil.NewError(c, http.StatusBadRequest, &core.ErrorResp{Message: "vendorId param must be set"})
+		return
+	}
+
+	packWaitlists, err := contr.analyticsService.PackWaitlistSize(c.Request.Context(), vendorId)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, packWaitlists)
+	return
+}
+
+// @Summary			Get item buybacks
+// @Description		Get how many of each of this vendors items were sold back to the platform and the tokens paid for them
+// @Param			vendorId path string true "vendor uid"
+// @Accept			json
+// @Produce			json
+// @Tags			Analytics
+// @Success			200 {object} []model.ItemBuybackAnalytic
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/analytics/buybacks/:vendorId [get]
+func (contr AnalyticsController) ItemBuybacks(c *gin.Context) {
+	vendorId := c.Param("vendorId")
+	if vendorId == "" {
+		httputil.NewError(c, http.StatusBadRequest, &core.ErrorResp{Message: "vendorId param must be set"})
+		return
+	}
+
+	itemBuybacks, err := contr.analyticsService.ItemBuybacks(c.Request.Context(), vendorId)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, itemBuybacks)
+	return
+}
@@ -0,0 +1,208 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"xo-packs/core"
+	"xo-packs/db"
+	"xo-packs/model"
+	"xo-packs/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/swaggo/swag/example/celler/httputil"
+)
+
+type AuctionController struct {
+	auctionService service.AuctionService
+	tokenService   service.TokenService
+}
+
+func NewAuctionController(auctionService service.AuctionService, tokenService service.TokenService) *AuctionController {
+	return &AuctionController{auctionService: auctionService, tokenService: tokenService}
+}
+
+func (contr AuctionController) Register(router *gin.Engine) {
+	router.GET("/auctions", contr.GetAuctions)
+	router.GET("/auction/:id", contr.GetAuction)
+	router.POST("/auction", contr.CreateAuction)
+	router.DELETE("/auction/:id", contr.CancelAuction)
+	router.POST("/auction/bid/:id", contr.PlaceBid)
+}
+
+// @Summary			Get auctions
+// @Description		Get auctions by status, soonest ending first. the reserve price is only shown to the creator
+// @Param			vendorId query string false "creator uid"
+// @Param			status query string false "active (default), sold, unsold or cancelled"
+// @Param			authorizedUid query string false "authorized uid"
+// @Accept			json
+// @Produce			json
+// @Tags			Auction
+// @Success			200 {object} []model.AuctionExpanded
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/auctions [GET]
+func (contr AuctionController) GetAuctions(c *gin.Context) {
+	auctions, err := contr.auctionService.GetAuctions(c.Request.Context(), c.Query("vendorId"), c.Query("status"), c.Query("authorizedUid"))
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, auctions)
+	return
+}
+
+// @Summary			Get an auction
+// @Description		Get an auction along with its bids. the reserve price is only shown to the creator
+// @Param			id path int true "auction id"
+// @Param			authorizedUid query string false "authorized uid"
+// @Accept			json
+// @Produce			json
+// @Tags			Auction
+// @Success			200 {object} model.AuctionExpanded
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/auction/:id [GET]
+func (contr AuctionController) GetAuction(c *gin.Context) {
+	auctionId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	auction, err := contr.auctionService.GetAuction(c.Request.Context(), auctionId, c.Query("authorizedUid"))
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, auction)
+	return
+}
+
+// @Summary			Create an auction
+// @Description		A creator auctions one of their items outside of packs with a start price, an optional reserve and an end time. the winner is minted the item when the auction closes
+// @Param			vendorId query string true "vendor uid"
+// @Param			auction body model.CreateAuctionReq true "item id, start and reserve price, RFC3339 start and end time"
+// @Accept			json
+// @Produce			json
+// @Tags			Auction
+// @Success			200 {object} model.AuctionExpanded
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/auction [POST]
+func (contr AuctionController) CreateAuction(c *gin.Context) {
+	vendorId := c.Query("vendorId")
+	if vendorId == "" {
+		httputil.NewError(c, http.StatusBadRequest, &core.ErrorResp{Message: "vendorId param must be present"})
+		return
+	}
+
+	authorizedUid := c.Query("authorizedUid")
+	if vendorId != authorizedUid {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "user is not authorized to perform this action"})
+		return
+	}
+
+	req := model.CreateAuctionReq{}
+	if err := c.BindJSON(&req); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	auction, err := contr.auctionService.CreateAuction(c.Request.Context(), vendorId, &req)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, auction)
+	return
+}
+
+// @Summary			Cancel an auction
+// @Description		A creator calls off one of their auctions before anyone has bid on it
+// @Param			vendorId query string true "vendor uid"
+// @Param			id path int true "auction id"
+// @Accept			json
+// @Produce			json
+// @Tags			Auction
+// @Success			200
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/auction/:id [DELETE]
+func (contr AuctionController) CancelAuction(c *gin.Context) {
+	vendorId := c.Query("vendorId")
+	if vendorId == "" {
+		httputil.NewError(c, http.StatusBadRequest, &core.ErrorResp{Message: "vendorId param must be present"})
+		return
+	}
+
+	authorizedUid := c.Query("authorizedUid")
+	if vendorId != authorizedUid {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "user is not authorized to perform this action"})
+		return
+	}
+
+	auctionId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := contr.auctionService.CancelAuction(c.Request.Context(), auctionId, vendorId); err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, "success")
+	return
+}
+
+// @Summary			Bid on an auction
+// @Description		Place a token bid. the bid is held from the users balance until they are outbid, when it is refunded, or win. bids late in an auction extend its end
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			id path int true "auction id"
+// @Param			bid body model.AuctionBidReq true "bid token amount"
+// @Accept			json
+// @Produce			json
+// @Tags			Auction
+// @Success			200 {object} model.AuctionBidResp
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/auction/bid/:id [POST]
+func (contr AuctionController) PlaceBid(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	auctionId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	req := model.AuctionBidReq{}
+	if err := c.BindJSON(&req); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := contr.auctionService.PlaceBid(c.Request.Context(), auctionId, authorizedUid, &req, contr.tokenService)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	// log the bid
+	core.AddLog(logrus.Fields{
+		"UID":         authorizedUid,
+		"AuctionId":   auctionId,
+		"TokenAmount": *resp.Bid.TokenAmount,
+		"EndsAt":      resp.EndsAt,
+	}, c, db.LOG_AUCTION_BID)
+
+	c.JSON(http.StatusOK, resp)
+	return
+}
@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"net/http"
+	"xo-packs/core"
+	"xo-packs/db"
+	"xo-packs/model"
+	"xo-packs/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/swaggo/swag/example/celler/httputil"
+)
+
+type BuybackController struct {
+	buybackService service.BuybackService
+	itemService    service.ItemService
+	tokenService   service.TokenService
+}
+
+func NewBuybackController(buybackService service.BuybackService, itemService service.ItemService, tokenService service.TokenService) *BuybackController {
+	return &BuybackController{buybackService: buybackService, itemService: itemService, tokenService: tokenService}
+}
+
+func (contr BuybackController) Register(router *gin.Engine) {
+	router.POST("/buyback", contr.BuybackUserItems)
+	router.GET("/buyback/allowance", contr.GetAllowance)
+	router.GET("/buyback/setting", contr.GetSetting)
+	router.PUT("/buyback/setting", contr.SetSetting)
+}
+
+// @Summary			Sell items back
+// @Description		Sell items from the users collection back to the platform for a percentage of their value in tokens. only items of creators that accept buybacks can be sold back and payouts are capped per day
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			buyback body model.BuybackReq true "user item ids"
+// @Accept			json
+// @Produce			json
+// @Tags			Buyback
+// @Success			200 {object} model.BuybackResp
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/buyback [POST]
+func (contr BuybackController) BuybackUserItems(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	req := model.BuybackReq{}
+	if err := c.BindJSON(&req); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := contr.buybackService.BuybackUserItems(c.Request.Context(), authorizedUid, &req, contr.itemService, contr.tokenService)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	// log the buyback
+	core.AddLog(logrus.Fields{
+		"UID":         authorizedUid,
+		"UserItemIds": resp.BoughtBackUserItemIds,
+		"Amount":      resp.Amount,
+	}, c, db.LOG_ITEM_BUYBACK)
+
+	c.JSON(http.StatusOK, resp)
+	return
+}
+
+// @Summary			Get buyback allowance
+// @Description		Get the buyback percentage along with how many tokens the user can still be paid for buybacks today
+// @Param			authorizedUid query string true "authorized uid"
+// @Accept			json
+// @Produce			json
+// @Tags			Buyback
+// @Success			200 {object} model.BuybackAllowance
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/buyback/allowance [GET]
+func (contr BuybackController) GetAllowance(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	allowance, err := contr.buybackService.GetAllowance(c.Request.Context(), authorizedUid)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, allowance)
+	return
+}
+
+// @Summary			Get buyback setting
+// @Description		Get whether a creator accepts buybacks of their items
+// @Param			vendorId query string true "vendor uid"
+// @Accept			json
+// @Produce			json
+// @Tags			Buyback
+// @Success			200 {object} model.BuybackSetting
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/buyback/setting [GET]
+func (contr BuybackController) GetSetting(c *gin.Context) {
+	vendorId := c.Query("vendorId")
+	if vendorId == "" {
+		httputil.NewError(c, http.StatusBadRequest, &core.ErrorResp{Message: "vendorId param must be present"})
+		return
+	}
+
+	setting, err := contr.buybackService.GetSetting(c.Request.Context(), vendorId)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, setting)
+	return
+}
+
+// @Summary			Set buyback setting
+// @Description		A creator opts their items in or out of buybacks
+// @Param			vendorId query string true "vendor uid"
+// @Param			setting body model.BuybackSettingReq true "whether buybacks are accepted"
+// @Accept			json
+// @Produce			json
+// @Tags			Buyback
+// @Success			200 {object} model.BuybackSetting
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/buyback/setting [PUT]
+func (contr BuybackController) SetSetting(c *gin.Context) {
+	vendorId := c.Query("vendorId")
+	if vendorId == "" {
+		httputil.NewError(c, http.StatusBadRequest, &core.ErrorResp{Message: "vendorId param must be present"})
+		return
+	}
+
+	authorizedUid := c.Query("authorizedUid")
+	if vendorId != authorizedUid {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "user is not authorized to perform this action"})
+		return
+	}
+
+	req := model.BuybackSettingReq{}
+	if err := c.BindJSON(&req); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	setting, err := contr.buybackService.SetSetting(c.Request.Context(), vendorId, &req)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, setting)
+	return
+}
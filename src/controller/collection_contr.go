@@ -0,0 +1,236 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"xo-packs/core"
+	"xo-packs/db"
+	"xo-packs/model"
+	"xo-packs/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/swaggo/swag/example/celler/httputil"
+)
+
+type CollectionController struct {
+	collectionService service.CollectionService
+}
+
+func NewCollectionController(collectionService service.CollectionService) *CollectionController {
+	return &CollectionController{collectionService: collectionService}
+}
+
+func (contr CollectionController) Register(router *gin.Engine) {
+	router.GET("/collection/sets", contr.GetSets)
+	router.GET("/collection/set/:id", contr.GetSet)
+	router.POST("/collection/set", contr.CreateSet)
+	router.DELETE("/collection/set/:id", contr.DeleteSet)
+	router.GET("/collection/set/progress/:id", contr.GetSetProgress)
+	router.GET("/collection/completions", contr.GetUserSetCompletions)
+}
+
+// @Summary 		Get collection sets
+// @Description 	Get the active sets along with their items and rewards
+// @Param			vendorId query string false "creator uid"
+// @Tags 			Collection
+// @Accept 			json
+// @Produce 		json
+// @Success 		200 {object} []model.CollectionSetExpanded
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/collection/sets [GET]
+func (contr CollectionController) GetSets(c *gin.Context) {
+	sets, err := contr.collectionService.GetSets(c.Request.Context(), c.Query("vendorId"), c.Request.URL.String())
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, sets)
+	return
+}
+
+// @Summary 		Get a collection set
+// @Description 	Get a set along with its items and rewards
+// @Param			id path int true "set id"
+// @Tags 			Collection
+// @Accept 			json
+// @Produce 		json
+// @Success 		200 {object} model.CollectionSetExpanded
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/collection/set/{id} [GET]
+func (contr CollectionController) GetSet(c *gin.Context) {
+	setId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	set, err := contr.collectionService.GetSet(c.Request.Context(), setId)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, set)
+	return
+}
+
+// @Summary 		Create a collection set
+// @Description 	A creator groups their items into a set with optional bonus tokens, exclusive item and badge for completing it
+// @Param			vendorId query string true "vendor uid"
+// @Param			set body model.CollectionSetReq true "set"
+// @Tags 			Collection
+// @Accept 			json
+// @Produce 		json
+// @Success 		201 {object} model.CollectionSetExpanded
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/collection/set [POST]
+func (contr CollectionController) CreateSet(c *gin.Context) {
+	vendorId := c.Query("vendorId")
+	if vendorId == "" {
+		httputil.NewError(c, http.StatusBadRequest, &core.ErrorResp{Message: "vendorId param must be present"})
+		return
+	}
+
+	authorizedUid := c.Query("authorizedUid")
+	if vendorId != authorizedUid {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "user is not authorized to perform this action"})
+		return
+	}
+
+	req := model.CollectionSetReq{}
+	if err := c.BindJSON(&req); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	set, err := contr.collectionService.CreateSet(c.Request.Context(), vendorId, &req)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusCreated, set)
+	return
+}
+
+// @Summary 		Delete a collection set
+// @Description 	A creator takes down one of their sets, completions already earned are kept
+// @Param			vendorId query string true "vendor uid"
+// @Param			id path int true "set id"
+// @Tags 			Collection
+// @Accept 			json
+// @Produce 		json
+// @Success 		200 {} string
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/collection/set/{id} [DELETE]
+func (contr CollectionController) DeleteSet(c *gin.Context) {
+	vendorId := c.Query("vendorId")
+	if vendorId == "" {
+		httputil.NewError(c, http.StatusBadRequest, &core.ErrorResp{Message: "vendorId param must be present"})
+		return
+	}
+
+	authorizedUid := c.Query("authorizedUid")
+	if vendorId != authorizedUid {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "user is not authorized to perform this action"})
+		return
+	}
+
+	setId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := contr.collectionService.DeleteSet(c.Request.Context(), setId, vendorId); err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, "success")
+	return
+}
+
+// @Summary 		Get set progress
+// @Description 	Get a set with each item flagged by whether the user holds it, and whether the user has completed it
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			id path int true "set id"
+// @Tags 			Collection
+// @Accept 			json
+// @Produce 		json
+// @Success 		200 {object} model.CollectionSetProgress
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/collection/set/progress/{id} [GET]
+func (contr CollectionController) GetSetProgress(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	setId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	progress, err := contr.collectionService.GetSetProgress(c.Request.Context(), setId, authorizedUid)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, progress)
+	return
+}
+
+// @Summary 		Get completed sets
+// @Description 	Get the sets the user has completed along with the rewards and badges earned
+// @Param			authorizedUid query string true "authorized uid"
+// @Tags 			Collection
+// @Accept 			json
+// @Produce 		json
+// @Success 		200 {object} []model.UserSetCompletionExpanded
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/collection/completions [GET]
+func (contr CollectionController) GetUserSetCompletions(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	completions, err := contr.collectionService.GetUserSetCompletions(c.Request.Context(), authorizedUid)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, completions)
+	return
+}
+
+// checks whether items just gained completed any sets for the user. runs after the items have already changed
+// hands so a failure is only printed and never fails the request
+func completeSets(c *gin.Context, collectionService service.CollectionService, uid string, itemService service.ItemService, tokenService service.TokenService) {
+	completions, err := collectionService.CompleteSets(c.Request.Context(), uid, itemService, tokenService)
+	if err != nil {
+		fmt.Println("unable to check set completions: ", err)
+		return
+	}
+
+	for _, completion := range completions {
+		core.AddLog(logrus.Fields{
+			"UID":               uid,
+			"SetId":             *completion.SetId,
+			"VendorId":          *completion.VendorId,
+			"RewardTokenAmount": *completion.RewardTokenAmount,
+			"RewardUserItemId":  completion.RewardUserItemId,
+		}, c, db.LOG_SET_COMPLETED)
+	}
+}
@@ -0,0 +1,210 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"xo-packs/core"
+	"xo-packs/db"
+	"xo-packs/model"
+	"xo-packs/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/swaggo/swag/example/celler/httputil"
+)
+
+type CraftingController struct {
+	craftingService   service.CraftingService
+	itemService       service.ItemService
+	tokenService      service.TokenService
+	collectionService service.CollectionService
+}
+
+func NewCraftingController(craftingService service.CraftingService, itemService service.ItemService, tokenService service.TokenService, collectionService service.CollectionService) *CraftingController {
+	return &CraftingController{craftingService: craftingService, itemService: itemService, tokenService: tokenService, collectionService: collectionService}
+}
+
+func (contr CraftingController) Register(router *gin.Engine) {
+	router.GET("/crafting/recipes", contr.GetRecipes)
+	router.GET("/crafting/recipe/:id", contr.GetRecipe)
+	router.POST("/crafting/recipe", contr.CreateRecipe)
+	router.DELETE("/crafting/recipe/:id", contr.DeleteRecipe)
+	router.POST("/crafting/recipe/craft/:id", contr.Craft)
+}
+
+// @Summary 		Get crafting recipes
+// @Description 	Get the recipes that are live or upcoming along with their inputs and outcome odds
+// @Param			vendorId query string false "creator uid"
+// @Tags 			Crafting
+// @Accept 			json
+// @Produce 		json
+// @Success 		200 {object} []model.CraftingRecipeExpanded
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/crafting/recipes [GET]
+func (contr CraftingController) GetRecipes(c *gin.Context) {
+	recipes, err := contr.craftingService.GetRecipes(c.Request.Context(), c.Query("vendorId"), c.Request.URL.String())
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, recipes)
+	return
+}
+
+// @Summary 		Get a crafting recipe
+// @Description 	Get a recipe along with its inputs and outcome odds
+// @Param			id path int true "recipe id"
+// @Tags 			Crafting
+// @Accept 			json
+// @Produce 		json
+// @Success 		200 {object} model.CraftingRecipeExpanded
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/crafting/recipe/{id} [GET]
+func (contr CraftingController) GetRecipe(c *gin.Context) {
+	recipeId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	recipe, err := contr.craftingService.GetRecipe(c.Request.Context(), recipeId)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, recipe)
+	return
+}
+
+// @Summary 		Create a crafting recipe
+// @Description 	A creator defines which of their items combine into which outcomes, with optional availability window, per user limit and crafting cost
+// @Param			vendorId query string true "vendor uid"
+// @Param			recipe body model.CraftingRecipeReq true "recipe"
+// @Tags 			Crafting
+// @Accept 			json
+// @Produce 		json
+// @Success 		201 {object} model.CraftingRecipeExpanded
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/crafting/recipe [POST]
+func (contr CraftingController) CreateRecipe(c *gin.Context) {
+	vendorId := c.Query("vendorId")
+	if vendorId == "" {
+		httputil.NewError(c, http.StatusBadRequest, &core.ErrorResp{Message: "vendorId param must be present"})
+		return
+	}
+
+	authorizedUid := c.Query("authorizedUid")
+	if vendorId != authorizedUid {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "user is not authorized to perform this action"})
+		return
+	}
+
+	req := model.CraftingRecipeReq{}
+	if err := c.BindJSON(&req); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	recipe, err := contr.craftingService.CreateRecipe(c.Request.Context(), vendorId, &req)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusCreated, recipe)
+	return
+}
+
+// @Summary 		Delete a crafting recipe
+// @Description 	A creator takes down one of their recipes
+// @Param			vendorId query string true "vendor uid"
+// @Param			id path int true "recipe id"
+// @Tags 			Crafting
+// @Accept 			json
+// @Produce 		json
+// @Success 		200 {} string
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/crafting/recipe/{id} [DELETE]
+func (contr CraftingController) DeleteRecipe(c *gin.Context) {
+	vendorId := c.Query("vendorId")
+	if vendorId == "" {
+		httputil.NewError(c, http.StatusBadRequest, &core.ErrorResp{Message: "vendorId param must be present"})
+		return
+	}
+
+	authorizedUid := c.Query("authorizedUid")
+	if vendorId != authorizedUid {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "user is not authorized to perform this action"})
+		return
+	}
+
+	recipeId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := contr.craftingService.DeleteRecipe(c.Request.Context(), recipeId, vendorId); err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, "success")
+	return
+}
+
+// @Summary 		Craft a recipe
+// @Description 	Submit items from the users collection to a recipe, they are consumed and a rolled outcome item is granted
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			id path int true "recipe id"
+// @Param			craft body model.CraftReq true "user item ids"
+// @Tags 			Crafting
+// @Accept 			json
+// @Produce 		json
+// @Success 		200 {object} model.CraftResp
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/crafting/recipe/craft/{id} [POST]
+func (contr CraftingController) Craft(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	recipeId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	req := model.CraftReq{}
+	if err := c.BindJSON(&req); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := contr.craftingService.Craft(c.Request.Context(), recipeId, authorizedUid, &req, contr.itemService)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	// log the craft
+	core.AddLog(logrus.Fields{
+		"UID":                 authorizedUid,
+		"RecipeId":            recipeId,
+		"ConsumedUserItemIds": resp.ConsumedUserItemIds,
+		"UserItemId":          *resp.UserItemId,
+		"ItemId":              *resp.ItemId,
+		"CraftingCost":        resp.CraftingCost,
+	}, c, db.LOG_ITEM_CRAFT)
+	completeSets(c, contr.collectionService, authorizedUid, contr.itemService, contr.tokenService)
+
+	c.JSON(http.StatusOK, resp)
+	return
+}
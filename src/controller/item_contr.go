@@ -5,10 +5,12 @@ import (
 	"strconv"
 	"strings"
 	"xo-packs/core"
+	"xo-packs/db"
 	"xo-packs/model"
 	"xo-packs/service"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 	"github.com/swaggo/swag/example/celler/httputil"
 )
 
@@ -16,19 +18,29 @@ type ItemController struct {
 	itemService   service.ItemService
 	vendorService service.VendorService
 	packService   service.PackService
+	tokenService  service.TokenService
 }
 
-func NewItemController(itemService service.ItemService, vendorService service.VendorService, packService service.PackService) *ItemController {
-	return &ItemController{itemService: itemService, vendorService: vendorService, packService: packService}
+func NewItemController(itemService service.ItemService, vendorService service.VendorService, packService service.PackService, tokenService service.TokenService) *ItemController {
+	return &ItemController{itemService: itemService, vendorService: vendorService, packService: packService, tokenService: tokenService}
 }
 
 func (contr ItemController) Register(router *gin.Engine) {
 	router.POST("/item", contr.CreateItem)
 	router.POST("/item/categories", contr.AddItemCategories)
 	router.GET("/item/:id", contr.GetItem)
+	router.GET("/item/instance/:id", contr.GetItemInstance)
 	router.PATCH("/item/:id", contr.PatchItem)
 	router.DELETE("/items/user", contr.DeleteUserItems)
 	router.DELETE("/items", contr.DeleteItems)
+	router.POST("/items/user/burn", contr.BurnUserItems)
+	router.GET("/items/burnRates", contr.GetBurnRates)
+	router.PUT("/items/burnRate", contr.SetVendorBurnRate)
+	router.GET("/items/rarities", contr.GetRarities)
+	router.PUT("/items/rarityOverride", contr.SetVendorRarityOverride)
+	router.DELETE("/items/rarityOverride", contr.DeleteVendorRarityOverride)
+	router.GET("/items/crafting/balance", contr.GetCraftingBalance)
+	router.GET("/items/user/expiring", contr.GetExpiringUserItems)
 }
 
 type ErrorResponse struct {
@@ -146,6 +158,33 @@ func (contr ItemController) GetItem(c *gin.Context) {
 	return
 }
 
+// @Summary			Get an item instance
+// @Description		Retrieve a pulled item along with its serial number and ownership history
+// @Param			id path int true "user item id"
+// @Accept 			json
+// @Produce			json
+// @Tags			Item
+// @Success			200 {object} model.ItemInstance
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/item/instance/{id} [GET]
+func (contr ItemController) GetItemInstance(c *gin.Context) {
+	userItemId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	itemInstance, err := contr.itemService.GetItemInstance(c.Request.Context(), userItemId, false)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, itemInstance)
+	return
+}
+
 // @Summary			Update an item
 // @Description		Update an item with new patch
 // @Param			itemPatch body model.Item true "item patch to update"
@@ -270,3 +309,280 @@ func (contr ItemController) DeleteItems(c *gin.Context) {
 	c.JSON(http.StatusOK, itemIds)
 	return
 }
+
+// @Summary			Burn user items
+// @Description		Destroy items from the users collection in exchange for tokens or crafting currency
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			burn body model.BurnItemsReq true "user item ids and payout currency, tokens or crafting"
+// @Accept			json
+// @Produce			json
+// @Tags			Item
+// @Success			200 {object} model.BurnItemsResp
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/items/user/burn [POST]
+func (contr ItemController) BurnUserItems(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	req := model.BurnItemsReq{}
+	if err := c.BindJSON(&req); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := contr.itemService.BurnUserItems(c.Request.Context(), authorizedUid, &req, contr.tokenService)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	// log the burn
+	core.AddLog(logrus.Fields{
+		"UID":         authorizedUid,
+		"UserItemIds": resp.BurnedUserItemIds,
+		"Currency":    resp.Currency,
+		"Amount":      resp.Amount,
+	}, c, db.LOG_ITEM_BURN)
+
+	c.JSON(http.StatusOK, resp)
+	return
+}
+
+// @Summary			Get burn rates
+// @Description		Get the platform and creator burn rates for each rarity
+// @Accept			json
+// @Produce			json
+// @Tags			Item
+// @Success			200 {object} []model.BurnRate
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/items/burnRates [GET]
+func (contr ItemController) GetBurnRates(c *gin.Context) {
+	rates, err := contr.itemService.GetBurnRates(c.Request.Context())
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, rates)
+	return
+}
+
+// @Summary			Set a creator burn rate
+// @Description		A creator sets what their items of a rarity burn for, overriding the platform rate
+// @Param			vendorId query string true "vendor uid"
+// @Param			rate body model.BurnRate true "rarity id, token rate and crafting rate"
+// @Accept			json
+// @Produce			json
+// @Tags			Item
+// @Success			200 {object} model.BurnRate
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/items/burnRate [PUT]
+func (contr ItemController) SetVendorBurnRate(c *gin.Context) {
+	vendorId := c.Query("vendorId")
+	if vendorId == "" {
+		httputil.NewError(c, http.StatusBadRequest, &core.ErrorResp{
+			Message: "vendorId param must be present",
+		})
+		return
+	}
+
+	authorizedUid := c.Query("authorizedUid")
+	if vendorId != authorizedUid {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{
+			Message: "user is not authorized to perform this action",
+		})
+		return
+	}
+
+	rate := model.BurnRate{}
+	if err := c.BindJSON(&rate); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	rate.VendorId = &vendorId
+
+	updatedRate, err := contr.itemService.SetBurnRate(c.Request.Context(), &rate)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, updatedRate)
+	return
+}
+
+// @Summary			Get rarities
+// @Description		Get the rarities from most common to rarest, labelled the way a creator shows them when a vendor id is given
+// @Param			vendorId query string false "vendor uid"
+// @Accept			json
+// @Produce			json
+// @Tags			Item
+// @Success			200 {object} []model.Rarity
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/items/rarities [GET]
+func (contr ItemController) GetRarities(c *gin.Context) {
+	rarities, err := contr.itemService.GetRarities(c.Request.Context(), c.Query("vendorId"))
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, rarities)
+	return
+}
+
+// @Summary			Set a creator rarity override
+// @Description		A creator sets the label and color their items of a rarity are shown with
+// @Param			vendorId query string true "vendor uid"
+// @Param			override body model.RarityOverride true "rarity id, label and color"
+// @Accept			json
+// @Produce			json
+// @Tags			Item
+// @Success			200 {object} model.RarityOverride
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/items/rarityOverride [PUT]
+func (contr ItemController) SetVendorRarityOverride(c *gin.Context) {
+	vendorId := c.Query("vendorId")
+	if vendorId == "" {
+		httputil.NewError(c, http.StatusBadRequest, &core.ErrorResp{
+			Message: "vendorId param must be present",
+		})
+		return
+	}
+
+	authorizedUid := c.Query("authorizedUid")
+	if vendorId != authorizedUid {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{
+			Message: "user is not authorized to perform this action",
+		})
+		return
+	}
+
+	override := model.RarityOverride{}
+	if err := c.BindJSON(&override); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	override.VendorId = &vendorId
+
+	updatedOverride, err := contr.itemService.SetRarityOverride(c.Request.Context(), &override)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, updatedOverride)
+	return
+}
+
+// @Summary			Remove a creator rarity override
+// @Description		A creator goes back to showing a rarity with the platform label and color
+// @Param			vendorId query string true "vendor uid"
+// @Param			rarityId query int true "rarity id"
+// @Accept			json
+// @Produce			json
+// @Tags			Item
+// @Success			200 {} string
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/items/rarityOverride [DELETE]
+func (contr ItemController) DeleteVendorRarityOverride(c *gin.Context) {
+	vendorId := c.Query("vendorId")
+	if vendorId == "" {
+		httputil.NewError(c, http.StatusBadRequest, &core.ErrorResp{
+			Message: "vendorId param must be present",
+		})
+		return
+	}
+
+	authorizedUid := c.Query("authorizedUid")
+	if vendorId != authorizedUid {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{
+			Message: "user is not authorized to perform this action",
+		})
+		return
+	}
+
+	rarityId, err := strconv.ParseUint(c.Query("rarityId"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := contr.itemService.DeleteRarityOverride(c.Request.Context(), vendorId, rarityId); err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, "success")
+	return
+}
+
+// @Summary			Get crafting balance
+// @Description		Get the crafting currency the user has earned from burning items
+// @Param			authorizedUid query string true "authorized uid"
+// @Accept			json
+// @Produce			json
+// @Tags			Item
+// @Success			200 {object} model.CraftingBalance
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/items/crafting/balance [GET]
+func (contr ItemController) GetCraftingBalance(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	craftingBalance, err := contr.itemService.GetCraftingBalance(c.Request.Context(), authorizedUid)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, craftingBalance)
+	return
+}
+
+// @Summary			Get expiring user items
+// @Description		Get the items in the users collection that run out their lifetime within the given number of days, soonest first
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			days query int false "days ahead to look, defaults to 7"
+// @Accept			json
+// @Produce			json
+// @Tags			Item
+// @Success			200 {object} []model.ExpiringUserItem
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/items/user/expiring [GET]
+func (contr ItemController) GetExpiringUserItems(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	days := 7
+	if rawDays := c.Query("days"); rawDays != "" {
+		parsedDays, err := strconv.Atoi(rawDays)
+		if err != nil {
+			httputil.NewError(c, http.StatusBadRequest, err)
+			return
+		}
+		days = parsedDays
+	}
+
+	expiringItems, err := contr.itemService.GetExpiringUserItems(c.Request.Context(), authorizedUid, days)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, expiringItems)
+	return
+}
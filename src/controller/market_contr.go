@@ -0,0 +1,247 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"xo-packs/core"
+	"xo-packs/db"
+	"xo-packs/model"
+	"xo-packs/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/swaggo/swag/example/celler/httputil"
+)
+
+type MarketController struct {
+	marketService     service.MarketService
+	itemService       service.ItemService
+	tokenService      service.TokenService
+	collectionService service.CollectionService
+}
+
+func NewMarketController(marketService service.MarketService, itemService service.ItemService, tokenService service.TokenService, collectionService service.CollectionService) *MarketController {
+	return &MarketController{marketService: marketService, itemService: itemService, tokenService: tokenService, collectionService: collectionService}
+}
+
+func (contr MarketController) Register(router *gin.Engine) {
+	router.GET("/market/listings", contr.SearchListings)
+	router.GET("/market/listing/:id", contr.GetListing)
+	router.POST("/market/listing", contr.CreateListing)
+	router.DELETE("/market/listing/:id", contr.CancelListing)
+	router.POST("/market/listing/buy/:id", contr.BuyListing)
+}
+
+// @Summary 		Search marketplace listings
+// @Description 	Get a page of active listings filtered by creator, item, rarity and price
+// @Param			pageNum query int true "page number"
+// @Param			creatorId query string false "creator uid"
+// @Param			itemId query int false "item id"
+// @Param			rarityId query int false "rarity id"
+// @Param			minPrice query number false "min token amount"
+// @Param			maxPrice query number false "max token amount"
+// @Param			sort query string false "priceAsc, priceDesc or newest"
+// @Tags 			Market
+// @Accept 			json
+// @Produce 		json
+// @Success 		200 {object} model.MarketListingPage
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/market/listings [GET]
+func (contr MarketController) SearchListings(c *gin.Context) {
+	pageNum, err := strconv.ParseUint(c.Query("pageNum"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if pageNum <= 0 {
+		httputil.NewError(c, http.StatusBadRequest, &core.ErrorResp{Message: "page number must be positive"})
+		return
+	}
+
+	filter := model.MarketListingFilter{}
+	if creatorId := c.Query("creatorId"); creatorId != "" {
+		filter.VendorId = &creatorId
+	}
+	if rawItemId := c.Query("itemId"); rawItemId != "" {
+		itemId, err := strconv.ParseUint(rawItemId, 10, 64)
+		if err != nil {
+			httputil.NewError(c, http.StatusBadRequest, err)
+			return
+		}
+		filter.ItemId = &itemId
+	}
+	if rawRarityId := c.Query("rarityId"); rawRarityId != "" {
+		rarityId, err := strconv.ParseUint(rawRarityId, 10, 64)
+		if err != nil {
+			httputil.NewError(c, http.StatusBadRequest, err)
+			return
+		}
+		filter.RarityId = &rarityId
+	}
+	if rawMinPrice := c.Query("minPrice"); rawMinPrice != "" {
+		minPrice, err := strconv.ParseFloat(rawMinPrice, 64)
+		if err != nil {
+			httputil.NewError(c, http.StatusBadRequest, err)
+			return
+		}
+		filter.MinTokenAmount = &minPrice
+	}
+	if rawMaxPrice := c.Query("maxPrice"); rawMaxPrice != "" {
+		maxPrice, err := strconv.ParseFloat(rawMaxPrice, 64)
+		if err != nil {
+			httputil.NewError(c, http.StatusBadRequest, err)
+			return
+		}
+		filter.MaxTokenAmount = &maxPrice
+	}
+
+	listingPage, err := contr.marketService.SearchListings(c.Request.Context(), &filter, c.Query("sort"), pageNum, c.Request.URL.String())
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, listingPage)
+	return
+}
+
+// @Summary 		Get a marketplace listing
+// @Description 	Get a listing along with the listed item and seller
+// @Param			id path int true "listing id"
+// @Tags 			Market
+// @Accept 			json
+// @Produce 		json
+// @Success 		200 {object} model.MarketListingExpanded
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/market/listing/{id} [GET]
+func (contr MarketController) GetListing(c *gin.Context) {
+	listingId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	listing, err := contr.marketService.GetListing(c.Request.Context(), listingId)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, listing)
+	return
+}
+
+// @Summary 		List an item on the marketplace
+// @Description 	A user can list an item from their collection for a token price
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			listing body model.CreateMarketListingReq true "user item and price"
+// @Tags 			Market
+// @Accept 			json
+// @Produce 		json
+// @Success 		201 {object} model.MarketListing
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/market/listing [POST]
+func (contr MarketController) CreateListing(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	req := model.CreateMarketListingReq{}
+	if err := c.BindJSON(&req); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	listing, err := contr.marketService.CreateListing(c.Request.Context(), authorizedUid, &req)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusCreated, listing)
+	return
+}
+
+// @Summary 		Cancel a marketplace listing
+// @Description 	A seller can take down one of their active listings
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			id path int true "listing id"
+// @Tags 			Market
+// @Accept 			json
+// @Produce 		json
+// @Success 		200 {} string
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/market/listing/{id} [DELETE]
+func (contr MarketController) CancelListing(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	listingId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := contr.marketService.CancelListing(c.Request.Context(), listingId, authorizedUid); err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, "success")
+	return
+}
+
+// @Summary 		Buy a marketplace listing
+// @Description 	Buy a listed item with tokens, the price is split between the seller, the creator and the platform
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			id path int true "listing id"
+// @Tags 			Market
+// @Accept 			json
+// @Produce 		json
+// @Success 		200 {object} model.MarketPurchaseResp
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/market/listing/buy/{id} [POST]
+func (contr MarketController) BuyListing(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	listingId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := contr.marketService.BuyListing(c.Request.Context(), listingId, authorizedUid, contr.itemService, contr.tokenService)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	// log the sale
+	core.AddLog(logrus.Fields{
+		"ListingId":      listingId,
+		"UserItemId":     *resp.UserItemId,
+		"BuyerUid":       *resp.Sale.BuyerUid,
+		"SellerUid":      *resp.Sale.SellerUid,
+		"CreatorUid":     *resp.Sale.CreatorUid,
+		"TokenAmount":    *resp.Sale.TokenAmount,
+		"PlatformFee":    *resp.Sale.PlatformFee,
+		"CreatorRoyalty": *resp.Sale.CreatorRoyalty,
+	}, c, db.LOG_MARKET_SALE)
+	completeSets(c, contr.collectionService, authorizedUid, contr.itemService, contr.tokenService)
+
+	c.JSON(http.StatusOK, resp)
+	return
+}
@@ -0,0 +1,286 @@
+package controller
+
+import (
+	"net/http"
+	"xo-packs/core"
+	"xo-packs/model"
+	"xo-packs/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/swaggo/swag/example/celler/httputil"
+)
+
+type NotificationController struct {
+	notificationService service.NotificationService
+}
+
+func NewNotificationController(notificationService service.NotificationService) *NotificationController {
+	return &NotificationController{notificationService: notificationService}
+}
+
+func (contr NotificationController) Register(router *gin.Engine) {
+	router.GET("/notifications", contr.GetNotifications)
+	router.POST("/notifications/read", contr.ReadNotifications)
+	router.GET("/notifications/bigPulls/optIn", contr.GetBigPullOptIn)
+	router.PUT("/notifications/bigPulls/optIn", contr.SetBigPullOptIn)
+	router.GET("/notifications/webhook", contr.GetWebhook)
+	router.PUT("/notifications/webhook", contr.SetWebhook)
+	router.DELETE("/notifications/webhook", contr.DeleteWebhook)
+	router.GET("/bigPulls/:vendorId", contr.GetBigPulls)
+}
+
+// @Summary			Get notifications
+// @Description		Get the newest notifications of the user along with how many are unread
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			unreadOnly query bool false "only unread notifications"
+// @Accept			json
+// @Produce			json
+// @Tags			Notification
+// @Success			200 {object} model.NotificationsResp
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/notifications [GET]
+func (contr NotificationController) GetNotifications(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	resp, err := contr.notificationService.GetNotifications(c.Request.Context(), authorizedUid, c.Query("unreadOnly") == "true")
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+	return
+}
+
+// @Summary			Mark notifications read
+// @Description		Marks the given notifications read, or every notification of the user when no ids are given
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			req body model.ReadNotificationsReq true "notification ids"
+// @Accept			json
+// @Produce			json
+// @Tags			Notification
+// @Success			200 {} string
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/notifications/read [POST]
+func (contr NotificationController) ReadNotifications(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	req := model.ReadNotificationsReq{}
+	if err := c.BindJSON(&req); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := contr.notificationService.ReadNotifications(c.Request.Context(), authorizedUid, &req); err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, "success")
+	return
+}
+
+// @Summary			Get big pull opt in
+// @Description		Get whether the users notify item pulls are shown on the creators recent big pulls feed
+// @Param			authorizedUid query string true "authorized uid"
+// @Accept			json
+// @Produce			json
+// @Tags			Notification
+// @Success			200 {object} model.BigPullOptIn
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/notifications/bigPulls/optIn [GET]
+func (contr NotificationController) GetBigPullOptIn(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	optIn, err := contr.notificationService.GetBigPullOptIn(c.Request.Context(), authorizedUid)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, optIn)
+	return
+}
+
+// @Summary			Set big pull opt in
+// @Description		Opt in or out of having notify item pulls shown on the creators recent big pulls feed. opting out hides past pulls as well
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			optIn body model.BigPullOptIn true "opted in"
+// @Accept			json
+// @Produce			json
+// @Tags			Notification
+// @Success			200 {object} model.BigPullOptIn
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/notifications/bigPulls/optIn [PUT]
+func (contr NotificationController) SetBigPullOptIn(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	optIn := model.BigPullOptIn{}
+	if err := c.BindJSON(&optIn); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	updatedOptIn, err := contr.notificationService.SetBigPullOptIn(c.Request.Context(), authorizedUid, &optIn)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, updatedOptIn)
+	return
+}
+
+// @Summary			Get recent big pulls
+// @Description		Get the latest notify item pulls of a creators fans, only fans that opted in are shown
+// @Param			vendorId path string true "vendor uid"
+// @Accept			json
+// @Produce			json
+// @Tags			Notification
+// @Success			200 {object} []model.BigPull
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/bigPulls/{vendorId} [GET]
+func (contr NotificationController) GetBigPulls(c *gin.Context) {
+	bigPulls, err := contr.notificationService.GetBigPulls(c.Request.Context(), c.Param("vendorId"))
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, bigPulls)
+	return
+}
+
+// @Summary			Get creator webhook
+// @Description		Get the webhook a creator is called on when a fan pulls one of their notify items
+// @Param			vendorId query string true "vendor uid"
+// @Accept			json
+// @Produce			json
+// @Tags			Notification
+// @Success			200 {object} model.CreatorWebhook
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/notifications/webhook [GET]
+func (contr NotificationController) GetWebhook(c *gin.Context) {
+	vendorId := c.Query("vendorId")
+	if vendorId == "" {
+		httputil.NewError(c, http.StatusBadRequest, &core.ErrorResp{
+			Message: "vendorId param must be present",
+		})
+		return
+	}
+
+	authorizedUid := c.Query("authorizedUid")
+	if vendorId != authorizedUid {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{
+			Message: "user is not authorized to perform this action",
+		})
+		return
+	}
+
+	webhook, err := contr.notificationService.GetWebhook(c.Request.Context(), vendorId)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, webhook)
+	return
+}
+
+// @Summary			Set creator webhook
+// @Description		Sets the https url a creator is called on when a fan pulls one of their notify items. calls carry an hmac-sha256 signature of the body made with the returned secret, a new secret is made every time the webhook is set
+// @Param			vendorId query string true "vendor uid"
+// @Param			webhook body model.CreatorWebhookReq true "webhook url"
+// @Accept			json
+// @Produce			json
+// @Tags			Notification
+// @Success			200 {object} model.CreatorWebhook
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/notifications/webhook [PUT]
+func (contr NotificationController) SetWebhook(c *gin.Context) {
+	vendorId := c.Query("vendorId")
+	if vendorId == "" {
+		httputil.NewError(c, http.StatusBadRequest, &core.ErrorResp{
+			Message: "vendorId param must be present",
+		})
+		return
+	}
+
+	authorizedUid := c.Query("authorizedUid")
+	if vendorId != authorizedUid {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{
+			Message: "user is not authorized to perform this action",
+		})
+		return
+	}
+
+	req := model.CreatorWebhookReq{}
+	if err := c.BindJSON(&req); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	webhook, err := contr.notificationService.SetWebhook(c.Request.Context(), vendorId, &req)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, webhook)
+	return
+}
+
+// @Summary			Remove creator webhook
+// @Description		Stops calling the creators webhook on notify item pulls
+// @Param			vendorId query string true "vendor uid"
+// @Accept			json
+// @Produce			json
+// @Tags			Notification
+// @Success			200 {} string
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/notifications/webhook [DELETE]
+func (contr NotificationController) DeleteWebhook(c *gin.Context) {
+	vendorId := c.Query("vendorId")
+	if vendorId == "" {
+		httputil.NewError(c, http.StatusBadRequest, &core.ErrorResp{
+			Message: "vendorId param must be present",
+		})
+		return
+	}
+
+	authorizedUid := c.Query("authorizedUid")
+	if vendorId != authorizedUid {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{
+			Message: "user is not authorized to perform this action",
+		})
+		return
+	}
+
+	if err := contr.notificationService.DeleteWebhook(c.Request.Context(), vendorId); err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, "success")
+	return
+}
@@ -18,11 +18,14 @@ import (
 )
 
 type PackController struct {
-	packService   service.PackService
-	vendorService service.VendorService
-	itemService   service.ItemService
-	userService   service.UserService
-	tokenService  service.TokenService
+	packService         service.PackService
+	vendorService       service.VendorService
+	itemService         service.ItemService
+	userService         service.UserService
+	tokenService        service.TokenService
+	collectionService   service.CollectionService
+	watermarkService    service.WatermarkService
+	notificationService service.NotificationService
 }
 
 func NewPackController(
@@ -31,13 +34,19 @@ func NewPackController(
 	itemService service.ItemService,
 	userService service.UserService,
 	tokenService service.TokenService,
+	collectionService service.CollectionService,
+	watermarkService service.WatermarkService,
+	notificationService service.NotificationService,
 ) *PackController {
 	return &PackController{
-		packService:   packService,
-		vendorService: vendorService,
-		itemService:   itemService,
-		userService:   userService,
-		tokenService:  tokenService,
+		packService:         packService,
+		vendorService:       vendorService,
+		itemService:         itemService,
+		userService:         userService,
+		tokenService:        tokenService,
+		collectionService:   collectionService,
+		watermarkService:    watermarkService,
+		notificationService: notificationService,
 	}
 }
 
@@ -45,14 +54,27 @@ func (contr PackController) Register(router *gin.Engine) {
 	router.POST("/pack/config", contr.CreatePackConfig)
 	router.POST("/pack/item/configs", contr.AddPackItemConfigs)
 	router.POST("/pack/generate", contr.GeneratePacks)
+	router.GET("/pack/generate/status/:id", contr.GetPackGenerationJob)
 	router.POST("/pack/buy", contr.BuyPacks) // associates packs to user
+	router.GET("/packs/gifts/received/:uid", contr.GetReceivedGifts)
+	router.POST("/pack/waitlist/:id", contr.JoinPackWaitlist)
+	router.DELETE("/pack/waitlist/:id", contr.LeavePackWaitlist)
+	router.GET("/pack/quote", contr.QuotePacks)
+	router.GET("/pack/price/tiers/:id", contr.GetPackPriceTiers)
+	router.POST("/pack/price/tiers", contr.SetPackPriceTiers)
 	router.POST("/pack/categories", contr.AddPackCategories)
 	router.GET("/pack/config/:id", contr.GetPackConfig)
+	router.POST("/pack/config/clone/:id", contr.ClonePackConfig)
 	router.GET("/pack/open/:id", contr.OpenPack) // associates pack items to user and returns Pack obj
+	router.POST("/packs/open", contr.OpenPacks)
+	router.POST("/pack/reveal/:id", contr.StartPackReveal)
+	router.GET("/pack/reveal/session/:id", contr.GetPackReveal)
+	router.POST("/pack/reveal/session/:id/slot/:slot", contr.RevealPackSlot)
 	router.GET("/packs/amount/:uid", contr.GetUserPackAmount)
 	router.GET("/pack/items/:id", contr.GetPackItems)
 	router.GET("/pack/items/preview/:id", contr.GetPackItemsPreview)
 	router.POST("/pack/items/generateOdds", contr.GeneratePackItemOdds)
+	router.POST("/pack/submit", contr.SubmitPackConfigs)
 	router.POST("/pack/activate", contr.ActivatePacks)
 	// router.POST("/pack/schedule", contr.SchedulePacks)
 	router.PATCH("/pack/config", contr.PatchPackConfig)
@@ -105,6 +127,58 @@ func (contr PackController) CreatePackConfig(c *gin.Context) {
 	return
 }
 
+// @Summary			Clone a pack config
+// @Description		Copy a pack config with its item configs, categories and price tiers into a new draft
+// @Accept 			json
+// @Produce			json
+// @Param			id path int true "Pack Config ID"
+// @Param			vendorId query string true "vendor uid"
+// @Param			overrides body model.ClonePackConfigReq false "optional title, price and qty overrides"
+// @Tags			Pack
+// @Success			201 {object} model.PackConfig
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/pack/config/clone/{id} [post]
+func (contr PackController) ClonePackConfig(c *gin.Context) {
+	packConfigId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	vendorId := c.Query("vendorId")
+	if vendorId == "" {
+		httputil.NewError(c, http.StatusBadRequest, &core.ErrorResp{
+			Message: "vendorId param must be present",
+		})
+		return
+	}
+	authorizedUid := c.Query("authorizedUid")
+	if vendorId != authorizedUid {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{
+			Message: "user is not authorized to perform this action",
+		})
+		return
+	}
+
+	overrides := model.ClonePackConfigReq{}
+	if c.Request.ContentLength > 0 {
+		if err := c.BindJSON(&overrides); err != nil {
+			httputil.NewError(c, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	packConfig, err := contr.packService.ClonePackConfig(c.Request.Context(), packConfigId, vendorId, &overrides, contr.vendorService)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusCreated, packConfig)
+	return
+}
+
 // @Summary 		get packs
 // @Description 	get a pack by id
 // @Tags 			Pack
@@ -293,7 +367,7 @@ func (contr PackController) OpenPack(c *gin.Context) {
 		return
 	}
 
-	pack, err = contr.packService.OpenPack(c.Request.Context(), id, authorizedUid, contr.itemService)
+	pack, err = contr.packService.OpenPack(c.Request.Context(), id, authorizedUid, contr.itemService, contr.watermarkService)
 	if err != nil {
 		httputil.NewError(c, http.StatusInternalServerError, err)
 		return
@@ -347,6 +421,206 @@ func (contr PackController) OpenPack(c *gin.Context) {
 		applyNotificationLog = false
 	}
 
+	contr.logPackPulls(c, authorizedUid, user, vendor, pack, applyNotificationLog)
+	completeSets(c, contr.collectionService, authorizedUid, contr.itemService, contr.tokenService)
+
+	c.JSON(http.StatusOK, pack)
+	return
+}
+
+// @Summary 		Open many packs
+// @Description 	Opens a list of packs, or every unopened pack of a pack config, in one transaction
+// @Tags 			Pack
+// @Accept 			json
+// @Produce 		json
+// @Param 			packs body model.OpenPacksReq true "pack ids or pack config id"
+// @Success 		200 {object} model.OpenPacksResp
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/packs/open [post]
+func (contr PackController) OpenPacks(c *gin.Context) {
+	openPacksReq := model.OpenPacksReq{}
+	if err := c.BindJSON(&openPacksReq); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if len(openPacksReq.PackIds) == 0 && openPacksReq.PackConfigId == nil {
+		httputil.NewError(c, http.StatusBadRequest, &core.ErrorResp{
+			Message: "pack ids or a pack config id must be present",
+		})
+		return
+	}
+
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{
+			Message: "user is not authorized to perform this action",
+		})
+		return
+	}
+
+	openedPacks, err := contr.packService.OpenPacks(c.Request.Context(), authorizedUid, openPacksReq.PackIds, openPacksReq.PackConfigId, contr.itemService, contr.watermarkService)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	packs := []*model.Pack{}
+	for _, openedPack := range openedPacks.Packs {
+		packs = append(packs, openedPack.Pack)
+	}
+	contr.logOpenedPacks(c, authorizedUid, packs)
+	completeSets(c, contr.collectionService, authorizedUid, contr.itemService, contr.tokenService)
+
+	c.JSON(http.StatusOK, openedPacks)
+	return
+}
+
+// @Summary 		Start a pack reveal
+// @Description 	Opens a pack into a reveal session, or resumes the session it was already opened into. Items are credited to the user right away
+// @Tags 			Pack
+// @Accept 			json
+// @Produce 		json
+// @Param 			id path int true "pack fact id"
+// @Success 		200 {object} model.PackRevealResp
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/pack/reveal/{id} [post]
+func (contr PackController) StartPackReveal(c *gin.Context) {
+	rawId := c.Param("id")
+	id, err := strconv.ParseUint(rawId, 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	authorizedUid := c.Query("authorizedUid")
+
+	// an unopened pack is kept for the pull logs, an opened pack can only resume its session
+	pack, err := contr.packService.GetPack(c.Request.Context(), id)
+	if err != nil {
+		pack = nil
+	} else if pack.OwnerId == nil || *pack.OwnerId != authorizedUid {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{
+			Message: "user is not authorized to perform this action",
+		})
+		return
+	}
+
+	reveal, err := contr.packService.StartPackReveal(c.Request.Context(), id, authorizedUid, contr.itemService, contr.watermarkService)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	if pack != nil && *reveal.Session.RevealedCount == 0 {
+		contr.logOpenedPacks(c, authorizedUid, []*model.Pack{pack})
+		completeSets(c, contr.collectionService, authorizedUid, contr.itemService, contr.tokenService)
+	}
+
+	c.JSON(http.StatusOK, reveal)
+	return
+}
+
+// @Summary 		Get a pack reveal
+// @Description 	Get a reveal session with the items revealed so far and rarity hints for every slot
+// @Tags 			Pack
+// @Accept 			json
+// @Produce 		json
+// @Param 			id path int true "reveal session id"
+// @Success 		200 {object} model.PackRevealResp
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/pack/reveal/session/{id} [get]
+func (contr PackController) GetPackReveal(c *gin.Context) {
+	rawId := c.Param("id")
+	id, err := strconv.ParseUint(rawId, 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	authorizedUid := c.Query("authorizedUid")
+
+	reveal, err := contr.packService.GetPackReveal(c.Request.Context(), id, authorizedUid, contr.itemService, contr.watermarkService)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reveal)
+	return
+}
+
+// @Summary 		Reveal a pack slot
+// @Description 	Reveals the next slot of a reveal session and returns its item with signed content
+// @Tags 			Pack
+// @Accept 			json
+// @Produce 		json
+// @Param 			id path int true "reveal session id"
+// @Param 			slot path int true "slot to reveal"
+// @Success 		200 {object} model.PackRevealSlotExpanded
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/pack/reveal/session/{id}/slot/{slot} [post]
+func (contr PackController) RevealPackSlot(c *gin.Context) {
+	rawId := c.Param("id")
+	id, err := strconv.ParseUint(rawId, 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	rawSlot := c.Param("slot")
+	slot, err := strconv.Atoi(rawSlot)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	authorizedUid := c.Query("authorizedUid")
+
+	revealedSlot, err := contr.packService.RevealPackSlot(c.Request.Context(), id, slot, authorizedUid, contr.itemService, contr.watermarkService)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, revealedSlot)
+	return
+}
+
+// logs opened packs without failing the request since the packs have already been opened
+func (contr PackController) logOpenedPacks(c *gin.Context, authorizedUid string, packs []*model.Pack) {
+	user, err := contr.userService.GetUser(c.Request.Context(), authorizedUid, true)
+	if err != nil || user.Username == nil || user.Email == nil {
+		fmt.Println("data quality error: unable to get user meta data for pack open logs")
+		return
+	}
+
+	vendors := map[string]*model.Vendor{}
+	for _, pack := range packs {
+		if pack.VendorId == nil {
+			continue
+		}
+
+		vendor, ok := vendors[*pack.VendorId]
+		if !ok {
+			vendor, err = contr.vendorService.GetVendor(c.Request.Context(), *pack.VendorId)
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			vendors[*pack.VendorId] = vendor
+		}
+
+		applyNotificationLog := vendor.UID != nil &&
+			vendor.Email != nil &&
+			vendor.Username != nil &&
+			vendor.FirstName != nil &&
+			vendor.LastName != nil
+		contr.logPackPulls(c, authorizedUid, user, vendor, pack, applyNotificationLog)
+	}
+}
+
+// logs a pack open along with each item pulled from it
+func (contr PackController) logPackPulls(c *gin.Context, authorizedUid string, user *model.User, vendor *model.Vendor, pack *model.Pack, applyNotificationLog bool) {
 	// logging pack open
 	packOpenLog := logrus.Fields{
 		"UID":       authorizedUid,
@@ -356,6 +630,11 @@ func (contr PackController) OpenPack(c *gin.Context) {
 	}
 	core.AddLog(packOpenLog, c, db.LOG_PACK_OPEN)
 
+	// the pack is already opened, so a failed notification is only printed
+	if err := contr.notificationService.NotifyPulls(c.Request.Context(), user, vendor, pack); err != nil {
+		fmt.Println("unable to notify creator of pulls from pack ", *pack.ID, ": ", err)
+	}
+
 	// logging item pulls
 	if applyNotificationLog {
 		numWorkers := len(pack.Items)
@@ -388,13 +667,10 @@ func (contr PackController) OpenPack(c *gin.Context) {
 			}(i)
 		}
 	}
-
-	c.JSON(http.StatusOK, pack)
-	return
 }
 
 // @Summary 		Add pack item configs
-// @Description 	Add pack item configs that determine how packs are built
+// @Description 	Add pack item configs that determine how packs are built. Submitted or approved packs go back to draft and are taken out of the shop until reviewed again
 // @Tags 			Pack
 // @Accept 			json
 // @Produce 		json
@@ -428,7 +704,7 @@ func (contr PackController) AddPackItemConfigs(c *gin.Context) {
 		return
 	}
 
-	if err := contr.packService.AddPackItemConfigs(c.Request.Context(), packItemConfigs); err != nil {
+	if err := contr.packService.AddPackItemConfigs(c.Request.Context(), packItemConfigs, contr.vendorService); err != nil {
 		httputil.NewError(c, http.StatusBadGateway, err)
 		return
 	}
@@ -438,13 +714,13 @@ func (contr PackController) AddPackItemConfigs(c *gin.Context) {
 }
 
 // @Summary 		Generate pack stock
-// @Description 	Add pack and item fact records to DB for logical pack stock quantities
+// @Description 	Queue a job that adds pack and item fact records to DB for logical pack stock quantities
 // @Tags 			Pack
 // @Accept 			json
 // @Produce 		json
 // @Param 			packConfigId query int true "Pack Config ID"
 // @Param			vendorId query string true "vendor uid"
-// @Success 		201 {object} core.AddPackSuccessResp
+// @Success 		202 {object} model.PackGenerationJob
 // @Failure 		500 {object} httputil.HTTPError
 // @Failure 		400 {object} httputil.HTTPError
 // @Router 			/pack/generate [post]
@@ -470,12 +746,56 @@ func (contr PackController) GeneratePacks(c *gin.Context) {
 		return
 	}
 
-	if err := contr.packService.GeneratePacks(c.Request.Context(), packConfigId, vendorId, contr.vendorService); err != nil {
+	job, err := contr.packService.GeneratePacks(c.Request.Context(), packConfigId, vendorId)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+	return
+}
+
+// @Summary 		Get pack generation status
+// @Description 	Get the status and progress of a pack generation job
+// @Tags 			Pack
+// @Accept 			json
+// @Produce 		json
+// @Param 			id path int true "Job ID"
+// @Param			vendorId query string true "vendor uid"
+// @Success 		200 {object} model.PackGenerationJob
+// @Failure 		500 {object} httputil.HTTPError
+// @Failure 		400 {object} httputil.HTTPError
+// @Router 			/pack/generate/status/{id} [get]
+func (contr PackController) GetPackGenerationJob(c *gin.Context) {
+	rawJobId := c.Param("id")
+	jobId, err := strconv.ParseUint(rawJobId, 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	vendorId := c.Query("vendorId")
+	if vendorId == "" {
+		httputil.NewError(c, http.StatusBadRequest, &core.ErrorResp{Message: "vendor uid must be present in params"})
+		return
+	}
+
+	authorizedUid := c.Query("authorizedUid")
+	if vendorId != authorizedUid {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{
+			Message: "user is not authorized to perform this action",
+		})
+		return
+	}
+
+	job, err := contr.packService.GetPackGenerationJob(c.Request.Context(), jobId, vendorId)
+	if err != nil {
 		httputil.NewError(c, http.StatusInternalServerError, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, core.AddPackSuccessResp{Message: "Successfully added bulk stock of packs"})
+	c.JSON(http.StatusOK, job)
 	return
 }
 
@@ -487,6 +807,9 @@ func (contr PackController) GeneratePacks(c *gin.Context) {
 // @Param 			packConfigId query int true "Pack Config ID"
 // @Param 			uid query string true "uid"
 // @Param 			amount query int true "amount of packs"
+// @Param 			recipientUid query string false "uid of the user the packs are gifted to"
+// @Param 			recipientUsername query string false "username of the user the packs are gifted to"
+// @Param 			giftMessage query string false "optional gift message"
 // @Success 		201 {object} model.PackBoughtResp
 // @Failure 		500 {object} httputil.HTTPError
 // @Failure 		400 {object} httputil.HTTPError
@@ -512,10 +835,33 @@ func (contr PackController) BuyPacks(c *gin.Context) {
 		return
 	}
 
+	// packs bought as a gift are owned by the recipient
+	var gift *model.PackGift
+	recipientUid := c.Query("recipientUid")
+	recipientUsername := c.Query("recipientUsername")
+	if recipientUid == "" && recipientUsername != "" {
+		recipient, err := contr.userService.GetUserByUsername(c.Request.Context(), recipientUsername)
+		if err != nil {
+			httputil.NewError(c, http.StatusInternalServerError, err)
+			return
+		}
+		if recipient.Uid == nil {
+			httputil.NewError(c, http.StatusBadRequest, &core.ErrorResp{Message: "gift recipient does not exist"})
+			return
+		}
+		recipientUid = *recipient.Uid
+	}
+	if recipientUid != "" {
+		gift = &model.PackGift{RecipientUid: &recipientUid}
+		if giftMessage := strings.TrimSpace(c.Query("giftMessage")); giftMessage != "" {
+			gift.Message = &giftMessage
+		}
+	}
+
 	var resp *model.PackBoughtResp
 	attempt := 0
 	for attempt < 3 {
-		resp, err = contr.packService.BuyPacks(c.Request.Context(), authorizedUid, packConfigId, float64(amount), contr.tokenService)
+		resp, err = contr.packService.BuyPacks(c.Request.Context(), authorizedUid, packConfigId, float64(amount), gift, contr.tokenService, contr.userService)
 		if err != nil {
 			if reflect.TypeOf(err) != reflect.TypeOf(core.DBErrorResp{}) {
 				break
@@ -534,6 +880,211 @@ func (contr PackController) BuyPacks(c *gin.Context) {
 	return
 }
 
+// @Summary 		Join a pack waitlist
+// @Description 	Wait for a sold out or upcoming pack and get notified when it is available
+// @Tags 			Pack
+// @Accept 			json
+// @Produce 		json
+// @Param 			id path int true "Pack Config ID"
+// @Success 		201 {object} model.PackWaitlistEntry
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/pack/waitlist/{id} [post]
+func (contr PackController) JoinPackWaitlist(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	packConfigId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	entry, err := contr.packService.JoinPackWaitlist(c.Request.Context(), authorizedUid, packConfigId)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusCreated, entry)
+	return
+}
+
+// @Summary 		Leave a pack waitlist
+// @Description 	Remove the user from the waitlist of a pack
+// @Tags 			Pack
+// @Accept 			json
+// @Produce 		json
+// @Param 			id path int true "Pack Config ID"
+// @Success 		200 {} string
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/pack/waitlist/{id} [delete]
+func (contr PackController) LeavePackWaitlist(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	packConfigId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := contr.packService.LeavePackWaitlist(c.Request.Context(), authorizedUid, packConfigId); err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, "success")
+	return
+}
+
+// @Summary 		Get received gifts
+// @Description 	Get the packs gifted to a user
+// @Tags 			Pack
+// @Accept 			json
+// @Produce 		json
+// @Param 			uid path string true "uid"
+// @Success 		200 {object} []model.ReceivedGift
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/packs/gifts/received/{uid} [get]
+func (contr PackController) GetReceivedGifts(c *gin.Context) {
+	uid := c.Param("uid")
+	authorizedUid := c.Query("authorizedUid")
+	if uid != authorizedUid {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{
+			Message: "user is not authorized to perform this action",
+		})
+		return
+	}
+
+	gifts, err := contr.packService.GetReceivedGifts(c.Request.Context(), uid)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, gifts)
+	return
+}
+
+// @Summary 		Quote a pack purchase
+// @Description 	Get the exact token cost of buying an amount of packs with price tiers applied
+// @Tags 			Pack
+// @Accept 			json
+// @Produce 		json
+// @Param 			packConfigId query int true "Pack Config ID"
+// @Param 			amount query int true "amount of packs"
+// @Success 		200 {object} model.PackQuote
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/pack/quote [get]
+func (contr PackController) QuotePacks(c *gin.Context) {
+	rawPackConfigId := c.Query("packConfigId")
+	packConfigId, err := strconv.ParseUint(rawPackConfigId, 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	rawAmount := c.Query("amount")
+	amount, err := strconv.Atoi(rawAmount)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	quote, err := contr.packService.QuotePacks(c.Request.Context(), packConfigId, amount)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, quote)
+	return
+}
+
+// @Summary 		Get pack price tiers
+// @Description 	Get the quantity price tiers of a pack config
+// @Tags 			Pack
+// @Accept 			json
+// @Produce 		json
+// @Param 			id path int true "Pack Config ID"
+// @Success 		200 {object} []model.PackPriceTier
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/pack/price/tiers/{id} [get]
+func (contr PackController) GetPackPriceTiers(c *gin.Context) {
+	rawPackConfigId := c.Param("id")
+	packConfigId, err := strconv.ParseUint(rawPackConfigId, 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	priceTiers, err := contr.packService.GetPackPriceTiers(c.Request.Context(), packConfigId)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, priceTiers)
+	return
+}
+
+// @Summary 		Set pack price tiers
+// @Description 	Replace the quantity price tiers of a pack config. Each tier has either a per pack or a total token amount for min qty packs. Submitted or approved packs go back to draft and are taken out of the shop until reviewed again
+// @Tags 			Pack
+// @Accept 			json
+// @Produce 		json
+// @Param 			packConfigId query int true "Pack Config ID"
+// @Param			vendorId query string true "vendor uid"
+// @Param 			priceTiers body []model.PackPriceTier true "price tiers"
+// @Success 		200 {object} []model.PackPriceTier
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/pack/price/tiers [post]
+func (contr PackController) SetPackPriceTiers(c *gin.Context) {
+	rawPackConfigId := c.Query("packConfigId")
+	packConfigId, err := strconv.ParseUint(rawPackConfigId, 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	vendorId := c.Query("vendorId")
+	if vendorId == "" {
+		httputil.NewError(c, http.StatusBadRequest, &core.ErrorResp{Message: "vendor uid must be present in params"})
+		return
+	}
+
+	authorizedUid := c.Query("authorizedUid")
+	if vendorId != authorizedUid {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{
+			Message: "user is not authorized to perform this action",
+		})
+		return
+	}
+
+	priceTiers := []*model.PackPriceTier{}
+	if err := c.BindJSON(&priceTiers); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	priceTiers, err = contr.packService.SetPackPriceTiers(c.Request.Context(), packConfigId, vendorId, priceTiers, contr.vendorService)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, priceTiers)
+	return
+}
+
 // @Summary 		Add pack categories
 // @Description 	Associates a set of categories to a pack config
 // @Tags 			Pack
@@ -577,7 +1128,7 @@ func (contr PackController) AddPackCategories(c *gin.Context) {
 }
 
 // @Summary 		Patch a pack config
-// @Description 	Update a pack config
+// @Description 	Update a pack config. Submitted or approved packs go back to draft and are taken out of the shop until reviewed again
 // @Tags 			Pack
 // @Accept 			json
 // @Produce 		json
@@ -617,7 +1168,7 @@ func (contr PackController) PatchPackConfig(c *gin.Context) {
 		return
 	}
 
-	packConfig, err := contr.packService.PatchPackConfig(c.Request.Context(), packConfigId, patchMap, vendorId)
+	packConfig, err := contr.packService.PatchPackConfig(c.Request.Context(), packConfigId, patchMap, vendorId, contr.vendorService)
 	if err != nil {
 		httputil.NewError(c, http.StatusInternalServerError, err)
 		return
@@ -626,6 +1177,54 @@ func (contr PackController) PatchPackConfig(c *gin.Context) {
 	return
 }
 
+// @Summary 		Submit pack(s) for review
+// @Description 	Submit draft or rejected pack configs to the admins for review before they can be activated
+// @Tags 			Pack
+// @Accept 			json
+// @Produce 		json
+// @Param 			vendorId query string true "vendor uid"
+// @Param 			ids query string true "pack config ids"
+// @Success 		200 {object} []int
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/pack/submit [post]
+func (contr PackController) SubmitPackConfigs(c *gin.Context) {
+	vendorId := c.Query("vendorId")
+	if vendorId == "" {
+		httputil.NewError(c, http.StatusBadRequest, &core.ErrorResp{
+			Message: "vendorId param must be present",
+		})
+		return
+	}
+	authorizedUid := c.Query("authorizedUid")
+	if vendorId != authorizedUid {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{
+			Message: "user is not authorized to perform this action",
+		})
+		return
+	}
+
+	rawPackConfigIds := strings.Split(c.Query("ids"), ",")
+	packConfigIds := make([]uint64, len(rawPackConfigIds))
+	for i, v := range rawPackConfigIds {
+		packConfigId, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			httputil.NewError(c, http.StatusBadRequest, err)
+			return
+		}
+		packConfigIds[i] = packConfigId
+	}
+
+	if err := contr.packService.SubmitPackConfigs(c.Request.Context(), packConfigIds, vendorId); err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, packConfigIds)
+	return
+}
+
 func (contr PackController) ActivatePacks(c *gin.Context) {
 	vendorId := c.Query("vendorId")
 	if vendorId == "" {
@@ -658,7 +1257,7 @@ func (contr PackController) ActivatePacks(c *gin.Context) {
 		return
 	}
 
-	err := contr.packService.ActivatePacks(c.Request.Context(), packConfigIds, vendorId, contr.vendorService)
+	err := contr.packService.ActivatePacks(c.Request.Context(), packConfigIds, vendorId, contr.vendorService, contr.notificationService)
 	if err != nil {
 		httputil.NewError(c, http.StatusInternalServerError, err)
 		return
@@ -2,10 +2,14 @@ package controller
 
 import (
 	"net/http"
+	"strconv"
 	"xo-packs/core"
+	"xo-packs/db"
+	"xo-packs/model"
 	"xo-packs/service"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 	"github.com/swaggo/swag/example/celler/httputil"
 )
 
@@ -28,8 +32,25 @@ func (contr ShippingController) Register(router *gin.Engine) {
 	router.GET("/shippingInfo/:uid", contr.GetShippingInfo)
 	router.POST("/shippingInfo", contr.UploadShippingInfo)
 	router.PATCH("/shippingInfo/:uid", contr.UpdateShippingInfo)
+	router.GET("/shipping/withdrawals", contr.GetUserWithdrawals)
+	router.GET("/shipping/withdrawal/:id", contr.GetWithdrawal)
+	router.POST("/shipping/withdrawal/cancel/:id", contr.CancelWithdrawal)
+	router.GET("/shipping/withdrawals/queue", contr.GetWithdrawalQueue)
+	router.PATCH("/shipping/withdrawal/status/:id", contr.UpdateWithdrawalStatus)
 }
 
+// @Summary			Get shipping info
+// @Description		Get the users current shipping address
+// @Param			uid path string true "uid"
+// @Param			authorizedUid query string true "authorized uid"
+// @Accept			json
+// @Produce			json
+// @Tags			Shipping
+// @Success			200 {object} model.ShippingInfo
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/shippingInfo/{uid} [GET]
 func (contr ShippingController) GetShippingInfo(c *gin.Context) {
 	uid := c.Param("uid")
 	if uid == "" {
@@ -38,13 +59,67 @@ func (contr ShippingController) GetShippingInfo(c *gin.Context) {
 		})
 		return
 	}
+	if uid != c.Query("authorizedUid") {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "user is not authorized to perform this action"})
+		return
+	}
 
+	shippingInfo, err := contr.shippingService.GetShippingInfo(c.Request.Context(), uid)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, shippingInfo)
+	return
 }
 
+// @Summary			Upload shipping info
+// @Description		Set the users shipping address, replacing any address they had before
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			shippingInfo body model.ShippingInfo true "shipping address"
+// @Accept			json
+// @Produce			json
+// @Tags			Shipping
+// @Success			201 {object} model.ShippingInfo
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/shippingInfo [POST]
 func (contr ShippingController) UploadShippingInfo(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
 
+	shippingInfo := model.ShippingInfo{}
+	if err := c.BindJSON(&shippingInfo); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	newShippingInfo, err := contr.shippingService.UploadShippingInfo(c.Request.Context(), authorizedUid, &shippingInfo)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusCreated, newShippingInfo)
+	return
 }
 
+// @Summary			Update shipping info
+// @Description		Patch fields of the users current shipping address
+// @Param			uid path string true "uid"
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			shippingInfo body object true "shipping address fields"
+// @Accept			json
+// @Produce			json
+// @Tags			Shipping
+// @Success			200 {object} model.ShippingInfo
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/shippingInfo/{uid} [PATCH]
 func (contr ShippingController) UpdateShippingInfo(c *gin.Context) {
 	uid := c.Param("uid")
 	if uid == "" {
@@ -53,5 +128,219 @@ func (contr ShippingController) UpdateShippingInfo(c *gin.Context) {
 		})
 		return
 	}
+	if uid != c.Query("authorizedUid") {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "user is not authorized to perform this action"})
+		return
+	}
+
+	var shippingInfoPatch map[string]interface{}
+	if err := c.BindJSON(&shippingInfoPatch); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	shippingInfo, err := contr.shippingService.UpdateShippingInfo(c.Request.Context(), uid, shippingInfoPatch)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, shippingInfo)
+	return
+}
+
+// @Summary			Get user withdrawals
+// @Description		Get the users withdrawals along with their fulfillment status and tracking
+// @Param			authorizedUid query string true "authorized uid"
+// @Accept			json
+// @Produce			json
+// @Tags			Shipping
+// @Success			200 {object} []model.ItemWithdrawalExpanded
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/shipping/withdrawals [GET]
+func (contr ShippingController) GetUserWithdrawals(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	withdrawals, err := contr.shippingService.GetUserWithdrawals(c.Request.Context(), authorizedUid)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, withdrawals)
+	return
+}
+
+// @Summary			Get a withdrawal
+// @Description		Track a withdrawal, visible to the user who requested it and the creator of the item
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			id path int true "withdrawal id"
+// @Accept			json
+// @Produce			json
+// @Tags			Shipping
+// @Success			200 {object} model.ItemWithdrawalExpanded
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/shipping/withdrawal/{id} [GET]
+func (contr ShippingController) GetWithdrawal(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	withdrawalId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	withdrawal, err := contr.shippingService.GetWithdrawal(c.Request.Context(), withdrawalId)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	if (withdrawal.Uid == nil || *withdrawal.Uid != authorizedUid) && (withdrawal.VendorId == nil || *withdrawal.VendorId != authorizedUid) {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "user is not authorized to perform this action"})
+		return
+	}
+	c.JSON(http.StatusOK, withdrawal)
+	return
+}
+
+// @Summary			Cancel a withdrawal
+// @Description		The user cancels their withdrawal before it ships and the item returns to their collection
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			id path int true "withdrawal id"
+// @Accept			json
+// @Produce			json
+// @Tags			Shipping
+// @Success			200 {object} model.ItemWithdrawal
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/shipping/withdrawal/cancel/{id} [POST]
+func (contr ShippingController) CancelWithdrawal(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	withdrawalId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	status := db.WITHDRAWAL_CANCELLED
+	req := model.WithdrawalStatusReq{Status: &status}
+	withdrawal, err := contr.shippingService.UpdateWithdrawalStatus(c.Request.Context(), withdrawalId, "", authorizedUid, authorizedUid, &req)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	logWithdrawalStatus(c, authorizedUid, withdrawal)
+	c.JSON(http.StatusOK, withdrawal)
+	return
+}
+
+// @Summary			Get the fulfillment queue
+// @Description		Get the withdrawals of a creators items to be worked, optionally filtered by status
+// @Param			vendorId query string true "vendor uid"
+// @Param			status query string false "requested, packed, shipped, delivered or cancelled"
+// @Accept			json
+// @Produce			json
+// @Tags			Shipping
+// @Success			200 {object} []model.ItemWithdrawalExpanded
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/shipping/withdrawals/queue [GET]
+func (contr ShippingController) GetWithdrawalQueue(c *gin.Context) {
+	vendorId := c.Query("vendorId")
+	if vendorId == "" {
+		httputil.NewError(c, http.StatusBadRequest, &core.ErrorResp{Message: "vendorId param must be present"})
+		return
+	}
+
+	authorizedUid := c.Query("authorizedUid")
+	if vendorId != authorizedUid {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "user is not authorized to perform this action"})
+		return
+	}
+
+	withdrawals, err := contr.shippingService.GetWithdrawalQueue(c.Request.Context(), vendorId, c.Query("status"))
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, withdrawals)
+	return
+}
+
+// @Summary			Update a withdrawal status
+// @Description		A creator moves a withdrawal of one of their items along requested, packed, shipped and delivered, or cancels it before it ships. shipping needs a carrier and tracking number
+// @Param			vendorId query string true "vendor uid"
+// @Param			id path int true "withdrawal id"
+// @Param			status body model.WithdrawalStatusReq true "status, carrier and tracking number"
+// @Accept			json
+// @Produce			json
+// @Tags			Shipping
+// @Success			200 {object} model.ItemWithdrawal
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/shipping/withdrawal/status/{id} [PATCH]
+func (contr ShippingController) UpdateWithdrawalStatus(c *gin.Context) {
+	vendorId := c.Query("vendorId")
+	if vendorId == "" {
+		httputil.NewError(c, http.StatusBadRequest, &core.ErrorResp{Message: "vendorId param must be present"})
+		return
+	}
+
+	authorizedUid := c.Query("authorizedUid")
+	if vendorId != authorizedUid {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "user is not authorized to perform this action"})
+		return
+	}
+
+	withdrawalId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	req := model.WithdrawalStatusReq{}
+	if err := c.BindJSON(&req); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	withdrawal, err := contr.shippingService.UpdateWithdrawalStatus(c.Request.Context(), withdrawalId, vendorId, "", authorizedUid, &req)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	logWithdrawalStatus(c, authorizedUid, withdrawal)
+	c.JSON(http.StatusOK, withdrawal)
+	return
+}
 
+func logWithdrawalStatus(c *gin.Context, actorUid string, withdrawal *model.ItemWithdrawal) {
+	core.AddLog(logrus.Fields{
+		"ActorUid":       actorUid,
+		"WithdrawalId":   *withdrawal.ID,
+		"UserItemId":     *withdrawal.UserItemId,
+		"UID":            *withdrawal.Uid,
+		"Status":         *withdrawal.Status,
+		"Carrier":        withdrawal.Carrier,
+		"TrackingNumber": withdrawal.TrackingNumber,
+	}, c, db.LOG_WITHDRAWAL_STATUS)
 }
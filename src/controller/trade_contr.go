@@ -0,0 +1,289 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"xo-packs/core"
+	"xo-packs/db"
+	"xo-packs/model"
+	"xo-packs/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/swaggo/swag/example/celler/httputil"
+)
+
+type TradeController struct {
+	tradeService      service.TradeService
+	userService       service.UserService
+	itemService       service.ItemService
+	tokenService      service.TokenService
+	collectionService service.CollectionService
+}
+
+func NewTradeController(tradeService service.TradeService, userService service.UserService, itemService service.ItemService, tokenService service.TokenService, collectionService service.CollectionService) *TradeController {
+	return &TradeController{tradeService: tradeService, userService: userService, itemService: itemService, tokenService: tokenService, collectionService: collectionService}
+}
+
+func (contr TradeController) Register(router *gin.Engine) {
+	router.GET("/trades", contr.GetTradeHistory)
+	router.GET("/trade/offer/:id", contr.GetTradeOffer)
+	router.POST("/trade/offer", contr.CreateTradeOffer)
+	router.POST("/trade/offer/counter/:id", contr.CounterTradeOffer)
+	router.POST("/trade/offer/accept/:id", contr.AcceptTradeOffer)
+	router.POST("/trade/offer/decline/:id", contr.DeclineTradeOffer)
+	router.DELETE("/trade/offer/:id", contr.CancelTradeOffer)
+}
+
+// @Summary 		Get trade history
+// @Description 	Get every trade offer the user sent or received, newest first
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			status query string false "pending, accepted, declined, countered, cancelled or expired"
+// @Tags 			Trade
+// @Accept 			json
+// @Produce 		json
+// @Success 		200 {object} []model.TradeOfferExpanded
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/trades [GET]
+func (contr TradeController) GetTradeHistory(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	offers, err := contr.tradeService.GetTradeHistory(c.Request.Context(), authorizedUid, c.Query("status"))
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, offers)
+	return
+}
+
+// @Summary 		Get a trade offer
+// @Description 	Get a trade offer the user sent or received along with its items
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			id path int true "trade offer id"
+// @Tags 			Trade
+// @Accept 			json
+// @Produce 		json
+// @Success 		200 {object} model.TradeOfferExpanded
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/trade/offer/{id} [GET]
+func (contr TradeController) GetTradeOffer(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	offerId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	offer, err := contr.tradeService.GetTradeOffer(c.Request.Context(), offerId, authorizedUid)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, offer)
+	return
+}
+
+// @Summary 		Send a trade offer
+// @Description 	Offer a set of the users items, and optionally tokens, for a set of another users items
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			offer body model.TradeOfferReq true "trade offer"
+// @Tags 			Trade
+// @Accept 			json
+// @Produce 		json
+// @Success 		201 {object} model.TradeOfferExpanded
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/trade/offer [POST]
+func (contr TradeController) CreateTradeOffer(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	req := model.TradeOfferReq{}
+	if err := c.BindJSON(&req); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	offer, err := contr.tradeService.CreateTradeOffer(c.Request.Context(), authorizedUid, &req, contr.userService)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusCreated, offer)
+	return
+}
+
+// @Summary 		Counter a trade offer
+// @Description 	The recipient of a pending offer answers with a new offer back to the sender
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			id path int true "trade offer id"
+// @Param			offer body model.TradeOfferReq true "counter offer, the recipient uid is ignored"
+// @Tags 			Trade
+// @Accept 			json
+// @Produce 		json
+// @Success 		201 {object} model.TradeOfferExpanded
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/trade/offer/counter/{id} [POST]
+func (contr TradeController) CounterTradeOffer(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	offerId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	req := model.TradeOfferReq{}
+	if err := c.BindJSON(&req); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	counter, err := contr.tradeService.CounterTradeOffer(c.Request.Context(), offerId, authorizedUid, &req)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusCreated, counter)
+	return
+}
+
+// @Summary 		Accept a trade offer
+// @Description 	The recipient accepts a pending offer, all items and tokens change hands at once
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			id path int true "trade offer id"
+// @Tags 			Trade
+// @Accept 			json
+// @Produce 		json
+// @Success 		200 {object} model.TradeOfferExpanded
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/trade/offer/accept/{id} [POST]
+func (contr TradeController) AcceptTradeOffer(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	offerId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	offer, err := contr.tradeService.AcceptTradeOffer(c.Request.Context(), offerId, authorizedUid, contr.itemService, contr.tokenService)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	// log the completed trade
+	core.AddLog(logrus.Fields{
+		"TradeOfferId":      offerId,
+		"SenderUid":         *offer.SenderUid,
+		"RecipientUid":      *offer.RecipientUid,
+		"OfferedItems":      len(offer.OfferedItems),
+		"RequestedItems":    len(offer.RequestedItems),
+		"SenderTokenAmount": *offer.SenderTokenAmount,
+	}, c, db.LOG_TRADE_ACCEPTED)
+
+	// either side of the trade may have completed a set
+	completeSets(c, contr.collectionService, *offer.SenderUid, contr.itemService, contr.tokenService)
+	completeSets(c, contr.collectionService, *offer.RecipientUid, contr.itemService, contr.tokenService)
+
+	c.JSON(http.StatusOK, offer)
+	return
+}
+
+// @Summary 		Decline a trade offer
+// @Description 	The recipient declines a pending offer
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			id path int true "trade offer id"
+// @Tags 			Trade
+// @Accept 			json
+// @Produce 		json
+// @Success 		200 {object} model.TradeOfferExpanded
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/trade/offer/decline/{id} [POST]
+func (contr TradeController) DeclineTradeOffer(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	offerId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	offer, err := contr.tradeService.DeclineTradeOffer(c.Request.Context(), offerId, authorizedUid)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, offer)
+	return
+}
+
+// @Summary 		Cancel a trade offer
+// @Description 	The sender withdraws a pending offer
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			id path int true "trade offer id"
+// @Tags 			Trade
+// @Accept 			json
+// @Produce 		json
+// @Success 		200 {object} model.TradeOfferExpanded
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router 			/trade/offer/{id} [DELETE]
+func (contr TradeController) CancelTradeOffer(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	offerId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	offer, err := contr.tradeService.CancelTradeOffer(c.Request.Context(), offerId, authorizedUid)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, offer)
+	return
+}
@@ -0,0 +1,208 @@
+package controller
+
+import (
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"xo-packs/core"
+	"xo-packs/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/swaggo/swag/example/celler/httputil"
+)
+
+type UploadController struct {
+	uploadService service.UploadService
+	itemService   service.ItemService
+	packService   service.PackService
+	userService   service.UserService
+	vendorService service.VendorService
+}
+
+func NewUploadController(uploadService service.UploadService, itemService service.ItemService, packService service.PackService, userService service.UserService, vendorService service.VendorService) *UploadController {
+	return &UploadController{uploadService: uploadService, itemService: itemService, packService: packService, userService: userService, vendorService: vendorService}
+}
+
+func (contr UploadController) Register(router *gin.Engine) {
+	router.POST("/upload/item/:id", contr.UploadItemContent)
+	router.POST("/upload/pack/:id", contr.UploadPackArt)
+	router.POST("/upload/profile", contr.UploadProfileImage)
+	router.POST("/upload/banner", contr.UploadBannerImage)
+}
+
+// @Summary			Upload item content
+// @Description		A creator uploads the main content and or thumbnail of one of their items. main content can be an image or a video, the thumbnail has to be an image
+// @Param			vendorId query string true "vendor uid"
+// @Param			id path int true "item id"
+// @Param			contentMain formData file false "main content"
+// @Param			contentThumb formData file false "thumbnail"
+// @Accept			multipart/form-data
+// @Produce			json
+// @Tags			Upload
+// @Success			200 {object} model.UploadResp
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/upload/item/:id [POST]
+func (contr UploadController) UploadItemContent(c *gin.Context) {
+	vendorId := c.Query("vendorId")
+	if vendorId == "" {
+		httputil.NewError(c, http.StatusBadRequest, &core.ErrorResp{Message: "vendorId param must be present"})
+		return
+	}
+
+	authorizedUid := c.Query("authorizedUid")
+	if vendorId != authorizedUid {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "user is not authorized to perform this action"})
+		return
+	}
+
+	itemId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	mainFile, err := optionalFormFile(c, "contentMain")
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	thumbFile, err := optionalFormFile(c, "contentThumb")
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := contr.uploadService.UploadItemContent(c.Request.Context(), vendorId, itemId, mainFile, thumbFile, contr.itemService, contr.packService)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+	return
+}
+
+// @Summary			Upload pack art
+// @Description		A creator uploads the cover image of one of their packs
+// @Param			vendorId query string true "vendor uid"
+// @Param			id path int true "pack config id"
+// @Param			image formData file true "pack art"
+// @Accept			multipart/form-data
+// @Produce			json
+// @Tags			Upload
+// @Success			200 {object} model.UploadResp
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/upload/pack/:id [POST]
+func (contr UploadController) UploadPackArt(c *gin.Context) {
+	vendorId := c.Query("vendorId")
+	if vendorId == "" {
+		httputil.NewError(c, http.StatusBadRequest, &core.ErrorResp{Message: "vendorId param must be present"})
+		return
+	}
+
+	authorizedUid := c.Query("authorizedUid")
+	if vendorId != authorizedUid {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "user is not authorized to perform this action"})
+		return
+	}
+
+	packConfigId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	image, err := c.FormFile("image")
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := contr.uploadService.UploadPackArt(c.Request.Context(), vendorId, packConfigId, image, contr.packService)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+	return
+}
+
+// @Summary			Upload profile image
+// @Description		Replace the users profile image. the returned version changes with every upload
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			image formData file true "profile image"
+// @Accept			multipart/form-data
+// @Produce			json
+// @Tags			Upload
+// @Success			200 {object} model.UploadResp
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/upload/profile [POST]
+func (contr UploadController) UploadProfileImage(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	image, err := c.FormFile("image")
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := contr.uploadService.UploadProfileImage(c.Request.Context(), authorizedUid, image, contr.userService, contr.vendorService)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+	return
+}
+
+// @Summary			Upload banner image
+// @Description		Replace the users banner image. the returned version changes with every upload
+// @Param			authorizedUid query string true "authorized uid"
+// @Param			image formData file true "banner image"
+// @Accept			multipart/form-data
+// @Produce			json
+// @Tags			Upload
+// @Success			200 {object} model.UploadResp
+// @Failure 		400 {object} httputil.HTTPError
+// @Failure 		401 {object} httputil.HTTPError
+// @Failure 		500 {object} httputil.HTTPError
+// @Router			/upload/banner [POST]
+func (contr UploadController) UploadBannerImage(c *gin.Context) {
+	authorizedUid := c.Query("authorizedUid")
+	if authorizedUid == "" {
+		httputil.NewError(c, http.StatusUnauthorized, &core.ErrorResp{Message: "valid uid must be present"})
+		return
+	}
+
+	image, err := c.FormFile("image")
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := contr.uploadService.UploadBannerImage(c.Request.Context(), authorizedUid, image, contr.userService, contr.vendorService)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+	return
+}
+
+// a missing file is fine, a malformed form is not
+func optionalFormFile(c *gin.Context, name string) (*multipart.FileHeader, error) {
+	file, err := c.FormFile(name)
+	if err == http.ErrMissingFile {
+		return nil, nil
+	}
+	return file, err
+}
@@ -16,13 +16,15 @@ import (
 )
 
 type UserController struct {
-	userService   service.UserService
-	vendorService service.VendorService
-	itemService   service.ItemService
+	userService      service.UserService
+	vendorService    service.VendorService
+	itemService      service.ItemService
+	shippingService  service.ShippingService
+	watermarkService service.WatermarkService
 }
 
-func NewUserController(userService service.UserService, vendorService service.VendorService, itemService service.ItemService) *UserController {
-	return &UserController{userService: userService, vendorService: vendorService, itemService: itemService}
+func NewUserController(userService service.UserService, vendorService service.VendorService, itemService service.ItemService, shippingService service.ShippingService, watermarkService service.WatermarkService) *UserController {
+	return &UserController{userService: userService, vendorService: vendorService, itemService: itemService, shippingService: shippingService, watermarkService: watermarkService}
 }
 
 func (contr UserController) Register(router *gin.Engine) {
@@ -130,14 +132,14 @@ func (contr UserController) AddFavorite(c *gin.Context) {
 }
 
 // @Summary			Withdrawal a user item
-// @Description		Allows a user to withdrawal an item from their collection which is externally fulfilled
+// @Description		Allows a user to withdrawal an item from their collection which is externally fulfilled, shipped to a snapshot of their current shipping info
 // @Param			uid query string true "uid"
 // @Param			authorizedUid query string true "authorized uid"
 // @Param			userItemId query int true "user item id"
 // @Accept			json
 // @Produce			json
 // @Tags			User
-// @Success			201 {object} model.ItemWithdrawal
+// @Success			201 {object} model.ItemWithdrawalExpanded
 // @Failure 		400 {object} httputil.HTTPError
 // @Failure 		500 {object} httputil.HTTPError
 // @Router			/user/item/withdrawal [post]
@@ -162,7 +164,7 @@ func (contr UserController) WithdrawalUserItem(c *gin.Context) {
 		return
 	}
 
-	userItemWithdrawal, err := contr.userService.WithdrawalUserItem(c.Request.Context(), userItemId, authorizedUid)
+	userItemWithdrawal, err := contr.shippingService.RequestWithdrawal(c.Request.Context(), userItemId, authorizedUid)
 	if err != nil {
 		httputil.NewError(c, http.StatusInternalServerError, err)
 		return
@@ -364,6 +366,7 @@ func (contr UserController) GetUserPackPage(c *gin.Context) {
 // @Param			sortBy query string false "sort by"
 // @Param			sortDir query string false "sort direction"
 // @Param			filterOn query string false "category filter"
+// @Param			rarityId query int false "rarity filter"
 // @Param			search query string false "search string"
 // @Tags			User
 // @Success			200 {object} model.UserItemPage
@@ -392,6 +395,14 @@ func (contr UserController) GetUserItemPage(c *gin.Context) {
 	sortBy := c.Query("sortBy")
 	sortDir := c.Query("sortDir")
 	filterOn := c.Query("filterOn")
+	rarityId := uint64(0)
+	if rawRarityId := c.Query("rarityId"); rawRarityId != "" {
+		rarityId, err = strconv.ParseUint(rawRarityId, 10, 64)
+		if err != nil {
+			httputil.NewError(c, http.StatusBadRequest, err)
+			return
+		}
+	}
 
 	userItemPage, err := contr.userService.GetUserItemPage(
 		c.Request.Context(),
@@ -400,9 +411,11 @@ func (contr UserController) GetUserItemPage(c *gin.Context) {
 		sortBy,
 		sortDir,
 		filterOn,
+		rarityId,
 		searchStr,
 		c.Request.URL.String(),
 		contr.itemService,
+		contr.watermarkService,
 	)
 	if err != nil {
 		httputil.NewError(c, http.StatusInternalServerError, err)
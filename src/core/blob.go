@@ -0,0 +1,174 @@
+package core
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+const (
+	BLOB_STORE_S3             = "s3"
+	BLOB_STORE_LOCAL          = "local"
+	DEFAULT_LOCAL_CONTENT_DIR = "content"
+	LOCAL_CONTENT_ROUTE       = "/content"
+)
+
+// where uploaded content is kept. keys are slash separated paths, URL gives the address content is served from
+type BlobStore interface {
+	Put(ctx context.Context, key string, body io.ReadSeeker, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// picks the store from CONTENT_STORE, s3 unless set to local. the s3 store writes to CONTENT_BUCKET and serves
+// from CONTENT_BASE_URL, the local store writes under CONTENT_LOCAL_DIR and is served by the api itself
+func NewBlobStore() (BlobStore, error) {
+	if os.Getenv("CONTENT_STORE") == BLOB_STORE_LOCAL {
+		dir := os.Getenv("CONTENT_LOCAL_DIR")
+		if dir == "" {
+			dir = DEFAULT_LOCAL_CONTENT_DIR
+		}
+		baseUrl := os.Getenv("CONTENT_BASE_URL")
+		if baseUrl == "" {
+			baseUrl = LOCAL_CONTENT_ROUTE
+		}
+		return NewLocalBlobStore(dir, baseUrl)
+	}
+	return NewS3BlobStore(os.Getenv("REGION"), os.Getenv("CONTENT_BUCKET"), os.Getenv("CONTENT_BASE_URL"))
+}
+
+type S3BlobStore struct {
+	client  *s3.S3
+	bucket  string
+	baseUrl string
+}
+
+func NewS3BlobStore(region string, bucket string, baseUrl string) (*S3BlobStore, error) {
+	sess, err := session.NewSession(&aws.Config{
+		Region: aws.String(region),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &S3BlobStore{client: s3.New(sess), bucket: bucket, baseUrl: strings.TrimSuffix(baseUrl, "/")}, nil
+}
+
+func (s *S3BlobStore) Put(ctx context.Context, key string, body io.ReadSeeker, contentType string) error {
+	_, err := s.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        body,
+		ContentType: aws.String(contentType),
+	})
+	return err
+}
+
+func (s *S3BlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	out, err := s.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Body, nil
+}
+
+func (s *S3BlobStore) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
+
+func (s *S3BlobStore) URL(key string) string {
+	return s.baseUrl + "/" + key
+}
+
+// keeps content on local disk, meant for development without an s3 bucket
+type LocalBlobStore struct {
+	root    string
+	baseUrl string
+}
+
+func NewLocalBlobStore(root string, baseUrl string) (*LocalBlobStore, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+	return &LocalBlobStore{root: root, baseUrl: strings.TrimSuffix(baseUrl, "/")}, nil
+}
+
+func (s *LocalBlobStore) Root() string {
+	return s.root
+}
+
+func (s *LocalBlobStore) Put(ctx context.Context, key string, body io.ReadSeeker, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	// write to a temporary file first so readers never see a partial upload
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *LocalBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+func (s *LocalBlobStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalBlobStore) URL(key string) string {
+	return s.baseUrl + "/" + key
+}
+
+// resolves a key under the root, refusing keys that would escape it
+func (s *LocalBlobStore) path(key string) (string, error) {
+	cleaned := filepath.Clean("/" + key)
+	if cleaned == "/" || strings.Contains(key, "..") {
+		return "", &ErrorResp{Message: "invalid content key " + key}
+	}
+	return filepath.Join(s.root, filepath.FromSlash(cleaned)), nil
+}
+
+// the key of a url served from the store, false for urls that live somewhere else
+func BlobKey(store BlobStore, url string) (string, bool) {
+	key, ok := strings.CutPrefix(url, store.URL(""))
+	return key, ok && key != ""
+}
@@ -6,13 +6,24 @@ import (
 	"xo-packs/model"
 )
 
+// how much of the base amount an item keeps for each tier it sits above the least rare tier in the pack.
+// tiers further up than the table reaches keep the last share
+var oddsTierDeltas = []float64{0.75, 0.5, .15, .10}
+
+func oddsTierDelta(step int) float64 {
+	if step <= 0 {
+		return 0
+	}
+	return oddsTierDeltas[min(step, len(oddsTierDeltas))-1]
+}
+
 type BasicItem struct {
 	ItemId uint64
 	Rarity int
 	Amount int
 }
 
-func DistributeBehind(items []*BasicItem, amountRemaining int, prev *BasicItem, leastRare int, deltaMap map[int]float64) {
+func DistributeBehind(items []*BasicItem, amountRemaining int, prev *BasicItem, leastRare int) {
 	if len(items) == 0 {
 		return
 	}
@@ -22,25 +33,27 @@ func DistributeBehind(items []*BasicItem, amountRemaining int, prev *BasicItem,
 
 	if item.Rarity == prev.Rarity {
 		item.Amount = prev.Amount
-		DistributeBehind(items[:idx], amountRemaining, item, leastRare, deltaMap)
+		DistributeBehind(items[:idx], amountRemaining, item, leastRare)
 		return
 	} else if item.Rarity == leastRare {
 		keep := int(max(math.Floor(float64((amountRemaining)/(idx+1))), 1))
 		item.Amount += keep
 		amountRemaining -= keep
-		DistributeBehind(items[:idx], amountRemaining, item, leastRare, deltaMap)
+		DistributeBehind(items[:idx], amountRemaining, item, leastRare)
 		return
 	} else {
-		deltaPct := deltaMap[item.Rarity-leastRare]
+		deltaPct := oddsTierDelta(item.Rarity - leastRare)
 		keep := int(max(math.Floor(float64(amountRemaining/(idx+1))*deltaPct), 1))
 		item.Amount += keep
 		amountRemaining -= keep
-		DistributeBehind(items[:idx], amountRemaining, item, leastRare, deltaMap)
+		DistributeBehind(items[:idx], amountRemaining, item, leastRare)
 		return
 	}
 }
 
-func GenerateOdds(rawItems []model.Item, totalItems int) (map[uint64]int, error) {
+// splits the total items of a pack config between its items, the rarer an item the fewer it gets. items are
+// expected in ascending order of tier, rarityTiers maps each rarity id to its tier as given by RarityTiers
+func GenerateOdds(rawItems []model.Item, totalItems int, rarityTiers map[uint64]int) (map[uint64]int, error) {
 	oddsMap := map[uint64]int{}
 
 	if len(rawItems) == 0 {
@@ -49,18 +62,19 @@ func GenerateOdds(rawItems []model.Item, totalItems int) (map[uint64]int, error)
 
 	// build basic items, determine least rare, build rarity freq map
 	basicItems := []*BasicItem{}
-	leastRare := 5
-	rarityFreqMap := map[int]int{
-		1: 0,
-		2: 0,
-		3: 0,
-		4: 0,
-		5: 0,
-	}
+	leastRare := math.MaxInt
+	rarityFreqMap := map[int]int{}
 	for _, item := range rawItems {
+		if item.RarityId == nil {
+			return nil, &ErrorResp{Message: fmt.Sprintf("item %v has no rarity", *item.ID)}
+		}
+		tier, ok := rarityTiers[*item.RarityId]
+		if !ok {
+			return nil, &ErrorResp{Message: fmt.Sprintf("item %v has unknown rarity %v", *item.ID, *item.RarityId)}
+		}
 		basicItem := BasicItem{
 			ItemId: *item.ID,
-			Rarity: int(*item.RarityId),
+			Rarity: tier,
 			Amount: 0,
 		}
 		basicItems = append(basicItems, &basicItem)
@@ -72,23 +86,17 @@ func GenerateOdds(rawItems []model.Item, totalItems int) (map[uint64]int, error)
 
 	// apply item amount
 	baseAmount := int(math.Floor(float64(totalItems / len(basicItems))))
-	deltaMap := map[int]float64{
-		1: 0.75,
-		2: 0.5,
-		3: .15,
-		4: .10,
-	}
 	prev := new(BasicItem)
 	for i, item := range basicItems {
 		if prev.ItemId != 0 {
 			if item.Rarity == prev.Rarity {
 				item.Amount = prev.Amount
 			} else if item.Rarity > prev.Rarity {
-				delta := deltaMap[item.Rarity-leastRare]
+				delta := oddsTierDelta(item.Rarity - leastRare)
 				keep := int(max(math.Floor((float64(baseAmount) * delta)), 1))
 				item.Amount += keep
 				amountRemaining := baseAmount - keep
-				DistributeBehind(basicItems[:i], amountRemaining, item, leastRare, deltaMap)
+				DistributeBehind(basicItems[:i], amountRemaining, item, leastRare)
 			}
 		} else {
 			item.Amount = baseAmount
@@ -141,3 +149,12 @@ func GenerateOdds(rawItems []model.Item, totalItems int) (map[uint64]int, error)
 	}
 	return oddsMap, nil
 }
+
+// scales the qty of an item config copied from a pack of sourcePacks packs to a pack of packs packs. rounding up
+// keeps the odds of every item and never leaves the copied items short of filling the new packs
+func ScalePackItemQty(qty int, sourcePacks int, packs int) int {
+	if sourcePacks <= 0 || packs == sourcePacks {
+		return qty
+	}
+	return (qty*packs + sourcePacks - 1) / sourcePacks
+}
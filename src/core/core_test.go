@@ -18,10 +18,51 @@ func TestGenerateOdds(t *testing.T) {
 		items = append(items, item)
 	}
 
-	odds, err := GenerateOdds(items, 1000)
+	tiers := map[uint64]int{1: 1, 2: 2, 3: 3, 4: 4, 5: 5}
+	odds, err := GenerateOdds(items, 1000, tiers)
 	if err != nil {
 		t.Error(err)
 	}
 
+	total := 0
+	for _, amount := range odds {
+		total += amount
+	}
+	if total != 1000 {
+		t.Errorf("expected the odds to add up to 1000, got %v", total)
+	}
+	if odds[1] <= odds[6] || odds[6] <= odds[9] || odds[9] <= odds[11] || odds[11] <= odds[12] {
+		t.Errorf("expected rarer items to get fewer of the total, got %v", odds)
+	}
+
+	unknown := uint64(9)
+	items = append(items, model.Item{ID: &unknown, RarityId: &unknown})
+	if _, err := GenerateOdds(items, 1000, tiers); err == nil {
+		t.Errorf("expected an item with an unknown rarity to be refused")
+	}
+
 	fmt.Println(odds)
-}
\ No newline at end of file
+}
+
+func TestScalePackItemQty(t *testing.T) {
+	cases := []struct{ qty, sourcePacks, packs, expected int }{
+		{50, 100, 100, 50},
+		{50, 100, 500, 250},
+		{3, 100, 50, 2},
+		{7, 0, 10, 7},
+	}
+	for _, tc := range cases {
+		if scaled := ScalePackItemQty(tc.qty, tc.sourcePacks, tc.packs); scaled != tc.expected {
+			t.Errorf("expected %v items for %v packs scaled to %v, got %v", tc.qty, tc.sourcePacks, tc.packs, scaled)
+		}
+	}
+
+	// a 100 pack config cloned to 500 packs still fills every slot
+	pool := 0
+	for _, qty := range []int{150, 200, 150} {
+		pool += ScalePackItemQty(qty, 100, 500)
+	}
+	if pool < 500*5 {
+		t.Errorf("expected the scaled pool to fill 2500 slots, got %v", pool)
+	}
+}
@@ -0,0 +1,75 @@
+package core
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"xo-packs/model"
+)
+
+// checks that the submitted items are exactly what a recipe asks for. an input names either a specific item
+// or a rarity, a rarity input only accepts the recipe creators own items. specific item inputs are filled first
+// so an item is never spent on a rarity input while its own slot is still open
+func MatchRecipeInputs(inputs []*model.CraftingRecipeInput, items []*model.CraftableItem, vendorId string) error {
+	itemsNeeded := map[uint64]int{}
+	raritiesNeeded := map[uint64]int{}
+	for _, input := range inputs {
+		if input.ItemId != nil {
+			itemsNeeded[*input.ItemId] += *input.Qty
+		} else if input.RarityId != nil {
+			raritiesNeeded[*input.RarityId] += *input.Qty
+		}
+	}
+
+	for _, item := range items {
+		if itemsNeeded[*item.ItemId] > 0 {
+			itemsNeeded[*item.ItemId]--
+			continue
+		}
+		if item.VendorId != nil && *item.VendorId == vendorId && item.RarityId != nil && raritiesNeeded[*item.RarityId] > 0 {
+			raritiesNeeded[*item.RarityId]--
+			continue
+		}
+		return &ErrorResp{Message: fmt.Sprintf("user item %v is not an input of this recipe", *item.UserItemId)}
+	}
+
+	for _, qty := range itemsNeeded {
+		if qty > 0 {
+			return &ErrorResp{Message: "not enough items were given for this recipe"}
+		}
+	}
+	for _, qty := range raritiesNeeded {
+		if qty > 0 {
+			return &ErrorResp{Message: "not enough items were given for this recipe"}
+		}
+	}
+	return nil
+}
+
+// picks the outcome a roll lands on, the roll must be in [0, total weight)
+func PickCraftingOutcome(outcomes []*model.CraftingRecipeOutcome, roll int) *model.CraftingRecipeOutcome {
+	for _, outcome := range outcomes {
+		if roll < *outcome.Weight {
+			return outcome
+		}
+		roll -= *outcome.Weight
+	}
+	return nil
+}
+
+// draws a recipe outcome with each outcomes chance proportional to its weight
+func RollCraftingOutcome(outcomes []*model.CraftingRecipeOutcome) (*model.CraftingRecipeOutcome, error) {
+	totalWeight := 0
+	for _, outcome := range outcomes {
+		totalWeight += *outcome.Weight
+	}
+	if totalWeight <= 0 {
+		return nil, &ErrorResp{Message: "recipe has no outcomes"}
+	}
+
+	roll, err := rand.Int(rand.Reader, big.NewInt(int64(totalWeight)))
+	if err != nil {
+		return nil, err
+	}
+	return PickCraftingOutcome(outcomes, int(roll.Int64())), nil
+}
@@ -0,0 +1,68 @@
+package core
+
+import (
+	"testing"
+	"xo-packs/model"
+)
+
+func TestMatchRecipeInputs(t *testing.T) {
+	creator := "creator"
+	other := "other"
+	common := uint64(1)
+	rare := uint64(2)
+	specificItem := uint64(10)
+	one := 1
+	two := 2
+	inputs := []*model.CraftingRecipeInput{
+		{ItemId: &specificItem, Qty: &one},
+		{RarityId: &common, Qty: &two},
+	}
+
+	newItem := func(userItemId uint64, itemId uint64, vendorId *string, rarityId *uint64) *model.CraftableItem {
+		return &model.CraftableItem{UserItemId: &userItemId, ItemId: &itemId, VendorId: vendorId, RarityId: rarityId}
+	}
+
+	// the specific item is also a common, its second copy counts towards the rarity input
+	valid := []*model.CraftableItem{
+		newItem(1, specificItem, &creator, &common),
+		newItem(2, 11, &creator, &common),
+		newItem(3, specificItem, &creator, &common),
+	}
+	if err := MatchRecipeInputs(inputs, valid, creator); err != nil {
+		t.Errorf("expected items to match recipe, got %v", err)
+	}
+
+	missing := valid[:2]
+	if err := MatchRecipeInputs(inputs, missing, creator); err == nil {
+		t.Errorf("expected too few items to fail")
+	}
+
+	otherCreator := []*model.CraftableItem{valid[0], valid[1], newItem(4, 12, &other, &common)}
+	if err := MatchRecipeInputs(inputs, otherCreator, creator); err == nil {
+		t.Errorf("expected another creators common to be rejected")
+	}
+
+	wrongRarity := []*model.CraftableItem{valid[0], valid[1], newItem(5, 13, &creator, &rare)}
+	if err := MatchRecipeInputs(inputs, wrongRarity, creator); err == nil {
+		t.Errorf("expected a rare to be rejected for a common input")
+	}
+}
+
+func TestPickCraftingOutcome(t *testing.T) {
+	itemIds := []uint64{1, 2, 3}
+	weights := []int{70, 25, 5}
+	outcomes := []*model.CraftingRecipeOutcome{}
+	for i := range itemIds {
+		outcomes = append(outcomes, &model.CraftingRecipeOutcome{ItemId: &itemIds[i], Weight: &weights[i]})
+	}
+
+	cases := map[int]uint64{0: 1, 69: 1, 70: 2, 94: 2, 95: 3, 99: 3}
+	for roll, expected := range cases {
+		if outcome := PickCraftingOutcome(outcomes, roll); outcome == nil || *outcome.ItemId != expected {
+			t.Errorf("expected roll %v to land on item %v", roll, expected)
+		}
+	}
+	if outcome := PickCraftingOutcome(outcomes, 100); outcome != nil {
+		t.Errorf("expected a roll past the total weight to land on nothing")
+	}
+}
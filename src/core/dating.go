@@ -47,3 +47,62 @@ func GenerateLast5Years() []string {
 
 	return years
 }
+
+// returns the age in whole years on the given date of someone born on birthday
+func AgeOn(birthday string, now time.Time) (int, error) {
+	var born time.Time
+	var err error
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+		born, err = time.Parse(layout, birthday)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	age := now.Year() - born.Year()
+	if now.Month() < born.Month() || (now.Month() == born.Month() && now.Day() < born.Day()) {
+		age--
+	}
+	return age, nil
+}
+
+// reports whether a user item with the given expired_at has run out its lifetime by now. items without a
+// lifetime have no expired_at and never expire. timestamps are stored as wall clock time so the zone the db
+// hands back is ignored, an unreadable timestamp is treated as expired
+func UserItemExpired(expiredAt *string, now time.Time) bool {
+	if expiredAt == nil {
+		return false
+	}
+	expires, ok := wallClockTime(*expiredAt, now.Location())
+	return !ok || !expires.After(now)
+}
+
+// reports whether a stored timestamp, such as the start or end of an auction, has been reached by now. an
+// unreadable timestamp is treated as reached
+func TimeReached(timestamp string, now time.Time) bool {
+	t, ok := wallClockTime(timestamp, now.Location())
+	return !ok || !t.After(now)
+}
+
+// anti sniping. a bid placed within the final window of an auction pushes the end out so that the window is
+// open again from the time of the bid, bids placed earlier leave the end as it is
+func AuctionEndAfterBid(endsAt string, bidAt time.Time, window time.Duration) time.Time {
+	ends, ok := wallClockTime(endsAt, bidAt.Location())
+	if !ok || ends.Sub(bidAt) < window {
+		return bidAt.Add(window)
+	}
+	return ends
+}
+
+// reads a timestamp stored as wall clock time in the given location, ignoring any zone the db attached to it
+func wallClockTime(timestamp string, loc *time.Location) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(layout, timestamp); err == nil {
+			return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc), true
+		}
+	}
+	return time.Time{}, false
+}
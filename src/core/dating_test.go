@@ -0,0 +1,42 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUserItemExpired(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	past := "2024-06-15T11:59:59Z"
+	exact := "2024-06-15 12:00:00"
+	future := "2024-06-15T12:00:01Z"
+	garbled := "next tuesday"
+
+	if UserItemExpired(nil, now) {
+		t.Errorf("expected an item without a lifetime to never expire")
+	}
+	if !UserItemExpired(&past, now) || !UserItemExpired(&exact, now) {
+		t.Errorf("expected an item past its expiry to be expired")
+	}
+	if UserItemExpired(&future, now) {
+		t.Errorf("expected an item before its expiry to be held")
+	}
+	if !UserItemExpired(&garbled, now) {
+		t.Errorf("expected an unreadable expiry to be treated as expired")
+	}
+}
+
+func TestAuctionEndAfterBid(t *testing.T) {
+	window := 2 * time.Minute
+	bidAt := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	if ends := AuctionEndAfterBid("2024-06-15T12:30:00Z", bidAt, window); !ends.Equal(time.Date(2024, 6, 15, 12, 30, 0, 0, time.UTC)) {
+		t.Errorf("expected an early bid to leave the end alone, got %v", ends)
+	}
+	if ends := AuctionEndAfterBid("2024-06-15 12:01:00", bidAt, window); !ends.Equal(bidAt.Add(window)) {
+		t.Errorf("expected a late bid to extend the end, got %v", ends)
+	}
+	if TimeReached("2024-06-15T12:00:01Z", bidAt) || !TimeReached("2024-06-15T12:00:00Z", bidAt) {
+		t.Errorf("expected an auction to end at its end time")
+	}
+}
@@ -0,0 +1,20 @@
+package core
+
+import "xo-packs/db"
+
+// the statuses a withdrawal can move to from each status. a withdrawal can only be cancelled before it has
+// shipped, once it is delivered or cancelled it is final
+var withdrawalTransitions = map[string][]string{
+	db.WITHDRAWAL_REQUESTED: {db.WITHDRAWAL_PACKED, db.WITHDRAWAL_SHIPPED, db.WITHDRAWAL_CANCELLED},
+	db.WITHDRAWAL_PACKED:    {db.WITHDRAWAL_SHIPPED, db.WITHDRAWAL_CANCELLED},
+	db.WITHDRAWAL_SHIPPED:   {db.WITHDRAWAL_DELIVERED},
+}
+
+func WithdrawalTransitionAllowed(from string, to string) bool {
+	for _, status := range withdrawalTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package core
+
+import (
+	"testing"
+	"xo-packs/db"
+)
+
+func TestWithdrawalTransitionAllowed(t *testing.T) {
+	allowed := [][2]string{
+		{db.WITHDRAWAL_REQUESTED, db.WITHDRAWAL_PACKED},
+		{db.WITHDRAWAL_REQUESTED, db.WITHDRAWAL_CANCELLED},
+		{db.WITHDRAWAL_PACKED, db.WITHDRAWAL_SHIPPED},
+		{db.WITHDRAWAL_SHIPPED, db.WITHDRAWAL_DELIVERED},
+	}
+	for _, transition := range allowed {
+		if !WithdrawalTransitionAllowed(transition[0], transition[1]) {
+			t.Errorf("expected %v to %v to be allowed", transition[0], transition[1])
+		}
+	}
+
+	denied := [][2]string{
+		{db.WITHDRAWAL_REQUESTED, db.WITHDRAWAL_DELIVERED},
+		{db.WITHDRAWAL_SHIPPED, db.WITHDRAWAL_CANCELLED},
+		{db.WITHDRAWAL_DELIVERED, db.WITHDRAWAL_CANCELLED},
+		{db.WITHDRAWAL_CANCELLED, db.WITHDRAWAL_REQUESTED},
+		{db.WITHDRAWAL_PACKED, db.WITHDRAWAL_PACKED},
+	}
+	for _, transition := range denied {
+		if WithdrawalTransitionAllowed(transition[0], transition[1]) {
+			t.Errorf("expected %v to %v to be denied", transition[0], transition[1])
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+)
+
+const (
+	RENDITION_THUMB_PREFIX = "thumb_"
+	RENDITION_TEASER       = "teaser"
+	TEASER_SIZE            = 256
+	TEASER_CELLS           = 12
+	RENDITION_QUALITY      = 85
+	REENCODE_QUALITY       = 92
+)
+
+// longest edge of each generated thumbnail
+var thumbnailSizes = []int{128, 256, 512}
+
+// an image derived from uploaded content, always a jpeg
+type Rendition struct {
+	Name   string
+	Data   []byte
+	Width  int
+	Height int
+}
+
+func ThumbnailRenditionName(size int) string {
+	return fmt.Sprintf("%v%v", RENDITION_THUMB_PREFIX, size)
+}
+
+// the largest thumbnail, used as an items thumbnail when the creator did not upload one
+func LargestThumbnailName() string {
+	return ThumbnailRenditionName(thumbnailSizes[len(thumbnailSizes)-1])
+}
+
+// drops exif, xmp, iptc and text metadata from jpeg and png uploads so location and camera details never
+// reach other users. jpegs that carry an exif orientation are rotated upright first since the tag that told
+// viewers how to display them goes away. other types are left as they are
+func StripMetadata(upload *ValidatedUpload) error {
+	data, err := io.ReadAll(upload.Body)
+	if err != nil {
+		return err
+	}
+
+	switch upload.MimeType {
+	case "image/jpeg":
+		data, err = stripJPEGMetadata(data)
+	case "image/png":
+		data, err = stripPNGMetadata(data)
+	}
+	if err != nil {
+		return err
+	}
+
+	upload.Body = bytes.NewReader(data)
+	upload.Size = int64(len(data))
+	return nil
+}
+
+// builds the thumbnails and the locked item teaser. formats without a decoder, like webp, get no renditions
+func GenerateRenditions(upload *ValidatedUpload) ([]*Rendition, error) {
+	img, _, err := image.Decode(upload.Body)
+	if _, seekErr := upload.Body.Seek(0, io.SeekStart); seekErr != nil {
+		return nil, seekErr
+	}
+	if err != nil {
+		if errors.Is(err, image.ErrFormat) {
+			return []*Rendition{}, nil
+		}
+		return nil, err
+	}
+
+	flat := flatten(img)
+	renditions := []*Rendition{}
+	for _, size := range thumbnailSizes {
+		rendition, err := encodeRendition(ThumbnailRenditionName(size), resize(flat, size))
+		if err != nil {
+			return nil, err
+		}
+		renditions = append(renditions, rendition)
+	}
+
+	teaser, err := encodeRendition(RENDITION_TEASER, pixelate(flat, TEASER_SIZE, TEASER_CELLS))
+	if err != nil {
+		return nil, err
+	}
+	return append(renditions, teaser), nil
+}
+
+func encodeRendition(name string, img *image.RGBA) (*Rendition, error) {
+	buf := bytes.Buffer{}
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: RENDITION_QUALITY}); err != nil {
+		return nil, err
+	}
+	return &Rendition{Name: name, Data: buf.Bytes(), Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}, nil
+}
+
+// draws the image onto white so transparent areas do not turn black in a jpeg
+func flatten(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	flat := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, bounds.Min, draw.Over)
+	return flat
+}
+
+// scales the image down so its longest edge is maxEdge, averaging every source pixel that lands in a
+// destination pixel. images that already fit are copied as they are
+func resize(src *image.RGBA, maxEdge int) *image.RGBA {
+	width, height := src.Bounds().Dx(), src.Bounds().Dy()
+	dstWidth, dstHeight := fitWithin(width, height, maxEdge)
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+
+	for dy := 0; dy < dstHeight; dy++ {
+		y0 := dy * height / dstHeight
+		y1 := max(y0+1, (dy+1)*height/dstHeight)
+		for dx := 0; dx < dstWidth; dx++ {
+			x0 := dx * width / dstWidth
+			x1 := max(x0+1, (dx+1)*width/dstWidth)
+
+			var r, g, b, a, count uint64
+			for y := y0; y < y1; y++ {
+				row := src.Pix[y*src.Stride:]
+				for x := x0; x < x1; x++ {
+					r += uint64(row[x*4])
+					g += uint64(row[x*4+1])
+					b += uint64(row[x*4+2])
+					a += uint64(row[x*4+3])
+					count++
+				}
+			}
+			i := dst.PixOffset(dx, dy)
+			dst.Pix[i] = uint8(r / count)
+			dst.Pix[i+1] = uint8(g / count)
+			dst.Pix[i+2] = uint8(b / count)
+			dst.Pix[i+3] = uint8(a / count)
+		}
+	}
+	return dst
+}
+
+// shrinks the image to a handful of cells and blows it back up without smoothing, enough to hint at the
+// colours of a locked item without giving it away
+func pixelate(src *image.RGBA, size int, cells int) *image.RGBA {
+	small := resize(src, cells)
+	width, height := fitWithin(src.Bounds().Dx(), src.Bounds().Dy(), size)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		sy := y * small.Bounds().Dy() / height
+		for x := 0; x < width; x++ {
+			sx := x * small.Bounds().Dx() / width
+			copy(dst.Pix[dst.PixOffset(x, y):dst.PixOffset(x, y)+4], small.Pix[small.PixOffset(sx, sy):small.PixOffset(sx, sy)+4])
+		}
+	}
+	return dst
+}
+
+func fitWithin(width int, height int, maxEdge int) (int, int) {
+	if width <= maxEdge && height <= maxEdge {
+		return width, height
+	}
+	if width >= height {
+		return maxEdge, max(1, height*maxEdge/width)
+	}
+	return max(1, width*maxEdge/height), maxEdge
+}
+
+// copies every jpeg segment except app1 (exif, xmp), app13 (iptc) and comments. the entropy coded data
+// after the start of scan is copied untouched so the image is not recompressed
+func stripJPEGMetadata(data []byte) ([]byte, error) {
+	invalid := &ErrorResp{Message: "invalid jpeg file"}
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, invalid
+	}
+
+	out := bytes.Buffer{}
+	out.Write(data[:2])
+	orientation := 1
+	for i := 2; ; {
+		if i+2 > len(data) || data[i] != 0xFF {
+			return nil, invalid
+		}
+		marker := data[i+1]
+		if marker == 0xFF {
+			i++
+			continue
+		}
+		if marker == 0xDA {
+			out.Write(data[i:])
+			break
+		}
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			out.Write(data[i : i+2])
+			i += 2
+			continue
+		}
+		if i+4 > len(data) {
+			return nil, invalid
+		}
+		end := i + 2 + int(binary.BigEndian.Uint16(data[i+2:i+4]))
+		if end < i+4 || end > len(data) {
+			return nil, invalid
+		}
+
+		switch marker {
+		case 0xE1:
+			if o := exifOrientation(data[i+4 : end]); o != 0 {
+				orientation = o
+			}
+		case 0xED, 0xFE:
+		default:
+			out.Write(data[i:end])
+		}
+		i = end
+	}
+
+	if orientation == 1 {
+		return out.Bytes(), nil
+	}
+	img, err := jpeg.Decode(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		return nil, err
+	}
+	buf := bytes.Buffer{}
+	if err := jpeg.Encode(&buf, orient(img, orientation), &jpeg.Options{Quality: REENCODE_QUALITY}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// reads the orientation tag from the first ifd of an app1 exif payload, 0 when there is none
+func exifOrientation(payload []byte) int {
+	if !bytes.HasPrefix(payload, []byte("Exif\x00\x00")) {
+		return 0
+	}
+	tiff := payload[6:]
+	if len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+
+	offset := int(order.Uint32(tiff[4:8]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return 0
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	for n := 0; n < count; n++ {
+		entry := offset + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			if o := int(order.Uint16(tiff[entry+8:])); o >= 1 && o <= 8 {
+				return o
+			}
+			return 0
+		}
+	}
+	return 0
+}
+
+// applies an exif orientation so the pixels are stored the way the image is meant to be viewed
+func orient(img image.Image, orientation int) *image.RGBA {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	dstWidth, dstHeight := width, height
+	if orientation >= 5 {
+		dstWidth, dstHeight = height, width
+	}
+
+	// where each destination pixel comes from in the source
+	source := func(x int, y int) (int, int) {
+		switch orientation {
+		case 2:
+			return width - 1 - x, y
+		case 3:
+			return width - 1 - x, height - 1 - y
+		case 4:
+			return x, height - 1 - y
+		case 5:
+			return y, x
+		case 6:
+			return y, height - 1 - x
+		case 7:
+			return width - 1 - y, height - 1 - x
+		case 8:
+			return width - 1 - y, x
+		}
+		return x, y
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		for x := 0; x < dstWidth; x++ {
+			sx, sy := source(x, y)
+			dst.Set(x, y, img.At(bounds.Min.X+sx, bounds.Min.Y+sy))
+		}
+	}
+	return dst
+}
+
+// copies every png chunk except the textual and exif ones
+func stripPNGMetadata(data []byte) ([]byte, error) {
+	invalid := &ErrorResp{Message: "invalid png file"}
+	signature := []byte("\x89PNG\r\n\x1a\n")
+	if !bytes.HasPrefix(data, signature) {
+		return nil, invalid
+	}
+
+	out := bytes.Buffer{}
+	out.Write(signature)
+	for i := len(signature); i < len(data); {
+		if i+8 > len(data) {
+			return nil, invalid
+		}
+		end := i + 12 + int(binary.BigEndian.Uint32(data[i:i+4]))
+		if end < i+12 || end > len(data) {
+			return nil, invalid
+		}
+		switch string(data[i+4 : i+8]) {
+		case "eXIf", "tEXt", "zTXt", "iTXt", "tIME":
+		default:
+			out.Write(data[i:end])
+		}
+		i = end
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func TestStripMetadata(t *testing.T) {
+	buf := bytes.Buffer{}
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 2)), nil); err != nil {
+		t.Fatal(err)
+	}
+	// exif app1 segment with a single big endian orientation entry of 6, rotate 90 clockwise
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08\x00\x01\x01\x12\x00\x03\x00\x00\x00\x01\x00\x06\x00\x00\x00\x00\x00\x00")
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := append([]byte{0xFF, 0xE1, 0x00, byte(len(payload) + 2)}, payload...)
+	withExif := append(append(append([]byte{}, buf.Bytes()[:2]...), segment...), buf.Bytes()[2:]...)
+
+	upload := &ValidatedUpload{Body: bytes.NewReader(withExif), MimeType: "image/jpeg", Size: int64(len(withExif))}
+	if err := StripMetadata(upload); err != nil {
+		t.Fatal(err)
+	}
+	stripped := make([]byte, upload.Size)
+	if _, err := upload.Body.Read(stripped); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(stripped, []byte("Exif")) {
+		t.Errorf("expected the exif segment to be stripped")
+	}
+	config, err := jpeg.DecodeConfig(bytes.NewReader(stripped))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Width != 2 || config.Height != 4 {
+		t.Errorf("expected the orientation to be applied, got %vx%v", config.Width, config.Height)
+	}
+
+	buf.Reset()
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	// text chunk with a dummy crc right after the ihdr chunk
+	text := []byte("\x00\x00\x00\x08tEXtGPS\x0052.1\x00\x00\x00\x00")
+	ihdrEnd := 8 + 12 + 13
+	withText := append(append(append([]byte{}, buf.Bytes()[:ihdrEnd]...), text...), buf.Bytes()[ihdrEnd:]...)
+	upload = &ValidatedUpload{Body: bytes.NewReader(withText), MimeType: "image/png", Size: int64(len(withText))}
+	if err := StripMetadata(upload); err != nil {
+		t.Fatal(err)
+	}
+	if upload.Size != int64(buf.Len()) {
+		t.Errorf("expected the text chunk to be stripped, got %v bytes instead of %v", upload.Size, buf.Len())
+	}
+}
+
+func TestGenerateRenditions(t *testing.T) {
+	buf := bytes.Buffer{}
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 600, 300))); err != nil {
+		t.Fatal(err)
+	}
+	upload := &ValidatedUpload{Body: bytes.NewReader(buf.Bytes()), MimeType: "image/png", Size: int64(buf.Len())}
+	renditions, err := GenerateRenditions(upload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string][2]int{"thumb_128": {128, 64}, "thumb_256": {256, 128}, "thumb_512": {512, 256}, RENDITION_TEASER: {256, 128}}
+	if len(renditions) != len(expected) {
+		t.Fatalf("expected %v renditions, got %v", len(expected), len(renditions))
+	}
+	for _, rendition := range renditions {
+		size, ok := expected[rendition.Name]
+		if !ok || rendition.Width != size[0] || rendition.Height != size[1] {
+			t.Errorf("unexpected rendition %v at %vx%v", rendition.Name, rendition.Width, rendition.Height)
+		}
+		if _, err := jpeg.DecodeConfig(bytes.NewReader(rendition.Data)); err != nil {
+			t.Errorf("expected rendition %v to be a jpeg, got %v", rendition.Name, err)
+		}
+	}
+}
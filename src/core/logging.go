@@ -19,3 +19,8 @@ func AddLog(fields logrus.Fields, ctx *gin.Context, infoMsg string) error {
 	}
 	return &ErrorResp{Message: "request log entry does not exist"}
 }
+
+// AddBackgroundLog logs an entry outside of a request, e.g. from a background job
+func AddBackgroundLog(fields logrus.Fields, infoMsg string) {
+	logrus.WithFields(fields).Info(infoMsg)
+}
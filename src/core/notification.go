@@ -0,0 +1,134 @@
+package core
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const WEBHOOK_SIGNATURE_HEADER = "X-XoPacks-Signature"
+
+// ranges that are not reachable on the public internet but are not covered by the net.IP checks
+var reservedNetworks = mustParseCIDRs("0.0.0.0/8", "100.64.0.0/10", "192.0.0.0/24", "198.18.0.0/15", "240.0.0.0/4")
+
+// what a creator is told when a fan pulls one of their notify items
+func NotifyPullMessage(username string, itemName string) string {
+	if username == "" {
+		return fmt.Sprintf("A fan pulled %v", itemName)
+	}
+	return fmt.Sprintf("@%v pulled %v", username, itemName)
+}
+
+// what a creator is told when an admin reviews one of their packs
+func PackReviewMessage(packTitle string, approved bool, notes *string) string {
+	if approved {
+		return fmt.Sprintf("%v was approved and can now be activated", packTitle)
+	}
+	if notes == nil || *notes == "" {
+		return fmt.Sprintf("%v was rejected", packTitle)
+	}
+	return fmt.Sprintf("%v was rejected: %v", packTitle, *notes)
+}
+
+// what a waitlisted fan is told when a pack they are waiting on goes on sale
+func PackWaitlistMessage(packTitle string) string {
+	return fmt.Sprintf("%v is on sale now", packTitle)
+}
+
+// the hex hmac-sha256 of a webhook body, sent in WEBHOOK_SIGNATURE_HEADER so creators can check a call came
+// from us
+func SignWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func NewWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// webhooks are only called over https so fan details are never sent in the clear. hosts that are plainly
+// internal are turned away here, the webhook client still checks every address it actually connects to
+func ValidWebhookUrl(rawUrl string) bool {
+	parsed, err := url.Parse(rawUrl)
+	if err != nil || parsed.Scheme != "https" || parsed.Hostname() == "" || parsed.User != nil {
+		return false
+	}
+	host := strings.ToLower(strings.TrimSuffix(parsed.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") || strings.HasSuffix(host, ".internal") || strings.HasSuffix(host, ".local") {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return PublicIP(ip)
+	}
+	return true
+}
+
+// whether an address is on the public internet, loopback, private, link local and cloud metadata addresses are not
+func PublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, network := range reservedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// an http client for calling creator webhooks. the address is checked after dns resolves it, right before connecting,
+// so a hostname that later resolves to an internal address is refused too. redirects are never followed and
+// proxies are not used since the proxy address would be checked instead of the webhook
+func NewWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network string, address string, conn syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !PublicIP(ip) {
+				return &ErrorResp{Message: fmt.Sprintf("webhook address %v is not public", host)}
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return &ErrorResp{Message: "webhook redirects are not followed"}
+		},
+	}
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := []*net.IPNet{}
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
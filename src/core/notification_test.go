@@ -0,0 +1,47 @@
+package core
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhookPayload(t *testing.T) {
+	body := []byte(`{"type":"notify_pull"}`)
+	signature := SignWebhookPayload("secret", body)
+	if signature != SignWebhookPayload("secret", body) {
+		t.Errorf("expected signing to be deterministic")
+	}
+	if signature == SignWebhookPayload("other", body) {
+		t.Errorf("expected another secret to give another signature")
+	}
+	if len(signature) != 64 {
+		t.Errorf("expected a hex sha256 signature, got %v", signature)
+	}
+
+	if !ValidWebhookUrl("https://example.com/hook") || ValidWebhookUrl("http://example.com/hook") || ValidWebhookUrl("https://") {
+		t.Errorf("expected only https urls with a host to be valid")
+	}
+	for _, internal := range []string{
+		"https://169.254.169.254/latest/meta-data",
+		"https://localhost:8080/hook",
+		"https://127.0.0.1/hook",
+		"https://10.0.0.5/hook",
+		"https://[::1]/hook",
+		"https://[fd00:ec2::254]/hook",
+		"https://metadata.google.internal/hook",
+	} {
+		if ValidWebhookUrl(internal) {
+			t.Errorf("expected %v to be rejected", internal)
+		}
+	}
+
+	// the client refuses internal addresses when connecting, whatever the url looked like
+	client := NewWebhookClient(time.Second)
+	if _, err := client.Get("https://127.0.0.1:1/hook"); err == nil || !strings.Contains(err.Error(), "not public") {
+		t.Errorf("expected the webhook client to refuse loopback, got %v", err)
+	}
+	if message := NotifyPullMessage("fan", "Golden Card"); message != "@fan pulled Golden Card" {
+		t.Errorf("unexpected message %v", message)
+	}
+}
@@ -0,0 +1,179 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"xo-packs/model"
+)
+
+// spending limits cap the tokens spent on a users packs over this many hours
+const SPENDING_LIMIT_WINDOW_HOURS = 24
+
+// prices an amount of packs using the pack config price tiers. the tier with the largest min qty that fits the
+// remaining amount is applied first; per pack tiers price the whole remainder while total price tiers are applied
+// as bundles of min qty packs with whatever is left over priced by the smaller tiers or the base token amount
+func TieredPackPrice(amount int, baseTokenAmount float64, tiers []*model.PackPriceTier) float64 {
+	sortedTiers := make([]*model.PackPriceTier, 0, len(tiers))
+	for _, tier := range tiers {
+		if tier.MinQty != nil && *tier.MinQty > 0 {
+			sortedTiers = append(sortedTiers, tier)
+		}
+	}
+	sort.Slice(sortedTiers, func(i, j int) bool {
+		return *sortedTiers[i].MinQty > *sortedTiers[j].MinQty
+	})
+
+	total := 0.0
+	remaining := amount
+	for _, tier := range sortedTiers {
+		if remaining <= 0 {
+			break
+		}
+		if *tier.MinQty > remaining {
+			continue
+		}
+
+		if tier.TotalTokenAmount != nil {
+			bundles := remaining / *tier.MinQty
+			total += float64(bundles) * *tier.TotalTokenAmount
+			remaining -= bundles * *tier.MinQty
+		} else if tier.PackTokenAmount != nil {
+			total += float64(remaining) * *tier.PackTokenAmount
+			remaining = 0
+		}
+	}
+	total += float64(remaining) * baseTokenAmount
+
+	return RoundTokenAmount(total)
+}
+
+func RoundTokenAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// validates a set of price tiers for a pack config. tiers are discounts, so none may cost more per pack
+// than the base token amount of the pack
+func ValidatePackPriceTiers(tiers []*model.PackPriceTier, baseTokenAmount float64) error {
+	seen := map[int]bool{}
+	for _, tier := range tiers {
+		if tier.MinQty == nil || *tier.MinQty < 2 {
+			return &ErrorResp{Message: "price tier min qty must be at least 2"}
+		}
+		if seen[*tier.MinQty] {
+			return &ErrorResp{Message: fmt.Sprintf("there is more than one price tier for min qty %v", *tier.MinQty)}
+		}
+		seen[*tier.MinQty] = true
+
+		if (tier.PackTokenAmount == nil) == (tier.TotalTokenAmount == nil) {
+			return &ErrorResp{Message: "price tier must have either a pack token amount or a total token amount"}
+		}
+		if tier.PackTokenAmount != nil && *tier.PackTokenAmount <= 0 {
+			return &ErrorResp{Message: "price tier pack token amount must be greater than 0"}
+		}
+		if tier.TotalTokenAmount != nil && *tier.TotalTokenAmount <= 0 {
+			return &ErrorResp{Message: "price tier total token amount must be greater than 0"}
+		}
+
+		packTokenAmount := 0.0
+		if tier.PackTokenAmount != nil {
+			packTokenAmount = *tier.PackTokenAmount
+		} else {
+			packTokenAmount = *tier.TotalTokenAmount / float64(*tier.MinQty)
+		}
+		if packTokenAmount > baseTokenAmount {
+			return &ErrorResp{Message: fmt.Sprintf("price tier for min qty %v costs more per pack than the pack price of %v", *tier.MinQty, baseTokenAmount)}
+		}
+	}
+	return nil
+}
+
+// builds one order per pack bought. every order records the effective per pack price of the purchase and the
+// rounding leftover is recorded as the last orders rounding adjustment, so the orders add up to the amount charged
+func NewPackOrders(uid string, packIds []uint64, totalTokenAmount float64, tokenRateId *uint64, orderedAt string) []model.PackOrder {
+	if len(packIds) == 0 {
+		return []model.PackOrder{}
+	}
+	packTokenAmount := RoundTokenAmount(totalTokenAmount / float64(len(packIds)))
+	roundingAdjustment := RoundTokenAmount(totalTokenAmount - packTokenAmount*float64(len(packIds)))
+	noAdjustment := 0.0
+
+	packOrders := make([]model.PackOrder, len(packIds))
+	for i := range packIds {
+		packOrders[i] = model.PackOrder{
+			PackId:             &packIds[i],
+			Uid:                &uid,
+			OrderedAt:          &orderedAt,
+			TokenAmount:        &packTokenAmount,
+			RoundingAdjustment: &noAdjustment,
+			TokenRateId:        tokenRateId,
+		}
+	}
+	packOrders[len(packIds)-1].RoundingAdjustment = &roundingAdjustment
+	return packOrders
+}
+
+const (
+	MARKET_PLATFORM_FEE_RATE    = 0.05
+	MARKET_CREATOR_ROYALTY_RATE = 0.05
+	AUCTION_MIN_INCREMENT_RATE  = 0.05
+	AUCTION_MIN_INCREMENT       = 1
+)
+
+// splits a marketplace sale price into the platform fee, the creator royalty and what is left for the seller.
+// rounding leftovers always land with the seller so the three parts add back up to the sale price
+func MarketSaleSplit(tokenAmount float64) (float64, float64, float64) {
+	platformFee := RoundTokenAmount(tokenAmount * MARKET_PLATFORM_FEE_RATE)
+	creatorRoyalty := RoundTokenAmount(tokenAmount * MARKET_CREATOR_ROYALTY_RATE)
+	sellerProceeds := RoundTokenAmount(tokenAmount - platformFee - creatorRoyalty)
+	return platformFee, creatorRoyalty, sellerProceeds
+}
+
+// picks the burn rate for an item. a creators rate for the items rarity overrides the platform rate,
+// nil is returned when neither is configured
+func ResolveBurnRate(rates []*model.BurnRate, vendorId string, rarityId uint64) *model.BurnRate {
+	var platformRate *model.BurnRate
+	for _, rate := range rates {
+		if rate.RarityId == nil || *rate.RarityId != rarityId {
+			continue
+		}
+		if rate.VendorId != nil && *rate.VendorId == vendorId {
+			return rate
+		}
+		if rate.VendorId == nil {
+			platformRate = rate
+		}
+	}
+	return platformRate
+}
+
+// what burning an item of the given value pays out at the given rate
+func BurnAmount(value float64, rate float64) float64 {
+	if value <= 0 || rate <= 0 {
+		return 0
+	}
+	return RoundTokenAmount(value * rate)
+}
+
+// what the platform pays to buy back an item of the given value at the given percentage of that value
+func BuybackAmount(value float64, percent int) float64 {
+	if value <= 0 || percent <= 0 {
+		return 0
+	}
+	return RoundTokenAmount(value * float64(percent) / 100)
+}
+
+// the lowest bid an auction accepts. the first bid must meet the start price, later bids must beat the
+// current bid by the larger of the flat and the percentage increment
+func MinimumBid(startPrice float64, currentBid *float64) float64 {
+	if currentBid == nil {
+		return RoundTokenAmount(startPrice)
+	}
+	return RoundTokenAmount(*currentBid + max(*currentBid*AUCTION_MIN_INCREMENT_RATE, AUCTION_MIN_INCREMENT))
+}
+
+// splits a winning auction bid into the platform fee and what is left for the creator
+func AuctionSaleSplit(tokenAmount float64) (float64, float64) {
+	platformFee := RoundTokenAmount(tokenAmount * MARKET_PLATFORM_FEE_RATE)
+	return platformFee, RoundTokenAmount(tokenAmount - platformFee)
+}
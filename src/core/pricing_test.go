@@ -0,0 +1,168 @@
+package core
+
+import (
+	"testing"
+	"xo-packs/model"
+)
+
+func TestTieredPackPrice(t *testing.T) {
+	fivePacks := 5
+	bundlePrice := 40.0
+	tenPacks := 10
+	packPrice := 8.0
+	tiers := []*model.PackPriceTier{
+		{MinQty: &fivePacks, TotalTokenAmount: &bundlePrice},
+		{MinQty: &tenPacks, PackTokenAmount: &packPrice},
+	}
+
+	cases := map[int]float64{
+		1:  10,
+		4:  40,
+		5:  40,
+		7:  60,
+		10: 80,
+		12: 96,
+	}
+	for amount, expected := range cases {
+		if price := TieredPackPrice(amount, 10, tiers); price != expected {
+			t.Errorf("expected %v packs to cost %v, got %v", amount, expected, price)
+		}
+	}
+
+	if price := TieredPackPrice(3, 10, nil); price != 30 {
+		t.Errorf("expected 3 packs without tiers to cost 30, got %v", price)
+	}
+}
+
+func TestValidatePackPriceTiers(t *testing.T) {
+	one := 1
+	five := 5
+	price := 40.0
+	if err := ValidatePackPriceTiers([]*model.PackPriceTier{{MinQty: &one, TotalTokenAmount: &price}}, 10); err == nil {
+		t.Error("expected min qty of 1 to be rejected")
+	}
+	if err := ValidatePackPriceTiers([]*model.PackPriceTier{{MinQty: &five, TotalTokenAmount: &price, PackTokenAmount: &price}}, 10); err == nil {
+		t.Error("expected tier with both prices to be rejected")
+	}
+	if err := ValidatePackPriceTiers([]*model.PackPriceTier{{MinQty: &five, TotalTokenAmount: &price}}, 10); err != nil {
+		t.Error(err)
+	}
+	if err := ValidatePackPriceTiers([]*model.PackPriceTier{{MinQty: &five, TotalTokenAmount: &price}}, 7.5); err == nil {
+		t.Error("expected a total price tier costing more per pack than the base price to be rejected")
+	}
+	if err := ValidatePackPriceTiers([]*model.PackPriceTier{{MinQty: &five, PackTokenAmount: &price}}, 10); err == nil {
+		t.Error("expected a pack price tier costing more than the base price to be rejected")
+	}
+}
+
+func TestNewPackOrders(t *testing.T) {
+	tokenRateId := uint64(1)
+	packOrders := NewPackOrders("buyer", []uint64{1, 2, 3}, 10, &tokenRateId, "2024-01-01 00:00:00")
+	if len(packOrders) != 3 {
+		t.Fatalf("expected 3 orders, got %v", len(packOrders))
+	}
+	for i, packOrder := range packOrders {
+		if *packOrder.PackId != uint64(i+1) {
+			t.Errorf("expected order %v to be for pack %v, got %v", i, i+1, *packOrder.PackId)
+		}
+		if *packOrder.TokenAmount != 3.33 {
+			t.Errorf("expected order %v to store the per pack price 3.33, got %v", i, *packOrder.TokenAmount)
+		}
+	}
+	if *packOrders[0].RoundingAdjustment != 0 || *packOrders[1].RoundingAdjustment != 0 {
+		t.Errorf("expected only the last order to carry a rounding adjustment")
+	}
+	if *packOrders[2].RoundingAdjustment != 0.01 {
+		t.Errorf("expected the last order to carry the 0.01 rounding leftover, got %v", *packOrders[2].RoundingAdjustment)
+	}
+
+	for _, total := range []float64{10, 99.99, 0.05, 250} {
+		packIds := []uint64{1, 2, 3, 4, 5, 6, 7}
+		sum := 0.0
+		for _, packOrder := range NewPackOrders("buyer", packIds, total, &tokenRateId, "2024-01-01 00:00:00") {
+			if *packOrder.TokenAmount != RoundTokenAmount(total/7) {
+				t.Errorf("expected every order to store the per pack price %v, got %v", RoundTokenAmount(total/7), *packOrder.TokenAmount)
+			}
+			sum += *packOrder.TokenAmount + *packOrder.RoundingAdjustment
+		}
+		if RoundTokenAmount(sum) != total {
+			t.Errorf("expected orders to add up to %v, got %v", total, sum)
+		}
+	}
+}
+
+func TestMarketSaleSplit(t *testing.T) {
+	cases := []float64{100, 33.33, 0.5, 7.77}
+	for _, price := range cases {
+		fee, royalty, proceeds := MarketSaleSplit(price)
+		if fee < 0 || royalty < 0 || proceeds < 0 {
+			t.Errorf("expected a non negative split of %v, got %v %v %v", price, fee, royalty, proceeds)
+		}
+		if total := RoundTokenAmount(fee + royalty + proceeds); total != price {
+			t.Errorf("expected the split of %v to add up to the price, got %v", price, total)
+		}
+	}
+
+	fee, royalty, proceeds := MarketSaleSplit(100)
+	if fee != 5 || royalty != 5 || proceeds != 90 {
+		t.Errorf("expected a 100 token sale to split 5/5/90, got %v/%v/%v", fee, royalty, proceeds)
+	}
+}
+
+func TestBuybackAmount(t *testing.T) {
+	if amount := BuybackAmount(80, 50); amount != 40 {
+		t.Errorf("expected half of an 80 token item to be 40, got %v", amount)
+	}
+	if amount := BuybackAmount(9.99, 30); amount != RoundTokenAmount(2.997) {
+		t.Errorf("expected the buyback amount to be rounded, got %v", amount)
+	}
+	if amount := BuybackAmount(0, 50); amount != 0 {
+		t.Errorf("expected an item without a value to pay nothing, got %v", amount)
+	}
+	if amount := BuybackAmount(80, 0); amount != 0 {
+		t.Errorf("expected a zero percentage to pay nothing, got %v", amount)
+	}
+}
+
+func TestResolveBurnRate(t *testing.T) {
+	common := uint64(1)
+	rare := uint64(2)
+	creator := "creator"
+	other := "other"
+	platformCommon := &model.BurnRate{RarityId: &common}
+	platformRare := &model.BurnRate{RarityId: &rare}
+	creatorRare := &model.BurnRate{RarityId: &rare, VendorId: &creator}
+	otherCommon := &model.BurnRate{RarityId: &common, VendorId: &other}
+	rates := []*model.BurnRate{creatorRare, platformCommon, otherCommon, platformRare}
+
+	if rate := ResolveBurnRate(rates, creator, rare); rate != creatorRare {
+		t.Errorf("expected the creator rate to override the platform rate")
+	}
+	if rate := ResolveBurnRate(rates, creator, common); rate != platformCommon {
+		t.Errorf("expected the platform rate when the creator has no rate for the rarity")
+	}
+	if rate := ResolveBurnRate(rates, creator, 3); rate != nil {
+		t.Errorf("expected no rate for an unconfigured rarity")
+	}
+
+	if amount := BurnAmount(12.5, 0.333); amount != 4.16 {
+		t.Errorf("expected burn amount 4.16, got %v", amount)
+	}
+	if amount := BurnAmount(10, 0); amount != 0 {
+		t.Errorf("expected a zero rate to pay nothing, got %v", amount)
+	}
+}
+
+func TestMinimumBid(t *testing.T) {
+	if bid := MinimumBid(25, nil); bid != 25 {
+		t.Errorf("expected the first bid to start at the start price, got %v", bid)
+	}
+	small := 10.0
+	if bid := MinimumBid(5, &small); bid != 11 {
+		t.Errorf("expected a small bid to be beaten by the flat increment, got %v", bid)
+	}
+	large := 200.0
+	if bid := MinimumBid(5, &large); bid != 210 {
+		t.Errorf("expected a large bid to be beaten by the percentage increment, got %v", bid)
+	}
+}
@@ -27,6 +27,8 @@ var FlattenedPackFieldList = []string{
 	"i.content_type as item_content_type",
 	"i.active as item_active",
 	"i.notify as item_notify",
+	"item_facts.serial_number as item_serial_number",
+	"mc.minted_qty as item_mint_count",
 }
 
 var PackExpandedFieldList = []string{
@@ -0,0 +1,57 @@
+package core
+
+import (
+	"regexp"
+	"sort"
+	"xo-packs/model"
+)
+
+var rarityColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// rarity colors are stored as #rrggbb so every client can render them the same way
+func ValidRarityColor(color string) bool {
+	return rarityColorPattern.MatchString(color)
+}
+
+// the tier of each rarity, 1 for the most common. tiers follow the ranking order of the rarities table
+// rather than the ranking values themselves, so rankings can be spaced out to leave room for new rarities
+func RarityTiers(rarities []*model.Rarity) map[uint64]int {
+	sorted := append([]*model.Rarity{}, rarities...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return *sorted[i].Ranking < *sorted[j].Ranking
+	})
+
+	tiers := map[uint64]int{}
+	tier := 0
+	var prevRanking *uint64
+	for _, rarity := range sorted {
+		if prevRanking == nil || *rarity.Ranking != *prevRanking {
+			tier++
+		}
+		tiers[*rarity.ID] = tier
+		prevRanking = rarity.Ranking
+	}
+	return tiers
+}
+
+// the rarities as a creator shows them. a creators override replaces the label and color of a rarity, the
+// name and ranking stay the platforms so odds and filters mean the same thing across creators
+func ApplyRarityOverrides(rarities []*model.Rarity, overrides []*model.RarityOverride, vendorId string) []*model.Rarity {
+	resolved := []*model.Rarity{}
+	for _, rarity := range rarities {
+		copied := *rarity
+		for _, override := range overrides {
+			if override.VendorId == nil || *override.VendorId != vendorId || override.RarityId == nil || *override.RarityId != *rarity.ID {
+				continue
+			}
+			if override.Label != nil {
+				copied.Label = override.Label
+			}
+			if override.Color != nil {
+				copied.Color = override.Color
+			}
+		}
+		resolved = append(resolved, &copied)
+	}
+	return resolved
+}
@@ -0,0 +1,35 @@
+package core
+
+import (
+	"testing"
+	"xo-packs/model"
+)
+
+func TestRarityTiers(t *testing.T) {
+	rarities := []*model.Rarity{}
+	for id, ranking := range map[uint64]uint64{1: 10, 2: 20, 3: 20, 4: 50, 5: 5} {
+		currId, currRanking := id, ranking
+		rarities = append(rarities, &model.Rarity{ID: &currId, Ranking: &currRanking})
+	}
+
+	tiers := RarityTiers(rarities)
+	expected := map[uint64]int{5: 1, 1: 2, 2: 3, 3: 3, 4: 4}
+	for id, tier := range expected {
+		if tiers[id] != tier {
+			t.Errorf("expected rarity %v to be tier %v, got %v", id, tier, tiers[id])
+		}
+	}
+
+	vendorId := "vendor"
+	rarityId := uint64(1)
+	label := "Starter"
+	overridden := ApplyRarityOverrides(rarities, []*model.RarityOverride{{VendorId: &vendorId, RarityId: &rarityId, Label: &label}}, vendorId)
+	for _, rarity := range overridden {
+		if *rarity.ID == rarityId && (rarity.Label == nil || *rarity.Label != label) {
+			t.Errorf("expected the creators label to replace the platform label")
+		}
+	}
+	if ValidRarityColor("red") || !ValidRarityColor("#ff00AA") {
+		t.Errorf("expected only #rrggbb colors to be valid")
+	}
+}
@@ -2,22 +2,182 @@ package core
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
 	"encoding/pem"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/service/cloudfront/sign"
 )
 
-func SignItemContentUrl(itemUrl string, pemKey string, keyPairID string) (*string, error) {
-	if itemUrl == "" {
-		return nil, nil
+const (
+	CONTENT_CLASS_IMAGE = "image"
+	CONTENT_CLASS_VIDEO = "video"
+	CONTENT_CLASS_THUMB = "thumb"
+
+	DEFAULT_SIGNED_URL_TTL_IMAGE    = 3600
+	DEFAULT_SIGNED_URL_TTL_VIDEO    = 4 * 3600
+	DEFAULT_SIGNED_URL_TTL_THUMB    = 24 * 3600
+	DEFAULT_SIGNED_URL_REFRESH      = 300
+	MAX_CACHED_SIGNED_URLS          = 50000
+	SIGNER_CLOUDFRONT               = "cloudfront"
+	SIGNER_LOCAL                    = "local"
+	SIGNED_URL_EXPIRES_PARAM        = "Expires"
+	SIGNED_URL_HMAC_SIGNATURE_PARAM = "Signature"
+)
+
+var videoExtensions = []string{".mp4", ".webm", ".mov"}
+
+// signs a content url so it can be fetched until the given time
+type URLSigner interface {
+	Sign(rawUrl string, expires time.Time) (string, error)
+}
+
+// signs with a cloudfront key pair. the key is parsed once when the signer is made
+type CloudFrontSigner struct {
+	signer *sign.URLSigner
+}
+
+func NewCloudFrontSigner(pemKey string, keyPairID string) (*CloudFrontSigner, error) {
+	privateKey, err := parseRSAPrivateKey(pemKey)
+	if err != nil {
+		return nil, err
+	}
+	return &CloudFrontSigner{signer: sign.NewURLSigner(keyPairID, privateKey)}, nil
+}
+
+func (s *CloudFrontSigner) Sign(rawUrl string, expires time.Time) (string, error) {
+	return s.signer.Sign(rawUrl, expires)
+}
+
+// signs with a shared secret for development, where there is no cloudfront distribution. urls carry the
+// same Expires param as cloudfront ones so expiry tracking works the same way
+type HMACSigner struct {
+	secret []byte
+}
+
+func NewHMACSigner(secret string) *HMACSigner {
+	return &HMACSigner{secret: []byte(secret)}
+}
+
+func (s *HMACSigner) Sign(rawUrl string, expires time.Time) (string, error) {
+	separator := "?"
+	if strings.Contains(rawUrl, "?") {
+		separator = "&"
+	}
+	withExpiry := fmt.Sprintf("%v%v%v=%v", rawUrl, separator, SIGNED_URL_EXPIRES_PARAM, expires.Unix())
+	return withExpiry + "&" + SIGNED_URL_HMAC_SIGNATURE_PARAM + "=" + s.signature(withExpiry), nil
+}
+
+// whether a url was signed with this secret and has not expired
+func (s *HMACSigner) Verify(signedUrl string, now time.Time) bool {
+	withExpiry, signature, ok := strings.Cut(signedUrl, "&"+SIGNED_URL_HMAC_SIGNATURE_PARAM+"=")
+	if !ok || !hmac.Equal([]byte(signature), []byte(s.signature(withExpiry))) {
+		return false
+	}
+	parsed, err := url.Parse(withExpiry)
+	if err != nil {
+		return false
+	}
+	expires, err := strconv.ParseInt(parsed.Query().Get(SIGNED_URL_EXPIRES_PARAM), 10, 64)
+	return err == nil && now.Unix() < expires
+}
+
+func (s *HMACSigner) signature(rawUrl string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(rawUrl))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+type signedUrl struct {
+	url     string
+	expires time.Time
+}
+
+// hands out signed urls, reusing a signed url until it is within the refresh margin of expiring. each class of
+// content is signed for its own ttl, videos stream for longer than images are looked at
+type ContentSigner struct {
+	signer  URLSigner
+	ttls    map[string]time.Duration
+	refresh time.Duration
+	mu      sync.Mutex
+	signed  map[string]signedUrl
+	now     func() time.Time
+}
+
+func NewContentSigner(signer URLSigner, ttls map[string]time.Duration, refresh time.Duration) *ContentSigner {
+	return &ContentSigner{signer: signer, ttls: ttls, refresh: refresh, signed: map[string]signedUrl{}, now: time.Now}
+}
+
+func (s *ContentSigner) SignContent(rawUrl string, contentClass string) (string, error) {
+	if contentClass == CONTENT_CLASS_IMAGE && IsVideoUrl(rawUrl) {
+		contentClass = CONTENT_CLASS_VIDEO
+	}
+	ttl, ok := s.ttls[contentClass]
+	if !ok {
+		ttl = DEFAULT_SIGNED_URL_TTL_IMAGE * time.Second
+	}
+	// never hand out a url with less than half its ttl left
+	refresh := min(s.refresh, ttl/2)
+	cacheKey := contentClass + ":" + rawUrl
+	now := s.now()
+
+	s.mu.Lock()
+	cached, ok := s.signed[cacheKey]
+	s.mu.Unlock()
+	if ok && now.Add(refresh).Before(cached.expires) {
+		return cached.url, nil
+	}
+
+	expires := now.Add(ttl)
+	signed, err := s.signer.Sign(rawUrl, expires)
+	if err != nil {
+		return "", err
 	}
 
+	s.mu.Lock()
+	if len(s.signed) >= MAX_CACHED_SIGNED_URLS {
+		s.evictExpired(now)
+	}
+	s.signed[cacheKey] = signedUrl{url: signed, expires: expires}
+	s.mu.Unlock()
+	return signed, nil
+}
+
+// drops expired urls, or everything when the cache is full of live ones. callers hold the lock
+func (s *ContentSigner) evictExpired(now time.Time) {
+	for key, cached := range s.signed {
+		if !now.Before(cached.expires) {
+			delete(s.signed, key)
+		}
+	}
+	if len(s.signed) >= MAX_CACHED_SIGNED_URLS {
+		s.signed = map[string]signedUrl{}
+	}
+}
+
+func IsVideoUrl(rawUrl string) bool {
+	parsed, err := url.Parse(rawUrl)
+	if err != nil {
+		return false
+	}
+	return slices.Contains(videoExtensions, strings.ToLower(path.Ext(parsed.Path)))
+}
+
+func parseRSAPrivateKey(pemKey string) (*rsa.PrivateKey, error) {
 	// Parse the PEM block
 	pemBlock, _ := pem.Decode([]byte(pemKey))
 	if pemBlock == nil || pemBlock.Type != "RSA PRIVATE KEY" {
@@ -25,12 +185,20 @@ func SignItemContentUrl(itemUrl string, pemKey string, keyPairID string) (*strin
 	}
 
 	// Parse the RSA private key
-	privateKey, err := x509.ParsePKCS1PrivateKey(pemBlock.Bytes)
+	return x509.ParsePKCS1PrivateKey(pemBlock.Bytes)
+}
+
+// signs a single url, parsing the key and building a signer every call. kept as the baseline the
+// ContentSigner benchmarks are measured against
+func SignItemContentUrl(itemUrl string, pemKey string, keyPairID string) (*string, error) {
+	if itemUrl == "" {
+		return nil, nil
+	}
+
+	signer, err := NewCloudFrontSigner(pemKey, keyPairID)
 	if err != nil {
 		return nil, err
 	}
-
-	signer := sign.NewURLSigner(keyPairID, privateKey)
 	signedURL, err := signer.Sign(itemUrl, time.Now().Add(1*time.Hour))
 	if err != nil {
 		return nil, err
@@ -38,30 +206,23 @@ func SignItemContentUrl(itemUrl string, pemKey string, keyPairID string) (*strin
 	return &signedURL, nil
 }
 
-func SignUrlBatch(urlMap map[int]map[string]*string, pemKey string, keyPairId string) (map[int]map[string]*string, error) {
+func SignUrlBatch(urlMap map[int]map[string]*string, signer *ContentSigner) (map[int]map[string]*string, error) {
 	for k := range urlMap {
-		mainUrl := urlMap[k]["contentMainUrl"]
-		thumbUrl := urlMap[k]["contentThumbUrl"]
-
-		signedMainUrl := mainUrl
-		signedThumbUrl := thumbUrl
-		var err error
-		if mainUrl != nil {
-			signedMainUrl, err = SignItemContentUrl(*mainUrl, pemKey, keyPairId)
-			if err != nil {
-				return nil, err
+		for field, contentClass := range map[string]string{"contentMainUrl": CONTENT_CLASS_IMAGE, "contentThumbUrl": CONTENT_CLASS_THUMB} {
+			rawUrl := urlMap[k][field]
+			if rawUrl == nil {
+				continue
 			}
-		}
-
-		if signedThumbUrl != nil {
-			signedThumbUrl, err = SignItemContentUrl(*thumbUrl, pemKey, keyPairId)
+			if *rawUrl == "" {
+				urlMap[k][field] = nil
+				continue
+			}
+			signed, err := signer.SignContent(*rawUrl, contentClass)
 			if err != nil {
 				return nil, err
 			}
+			urlMap[k][field] = &signed
 		}
-
-		urlMap[k]["contentMainUrl"] = signedMainUrl
-		urlMap[k]["contentThumbUrl"] = signedThumbUrl
 	}
 	return urlMap, nil
 }
@@ -0,0 +1,126 @@
+package core
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHMACSigner(t *testing.T) {
+	signer := NewHMACSigner("secret")
+	now := time.Now()
+	signed, err := signer.Sign("http://localhost/content/items/1.png", now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !signer.Verify(signed, now) {
+		t.Errorf("expected %v to verify", signed)
+	}
+	if signer.Verify(signed, now.Add(2*time.Hour)) {
+		t.Errorf("expected an expired url to fail")
+	}
+	if signer.Verify(strings.Replace(signed, "items/1", "items/2", 1), now) {
+		t.Errorf("expected a tampered url to fail")
+	}
+	if NewHMACSigner("other").Verify(signed, now) {
+		t.Errorf("expected a url signed with another secret to fail")
+	}
+}
+
+func TestContentSignerReuse(t *testing.T) {
+	ttls := map[string]time.Duration{
+		CONTENT_CLASS_IMAGE: time.Hour,
+		CONTENT_CLASS_VIDEO: 4 * time.Hour,
+		CONTENT_CLASS_THUMB: 24 * time.Hour,
+	}
+	signer := NewContentSigner(NewHMACSigner("secret"), ttls, 5*time.Minute)
+	now := time.Now()
+	signer.now = func() time.Time { return now }
+
+	first, err := signer.SignContent("http://localhost/content/items/1.png", CONTENT_CLASS_IMAGE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(30 * time.Minute)
+	if second, _ := signer.SignContent("http://localhost/content/items/1.png", CONTENT_CLASS_IMAGE); second != first {
+		t.Errorf("expected the signed url to be reused before it nears expiry")
+	}
+	now = now.Add(26 * time.Minute)
+	if third, _ := signer.SignContent("http://localhost/content/items/1.png", CONTENT_CLASS_IMAGE); third == first {
+		t.Errorf("expected the url to be signed again within the refresh margin")
+	}
+
+	video, _ := signer.SignContent("http://localhost/content/items/1.mp4", CONTENT_CLASS_IMAGE)
+	if !strings.Contains(video, fmt.Sprintf("Expires=%v", now.Add(4*time.Hour).Unix())) {
+		t.Errorf("expected a video to be signed for the video ttl, got %v", video)
+	}
+	thumb, _ := signer.SignContent("http://localhost/content/items/1.png", CONTENT_CLASS_THUMB)
+	if !strings.Contains(thumb, fmt.Sprintf("Expires=%v", now.Add(24*time.Hour).Unix())) {
+		t.Errorf("expected a thumb to be signed for the thumb ttl, got %v", thumb)
+	}
+}
+
+func benchmarkPemKey(b *testing.B) string {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+}
+
+func benchmarkUrls() []string {
+	urls := make([]string, 50)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("https://cdn.example.com/items/%v.png", i)
+	}
+	return urls
+}
+
+// the signing done before signers were kept, the key is parsed again for every url
+
+func BenchmarkSignItemContentUrl(b *testing.B) {
+	pemKey := benchmarkPemKey(b)
+	urls := benchmarkUrls()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := SignItemContentUrl(urls[i%len(urls)], pemKey, "KEYPAIR"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCloudFrontSigner(b *testing.B) {
+	signer, err := NewCloudFrontSigner(benchmarkPemKey(b), "KEYPAIR")
+	if err != nil {
+		b.Fatal(err)
+	}
+	urls := benchmarkUrls()
+	expires := time.Now().Add(time.Hour)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := signer.Sign(urls[i%len(urls)], expires); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkContentSignerCached(b *testing.B) {
+	cloudfront, err := NewCloudFrontSigner(benchmarkPemKey(b), "KEYPAIR")
+	if err != nil {
+		b.Fatal(err)
+	}
+	signer := NewContentSigner(cloudfront, map[string]time.Duration{}, DEFAULT_SIGNED_URL_REFRESH*time.Second)
+	urls := benchmarkUrls()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := signer.SignContent(urls[i%len(urls)], CONTENT_CLASS_IMAGE); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
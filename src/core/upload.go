@@ -1,26 +1,96 @@
 package core
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"mime/multipart"
 	"os"
+	"strings"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/gabriel-vasile/mimetype"
 )
 
-func UploadImage(file *multipart.FileHeader, key string, bucket string) error {
-	fmt.Println("Inside upload")
-	region := os.Getenv("REGION")
+const (
+	UPLOAD_ITEM_CONTENT = "item_content"
+	UPLOAD_ITEM_THUMB   = "item_thumb"
+	UPLOAD_PACK_ART     = "pack_art"
+	UPLOAD_PROFILE      = "profile"
+	UPLOAD_BANNER       = "banner"
+	UPLOAD_LEAKED_IMAGE = "leaked_image"
+
+	ITEM_CONTENT_IMAGE = "img"
+	ITEM_CONTENT_VIDEO = "vid"
+
+	MEGABYTE = 1 << 20
+)
+
+var uploadImageTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+var uploadVideoTypes = []string{"video/mp4", "video/webm", "video/quicktime"}
+
+type UploadRule struct {
+	MaxSize      int64
+	AllowedTypes []string
+}
+
+// what each kind of upload accepts. item content can be a video, everything else has to be an image
+var uploadRules = map[string]UploadRule{
+	UPLOAD_ITEM_CONTENT: {MaxSize: 100 * MEGABYTE, AllowedTypes: append(append([]string{}, uploadImageTypes...), uploadVideoTypes...)},
+	UPLOAD_ITEM_THUMB:   {MaxSize: 10 * MEGABYTE, AllowedTypes: uploadImageTypes},
+	UPLOAD_PACK_ART:     {MaxSize: 10 * MEGABYTE, AllowedTypes: uploadImageTypes},
+	UPLOAD_PROFILE:      {MaxSize: 5 * MEGABYTE, AllowedTypes: uploadImageTypes},
+	UPLOAD_BANNER:       {MaxSize: 10 * MEGABYTE, AllowedTypes: uploadImageTypes},
+	UPLOAD_LEAKED_IMAGE: {MaxSize: 100 * MEGABYTE, AllowedTypes: uploadImageTypes},
+}
+
+// an upload that passed validation, ready to be handed to a BlobStore
+type ValidatedUpload struct {
+	Body      io.ReadSeeker
+	MimeType  string
+	Extension string
+	Size      int64
+}
+
+// checks the size and sniffs the real type of an upload from its content rather than trusting the client's
+// Content-Type header. the body is rewound so it can be stored afterwards
+func ValidateUpload(body io.ReadSeeker, size int64, kind string) (*ValidatedUpload, error) {
+	rule, ok := uploadRules[kind]
+	if !ok {
+		return nil, &ErrorResp{Message: "unknown upload kind " + kind}
+	}
+	if size <= 0 {
+		return nil, &ErrorResp{Message: "uploaded file is empty"}
+	}
+	if size > rule.MaxSize {
+		return nil, &ErrorResp{Message: fmt.Sprintf("uploaded file is too large, the limit is %vMB", rule.MaxSize/MEGABYTE)}
+	}
 
-	// Create a new AWS session
-	sess := session.Must(session.NewSession(&aws.Config{
-		Region: aws.String(region),
-	}))
+	mime, err := mimetype.DetectReader(body)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if !mimetype.EqualsAny(mime.String(), rule.AllowedTypes...) {
+		return nil, &ErrorResp{Message: fmt.Sprintf("file type %v is not allowed, expected one of %v", mime.String(), strings.Join(rule.AllowedTypes, ", "))}
+	}
+	return &ValidatedUpload{Body: body, MimeType: mime.String(), Extension: mime.Extension(), Size: size}, nil
+}
 
-	// Initialize S3 service
-	s3Svc := s3.New(sess)
+// the items.content_type value for an uploaded mime type
+func ItemContentType(mimeType string) string {
+	if strings.HasPrefix(mimeType, "video/") {
+		return ITEM_CONTENT_VIDEO
+	}
+	return ITEM_CONTENT_IMAGE
+}
+
+func UploadImage(file *multipart.FileHeader, key string, bucket string) error {
+	store, err := NewS3BlobStore(os.Getenv("REGION"), bucket, "")
+	if err != nil {
+		return err
+	}
 
 	// Open the file
 	fileContent, err := file.Open()
@@ -28,20 +98,10 @@ func UploadImage(file *multipart.FileHeader, key string, bucket string) error {
 		fmt.Println(err)
 		return err
 	}
-	fmt.Println("Getting file content")
 	defer fileContent.Close()
 
 	// Upload the file to S3
-	uploadInput := &s3.PutObjectInput{
-		Bucket:        aws.String(bucket),
-		Key:           aws.String(key),
-		Body:          fileContent,
-		ContentType:   aws.String(file.Header.Get("Content-Type")),
-		ContentLength: aws.Int64(file.Size),
-	}
-
-	_, err = s3Svc.PutObject(uploadInput)
-	if err != nil {
+	if err := store.Put(context.Background(), key, fileContent, file.Header.Get("Content-Type")); err != nil {
 		fmt.Println(err)
 		return err
 	}
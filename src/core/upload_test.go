@@ -0,0 +1,40 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestValidateUpload(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00")
+	upload, err := ValidateUpload(bytes.NewReader(png), int64(len(png)), UPLOAD_PROFILE)
+	if err != nil {
+		t.Fatalf("expected a png profile image to be accepted, got %v", err)
+	}
+	if upload.MimeType != "image/png" || upload.Extension != ".png" {
+		t.Errorf("expected the png type to be detected, got %v %v", upload.MimeType, upload.Extension)
+	}
+	if pos, _ := upload.Body.Seek(0, 1); pos != 0 {
+		t.Errorf("expected the body to be rewound after sniffing, at %v", pos)
+	}
+
+	text := []byte("<html><body>not an image</body></html>")
+	if _, err := ValidateUpload(bytes.NewReader(text), int64(len(text)), UPLOAD_BANNER); err == nil {
+		t.Errorf("expected html to be rejected as a banner")
+	}
+	if _, err := ValidateUpload(bytes.NewReader(png), 6*MEGABYTE, UPLOAD_PROFILE); err == nil {
+		t.Errorf("expected an oversized profile image to be rejected")
+	}
+
+	mp4 := []byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom")
+	if _, err := ValidateUpload(bytes.NewReader(mp4), int64(len(mp4)), UPLOAD_ITEM_THUMB); err == nil {
+		t.Errorf("expected a video thumbnail to be rejected")
+	}
+	upload, err = ValidateUpload(bytes.NewReader(mp4), int64(len(mp4)), UPLOAD_ITEM_CONTENT)
+	if err != nil {
+		t.Fatalf("expected video item content to be accepted, got %v", err)
+	}
+	if ItemContentType(upload.MimeType) != ITEM_CONTENT_VIDEO {
+		t.Errorf("expected video content to be stored as vid, got %v", ItemContentType(upload.MimeType))
+	}
+}
@@ -0,0 +1,160 @@
+package core
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"hash/fnv"
+	"image"
+)
+
+const (
+	WATERMARK_BLOCK_SIZE   = 8
+	WATERMARK_STRENGTH     = 3
+	WATERMARK_ID_BITS      = 32
+	WATERMARK_CHECK_BITS   = 16
+	WATERMARK_PAYLOAD_BITS = WATERMARK_ID_BITS + WATERMARK_CHECK_BITS
+	WATERMARK_MIN_REPEATS  = 4
+	WATERMARK_MIN_BLOCKS   = WATERMARK_PAYLOAD_BITS * WATERMARK_MIN_REPEATS
+
+	// a pending watermark older than this was abandoned by its worker and can be claimed again
+	WATERMARK_PENDING_STALE_SECONDS = 300
+)
+
+// hashes the secret watermarks are keyed with, without it the pattern cannot be told apart from noise
+func WatermarkSeed(secret string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(secret))
+	return h.Sum64()
+}
+
+// whether an image has enough blocks to carry the payload the minimum number of times
+func CanWatermark(bounds image.Rectangle) bool {
+	return (bounds.Dx()/WATERMARK_BLOCK_SIZE)*(bounds.Dy()/WATERMARK_BLOCK_SIZE) >= WATERMARK_MIN_BLOCKS
+}
+
+// hides a watermark id in the image. every 8x8 block nudges its brightness up in two opposite quadrants and
+// down in the other two, the direction set by one payload bit and flipped by a keyed per block sign. the
+// change is a few levels so it is not visible, and since the payload repeats across hundreds of blocks it
+// survives jpeg recompression. the image is expected to come back at the size it was issued at, crops and
+// resizes break the block grid
+func EmbedWatermark(img image.Image, id uint32, seed uint64) *image.RGBA {
+	dst := flatten(img)
+	payload := watermarkPayload(id)
+	forEachWatermarkBlock(dst, seed, func(block int, sign int, x0 int, y0 int) {
+		bit := payload[block%WATERMARK_PAYLOAD_BITS]*2 - 1
+		for y := y0; y < y0+WATERMARK_BLOCK_SIZE; y++ {
+			for x := x0; x < x0+WATERMARK_BLOCK_SIZE; x++ {
+				delta := WATERMARK_STRENGTH * bit * sign * quadrantSign(x-x0, y-y0)
+				i := dst.PixOffset(x, y)
+				for c := 0; c < 3; c++ {
+					dst.Pix[i+c] = clampChannel(int(dst.Pix[i+c]) + delta)
+				}
+			}
+		}
+	})
+	return dst
+}
+
+// reads back the watermark id, false when the checksum does not match which means the image was never
+// watermarked, was watermarked with another seed, or was altered too much
+func ExtractWatermark(img image.Image, seed uint64) (uint32, bool) {
+	if !CanWatermark(img.Bounds()) {
+		return 0, false
+	}
+	src := flatten(img)
+	scores := make([]float64, WATERMARK_PAYLOAD_BITS)
+	forEachWatermarkBlock(src, seed, func(block int, sign int, x0 int, y0 int) {
+		luma := [WATERMARK_BLOCK_SIZE * WATERMARK_BLOCK_SIZE]float64{}
+		mean := 0.0
+		for y := 0; y < WATERMARK_BLOCK_SIZE; y++ {
+			for x := 0; x < WATERMARK_BLOCK_SIZE; x++ {
+				i := src.PixOffset(x0+x, y0+y)
+				l := 0.299*float64(src.Pix[i]) + 0.587*float64(src.Pix[i+1]) + 0.114*float64(src.Pix[i+2])
+				luma[y*WATERMARK_BLOCK_SIZE+x] = l
+				mean += l
+			}
+		}
+		mean /= float64(len(luma))
+
+		correlation := 0.0
+		for y := 0; y < WATERMARK_BLOCK_SIZE; y++ {
+			for x := 0; x < WATERMARK_BLOCK_SIZE; x++ {
+				correlation += (luma[y*WATERMARK_BLOCK_SIZE+x] - mean) * float64(quadrantSign(x, y))
+			}
+		}
+		scores[block%WATERMARK_PAYLOAD_BITS] += correlation * float64(sign)
+	})
+
+	var id uint32
+	for i := 0; i < WATERMARK_ID_BITS; i++ {
+		if scores[i] > 0 {
+			id |= 1 << i
+		}
+	}
+	var check uint16
+	for i := 0; i < WATERMARK_CHECK_BITS; i++ {
+		if scores[WATERMARK_ID_BITS+i] > 0 {
+			check |= 1 << i
+		}
+	}
+	return id, check == watermarkCheck(id)
+}
+
+// the id followed by its checksum, one bit per entry
+func watermarkPayload(id uint32) []int {
+	payload := make([]int, WATERMARK_PAYLOAD_BITS)
+	for i := 0; i < WATERMARK_ID_BITS; i++ {
+		payload[i] = int(id>>i) & 1
+	}
+	check := watermarkCheck(id)
+	for i := 0; i < WATERMARK_CHECK_BITS; i++ {
+		payload[WATERMARK_ID_BITS+i] = int(check>>i) & 1
+	}
+	return payload
+}
+
+func watermarkCheck(id uint32) uint16 {
+	buf := make([]byte, 4)
+	binary.BigEndian.PutUint32(buf, id)
+	return uint16(crc32.ChecksumIEEE(buf))
+}
+
+// walks the whole blocks of the image in reading order along with their keyed sign
+func forEachWatermarkBlock(img *image.RGBA, seed uint64, fn func(block int, sign int, x0 int, y0 int)) {
+	columns := img.Bounds().Dx() / WATERMARK_BLOCK_SIZE
+	rows := img.Bounds().Dy() / WATERMARK_BLOCK_SIZE
+	for row := 0; row < rows; row++ {
+		for column := 0; column < columns; column++ {
+			block := row*columns + column
+			sign := 1
+			if splitmix64(seed^uint64(block))&1 == 1 {
+				sign = -1
+			}
+			fn(block, sign, column*WATERMARK_BLOCK_SIZE, row*WATERMARK_BLOCK_SIZE)
+		}
+	}
+}
+
+func quadrantSign(x int, y int) int {
+	if (x < WATERMARK_BLOCK_SIZE/2) == (y < WATERMARK_BLOCK_SIZE/2) {
+		return 1
+	}
+	return -1
+}
+
+func splitmix64(x uint64) uint64 {
+	x += 0x9E3779B97F4A7C15
+	x = (x ^ (x >> 30)) * 0xBF58476D1CE4E5B9
+	x = (x ^ (x >> 27)) * 0x94D049BB133111EB
+	return x ^ (x >> 31)
+}
+
+func clampChannel(v int) uint8 {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return uint8(v)
+}
@@ -0,0 +1,44 @@
+package core
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	mrand "math/rand"
+	"testing"
+)
+
+func TestWatermarkRoundTrip(t *testing.T) {
+	r := mrand.New(mrand.NewSource(1))
+	img := image.NewRGBA(image.Rect(0, 0, 320, 240))
+	for y := 0; y < 240; y++ {
+		for x := 0; x < 320; x++ {
+			v := uint8((x*255/320 + y/2 + r.Intn(40)) % 256)
+			img.Set(x, y, color.RGBA{v, 255 - v, uint8(x), 255})
+		}
+	}
+
+	seed := WatermarkSeed("secret")
+	buf := bytes.Buffer{}
+	if err := jpeg.Encode(&buf, EmbedWatermark(img, 4242, seed), &jpeg.Options{Quality: 75}); err != nil {
+		t.Fatal(err)
+	}
+	leaked, err := jpeg.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if id, ok := ExtractWatermark(leaked, seed); !ok || id != 4242 {
+		t.Errorf("expected watermark 4242 to survive recompression, got %v %v", id, ok)
+	}
+	if _, ok := ExtractWatermark(leaked, WatermarkSeed("other")); ok {
+		t.Errorf("expected a different secret to read no watermark")
+	}
+	if _, ok := ExtractWatermark(img, seed); ok {
+		t.Errorf("expected the original image to carry no watermark")
+	}
+	if CanWatermark(image.Rect(0, 0, 64, 64)) {
+		t.Errorf("expected a tiny image to be too small to watermark")
+	}
+}
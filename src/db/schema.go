@@ -15,6 +15,37 @@ const (
 	SCHEMA_PACK_ITEM_CONFIGS          = "main.pack_item_configs"
 	SCHEMA_PACK_ITEM_FACTS            = "main.pack_item_facts"
 	SCHEMA_PACK_CATEGORIES            = "main.pack_categories"
+	SCHEMA_PACK_GENERATION_JOBS       = "main.pack_generation_jobs"
+	SCHEMA_PACK_PRICE_TIERS           = "main.pack_price_tiers"
+	SCHEMA_PACK_GIFTS                 = "main.pack_gifts"
+	SCHEMA_USER_SPENDING_LIMITS       = "main.user_spending_limits"
+	SCHEMA_PACK_REVEAL_SESSIONS       = "main.pack_reveal_sessions"
+	SCHEMA_PACK_REVEAL_SLOTS          = "main.pack_reveal_slots"
+	SCHEMA_PACK_WAITLIST              = "main.pack_waitlist"
+	SCHEMA_ITEM_MINT_COUNTS           = "main.item_mint_counts"
+	SCHEMA_ITEM_EVENTS                = "main.item_events"
+	SCHEMA_MARKET_LISTINGS            = "main.market_listings"
+	SCHEMA_RARITY                     = "main.rarity"
+	SCHEMA_RARITY_OVERRIDES           = "main.rarity_overrides"
+	SCHEMA_TRADE_OFFERS               = "main.trade_offers"
+	SCHEMA_TRADE_OFFER_ITEMS          = "main.trade_offer_items"
+	SCHEMA_BURN_RATES                 = "main.burn_rates"
+	SCHEMA_CRAFTING_RECIPES           = "main.crafting_recipes"
+	SCHEMA_CRAFTING_RECIPE_INPUTS     = "main.crafting_recipe_inputs"
+	SCHEMA_CRAFTING_RECIPE_OUTCOMES   = "main.crafting_recipe_outcomes"
+	SCHEMA_USER_CRAFTS                = "main.user_crafts"
+	SCHEMA_COLLECTION_SETS            = "main.collection_sets"
+	SCHEMA_COLLECTION_SET_ITEMS       = "main.collection_set_items"
+	SCHEMA_USER_SET_COMPLETIONS       = "main.user_set_completions"
+	SCHEMA_SHIPPING_INFO              = "main.shipping_info"
+	SCHEMA_BUYBACK_SETTINGS           = "main.buyback_settings"
+	SCHEMA_AUCTIONS                   = "main.auctions"
+	SCHEMA_AUCTION_BIDS               = "main.auction_bids"
+	SCHEMA_ITEM_RENDITIONS            = "main.item_renditions"
+	SCHEMA_CONTENT_WATERMARKS         = "main.content_watermarks"
+	SCHEMA_NOTIFICATIONS              = "main.notifications"
+	SCHEMA_BIG_PULL_OPT_INS           = "main.big_pull_opt_ins"
+	SCHEMA_CREATOR_WEBHOOKS           = "main.creator_webhooks"
 	SCHEMA_TOKEN_BUNDLE               = "financial.token_bundle"
 	SCHEMA_TOKEN_BALANCE              = "financial.token_balance"
 	SCHEMA_TOKEN_ORDERS               = "financial.token_orders"
@@ -22,6 +53,11 @@ const (
 	SCHEMA_NEW_SALES_TRANSACTIONS     = "financial.new_sales_transactions"
 	SCHEMA_TRANSACTIONS               = "financial.transactions"
 	SCHEMA_ITEM_WITHDRAWALS           = "financial.item_withdrawals"
+	SCHEMA_MARKET_SALES               = "financial.market_sales"
+	SCHEMA_CRAFTING_BALANCE           = "financial.crafting_balance"
+	SCHEMA_ITEM_BURNS                 = "financial.item_burns"
+	SCHEMA_ITEM_BUYBACKS              = "financial.item_buybacks"
+	SCHEMA_TOKEN_LEDGER               = "financial.token_ledger"
 	SCHEMA_SIGN_INS                   = "logging.sign_ins"
 	SCHEMA_AGE_AGREEMENTS             = "logging.age_agreements"
 	SCHEMA_USER_ACCOUNT_CREATION_LOGS = "logging.user_account_creation_logs"
@@ -54,6 +90,8 @@ const (
 	KEY_PACK_CONFIG           = "pack_config_"
 	KEY_PACK_ITEMS            = "pack_items_"
 	KEY_PACK_ITEM_CONFIGS     = "pack_item_configs_"
+	KEY_PACK_PRICE_TIERS      = "pack_price_tiers_"
+	KEY_GIFTS_RECEIVED        = "gifts_received_"
 	KEY_PACK_VENDOR_CONFIGS   = "pack_vendor_configs_"
 	KEY_ITEM                  = "item_"
 	KEY_USER_ITEM             = "user_item_"
@@ -79,6 +117,23 @@ const (
 	KEY_TOP_CUSTOMERS         = "top_customers_"
 	KEY_PACK_SALES            = "_pack_sales_"
 	KEY_PACK_QTY              = "pack_qty_sold_"
+	KEY_PACK_WAITLIST_SIZE    = "pack_waitlist_size_"
+	KEY_MARKET_LISTING        = "market_listing_"
+	KEY_CRAFTING_BALANCE      = "crafting_balance_"
+	KEY_BURN_RATES            = "burn_rates"
+	KEY_RARITIES              = "rarities"
+	KEY_RARITY_OVERRIDES      = "rarity_overrides"
+	KEY_CRAFTING_RECIPE       = "crafting_recipe_"
+	KEY_COLLECTION_SET        = "collection_set_"
+	KEY_USER_SET_COMPLETIONS  = "user_set_completions_"
+	KEY_SHIPPING_INFO         = "shipping_info_"
+	KEY_USER_WITHDRAWALS      = "user_withdrawals_"
+	KEY_BUYBACK_SETTING       = "buyback_setting_"
+	KEY_ITEM_BUYBACKS         = "item_buybacks_"
+	KEY_CONTENT_WATERMARK     = "content_watermark_"
+	KEY_BIG_PULLS             = "big_pulls_"
+	KEY_BIG_PULL_OPT_IN       = "big_pull_opt_in_"
+	KEY_CREATOR_WEBHOOK       = "creator_webhook_"
 	KEY_PEM                   = "pem_key"
 	KEY_KEY_PAIR_ID           = "key_pair_id"
 	// KEY_CREATOR_PENDING_APPLICATION   = "creator_pending_application_"
@@ -93,6 +148,121 @@ const (
 	KEY_REPORT_OPTS                = "report_opts"
 )
 
+// PACK GENERATION JOB STATUSES
+const (
+	JOB_STATUS_QUEUED  = "queued"
+	JOB_STATUS_RUNNING = "running"
+	JOB_STATUS_FAILED  = "failed"
+	JOB_STATUS_DONE    = "done"
+)
+
+// only one queued or running generation job may exist per pack config, enforced by
+// create unique index pack_generation_jobs_active_idx on main.pack_generation_jobs (pack_config_id) where status in ('queued', 'running')
+const INDEX_PACK_GENERATION_JOBS_ACTIVE = "pack_generation_jobs_active_idx"
+
+// PACK CONFIG REVIEW STATUSES
+// pack configs from before reviews existed are backfilled so the live catalog stays in the shop and everything else is reviewed
+// update main.pack_configs set review_status = case when active then 'approved' else 'draft' end where review_status is null
+const (
+	PACK_REVIEW_DRAFT     = "draft"
+	PACK_REVIEW_SUBMITTED = "submitted"
+	PACK_REVIEW_APPROVED  = "approved"
+	PACK_REVIEW_REJECTED  = "rejected"
+)
+
+// ITEM INSTANCE PROVENANCE EVENTS
+const (
+	ITEM_EVENT_PULLED      = "pulled"
+	ITEM_EVENT_TRANSFERRED = "transferred"
+	ITEM_EVENT_WITHDRAWN   = "withdrawn"
+	ITEM_EVENT_BURNED      = "burned"
+	ITEM_EVENT_BOUGHT_BACK = "bought_back"
+	ITEM_EVENT_AUCTIONED   = "auctioned"
+	ITEM_EVENT_CRAFTED     = "crafted"
+	ITEM_EVENT_REWARDED    = "rewarded"
+	ITEM_EVENT_RETURNED    = "returned"
+	ITEM_EVENT_EXPIRED     = "expired"
+	ITEM_EVENT_DELETED     = "deleted"
+)
+
+// MARKETPLACE LISTING STATUSES
+const (
+	MARKET_LISTING_ACTIVE    = "active"
+	MARKET_LISTING_SOLD      = "sold"
+	MARKET_LISTING_CANCELLED = "cancelled"
+	MARKET_LISTING_REMOVED   = "removed"
+)
+
+// MARKETPLACE SALE STATUSES
+// a sale is written once the item has moved and every share of the price has been paid out
+const (
+	MARKET_SALE_SETTLED = "settled"
+)
+
+// TOKEN LEDGER ENTRY TYPES
+// every token movement of a marketplace sale is recorded, debits are negative and the platform fee is kept under a null uid
+// create table financial.token_ledger (id bigserial primary key, uid text, entry_type text not null, token_amount numeric not null, market_sale_id bigint, created_at timestamp not null)
+const (
+	TOKEN_LEDGER_MARKET_PURCHASE = "market_purchase"
+	TOKEN_LEDGER_MARKET_PROCEEDS = "market_proceeds"
+	TOKEN_LEDGER_MARKET_ROYALTY  = "market_royalty"
+	TOKEN_LEDGER_MARKET_FEE      = "market_fee"
+)
+
+// TRADE OFFER STATUSES
+const (
+	TRADE_OFFER_PENDING   = "pending"
+	TRADE_OFFER_ACCEPTED  = "accepted"
+	TRADE_OFFER_DECLINED  = "declined"
+	TRADE_OFFER_COUNTERED = "countered"
+	TRADE_OFFER_CANCELLED = "cancelled"
+	TRADE_OFFER_EXPIRED   = "expired"
+)
+
+// ITEM WITHDRAWAL FULFILLMENT STATUSES
+const (
+	WITHDRAWAL_REQUESTED = "requested"
+	WITHDRAWAL_PACKED    = "packed"
+	WITHDRAWAL_SHIPPED   = "shipped"
+	WITHDRAWAL_DELIVERED = "delivered"
+	WITHDRAWAL_CANCELLED = "cancelled"
+)
+
+// AUCTION STATUSES
+const (
+	AUCTION_ACTIVE    = "active"
+	AUCTION_SOLD      = "sold"
+	AUCTION_UNSOLD    = "unsold"
+	AUCTION_CANCELLED = "cancelled"
+)
+
+// AUCTION BID STATUSES
+const (
+	AUCTION_BID_HELD     = "held"
+	AUCTION_BID_REFUNDED = "refunded"
+	AUCTION_BID_WON      = "won"
+)
+
+// CONTENT WATERMARK STATUSES
+const (
+	WATERMARK_PENDING = "pending"
+	WATERMARK_READY   = "ready"
+	WATERMARK_SKIPPED = "skipped"
+)
+
+// NOTIFICATION TYPES
+const (
+	NOTIFICATION_NOTIFY_PULL   = "notify_pull"
+	NOTIFICATION_PACK_REVIEW   = "pack_review"
+	NOTIFICATION_PACK_WAITLIST = "pack_waitlist"
+)
+
+// ITEM BURN PAYOUT CURRENCIES
+const (
+	BURN_CURRENCY_TOKENS   = "tokens"
+	BURN_CURRENCY_CRAFTING = "crafting"
+)
+
 // LOG MSG HEADERS
 const (
 	LOG_USER_CREATE             = "client_logs_new_user_log"
@@ -108,4 +278,19 @@ const (
 	LOG_ADMIN_LOG               = "admin_login"
 	LOG_REFERRAL                = "client_logs_referral_log"
 	LOG_REPORT                  = "client_logs_report_log"
+	LOG_PACK_REVIEW_STATUS      = "client_logs_pack_review_status_log"
+	LOG_PACK_WAITLIST_RESTOCK   = "client_logs_pack_waitlist_restock_log"
+	LOG_PACK_RELEASE            = "client_logs_pack_release_log"
+	LOG_PACK_GENERATION_ERROR   = "client_logs_pack_generation_error_log"
+	LOG_MARKET_SALE             = "client_logs_market_sale_log"
+	LOG_USER_BAN                = "admin_user_ban"
+	LOG_USER_SPENDING_LIMIT     = "admin_user_spending_limit"
+	LOG_TRADE_ACCEPTED          = "client_logs_trade_accepted_log"
+	LOG_ITEM_BURN               = "client_logs_item_burn_log"
+	LOG_ITEM_CRAFT              = "client_logs_item_craft_log"
+	LOG_SET_COMPLETED           = "client_logs_set_completed_log"
+	LOG_WITHDRAWAL_STATUS       = "client_logs_withdrawal_status_log"
+	LOG_ITEM_BUYBACK            = "client_logs_item_buyback_log"
+	LOG_AUCTION_BID             = "client_logs_auction_bid_log"
+	LOG_WATERMARK_IDENTIFY      = "admin_watermark_identify"
 )
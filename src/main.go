@@ -68,6 +68,13 @@ func main() {
 	}
 	defer cacheClient.Close()
 
+	// content storage
+	blobStore, err := core.NewBlobStore()
+	if err != nil {
+		fmt.Println(err)
+		panic("ERROR CREATING CONTENT STORE")
+	}
+
 	// router setup
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.Default()
@@ -76,6 +83,11 @@ func main() {
 	router.Use(middleware.FulfilledRequestLoggingMiddleware())
 	router.Use(middleware.RequestLoggingMiddleware())
 
+	// content kept on local disk is served by the api itself
+	if localStore, ok := blobStore.(*core.LocalBlobStore); ok {
+		router.Static(core.LOCAL_CONTENT_ROUTE, localStore.Root())
+	}
+
 	// repository instantiation
 	userRepo := repository.NewUserRepo(dbConn, cacheClient)
 	loggingRepo := repository.NewLoggingRepo(dbConn, cacheClient)
@@ -91,6 +103,16 @@ func main() {
 	reportRepo := repository.NewReportRepo(dbConn, cacheClient)
 	transactionRepo := repository.NewTransactionRepo(dbConn, cacheClient)
 	financialRepo := repository.NewFinancialRepo(dbConn, cacheClient)
+	marketRepo := repository.NewMarketRepo(dbConn, cacheClient)
+	tradeRepo := repository.NewTradeRepo(dbConn, cacheClient)
+	craftingRepo := repository.NewCraftingRepo(dbConn, cacheClient)
+	collectionRepo := repository.NewCollectionRepo(dbConn, cacheClient)
+	shippingRepo := repository.NewShippingRepo(dbConn, cacheClient)
+	buybackRepo := repository.NewBuybackRepo(dbConn, cacheClient)
+	auctionRepo := repository.NewAuctionRepo(dbConn, cacheClient)
+	uploadRepo := repository.NewUploadRepo(dbConn, cacheClient, blobStore)
+	notificationRepo := repository.NewNotificationRepo(dbConn, cacheClient)
+	watermarkRepo := repository.NewWatermarkRepo(dbConn, cacheClient, blobStore)
 
 	// services
 	userService := service.NewUserService(userRepo)
@@ -108,23 +130,45 @@ func main() {
 	reportService := service.NewReportService(reportRepo)
 	transactionService := service.NewTransactionService(transactionRepo)
 	financialService := service.NewFinancialService(financialRepo)
+	marketService := service.NewMarketService(marketRepo)
+	tradeService := service.NewTradeService(tradeRepo)
+	craftingService := service.NewCraftingService(craftingRepo)
+	collectionService := service.NewCollectionService(collectionRepo)
+	shippingService := service.NewShippingService(shippingRepo)
+	buybackService := service.NewBuybackService(buybackRepo)
+	auctionService := service.NewAuctionService(auctionRepo)
+	uploadService := service.NewUploadService(uploadRepo)
+	notificationService := service.NewNotificationService(notificationRepo)
+	watermarkService := service.NewWatermarkService(watermarkRepo)
+
+	// background workers
+	go packService.RunPackGenerationWorker(vendorService, notificationService)
 
 	// controller instantiation
-	userContr := controller.NewUserController(userService, vendorService, itemService)
+	userContr := controller.NewUserController(userService, vendorService, itemService, shippingService, watermarkService)
 	vendorContr := controller.NewVendorController(vendorService, categoryService, packService, itemService)
 	tokenContr := controller.NewTokenController(tokenService)
-	packContr := controller.NewPackController(packService, vendorService, itemService, userService, tokenService)
+	packContr := controller.NewPackController(packService, vendorService, itemService, userService, tokenService, collectionService, watermarkService, notificationService)
 	loggingContr := controller.NewLoggingService(loggingService, userService)
-	itemContr := controller.NewItemController(itemService, vendorService, packService)
+	itemContr := controller.NewItemController(itemService, vendorService, packService, tokenService)
 	firebaseContr := controller.NewFirebaseController(firebaseService, userService)
 	categoryContr := controller.NewCategoryController(categoryService)
 	analyticsContr := controller.NewAnalyticsController(analyticsService)
 	transactionContr := controller.NewTransactionController(transactionService, tokenService)
-	adminContr := controller.NewAdminController(userService, applicationService, adminService)
+	adminContr := controller.NewAdminController(userService, applicationService, adminService, packService, itemService, shippingService, watermarkService, notificationService)
 	applicationContr := controller.NewApplicationController(applicationService, referralService)
 	referralContr := controller.NewReferralController(referralService, vendorService)
 	reportContr := controller.NewReportController(reportService)
 	financialContr := controller.NewFinancialController(financialService)
+	marketContr := controller.NewMarketController(marketService, itemService, tokenService, collectionService)
+	tradeContr := controller.NewTradeController(tradeService, userService, itemService, tokenService, collectionService)
+	craftingContr := controller.NewCraftingController(craftingService, itemService, tokenService, collectionService)
+	collectionContr := controller.NewCollectionController(collectionService)
+	shippingContr := controller.NewShippingController(shippingService, userService)
+	buybackContr := controller.NewBuybackController(buybackService, itemService, tokenService)
+	auctionContr := controller.NewAuctionController(auctionService, tokenService)
+	uploadContr := controller.NewUploadController(uploadService, itemService, packService, userService, vendorService)
+	notificationContr := controller.NewNotificationController(notificationService)
 
 	// controller registration
 	userContr.Register(router)
@@ -142,6 +186,15 @@ func main() {
 	referralContr.Register(router)
 	reportContr.Register(router)
 	financialContr.Register(router)
+	marketContr.Register(router)
+	tradeContr.Register(router)
+	craftingContr.Register(router)
+	collectionContr.Register(router)
+	shippingContr.Register(router)
+	buybackContr.Register(router)
+	auctionContr.Register(router)
+	uploadContr.Register(router)
+	notificationContr.Register(router)
 
 	InitRoutes(router)
 
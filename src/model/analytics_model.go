@@ -17,7 +17,20 @@ type PackQtySoldAnalytic struct {
 	QtySold   *uint64 `db:"qty_sold" json:"qtySold"`
 }
 
+type PackWaitlistAnalytic struct {
+	PackConfigId *uint64 `db:"pack_config_id" json:"packConfigId"`
+	PackTitle    *string `db:"pack_title" json:"packTitle"`
+	WaitlistSize *uint64 `db:"waitlist_size" json:"waitlistSize"`
+}
+
 type PackAnalyticsResp struct {
 	TimeAxis []string             `json:"timeAxis"`
 	DataSet  []*PackSalesAnalytic `json:"dataSet"`
 }
+
+type ItemBuybackAnalytic struct {
+	ItemId       *uint64  `db:"item_id" json:"itemId"`
+	ItemName     *string  `db:"item_name" json:"itemName"`
+	BuybackCount *uint64  `db:"buyback_count" json:"buybackCount"`
+	TokensPaid   *float64 `db:"tokens_paid" json:"tokensPaid"`
+}
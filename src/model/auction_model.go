@@ -0,0 +1,51 @@
+package model
+
+type CreateAuctionReq struct {
+	ItemId       *uint64  `json:"itemId"`
+	StartPrice   *float64 `json:"startPrice"`
+	ReservePrice *float64 `json:"reservePrice"`
+	StartsAt     *string  `json:"startsAt"`
+	EndsAt       *string  `json:"endsAt"`
+}
+
+type AuctionBidReq struct {
+	TokenAmount *float64 `json:"tokenAmount"`
+}
+
+type AuctionExpanded struct {
+	ID               *uint64       `db:"id" json:"id"`
+	VendorId         *string       `db:"vendor_id" json:"vendorId"`
+	VendorUsername   *string       `db:"vendor_username" json:"vendorUsername"`
+	ItemId           *uint64       `db:"item_id" json:"itemId"`
+	ItemName         *string       `db:"item_name" json:"itemName"`
+	ItemImageUrl     *string       `db:"item_image_url" json:"itemImageUrl"`
+	StartPrice       *float64      `db:"start_price" json:"startPrice"`
+	ReservePrice     *float64      `db:"reserve_price" json:"reservePrice"`
+	CurrentBid       *float64      `db:"current_bid" json:"currentBid"`
+	CurrentBidderUid *string       `db:"current_bidder_uid" json:"currentBidderUid"`
+	BidCount         *uint64       `db:"bid_count" json:"bidCount"`
+	Status           *string       `db:"status" json:"status"`
+	UserItemId       *uint64       `db:"user_item_id" json:"userItemId"`
+	StartsAt         *string       `db:"starts_at" json:"startsAt"`
+	EndsAt           *string       `db:"ends_at" json:"endsAt"`
+	CreatedAt        *string       `db:"created_at" json:"createdAt"`
+	ClosedAt         *string       `db:"closed_at" json:"closedAt"`
+	ReserveMet       bool          `db:"-" json:"reserveMet"`
+	MinimumBid       float64       `db:"-" json:"minimumBid"`
+	Bids             []*AuctionBid `db:"-" json:"bids"`
+}
+
+type AuctionBidResp struct {
+	Bid        *AuctionBid `json:"bid"`
+	EndsAt     string      `json:"endsAt"`
+	NewBalance float64     `json:"newBalance"`
+}
+
+// who needs their caches cleared once an auction closes
+type ClosedAuction struct {
+	AuctionId  uint64  `json:"auctionId"`
+	Status     string  `json:"status"`
+	VendorId   string  `json:"vendorId"`
+	BidderUid  *string `json:"bidderUid"`
+	UserItemId *uint64 `json:"userItemId"`
+}
@@ -0,0 +1,30 @@
+package model
+
+type BuybackReq struct {
+	UserItemIds []uint64 `json:"userItemIds"`
+}
+
+type BuybackResp struct {
+	BoughtBackUserItemIds []uint64 `json:"boughtBackUserItemIds"`
+	Amount                float64  `json:"amount"`
+	NewBalance            float64  `json:"newBalance"`
+}
+
+type BuybackSettingReq struct {
+	Enabled *bool `json:"enabled"`
+}
+
+type BuybackAllowance struct {
+	Percent   int     `json:"percent"`
+	DailyCap  float64 `json:"dailyCap"`
+	UsedToday float64 `json:"usedToday"`
+	Remaining float64 `json:"remaining"`
+}
+
+type BuybackableItem struct {
+	UserItemId     *uint64  `db:"user_item_id" json:"userItemId"`
+	ItemId         *uint64  `db:"item_id" json:"itemId"`
+	VendorId       *string  `db:"vendor_id" json:"vendorId"`
+	Value          *float64 `db:"value" json:"value"`
+	BuybackEnabled *bool    `db:"buyback_enabled" json:"buybackEnabled"`
+}
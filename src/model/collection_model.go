@@ -0,0 +1,56 @@
+package model
+
+type CollectionSetReq struct {
+	Name                *string  `json:"name"`
+	Description         *string  `json:"description"`
+	ItemIds             []uint64 `json:"itemIds"`
+	RewardTokenAmount   *float64 `json:"rewardTokenAmount"`
+	RewardItemId        *uint64  `json:"rewardItemId"`
+	RewardBadgeName     *string  `json:"rewardBadgeName"`
+	RewardBadgeImageUrl *string  `json:"rewardBadgeImageUrl"`
+}
+
+type CollectionSetItemExpanded struct {
+	SetId    *uint64 `db:"set_id" json:"-"`
+	ItemId   *uint64 `db:"item_id" json:"itemId"`
+	Name     *string `db:"name" json:"name"`
+	ImageUrl *string `db:"image_url" json:"imageUrl"`
+	RarityId *uint64 `db:"rarity_id" json:"rarityId"`
+	Rarity   *string `db:"rarity" json:"rarity"`
+	Owned    bool    `db:"-" json:"owned"`
+}
+
+type CollectionSetExpanded struct {
+	ID                  *uint64                      `db:"id" json:"id"`
+	VendorId            *string                      `db:"vendor_id" json:"vendorId"`
+	VendorUsername      *string                      `db:"vendor_username" json:"vendorUsername"`
+	Name                *string                      `db:"name" json:"name"`
+	Description         *string                      `db:"description" json:"description"`
+	RewardTokenAmount   *float64                     `db:"reward_token_amount" json:"rewardTokenAmount"`
+	RewardItemId        *uint64                      `db:"reward_item_id" json:"rewardItemId"`
+	RewardItemName      *string                      `db:"reward_item_name" json:"rewardItemName"`
+	RewardItemImageUrl  *string                      `db:"reward_item_image_url" json:"rewardItemImageUrl"`
+	RewardBadgeName     *string                      `db:"reward_badge_name" json:"rewardBadgeName"`
+	RewardBadgeImageUrl *string                      `db:"reward_badge_image_url" json:"rewardBadgeImageUrl"`
+	CreatedAt           *string                      `db:"created_at" json:"createdAt"`
+	Items               []*CollectionSetItemExpanded `db:"-" json:"items"`
+}
+
+type CollectionSetProgress struct {
+	Set         *CollectionSetExpanded `json:"set"`
+	OwnedCount  int                    `json:"ownedCount"`
+	TotalCount  int                    `json:"totalCount"`
+	Completed   bool                   `json:"completed"`
+	CompletedAt *string                `json:"completedAt"`
+}
+
+type UserSetCompletionExpanded struct {
+	SetId               *uint64  `db:"set_id" json:"setId"`
+	Name                *string  `db:"name" json:"name"`
+	VendorId            *string  `db:"vendor_id" json:"vendorId"`
+	RewardTokenAmount   *float64 `db:"reward_token_amount" json:"rewardTokenAmount"`
+	RewardUserItemId    *uint64  `db:"reward_user_item_id" json:"rewardUserItemId"`
+	RewardBadgeName     *string  `db:"reward_badge_name" json:"rewardBadgeName"`
+	RewardBadgeImageUrl *string  `db:"reward_badge_image_url" json:"rewardBadgeImageUrl"`
+	CompletedAt         *string  `db:"completed_at" json:"completedAt"`
+}
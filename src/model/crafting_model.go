@@ -0,0 +1,68 @@
+package model
+
+type CraftingRecipeReq struct {
+	Name         *string                  `json:"name"`
+	Description  *string                  `json:"description"`
+	CraftingCost *float64                 `json:"craftingCost"`
+	PerUserLimit *int                     `json:"perUserLimit"`
+	StartsAt     *string                  `json:"startsAt"`
+	EndsAt       *string                  `json:"endsAt"`
+	Inputs       []*CraftingRecipeInput   `json:"inputs"`
+	Outcomes     []*CraftingRecipeOutcome `json:"outcomes"`
+}
+
+type CraftingRecipeInputExpanded struct {
+	RecipeId *uint64 `db:"recipe_id" json:"-"`
+	ItemId   *uint64 `db:"item_id" json:"itemId"`
+	Name     *string `db:"name" json:"name"`
+	ImageUrl *string `db:"image_url" json:"imageUrl"`
+	RarityId *uint64 `db:"rarity_id" json:"rarityId"`
+	Rarity   *string `db:"rarity" json:"rarity"`
+	Qty      *int    `db:"qty" json:"qty"`
+}
+
+type CraftingRecipeOutcomeExpanded struct {
+	RecipeId *uint64 `db:"recipe_id" json:"-"`
+	ItemId   *uint64 `db:"item_id" json:"itemId"`
+	Name     *string `db:"name" json:"name"`
+	ImageUrl *string `db:"image_url" json:"imageUrl"`
+	RarityId *uint64 `db:"rarity_id" json:"rarityId"`
+	Rarity   *string `db:"rarity" json:"rarity"`
+	Weight   *int    `db:"weight" json:"weight"`
+	Odds     float64 `db:"-" json:"odds"`
+}
+
+type CraftingRecipeExpanded struct {
+	ID             *uint64                          `db:"id" json:"id"`
+	VendorId       *string                          `db:"vendor_id" json:"vendorId"`
+	VendorUsername *string                          `db:"vendor_username" json:"vendorUsername"`
+	Name           *string                          `db:"name" json:"name"`
+	Description    *string                          `db:"description" json:"description"`
+	CraftingCost   *float64                         `db:"crafting_cost" json:"craftingCost"`
+	PerUserLimit   *int                             `db:"per_user_limit" json:"perUserLimit"`
+	StartsAt       *string                          `db:"starts_at" json:"startsAt"`
+	EndsAt         *string                          `db:"ends_at" json:"endsAt"`
+	CreatedAt      *string                          `db:"created_at" json:"createdAt"`
+	Inputs         []*CraftingRecipeInputExpanded   `db:"-" json:"inputs"`
+	Outcomes       []*CraftingRecipeOutcomeExpanded `db:"-" json:"outcomes"`
+}
+
+type CraftReq struct {
+	UserItemIds []uint64 `json:"userItemIds"`
+}
+
+type CraftResp struct {
+	RecipeId            *uint64  `json:"recipeId"`
+	ConsumedUserItemIds []uint64 `json:"consumedUserItemIds"`
+	UserItemId          *uint64  `json:"userItemId"`
+	ItemId              *uint64  `json:"itemId"`
+	SerialNumber        *uint64  `json:"serialNumber"`
+	CraftingCost        float64  `json:"craftingCost"`
+}
+
+type CraftableItem struct {
+	UserItemId *uint64 `db:"user_item_id" json:"userItemId"`
+	ItemId     *uint64 `db:"item_id" json:"itemId"`
+	VendorId   *string `db:"vendor_id" json:"vendorId"`
+	RarityId   *uint64 `db:"rarity_id" json:"rarityId"`
+}
@@ -30,3 +30,65 @@ type PackItemConfigExpanded struct {
 	ContentThumbUrl *string  `db:"content_thumb_url" json:"contentThumbUrl"`
 	ContentType     *string  `db:"content_type" json:"contentType"`
 }
+
+type ItemEventExpanded struct {
+	ID            *uint64 `db:"id" json:"id"`
+	EventType     *string `db:"event_type" json:"eventType"`
+	FromUid       *string `db:"from_uid" json:"fromUid,omitempty"`
+	FromUsername  *string `db:"from_username" json:"fromUsername"`
+	ToUid         *string `db:"to_uid" json:"toUid,omitempty"`
+	ToUsername    *string `db:"to_username" json:"toUsername"`
+	PackId        *uint64 `db:"pack_id" json:"packId"`
+	ActorUid      *string `db:"actor_uid" json:"actorUid,omitempty"`
+	ActorUsername *string `db:"actor_username" json:"actorUsername"`
+	Notes         *string `db:"notes" json:"notes,omitempty"`
+	CreatedAt     *string `db:"created_at" json:"createdAt"`
+}
+
+type ItemInstance struct {
+	UserItemId      *uint64              `db:"user_item_id" json:"userItemId"`
+	ItemId          *uint64              `db:"item_id" json:"itemId"`
+	Name            *string              `db:"name" json:"name"`
+	Description     *string              `db:"description" json:"description"`
+	ImageUrl        *string              `db:"image_url" json:"imageUrl"`
+	VendorId        *string              `db:"vendor_id" json:"vendorId"`
+	SerialNumber    *uint64              `db:"serial_number" json:"serialNumber"`
+	MintCount       *uint64              `db:"mint_count" json:"mintCount"`
+	OwnerUid        *string              `db:"owner_uid" json:"ownerUid,omitempty"`
+	OwnerUsername   *string              `db:"owner_username" json:"ownerUsername"`
+	AcquiredAt      *string              `db:"acquired_at" json:"acquiredAt"`
+	RemovedAt       *string              `db:"removed_at" json:"removedAt"`
+	ExpiredAt       *string              `db:"expired_at" json:"expiredAt"`
+	OwnershipEvents []*ItemEventExpanded `db:"-" json:"ownershipEvents"`
+}
+
+type BurnItemsReq struct {
+	UserItemIds []uint64 `json:"userItemIds"`
+	Currency    *string  `json:"currency"`
+}
+
+type BurnItemsResp struct {
+	BurnedUserItemIds []uint64 `json:"burnedUserItemIds"`
+	Currency          string   `json:"currency"`
+	Amount            float64  `json:"amount"`
+	NewBalance        float64  `json:"newBalance"`
+}
+
+type BurnableItem struct {
+	UserItemId *uint64  `db:"user_item_id" json:"userItemId"`
+	ItemId     *uint64  `db:"item_id" json:"itemId"`
+	VendorId   *string  `db:"vendor_id" json:"vendorId"`
+	RarityId   *uint64  `db:"rarity_id" json:"rarityId"`
+	Value      *float64 `db:"value" json:"value"`
+}
+
+type ExpiringUserItem struct {
+	UserItemId   *uint64 `db:"user_item_id" json:"userItemId"`
+	ItemId       *uint64 `db:"item_id" json:"itemId"`
+	Name         *string `db:"name" json:"name"`
+	ImageUrl     *string `db:"image_url" json:"imageUrl"`
+	VendorId     *string `db:"vendor_id" json:"vendorId"`
+	SerialNumber *uint64 `db:"serial_number" json:"serialNumber"`
+	AcquiredAt   *string `db:"acquired_at" json:"acquiredAt"`
+	ExpiredAt    *string `db:"expired_at" json:"expiredAt"`
+}
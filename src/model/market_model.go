@@ -0,0 +1,41 @@
+package model
+
+type MarketListingExpanded struct {
+	ID             *uint64  `db:"id" json:"id"`
+	UserItemId     *uint64  `db:"user_item_id" json:"userItemId"`
+	ItemId         *uint64  `db:"item_id" json:"itemId"`
+	SellerUid      *string  `db:"seller_uid" json:"sellerUid"`
+	SellerUsername *string  `db:"seller_username" json:"sellerUsername"`
+	TokenAmount    *float64 `db:"token_amount" json:"tokenAmount"`
+	Status         *string  `db:"status" json:"status"`
+	CreatedAt      *string  `db:"created_at" json:"createdAt"`
+	Name           *string  `db:"name" json:"name"`
+	Description    *string  `db:"description" json:"description"`
+	ImageUrl       *string  `db:"image_url" json:"imageUrl"`
+	VendorId       *string  `db:"vendor_id" json:"vendorId"`
+	VendorUsername *string  `db:"vendor_username" json:"vendorUsername"`
+	RarityId       *uint64  `db:"rarity_id" json:"rarityId"`
+	Rarity         *string  `db:"rarity" json:"rarity"`
+	SerialNumber   *uint64  `db:"serial_number" json:"serialNumber"`
+	MintCount      *uint64  `db:"mint_count" json:"mintCount"`
+	ListingAmount  *uint64  `db:"listing_amount" json:"listingAmount"`
+}
+
+type MarketListingFilter struct {
+	VendorId       *string
+	ItemId         *uint64
+	RarityId       *uint64
+	MinTokenAmount *float64
+	MaxTokenAmount *float64
+}
+
+type CreateMarketListingReq struct {
+	UserItemId  *uint64  `json:"userItemId"`
+	TokenAmount *float64 `json:"tokenAmount"`
+}
+
+type MarketPurchaseResp struct {
+	Sale       *MarketSale `json:"sale"`
+	UserItemId *uint64     `json:"userItemId"`
+	NewBalance float64     `json:"newBalance"`
+}
@@ -0,0 +1,65 @@
+package model
+
+type Notification struct {
+	ID           *uint64 `db:"id" json:"id"`
+	Uid          *string `db:"uid" json:"uid"`
+	Type         *string `db:"type" json:"type"`
+	ActorUid     *string `db:"actor_uid" json:"actorUid"`
+	ItemId       *uint64 `db:"item_id" json:"itemId"`
+	PackId       *uint64 `db:"pack_id" json:"packId"`
+	PackConfigId *uint64 `db:"pack_config_id" json:"packConfigId"`
+	Message      *string `db:"message" json:"message"`
+	ReadAt       *string `db:"read_at" json:"readAt"`
+	CreatedAt    *string `db:"created_at" json:"createdAt"`
+}
+
+type NotificationsResp struct {
+	UnreadAmount  int             `json:"unreadAmount"`
+	Notifications []*Notification `json:"notifications"`
+}
+
+type ReadNotificationsReq struct {
+	NotificationIds []uint64 `json:"notificationIds"`
+}
+
+type BigPull struct {
+	NotificationId *uint64 `db:"notification_id" json:"notificationId"`
+	Uid            *string `db:"uid" json:"uid"`
+	Username       *string `db:"username" json:"username"`
+	DisplayName    *string `db:"display_name" json:"displayName"`
+	UserImageUrl   *string `db:"user_image_url" json:"userImageUrl"`
+	ItemId         *uint64 `db:"item_id" json:"itemId"`
+	ItemName       *string `db:"item_name" json:"itemName"`
+	ItemImageUrl   *string `db:"item_image_url" json:"itemImageUrl"`
+	RarityId       *uint64 `db:"rarity_id" json:"rarityId"`
+	Rarity         *string `db:"rarity" json:"rarity"`
+	PulledAt       *string `db:"pulled_at" json:"pulledAt"`
+}
+
+type BigPullOptIn struct {
+	OptedIn bool `json:"optedIn"`
+}
+
+type CreatorWebhook struct {
+	VendorId  *string `db:"vendor_id" json:"vendorId"`
+	Url       *string `db:"url" json:"url"`
+	Secret    *string `db:"secret" json:"secret"`
+	CreatedAt *string `db:"created_at" json:"createdAt"`
+	UpdatedAt *string `db:"updated_at" json:"updatedAt"`
+}
+
+type CreatorWebhookReq struct {
+	Url *string `json:"url"`
+}
+
+// the body posted to a creators webhook when one of their notify items is pulled
+type NotifyPullEvent struct {
+	Type        string `json:"type"`
+	VendorId    string `json:"vendorId"`
+	FanUid      string `json:"fanUid"`
+	FanUsername string `json:"fanUsername"`
+	ItemId      uint64 `json:"itemId"`
+	ItemName    string `json:"itemName"`
+	PackId      uint64 `json:"packId"`
+	PulledAt    string `json:"pulledAt"`
+}
@@ -65,9 +65,101 @@ type PackFlatten struct {
 	ItemContentType     *string `db:"item_content_type" json:"itemContentType"`
 	ItemActive          *bool   `db:"item_active" json:"itemActive"`
 	ItemNotify          *bool   `db:"item_notify" json:"itemNotify"`
+	ItemSerialNumber    *uint64 `db:"item_serial_number" json:"itemSerialNumber"`
+	ItemMintCount       *uint64 `db:"item_mint_count" json:"itemMintCount"`
 }
 
 type PackBoughtResp struct {
-	PackIds    []uint64 `json:"packIds"`
-	NewBalance float64  `json:"newBalance"`
+	PackIds          []uint64  `json:"packIds"`
+	NewBalance       float64   `json:"newBalance"`
+	TotalTokenAmount float64   `json:"totalTokenAmount"`
+	Gift             *PackGift `json:"gift,omitempty"`
+}
+
+type PackQuote struct {
+	PackConfigId     uint64  `json:"packConfigId"`
+	Amount           int     `json:"amount"`
+	BaseTokenAmount  float64 `json:"baseTokenAmount"`
+	PackTokenAmount  float64 `json:"packTokenAmount"`
+	TotalTokenAmount float64 `json:"totalTokenAmount"`
+	Savings          float64 `json:"savings"`
+}
+
+type OpenPacksReq struct {
+	PackIds      []uint64 `json:"packIds"`
+	PackConfigId *uint64  `json:"packConfigId"`
+}
+
+type RarityCount struct {
+	RarityId uint64  `json:"rarityId"`
+	Rarity   *string `json:"rarity"`
+	Label    *string `json:"label"`
+	Color    *string `json:"color"`
+	Amount   int     `json:"amount"`
+}
+
+type OpenedPack struct {
+	Pack          *Pack          `json:"pack"`
+	RaritySummary []*RarityCount `json:"raritySummary"`
+}
+
+type OpenPacksResp struct {
+	Packs         []*OpenedPack  `json:"packs"`
+	PackAmount    int            `json:"packAmount"`
+	ItemAmount    int            `json:"itemAmount"`
+	RaritySummary []*RarityCount `json:"raritySummary"`
+}
+
+type PackRevealSlotExpanded struct {
+	Slot            *int    `db:"slot" json:"slot"`
+	RevealedAt      *string `db:"revealed_at" json:"revealedAt"`
+	RarityId        *uint64 `db:"rarity_id" json:"rarityId"`
+	ItemId          *uint64 `db:"item_id" json:"itemId"`
+	Name            *string `db:"name" json:"name"`
+	Description     *string `db:"description" json:"description"`
+	ImageUrl        *string `db:"image_url" json:"imageUrl"`
+	ContentMainUrl  *string `db:"content_main_url" json:"contentMainUrl"`
+	ContentThumbUrl *string `db:"content_thumb_url" json:"contentThumbUrl"`
+	ContentType     *string `db:"content_type" json:"contentType"`
+	VendorId        *string `db:"vendor_id" json:"vendorId"`
+	Notify          *bool   `db:"notify" json:"notify"`
+	SerialNumber    *uint64 `db:"serial_number" json:"serialNumber"`
+	MintCount       *uint64 `db:"mint_count" json:"mintCount"`
+}
+
+type PackRevealResp struct {
+	Session       *PackRevealSession        `json:"session"`
+	RarityHints   []*uint64                 `json:"rarityHints"`
+	RevealedItems []*PackRevealSlotExpanded `json:"revealedItems"`
+}
+
+type ReceivedGift struct {
+	ID             *uint64 `db:"id" json:"id"`
+	SenderUid      *string `db:"sender_uid" json:"senderUid"`
+	SenderUsername *string `db:"sender_username" json:"senderUsername"`
+	PackConfigId   *uint64 `db:"pack_config_id" json:"packConfigId"`
+	Title          *string `db:"title" json:"title"`
+	ImageUrl       *string `db:"image_url" json:"imageUrl"`
+	PackAmount     *int    `db:"pack_amount" json:"packAmount"`
+	Message        *string `db:"message" json:"message"`
+	CreatedAt      *string `db:"created_at" json:"createdAt"`
+}
+
+type PackReview struct {
+	PackConfig *PackConfig               `json:"packConfig"`
+	Items      []*PackItemConfigExpanded `json:"items"`
+	Odds       []*PackItemPreview        `json:"odds"`
+}
+
+type ClonePackConfigReq struct {
+	Title       *string  `json:"title"`
+	TokenAmount *float64 `json:"tokenAmount"`
+	Qty         *int     `json:"qty"`
+}
+
+type WaitlistedUser struct {
+	ID       *uint64 `db:"id" json:"id"`
+	Uid      *string `db:"uid" json:"uid"`
+	Username *string `db:"username" json:"username"`
+	Email    *string `db:"email" json:"email"`
 }
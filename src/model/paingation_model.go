@@ -101,6 +101,8 @@ type PageUserItem struct {
 	Categories            []string `db:"categories" json:"categories"`
 	Rarity                *string  `db:"rarity" json:"rarity"`
 	Ranking               *uint64  `db:"ranking" json:"ranking"`
+	RarityLabel           *string  `db:"rarity_label" json:"rarityLabel"`
+	RarityColor           *string  `db:"rarity_color" json:"rarityColor"`
 	ContentMainUrl        *string  `db:"content_main_url" json:"contentMainUrl"`
 	ContentThumbUrl       *string  `db:"content_thumb_url" json:"contentThumbUrl"`
 	ContentExpiredTime    *string  `db:"content_expired_time" json:"contentExpiredTime"`
@@ -124,7 +126,9 @@ type PageUserItem struct {
 	TotalItemsAmount      *uint64  `db:"total_items_amount" json:"totalItemsAmount"`
 	OwnerId               *string  `db:"owner_id" json:"ownerId"`
 	AcquiredAt            *string  `db:"acquired_at" json:"acquiredAt"`
-	ExpiredAt             *string  `db:"expiredAt" json:"expiredAt"`
+	ExpiredAt             *string  `db:"expired_at" json:"expiredAt"`
+	SerialNumber          *uint64  `db:"serial_number" json:"serialNumber"`
+	MintCount             *uint64  `db:"mint_count" json:"mintCount"`
 }
 
 type PageVendorItem struct {
@@ -139,6 +143,8 @@ type PageVendorItem struct {
 	Categories            []string `db:"categories" json:"categories"`
 	Rarity                *string  `db:"rarity" json:"rarity"`
 	Ranking               *uint64  `db:"ranking" json:"ranking"`
+	RarityLabel           *string  `db:"rarity_label" json:"rarityLabel"`
+	RarityColor           *string  `db:"rarity_color" json:"rarityColor"`
 	ContentMainUrl        *string  `db:"content_main_url" json:"contentMainUrl"`
 	ContentThumbUrl       *string  `db:"content_thumb_url" json:"contentThumbUrl"`
 	ContentExpiredTime    *string  `db:"content_expired_time" json:"contentExpiredTime"`
@@ -253,3 +259,10 @@ type UserFavoritePage struct {
 	PageSize           *uint64             `json:"pageSize"`
 	Page               []*PageUserFavorite `json:"page"`
 }
+
+type MarketListingPage struct {
+	ListingAmount *uint64                  `json:"listingAmount"`
+	NextPage      *uint64                  `json:"nextPage"`
+	PageSize      *uint64                  `json:"pageSize"`
+	Page          []*MarketListingExpanded `json:"page"`
+}
@@ -0,0 +1,34 @@
+package model
+
+type WithdrawalStatusReq struct {
+	Status         *string `json:"status"`
+	Carrier        *string `json:"carrier"`
+	TrackingNumber *string `json:"trackingNumber"`
+}
+
+type ItemWithdrawalExpanded struct {
+	ID             *uint64 `db:"id" json:"id"`
+	UserItemId     *uint64 `db:"user_item_id" json:"userItemId"`
+	Uid            *string `db:"uid" json:"uid"`
+	Username       *string `db:"username" json:"username"`
+	Status         *string `db:"status" json:"status"`
+	Name           *string `db:"name" json:"name"`
+	StreetAddress1 *string `db:"street_address1" json:"streetAddress1"`
+	StreetAddress2 *string `db:"street_address2" json:"streetAddress2"`
+	City           *string `db:"city" json:"city"`
+	State          *string `db:"state" json:"state"`
+	Zip            *string `db:"zip" json:"zip"`
+	Country        *string `db:"country" json:"country"`
+	Carrier        *string `db:"carrier" json:"carrier"`
+	TrackingNumber *string `db:"tracking_number" json:"trackingNumber"`
+	WithdrawnAt    *string `db:"withdrawn_at" json:"withdrawnAt"`
+	PackedAt       *string `db:"packed_at" json:"packedAt"`
+	ShippedAt      *string `db:"shipped_at" json:"shippedAt"`
+	FulfilledAt    *string `db:"fulfilled_at" json:"fulfilledAt"`
+	CancelledAt    *string `db:"cancelled_at" json:"cancelledAt"`
+	ItemId         *uint64 `db:"item_id" json:"itemId"`
+	ItemName       *string `db:"item_name" json:"itemName"`
+	ImageUrl       *string `db:"image_url" json:"imageUrl"`
+	SerialNumber   *uint64 `db:"serial_number" json:"serialNumber"`
+	VendorId       *string `db:"vendor_id" json:"vendorId"`
+}
@@ -17,13 +17,16 @@ type TokenBalance struct {
 	UpdatedAt *string  `db:"updated_at" json:"updatedAt"`
 }
 
+// token_amount is the effective per pack price of the purchase. rounding_adjustment is what the last order
+// of a purchase adds on top of it so the orders add up to the amount charged, it is 0 on every other order
 type PackOrder struct {
-	ID          *uint64  `db:"id" json:"id"`
-	PackId      *uint64  `db:"pack_id" json:"packId"`
-	Uid         *string  `db:"uid" json:"uid"`
-	TokenAmount *float64 `db:"token_amount" json:"tokenAmount"`
-	TokenRateId *uint64  `db:"token_rate_id" json:"tokenRateId"`
-	OrderedAt   *string  `db:"ordered_at" json:"orderedAt"`
+	ID                 *uint64  `db:"id" json:"id"`
+	PackId             *uint64  `db:"pack_id" json:"packId"`
+	Uid                *string  `db:"uid" json:"uid"`
+	TokenAmount        *float64 `db:"token_amount" json:"tokenAmount"`
+	RoundingAdjustment *float64 `db:"rounding_adjustment" json:"roundingAdjustment"`
+	TokenRateId        *uint64  `db:"token_rate_id" json:"tokenRateId"`
+	OrderedAt          *string  `db:"ordered_at" json:"orderedAt"`
 }
 
 type TokenOrder struct {
@@ -45,10 +48,24 @@ type TokenCurrencyRate struct {
 }
 
 type ItemWithdrawal struct {
-	ID          *uint64 `db:"id" json:"id"`
-	UserItemId  *uint64 `db:"user_item_id" json:"userItemId"`
-	WithdrawnAt *string `db:"withdrawn_at" json:"withdrawnAt"`
-	FulfilledAt *string `db:"fulfilled_at" json:"fulfilledAt"`
+	ID             *uint64 `db:"id" json:"id"`
+	UserItemId     *uint64 `db:"user_item_id" json:"userItemId"`
+	Uid            *string `db:"uid" json:"uid"`
+	Status         *string `db:"status" json:"status"`
+	Name           *string `db:"name" json:"name"`
+	StreetAddress1 *string `db:"street_address1" json:"streetAddress1"`
+	StreetAddress2 *string `db:"street_address2" json:"streetAddress2"`
+	City           *string `db:"city" json:"city"`
+	State          *string `db:"state" json:"state"`
+	Zip            *string `db:"zip" json:"zip"`
+	Country        *string `db:"country" json:"country"`
+	Carrier        *string `db:"carrier" json:"carrier"`
+	TrackingNumber *string `db:"tracking_number" json:"trackingNumber"`
+	WithdrawnAt    *string `db:"withdrawn_at" json:"withdrawnAt"`
+	PackedAt       *string `db:"packed_at" json:"packedAt"`
+	ShippedAt      *string `db:"shipped_at" json:"shippedAt"`
+	FulfilledAt    *string `db:"fulfilled_at" json:"fulfilledAt"`
+	CancelledAt    *string `db:"cancelled_at" json:"cancelledAt"`
 }
 
 type NewSalesTransaction struct {
@@ -183,3 +200,54 @@ type AllEarningsPeriod struct {
 	CurrentPeriod  bool     `db:"current_period" json:"currentPeriod"`
 	PayoutStatus   *string  `db:"payout_status" json:"payoutStatus"`
 }
+
+type MarketSale struct {
+	ID             *uint64  `db:"id" json:"id"`
+	ListingId      *uint64  `db:"listing_id" json:"listingId"`
+	BuyerUid       *string  `db:"buyer_uid" json:"buyerUid"`
+	SellerUid      *string  `db:"seller_uid" json:"sellerUid"`
+	CreatorUid     *string  `db:"creator_uid" json:"creatorUid"`
+	TokenAmount    *float64 `db:"token_amount" json:"tokenAmount"`
+	PlatformFee    *float64 `db:"platform_fee" json:"platformFee"`
+	CreatorRoyalty *float64 `db:"creator_royalty" json:"creatorRoyalty"`
+	SellerProceeds *float64 `db:"seller_proceeds" json:"sellerProceeds"`
+	Status         *string  `db:"status" json:"status"`
+	SettledAt      *string  `db:"settled_at" json:"settledAt"`
+}
+
+// a signed token movement, uid is nil for entries kept by the platform
+type TokenLedgerEntry struct {
+	ID           *uint64  `db:"id" json:"id"`
+	Uid          *string  `db:"uid" json:"uid"`
+	EntryType    *string  `db:"entry_type" json:"entryType"`
+	TokenAmount  *float64 `db:"token_amount" json:"tokenAmount"`
+	MarketSaleId *uint64  `db:"market_sale_id" json:"marketSaleId"`
+	CreatedAt    *string  `db:"created_at" json:"createdAt"`
+}
+
+type CraftingBalance struct {
+	ID        *uint64  `db:"id" json:"id"`
+	UID       *string  `db:"uid" json:"uid"`
+	Balance   *float64 `db:"balance" json:"balance"`
+	UpdatedAt *string  `db:"updated_at" json:"updatedAt"`
+}
+
+type ItemBurn struct {
+	ID         *uint64  `db:"id" json:"id"`
+	UserItemId *uint64  `db:"user_item_id" json:"userItemId"`
+	Uid        *string  `db:"uid" json:"uid"`
+	Currency   *string  `db:"currency" json:"currency"`
+	Amount     *float64 `db:"amount" json:"amount"`
+	BurnedAt   *string  `db:"burned_at" json:"burnedAt"`
+}
+
+type ItemBuyback struct {
+	ID           *uint64  `db:"id" json:"id"`
+	UserItemId   *uint64  `db:"user_item_id" json:"userItemId"`
+	Uid          *string  `db:"uid" json:"uid"`
+	ItemId       *uint64  `db:"item_id" json:"itemId"`
+	VendorId     *string  `db:"vendor_id" json:"vendorId"`
+	Value        *float64 `db:"value" json:"value"`
+	Amount       *float64 `db:"amount" json:"amount"`
+	BoughtBackAt *string  `db:"bought_back_at" json:"boughtBackAt"`
+}
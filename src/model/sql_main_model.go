@@ -41,16 +41,84 @@ type PackConfig struct {
 	ContentThumbUrl *string  `db:"content_thumb_url" json:"contentThumbUrl"`
 	Active          *bool    `db:"active" json:"active"`
 	QtySold         *uint64  `db:"qty_sold" json:"qtySold"`
+	ReviewStatus    *string  `db:"review_status" json:"reviewStatus"`
+	ReviewNotes     *string  `db:"review_notes" json:"reviewNotes"`
+	SubmittedAt     *string  `db:"submitted_at" json:"submittedAt"`
+	ReviewedAt      *string  `db:"reviewed_at" json:"reviewedAt"`
+	ReviewedBy      *string  `db:"reviewed_by" json:"reviewedBy"`
 }
 
 type PackFact struct {
+	ID              *uint64 `db:"id" json:"id"`
+	CreatedAt       *string `db:"created_at" json:"createdAt"`
+	PurchasedAt     *string `db:"purchased_at" json:"purchasedAt"`
+	OpenedAt        *string `db:"opened_at" json:"openedAt"`
+	OwnerID         *string `db:"owner_id" json:"ownerId"`
+	PackConfigID    *uint64 `db:"pack_config_id" json:"packConfigId"`
+	Active          *bool   `db:"active" json:"active"`
+	GenerationJobID *uint64 `db:"generation_job_id" json:"generationJobId"`
+}
+
+type PackWaitlistEntry struct {
 	ID           *uint64 `db:"id" json:"id"`
+	Uid          *string `db:"uid" json:"uid"`
+	PackConfigId *uint64 `db:"pack_config_id" json:"packConfigId"`
 	CreatedAt    *string `db:"created_at" json:"createdAt"`
-	PurchasedAt  *string `db:"purchased_at" json:"purchasedAt"`
-	OpenedAt     *string `db:"opened_at" json:"openedAt"`
-	OwnerID      *string `db:"owner_id" json:"ownerId"`
-	PackConfigID *uint64 `db:"pack_config_id" json:"packConfigId"`
-	Active       *bool   `db:"active" json:"active"`
+	NotifiedAt   *string `db:"notified_at" json:"notifiedAt"`
+}
+
+type PackPriceTier struct {
+	ID               *uint64  `db:"id" json:"id"`
+	PackConfigID     *uint64  `db:"pack_config_id" json:"packConfigId"`
+	MinQty           *int     `db:"min_qty" json:"minQty"`
+	PackTokenAmount  *float64 `db:"pack_token_amount" json:"packTokenAmount"`
+	TotalTokenAmount *float64 `db:"total_token_amount" json:"totalTokenAmount"`
+	CreatedAt        *string  `db:"created_at" json:"createdAt"`
+}
+
+type PackGift struct {
+	ID           *uint64 `db:"id" json:"id"`
+	SenderUid    *string `db:"sender_uid" json:"senderUid"`
+	RecipientUid *string `db:"recipient_uid" json:"recipientUid"`
+	PackConfigId *uint64 `db:"pack_config_id" json:"packConfigId"`
+	PackAmount   *int    `db:"pack_amount" json:"packAmount"`
+	Message      *string `db:"message" json:"message"`
+	CreatedAt    *string `db:"created_at" json:"createdAt"`
+}
+
+type PackGenerationJob struct {
+	ID            *uint64 `db:"id" json:"id"`
+	PackConfigID  *uint64 `db:"pack_config_id" json:"packConfigId"`
+	VendorID      *string `db:"vendor_id" json:"vendorId"`
+	Status        *string `db:"status" json:"status"`
+	TotalPacks    *int    `db:"total_packs" json:"totalPacks"`
+	PacksUploaded *int    `db:"packs_uploaded" json:"packsUploaded"`
+	ErrorMessage  *string `db:"error_message" json:"errorMessage"`
+	CreatedAt     *string `db:"created_at" json:"createdAt"`
+	StartedAt     *string `db:"started_at" json:"startedAt"`
+	FinishedAt    *string `db:"finished_at" json:"finishedAt"`
+	UpdatedAt     *string `db:"updated_at" json:"updatedAt"`
+}
+
+type PackRevealSession struct {
+	ID            *uint64 `db:"id" json:"id"`
+	PackId        *uint64 `db:"pack_id" json:"packId"`
+	Uid           *string `db:"uid" json:"uid"`
+	ItemCount     *int    `db:"item_count" json:"itemCount"`
+	RevealedCount *int    `db:"revealed_count" json:"revealedCount"`
+	CreatedAt     *string `db:"created_at" json:"createdAt"`
+	CompletedAt   *string `db:"completed_at" json:"completedAt"`
+}
+
+type PackRevealSlot struct {
+	ID           *uint64 `db:"id" json:"id"`
+	SessionId    *uint64 `db:"session_id" json:"sessionId"`
+	Slot         *int    `db:"slot" json:"slot"`
+	ItemId       *uint64 `db:"item_id" json:"itemId"`
+	RarityId     *uint64 `db:"rarity_id" json:"rarityId"`
+	RevealedAt   *string `db:"revealed_at" json:"revealedAt"`
+	SerialNumber *uint64 `db:"serial_number" json:"serialNumber"`
+	UserItemId   *uint64 `db:"user_item_id" json:"userItemId"`
 }
 
 type Item struct {
@@ -69,6 +137,9 @@ type Item struct {
 	Active          *bool    `db:"active" json:"active"`
 	Notify          *bool    `db:"notify" json:"notify"`
 	Value           *float64 `db:"value" json:"value"`
+	SerialNumber    *uint64  `db:"serial_number" json:"serialNumber"`
+	MintCount       *uint64  `db:"mint_count" json:"mintCount"`
+	LifetimeDays    *int     `db:"lifetime_days" json:"lifetimeDays"`
 }
 
 type PackItemConfig struct {
@@ -81,9 +152,15 @@ type PackItemConfig struct {
 }
 
 type PackItemFact struct {
-	ID     *uint64 `db:"id" json:"id"`
-	ItemID *uint64 `db:"item_id" json:"itemId"`
-	PackID *uint64 `db:"pack_id" json:"packId"`
+	ID           *uint64 `db:"id" json:"id"`
+	ItemID       *uint64 `db:"item_id" json:"itemId"`
+	PackID       *uint64 `db:"pack_id" json:"packId"`
+	SerialNumber *uint64 `db:"serial_number" json:"serialNumber"`
+}
+
+type ItemMintCount struct {
+	ItemId    *uint64 `db:"item_id" json:"itemId"`
+	MintedQty *uint64 `db:"minted_qty" json:"mintedQty"`
 }
 
 type PackItemPreview struct {
@@ -92,16 +169,40 @@ type PackItemPreview struct {
 	ItemName        *string  `db:"item_name" json:"itemName"`
 	ItemContentType *string  `db:"item_content_type" json:"itemContentType"`
 	ItemRarity      *string  `db:"item_rarity" json:"itemRarity"`
+	ItemRarityLabel *string  `db:"item_rarity_label" json:"itemRarityLabel"`
+	ItemRarityColor *string  `db:"item_rarity_color" json:"itemRarityColor"`
 	ItemChance      *float64 `db:"item_chance" json:"itemChance"`
+	PreviewUrl      *string  `db:"preview_url" json:"previewUrl"`
 }
 
 type UserItem struct {
+	ID           *uint64 `db:"id" json:"id"`
+	Uid          *string `db:"uid" json:"uid"`
+	ItemId       *uint64 `db:"item_id" json:"itemId"`
+	AcquiredAt   *string `db:"acquired_at" json:"acquiredAt"`
+	RemovedAt    *string `db:"removed_at" json:"removedAt"`
+	ExpiredAt    *string `db:"expired_at" json:"expiredAt"`
+	BoughtBackAt *string `db:"bought_back_at" json:"boughtBackAt"`
+	SerialNumber *uint64 `db:"serial_number" json:"serialNumber"`
+}
+
+type ItemEvent struct {
 	ID         *uint64 `db:"id" json:"id"`
-	Uid        *string `db:"uid" json:"uid"`
-	ItemId     *uint64 `db:"item_id" json:"itemId"`
-	AcquiredAt *string `db:"acquired_at" json:"acquiredAt"`
-	RemovedAt  *string `db:"removed_at" json:"removedAt"`
-	ExpiredAt  *string `db:"expired_at" json:"expiredAt"`
+	UserItemId *uint64 `db:"user_item_id" json:"userItemId"`
+	EventType  *string `db:"event_type" json:"eventType"`
+	FromUid    *string `db:"from_uid" json:"fromUid"`
+	ToUid      *string `db:"to_uid" json:"toUid"`
+	PackId     *uint64 `db:"pack_id" json:"packId"`
+	ActorUid   *string `db:"actor_uid" json:"actorUid"`
+	Notes      *string `db:"notes" json:"notes"`
+	CreatedAt  *string `db:"created_at" json:"createdAt"`
+}
+
+type UserSpendingLimit struct {
+	Uid             *string  `db:"uid" json:"uid"`
+	DailyTokenLimit *float64 `db:"daily_token_limit" json:"dailyTokenLimit"`
+	LastSpentAt     *string  `db:"last_spent_at" json:"lastSpentAt"`
+	UpdatedAt       *string  `db:"updated_at" json:"updatedAt"`
 }
 
 type Favorite struct {
@@ -220,11 +321,196 @@ type Faq struct {
 type ShippingInfo struct {
 	ID             *uint64 `db:"id" json:"id"`
 	Uid            *string `db:"uid" json:"uid"`
+	Name           *string `db:"name" json:"name"`
 	StreetAddress1 *string `db:"street_address1" json:"streetAddress1"`
 	StreetAddress2 *string `db:"street_address2" json:"streetAddress2"`
 	City           *string `db:"city" json:"city"`
 	State          *string `db:"state" json:"state"`
 	Zip            *string `db:"zip" json:"zip"`
+	Country        *string `db:"country" json:"country"`
 	CreatedAt      *string `db:"created_at" json:"createdAt"`
+	UpdatedAt      *string `db:"updated_at" json:"updatedAt"`
 	DeletedAt      *string `db:"deleted_at" json:"deletedAt"`
 }
+
+type MarketListing struct {
+	ID            *uint64  `db:"id" json:"id"`
+	UserItemId    *uint64  `db:"user_item_id" json:"userItemId"`
+	ItemId        *uint64  `db:"item_id" json:"itemId"`
+	SellerUid     *string  `db:"seller_uid" json:"sellerUid"`
+	TokenAmount   *float64 `db:"token_amount" json:"tokenAmount"`
+	Status        *string  `db:"status" json:"status"`
+	BuyerUid      *string  `db:"buyer_uid" json:"buyerUid"`
+	RemovalReason *string  `db:"removal_reason" json:"removalReason"`
+	CreatedAt     *string  `db:"created_at" json:"createdAt"`
+	SoldAt        *string  `db:"sold_at" json:"soldAt"`
+	CancelledAt   *string  `db:"cancelled_at" json:"cancelledAt"`
+}
+
+type TradeOffer struct {
+	ID                *uint64  `db:"id" json:"id"`
+	SenderUid         *string  `db:"sender_uid" json:"senderUid"`
+	RecipientUid      *string  `db:"recipient_uid" json:"recipientUid"`
+	SenderTokenAmount *float64 `db:"sender_token_amount" json:"senderTokenAmount"`
+	Message           *string  `db:"message" json:"message"`
+	Status            *string  `db:"status" json:"status"`
+	CounterOfId       *uint64  `db:"counter_of_id" json:"counterOfId"`
+	CreatedAt         *string  `db:"created_at" json:"createdAt"`
+	ExpiresAt         *string  `db:"expires_at" json:"expiresAt"`
+	RespondedAt       *string  `db:"responded_at" json:"respondedAt"`
+}
+
+type TradeOfferItem struct {
+	ID           *uint64 `db:"id" json:"id"`
+	TradeOfferId *uint64 `db:"trade_offer_id" json:"tradeOfferId"`
+	UserItemId   *uint64 `db:"user_item_id" json:"userItemId"`
+	OwnerUid     *string `db:"owner_uid" json:"ownerUid"`
+}
+
+type Rarity struct {
+	ID        *uint64 `db:"id" json:"id"`
+	Rarity    *string `db:"rarity" json:"rarity"`
+	Ranking   *uint64 `db:"ranking" json:"ranking"`
+	Label     *string `db:"label" json:"label"`
+	Color     *string `db:"color" json:"color"`
+	CreatedAt *string `db:"created_at" json:"createdAt"`
+	UpdatedAt *string `db:"updated_at" json:"updatedAt"`
+}
+
+type RarityOverride struct {
+	ID        *uint64 `db:"id" json:"id"`
+	VendorId  *string `db:"vendor_id" json:"vendorId"`
+	RarityId  *uint64 `db:"rarity_id" json:"rarityId"`
+	Label     *string `db:"label" json:"label"`
+	Color     *string `db:"color" json:"color"`
+	CreatedAt *string `db:"created_at" json:"createdAt"`
+	UpdatedAt *string `db:"updated_at" json:"updatedAt"`
+}
+
+type BurnRate struct {
+	ID           *uint64  `db:"id" json:"id"`
+	VendorId     *string  `db:"vendor_id" json:"vendorId"`
+	RarityId     *uint64  `db:"rarity_id" json:"rarityId"`
+	TokenRate    *float64 `db:"token_rate" json:"tokenRate"`
+	CraftingRate *float64 `db:"crafting_rate" json:"craftingRate"`
+	CreatedAt    *string  `db:"created_at" json:"createdAt"`
+	UpdatedAt    *string  `db:"updated_at" json:"updatedAt"`
+}
+
+type CraftingRecipe struct {
+	ID           *uint64  `db:"id" json:"id"`
+	VendorId     *string  `db:"vendor_id" json:"vendorId"`
+	Name         *string  `db:"name" json:"name"`
+	Description  *string  `db:"description" json:"description"`
+	CraftingCost *float64 `db:"crafting_cost" json:"craftingCost"`
+	PerUserLimit *int     `db:"per_user_limit" json:"perUserLimit"`
+	StartsAt     *string  `db:"starts_at" json:"startsAt"`
+	EndsAt       *string  `db:"ends_at" json:"endsAt"`
+	Active       *bool    `db:"active" json:"active"`
+	CreatedAt    *string  `db:"created_at" json:"createdAt"`
+	DeletedAt    *string  `db:"deleted_at" json:"deletedAt"`
+}
+
+type CraftingRecipeInput struct {
+	ID       *uint64 `db:"id" json:"id"`
+	RecipeId *uint64 `db:"recipe_id" json:"recipeId"`
+	ItemId   *uint64 `db:"item_id" json:"itemId"`
+	RarityId *uint64 `db:"rarity_id" json:"rarityId"`
+	Qty      *int    `db:"qty" json:"qty"`
+}
+
+type CraftingRecipeOutcome struct {
+	ID       *uint64 `db:"id" json:"id"`
+	RecipeId *uint64 `db:"recipe_id" json:"recipeId"`
+	ItemId   *uint64 `db:"item_id" json:"itemId"`
+	Weight   *int    `db:"weight" json:"weight"`
+}
+
+type UserCraft struct {
+	ID               *uint64 `db:"id" json:"id"`
+	RecipeId         *uint64 `db:"recipe_id" json:"recipeId"`
+	Uid              *string `db:"uid" json:"uid"`
+	OutputUserItemId *uint64 `db:"output_user_item_id" json:"outputUserItemId"`
+	CraftedAt        *string `db:"crafted_at" json:"craftedAt"`
+}
+
+type CollectionSet struct {
+	ID                  *uint64  `db:"id" json:"id"`
+	VendorId            *string  `db:"vendor_id" json:"vendorId"`
+	Name                *string  `db:"name" json:"name"`
+	Description         *string  `db:"description" json:"description"`
+	RewardTokenAmount   *float64 `db:"reward_token_amount" json:"rewardTokenAmount"`
+	RewardItemId        *uint64  `db:"reward_item_id" json:"rewardItemId"`
+	RewardBadgeName     *string  `db:"reward_badge_name" json:"rewardBadgeName"`
+	RewardBadgeImageUrl *string  `db:"reward_badge_image_url" json:"rewardBadgeImageUrl"`
+	Active              *bool    `db:"active" json:"active"`
+	CreatedAt           *string  `db:"created_at" json:"createdAt"`
+	DeletedAt           *string  `db:"deleted_at" json:"deletedAt"`
+}
+
+type CollectionSetItem struct {
+	ID     *uint64 `db:"id" json:"id"`
+	SetId  *uint64 `db:"set_id" json:"setId"`
+	ItemId *uint64 `db:"item_id" json:"itemId"`
+}
+
+type UserSetCompletion struct {
+	ID                *uint64  `db:"id" json:"id"`
+	SetId             *uint64  `db:"set_id" json:"setId"`
+	Uid               *string  `db:"uid" json:"uid"`
+	RewardTokenAmount *float64 `db:"reward_token_amount" json:"rewardTokenAmount"`
+	RewardUserItemId  *uint64  `db:"reward_user_item_id" json:"rewardUserItemId"`
+	CompletedAt       *string  `db:"completed_at" json:"completedAt"`
+}
+
+type BuybackSetting struct {
+	VendorId  *string `db:"vendor_id" json:"vendorId"`
+	Enabled   *bool   `db:"enabled" json:"enabled"`
+	UpdatedAt *string `db:"updated_at" json:"updatedAt"`
+}
+
+type Auction struct {
+	ID               *uint64  `db:"id" json:"id"`
+	VendorId         *string  `db:"vendor_id" json:"vendorId"`
+	ItemId           *uint64  `db:"item_id" json:"itemId"`
+	StartPrice       *float64 `db:"start_price" json:"startPrice"`
+	ReservePrice     *float64 `db:"reserve_price" json:"reservePrice"`
+	CurrentBid       *float64 `db:"current_bid" json:"currentBid"`
+	CurrentBidderUid *string  `db:"current_bidder_uid" json:"currentBidderUid"`
+	BidCount         *uint64  `db:"bid_count" json:"bidCount"`
+	Status           *string  `db:"status" json:"status"`
+	UserItemId       *uint64  `db:"user_item_id" json:"userItemId"`
+	StartsAt         *string  `db:"starts_at" json:"startsAt"`
+	EndsAt           *string  `db:"ends_at" json:"endsAt"`
+	CreatedAt        *string  `db:"created_at" json:"createdAt"`
+	ClosedAt         *string  `db:"closed_at" json:"closedAt"`
+}
+
+type AuctionBid struct {
+	ID          *uint64  `db:"id" json:"id"`
+	AuctionId   *uint64  `db:"auction_id" json:"auctionId"`
+	Uid         *string  `db:"uid" json:"uid"`
+	TokenAmount *float64 `db:"token_amount" json:"tokenAmount"`
+	Status      *string  `db:"status" json:"status"`
+	CreatedAt   *string  `db:"created_at" json:"createdAt"`
+	RefundedAt  *string  `db:"refunded_at" json:"refundedAt"`
+}
+
+type ItemRendition struct {
+	ItemId    *uint64 `db:"item_id" json:"itemId"`
+	Name      *string `db:"name" json:"name"`
+	Url       *string `db:"url" json:"url"`
+	Width     *int    `db:"width" json:"width"`
+	Height    *int    `db:"height" json:"height"`
+	CreatedAt *string `db:"created_at" json:"createdAt"`
+}
+
+type ContentWatermark struct {
+	ID             *uint64 `db:"id" json:"id"`
+	Uid            *string `db:"uid" json:"uid"`
+	ItemId         *uint64 `db:"item_id" json:"itemId"`
+	ContentUrl     *string `db:"content_url" json:"contentUrl"`
+	WatermarkedUrl *string `db:"watermarked_url" json:"watermarkedUrl"`
+	Status         *string `db:"status" json:"status"`
+	CreatedAt      *string `db:"created_at" json:"createdAt"`
+}
@@ -0,0 +1,37 @@
+package model
+
+type TradeOfferReq struct {
+	RecipientUid         *string  `json:"recipientUid"`
+	OfferedUserItemIds   []uint64 `json:"offeredUserItemIds"`
+	RequestedUserItemIds []uint64 `json:"requestedUserItemIds"`
+	TokenAmount          *float64 `json:"tokenAmount"`
+	Message              *string  `json:"message"`
+}
+
+type TradeOfferItemExpanded struct {
+	TradeOfferId *uint64 `db:"trade_offer_id" json:"-"`
+	UserItemId   *uint64 `db:"user_item_id" json:"userItemId"`
+	OwnerUid     *string `db:"owner_uid" json:"ownerUid"`
+	ItemId       *uint64 `db:"item_id" json:"itemId"`
+	Name         *string `db:"name" json:"name"`
+	ImageUrl     *string `db:"image_url" json:"imageUrl"`
+	VendorId     *string `db:"vendor_id" json:"vendorId"`
+	SerialNumber *uint64 `db:"serial_number" json:"serialNumber"`
+}
+
+type TradeOfferExpanded struct {
+	ID                *uint64                   `db:"id" json:"id"`
+	SenderUid         *string                   `db:"sender_uid" json:"senderUid"`
+	SenderUsername    *string                   `db:"sender_username" json:"senderUsername"`
+	RecipientUid      *string                   `db:"recipient_uid" json:"recipientUid"`
+	RecipientUsername *string                   `db:"recipient_username" json:"recipientUsername"`
+	SenderTokenAmount *float64                  `db:"sender_token_amount" json:"senderTokenAmount"`
+	Message           *string                   `db:"message" json:"message"`
+	Status            *string                   `db:"status" json:"status"`
+	CounterOfId       *uint64                   `db:"counter_of_id" json:"counterOfId"`
+	CreatedAt         *string                   `db:"created_at" json:"createdAt"`
+	ExpiresAt         *string                   `db:"expires_at" json:"expiresAt"`
+	RespondedAt       *string                   `db:"responded_at" json:"respondedAt"`
+	OfferedItems      []*TradeOfferItemExpanded `db:"-" json:"offeredItems"`
+	RequestedItems    []*TradeOfferItemExpanded `db:"-" json:"requestedItems"`
+}
@@ -0,0 +1,11 @@
+package model
+
+type UploadResp struct {
+	ContentMainUrl  *string          `json:"contentMainUrl,omitempty"`
+	ContentThumbUrl *string          `json:"contentThumbUrl,omitempty"`
+	ContentType     *string          `json:"contentType,omitempty"`
+	ImageUrl        *string          `json:"imageUrl,omitempty"`
+	BannerUrl       *string          `json:"bannerUrl,omitempty"`
+	Version         *int             `json:"version,omitempty"`
+	Renditions      []*ItemRendition `json:"renditions,omitempty"`
+}
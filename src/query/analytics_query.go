@@ -168,7 +168,7 @@ var TopCustomers = `
 	select
 		u.username
 		, count(*) as packs_purchased
-		, sum(o.token_amount / r.token_amount)::numeric(6,2) as amount_spent
+		, sum((o.token_amount + coalesce(o.rounding_adjustment, 0)) / r.token_amount)::numeric(6,2) as amount_spent
 	from
 		financial.pack_orders o
 	join
@@ -202,7 +202,7 @@ var PackSalesByDay = `
 	select
 		date_series.order_date::date as granularity
 		, count(o.ordered_at) as qty_sold
-		, sum(o.token_amount / r.token_amount)::numeric(6,2) as total_sales
+		, sum((o.token_amount + coalesce(o.rounding_adjustment, 0)) / r.token_amount)::numeric(6,2) as total_sales
 	from
  		financial.pack_orders o
 	join
@@ -236,7 +236,7 @@ var PackSalesByMonth = `
 	select
 		month_series.order_month as granularity
 		, count(o.ordered_at) qty_sold
-		, sum(o.token_amount / r.token_amount)::numeric(6,2) as total_sales
+		, sum((o.token_amount + coalesce(o.rounding_adjustment, 0)) / r.token_amount)::numeric(6,2) as total_sales
 	from
 		financial.pack_orders o
 	join
@@ -271,7 +271,7 @@ var PackSalesByYear = `
 	select
 		year_series.order_year as granularity
 		, count(o.ordered_at) as qty_sold
-		, sum(o.token_amount / r.token_amount)::numeric(6,2) as total_sales
+		, sum((o.token_amount + coalesce(o.rounding_adjustment, 0)) / r.token_amount)::numeric(6,2) as total_sales
 	from
  		financial.pack_orders o
 	join
@@ -303,3 +303,42 @@ var PackQtySold = `
 		vendor_id = '%v'
 		and active = true;
 `
+
+var PackWaitlistSize = `
+	select
+		pc.id as pack_config_id
+		, pc.title as pack_title
+		, count(w.id) as waitlist_size
+	from
+		main.pack_configs pc
+	join
+		main.pack_waitlist w
+		on w.pack_config_id = pc.id
+		and w.notified_at is null
+	where
+		pc.vendor_id = $1
+		and pc.deleted_at is null
+	group by
+		pc.id
+	order by
+		waitlist_size desc;
+`
+
+var ItemBuybacks = `
+	select
+		i.id as item_id
+		, i.name as item_name
+		, count(b.id) as buyback_count
+		, coalesce(sum(b.amount), 0) as tokens_paid
+	from
+		financial.item_buybacks b
+	join
+		main.items i
+		on i.id = b.item_id
+	where
+		b.vendor_id = $1
+	group by
+		i.id
+	order by
+		tokens_paid desc;
+`
@@ -190,7 +190,10 @@ var PackItemPreview = `
 			when i.content_type = 'vid' then 'video'
 		end as item_content_type
 		, r.rarity as item_rarity
+		, coalesce(ro.label, r.label) as item_rarity_label
+		, coalesce(ro.color, r.color) as item_rarity_color
 		, round((pic.qty::float / (pc.qty::float * pc.item_qty::float))::numeric * 100, 2) as item_chance
+		, ir.url as preview_url
 	from 
 		main.pack_configs pc
 	join
@@ -202,8 +205,16 @@ var PackItemPreview = `
 	join
 		main.rarity r
 		on i.rarity_id = r.id
+	left join
+		main.rarity_overrides ro
+		on ro.rarity_id = r.id
+		and ro.vendor_id = i.vendor_id
+	left join
+		main.item_renditions ir
+		on ir.item_id = i.id
+		and ir.name = 'teaser'
 	where 
 		pc.id = %v
 	order by
-		r.id desc;
+		r.ranking desc;
 `
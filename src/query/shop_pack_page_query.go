@@ -39,6 +39,7 @@ var ShopPackPageDefault = `
 		main.vendors v
 		on v.uid = pc.vendor_id
 		and pc.active = true
+		and pc.review_status = 'approved'
 		and pc.current_stock > 0
 		and v.active = true
 	left join
@@ -98,6 +99,7 @@ var ShopPackPageSortBy = `
 		main.vendors v
 		on v.uid = pc.vendor_id
 		and pc.active = true
+		and pc.review_status = 'approved'
 		and pc.current_stock > 0
 		and v.active = true
 	left join
@@ -157,6 +159,7 @@ var ShopPackPageFilterOn = `
 		main.vendors v
 		on v.uid = pc.vendor_id
 		and pc.active = true
+		and pc.review_status = 'approved'
 		and pc.current_stock > 0
 		and v.active = true
 	left join
@@ -218,6 +221,7 @@ var ShopPackPageSortByFilterOn = `
 		main.vendors v
 		on v.uid = pc.vendor_id
 		and pc.active = true
+		and pc.review_status = 'approved'
 		and pc.current_stock > 0
 		and v.active = true
 	left join
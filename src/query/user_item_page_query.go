@@ -18,6 +18,8 @@ var UserItemPageDefault = `
 		, string_agg(c.category, ',') as raw_categories
 		, r.rarity
 		, r.ranking
+		, coalesce(ro.label, r.label) as rarity_label
+		, coalesce(ro.color, r.color) as rarity_color
 		, v.uid as vendor_id
 		, v.display_name as vendor_display_name
 		, v.first_name as vendor_first_name
@@ -33,12 +35,22 @@ var UserItemPageDefault = `
 		, ui.id as user_item_id
 		, ui.uid as owner_id
 		, ui.acquired_at
+		, ui.serial_number
+		, ui.expired_at
+		, mc.minted_qty as mint_count
 		, count(*) over () as total_items_amount
 	from
 		main.items i
 	left join
 		main.rarity r
 		on i.rarity_id = r.id
+	left join
+		main.rarity_overrides ro
+		on ro.rarity_id = r.id
+		and ro.vendor_id = i.vendor_id
+	left join
+		main.item_mint_counts mc
+		on mc.item_id = i.id
 	join
 		main.vendors v
 		on i.vendor_id = v.uid
@@ -47,6 +59,8 @@ var UserItemPageDefault = `
 		on i.id = ui.item_id
 		and ui.uid = '%v'
 		and ui.removed_at is null
+		and (ui.expired_at is null or ui.expired_at > now())
+		and (%v = 0 or i.rarity_id = %v)
 	left join
 		main.item_categories
 		on item_categories.item_id = i.id
@@ -54,7 +68,7 @@ var UserItemPageDefault = `
 		main.categories c
 		on c.id = item_categories.category_id
 	group by
-		i.id, v.uid, r.id, ui.id
+		i.id, v.uid, r.id, ui.id, mc.item_id, ro.id
 	having
 		lower(v.username) like '%%%v%%'
 		or lower(v.first_name) like '%%%v%%'
@@ -85,6 +99,8 @@ var UserItemPageSortBy = `
 		, string_agg(c.category, ',') as raw_categories
 		, r.rarity
 		, r.ranking
+		, coalesce(ro.label, r.label) as rarity_label
+		, coalesce(ro.color, r.color) as rarity_color
 		, v.uid as vendor_id
 		, v.display_name as vendor_display_name
 		, v.first_name as vendor_first_name
@@ -100,12 +116,22 @@ var UserItemPageSortBy = `
 		, ui.id as user_item_id
 		, ui.uid as owner_id
 		, ui.acquired_at
+		, ui.serial_number
+		, ui.expired_at
+		, mc.minted_qty as mint_count
 		, count(*) over () as total_items_amount
 	from
 		main.items i
 	left join
 		main.rarity r
 		on i.rarity_id = r.id
+	left join
+		main.rarity_overrides ro
+		on ro.rarity_id = r.id
+		and ro.vendor_id = i.vendor_id
+	left join
+		main.item_mint_counts mc
+		on mc.item_id = i.id
 	join
 		main.vendors v
 		on i.vendor_id = v.uid
@@ -114,6 +140,8 @@ var UserItemPageSortBy = `
 		on i.id = ui.item_id
 		and ui.uid = '%v'
 		and ui.removed_at is null
+		and (ui.expired_at is null or ui.expired_at > now())
+		and (%v = 0 or i.rarity_id = %v)
 	left join
 		main.item_categories
 		on item_categories.item_id = i.id
@@ -121,7 +149,7 @@ var UserItemPageSortBy = `
 		main.categories c
 		on c.id = item_categories.category_id
 	group by
-		i.id, v.uid, r.id, ui.id
+		i.id, v.uid, r.id, ui.id, mc.item_id, ro.id
 	having
 		lower(v.username) like '%%%v%%'
 		or lower(v.first_name) like '%%%v%%'
@@ -152,6 +180,8 @@ var UserItemPageFilterOn = `
 		, string_agg(c.category, ',') as raw_categories
 		, r.rarity
 		, r.ranking
+		, coalesce(ro.label, r.label) as rarity_label
+		, coalesce(ro.color, r.color) as rarity_color
 		, v.uid as vendor_id
 		, v.display_name as vendor_display_name
 		, v.first_name as vendor_first_name
@@ -167,12 +197,22 @@ var UserItemPageFilterOn = `
 		, ui.id as user_item_id
 		, ui.uid as owner_id
 		, ui.acquired_at
+		, ui.serial_number
+		, ui.expired_at
+		, mc.minted_qty as mint_count
 		, count(*) over () as total_items_amount
 	from
 		main.items i
 	left join
 		main.rarity r
 		on i.rarity_id = r.id
+	left join
+		main.rarity_overrides ro
+		on ro.rarity_id = r.id
+		and ro.vendor_id = i.vendor_id
+	left join
+		main.item_mint_counts mc
+		on mc.item_id = i.id
 	join
 		main.vendors v
 		on i.vendor_id = v.uid
@@ -181,6 +221,8 @@ var UserItemPageFilterOn = `
 		on i.id = ui.item_id
 		and ui.uid = '%v'
 		and ui.removed_at is null
+		and (ui.expired_at is null or ui.expired_at > now())
+		and (%v = 0 or i.rarity_id = %v)
 	left join
 		main.item_categories
 		on item_categories.item_id = i.id
@@ -188,7 +230,7 @@ var UserItemPageFilterOn = `
 		main.categories c
 		on c.id = item_categories.category_id
 	group by
-		i.id, v.uid, r.id, ui.id
+		i.id, v.uid, r.id, ui.id, mc.item_id, ro.id
 	having
 		string_agg(c.category, ',') like '%%%v%%'
 		and (
@@ -221,6 +263,8 @@ var UserItemPageSortByFilterOn = `
 		, string_agg(c.category, ',') as raw_categories
 		, r.rarity
 		, r.ranking
+		, coalesce(ro.label, r.label) as rarity_label
+		, coalesce(ro.color, r.color) as rarity_color
 		, v.uid as vendor_id
 		, v.display_name as vendor_display_name
 		, v.first_name as vendor_first_name
@@ -236,12 +280,22 @@ var UserItemPageSortByFilterOn = `
 		, ui.id as user_item_id
 		, ui.uid as owner_id
 		, ui.acquired_at
+		, ui.serial_number
+		, ui.expired_at
+		, mc.minted_qty as mint_count
 		, count(*) over () as total_items_amount
 	from
 		main.items i
 	left join
 		main.rarity r
 		on i.rarity_id = r.id
+	left join
+		main.rarity_overrides ro
+		on ro.rarity_id = r.id
+		and ro.vendor_id = i.vendor_id
+	left join
+		main.item_mint_counts mc
+		on mc.item_id = i.id
 	join
 		main.vendors v
 		on i.vendor_id = v.uid
@@ -250,6 +304,8 @@ var UserItemPageSortByFilterOn = `
 		on i.id = ui.item_id
 		and ui.uid = '%v'
 		and ui.removed_at is null
+		and (ui.expired_at is null or ui.expired_at > now())
+		and (%v = 0 or i.rarity_id = %v)
 	left join
 		main.item_categories
 		on item_categories.item_id = i.id
@@ -257,7 +313,7 @@ var UserItemPageSortByFilterOn = `
 		main.categories c
 		on c.id = item_categories.category_id
 	group by
-		i.id, v.uid, r.id, ui.id
+		i.id, v.uid, r.id, ui.id, mc.item_id, ro.id
 	having
 		string_agg(c.category, ',') like '%%%v%%'
 		and (
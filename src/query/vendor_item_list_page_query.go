@@ -19,6 +19,8 @@ select
 		, string_agg(c.category, ',') as raw_categories
 		, r.rarity
 		, r.ranking
+		, coalesce(ro.label, r.label) as rarity_label
+		, coalesce(ro.color, r.color) as rarity_color
 		, v.uid as vendor_id
 		, v.first_name as vendor_first_name
 		, v.last_name as vendor_last_name
@@ -37,6 +39,10 @@ select
 	left join
 		main.rarity r
 		on i.rarity_id = r.id
+	left join
+		main.rarity_overrides ro
+		on ro.rarity_id = r.id
+		and ro.vendor_id = i.vendor_id
 	join
 		main.vendors v
 		on i.vendor_id = v.uid
@@ -49,7 +55,7 @@ select
 		main.categories c
 		on c.id = item_categories.category_id
 	group by
-		i.id, v.uid, r.id
+		i.id, v.uid, r.id, ro.id
 	having
 		lower(i.description) like '%%%v%%'
 		or lower(i.name) like '%%%v%%'
@@ -78,6 +84,8 @@ select
 		, string_agg(c.category, ',') as raw_categories
 		, r.rarity
 		, r.ranking
+		, coalesce(ro.label, r.label) as rarity_label
+		, coalesce(ro.color, r.color) as rarity_color
 		, v.uid as vendor_id
 		, v.first_name as vendor_first_name
 		, v.last_name as vendor_last_name
@@ -96,6 +104,10 @@ select
 	left join
 		main.rarity r
 		on i.rarity_id = r.id
+	left join
+		main.rarity_overrides ro
+		on ro.rarity_id = r.id
+		and ro.vendor_id = i.vendor_id
 	join
 		main.vendors v
 		on i.vendor_id = v.uid
@@ -108,7 +120,7 @@ select
 		main.categories c
 		on c.id = item_categories.category_id
 	group by
-		i.id, v.uid, r.id
+		i.id, v.uid, r.id, ro.id
 	having
 		lower(i.description) like '%%%v%%'
 		or lower(i.name) like '%%%v%%'
@@ -137,6 +149,8 @@ select
 		, string_agg(c.category, ',') as raw_categories
 		, r.rarity
 		, r.ranking
+		, coalesce(ro.label, r.label) as rarity_label
+		, coalesce(ro.color, r.color) as rarity_color
 		, v.uid as vendor_id
 		, v.first_name as vendor_first_name
 		, v.last_name as vendor_last_name
@@ -155,6 +169,10 @@ select
 	left join
 		main.rarity r
 		on i.rarity_id = r.id
+	left join
+		main.rarity_overrides ro
+		on ro.rarity_id = r.id
+		and ro.vendor_id = i.vendor_id
 	join
 		main.vendors v
 		on i.vendor_id = v.uid
@@ -167,7 +185,7 @@ select
 		main.categories c
 		on c.id = item_categories.category_id
 	group by
-		i.id, v.uid, r.id
+		i.id, v.uid, r.id, ro.id
 	having
 		string_agg(c.category, ',') like '%%%v%%'
 		and (
@@ -199,6 +217,8 @@ select
 		, string_agg(c.category, ',') as raw_categories
 		, r.rarity
 		, r.ranking
+		, coalesce(ro.label, r.label) as rarity_label
+		, coalesce(ro.color, r.color) as rarity_color
 		, v.uid as vendor_id
 		, v.first_name as vendor_first_name
 		, v.last_name as vendor_last_name
@@ -217,6 +237,10 @@ select
 	left join
 		main.rarity r
 		on i.rarity_id = r.id
+	left join
+		main.rarity_overrides ro
+		on ro.rarity_id = r.id
+		and ro.vendor_id = i.vendor_id
 	join
 		main.vendors v
 		on i.vendor_id = v.uid
@@ -229,7 +253,7 @@ select
 		main.categories c
 		on c.id = item_categories.category_id
 	group by
-		i.id, v.uid, r.id
+		i.id, v.uid, r.id, ro.id
 	having
 		string_agg(c.category, ',') like '%%%v%%'
 		and (
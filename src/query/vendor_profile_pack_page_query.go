@@ -38,6 +38,7 @@ var VendorProfilePackPageDefault = `
 		main.vendors v
 		on v.uid = pc.vendor_id
 		and pc.active = true
+		and pc.review_status = 'approved'
 		and pc.current_stock > 0
 		and v.uid = '%v'
 		and v.active = true
@@ -93,6 +94,7 @@ var VendorProfilePackPageSortBy = `
 		main.vendors v
 		on v.uid = pc.vendor_id
 		and pc.active = true
+		and pc.review_status = 'approved'
 		and pc.current_stock > 0
 		and v.uid = '%v'
 		and v.active = true
@@ -148,6 +150,7 @@ var VendorProfilePackPageFilterOn = `
 		main.vendors v
 		on v.uid = pc.vendor_id
 		and pc.active = true
+		and pc.review_status = 'approved'
 		and pc.current_stock > 0
 		and v.uid = '%v'
 		and v.active = true
@@ -205,6 +208,7 @@ var VendorProfilePackPageSortByFilterOn = `
 		main.vendors v
 		on v.uid = pc.vendor_id
 		and pc.active = true
+		and pc.review_status = 'approved'
 		and pc.current_stock > 0
 		and v.uid = '%v'
 		and v.active = true
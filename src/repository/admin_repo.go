@@ -19,6 +19,7 @@ type AdminRepository interface {
 	ApproveVendor(context.Context, string, string) error
 	RejectVendor(context.Context, string, string) error
 	RemoveVendor(context.Context, string, string) error
+	BanUser(context.Context, string) error
 	AddFaq(context.Context, *model.Faq) (*model.Faq, error)
 	EditFaq(context.Context, map[string]interface{}, uint64) error
 	RemoveFaq(context.Context, uint64) error
@@ -148,6 +149,56 @@ func (r *AdminRepoImpl) RemoveVendor(c context.Context, uid string, uername stri
 	return &core.ErrorResp{Message: "unable to update user is_vendor privileges"}
 }
 
+// deactivates a user and takes down every listing they have on the marketplace
+func (r *AdminRepoImpl) BanUser(c context.Context, uid string) error {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Update(db.SCHEMA_USERS).
+		SetMap(map[string]interface{}{"active": false, "updated_at": now}).
+		Where(squirrel.Eq{"uid": uid, "active": true}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected <= 0 {
+		err = &core.ErrorResp{Message: "user does not exist or is already inactive"}
+		return err
+	}
+
+	if _, err = removeMarketListings(ctx, tx, squirrel.Eq{"seller_uid": uid}, "seller banned", now); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
 func (r *AdminRepoImpl) RejectVendor(c context.Context, uid string, uername string) error {
 	ctx, cancel := context.WithTimeout(c, 5*time.Second)
 	defer cancel()
@@ -25,6 +25,8 @@ type AnalyticsRepository interface {
 	TopCustomers(context.Context, string) ([]*model.CustomerAnalytic, error)
 	PackSales(context.Context, string, string, int64, int64, string) ([]*model.PackSalesAnalytic, error)
 	PackQtySold(context.Context, string) ([]*model.PackQtySoldAnalytic, error)
+	PackWaitlistSize(context.Context, string) ([]*model.PackWaitlistAnalytic, error)
+	ItemBuybacks(context.Context, string) ([]*model.ItemBuybackAnalytic, error)
 }
 
 type AnalyticsRepoImpl struct {
@@ -475,3 +477,97 @@ func (r *AnalyticsRepoImpl) PackQtySold(c context.Context, vendorId string) ([]*
 		return packsQtySold, nil
 	}
 }
+
+func (r *AnalyticsRepoImpl) PackWaitlistSize(c context.Context, vendorId string) ([]*model.PackWaitlistAnalytic, error) {
+	val, err := r.cache.Get(c, db.KEY_PACK_WAITLIST_SIZE+vendorId).Result()
+	if err != nil {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+		if err != nil {
+			return nil, err
+		}
+
+		defer func() {
+			tx.Commit()
+		}()
+
+		rows, err := tx.QueryxContext(ctx, query.PackWaitlistSize, vendorId)
+		if err != nil {
+			return nil, err
+		}
+
+		packWaitlists := []*model.PackWaitlistAnalytic{}
+		defer rows.Close()
+		for rows.Next() {
+			packWaitlist := model.PackWaitlistAnalytic{}
+			if err = rows.StructScan(&packWaitlist); err != nil {
+				return nil, err
+			}
+			packWaitlists = append(packWaitlists, &packWaitlist)
+		}
+
+		packWaitlistsBytes, err := json.Marshal(packWaitlists)
+		if err != nil {
+			return nil, err
+		}
+		if err = r.cache.Set(c, db.KEY_PACK_WAITLIST_SIZE+vendorId, packWaitlistsBytes, time.Duration(3600)*time.Second).Err(); err != nil {
+			return nil, err
+		}
+		return packWaitlists, nil
+	} else {
+		packWaitlists := []*model.PackWaitlistAnalytic{}
+		if err = json.Unmarshal([]byte(val), &packWaitlists); err != nil {
+			return nil, err
+		}
+		return packWaitlists, nil
+	}
+}
+
+func (r *AnalyticsRepoImpl) ItemBuybacks(c context.Context, vendorId string) ([]*model.ItemBuybackAnalytic, error) {
+	val, err := r.cache.Get(c, db.KEY_ITEM_BUYBACKS+vendorId).Result()
+	if err != nil {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+		if err != nil {
+			return nil, err
+		}
+
+		defer func() {
+			tx.Commit()
+		}()
+
+		rows, err := tx.QueryxContext(ctx, query.ItemBuybacks, vendorId)
+		if err != nil {
+			return nil, err
+		}
+
+		itemBuybacks := []*model.ItemBuybackAnalytic{}
+		defer rows.Close()
+		for rows.Next() {
+			itemBuyback := model.ItemBuybackAnalytic{}
+			if err = rows.StructScan(&itemBuyback); err != nil {
+				return nil, err
+			}
+			itemBuybacks = append(itemBuybacks, &itemBuyback)
+		}
+
+		itemBuybacksBytes, err := json.Marshal(itemBuybacks)
+		if err != nil {
+			return nil, err
+		}
+		if err = r.cache.Set(c, db.KEY_ITEM_BUYBACKS+vendorId, itemBuybacksBytes, time.Duration(3600)*time.Second).Err(); err != nil {
+			return nil, err
+		}
+		return itemBuybacks, nil
+	} else {
+		itemBuybacks := []*model.ItemBuybackAnalytic{}
+		if err = json.Unmarshal([]byte(val), &itemBuybacks); err != nil {
+			return nil, err
+		}
+		return itemBuybacks, nil
+	}
+}
@@ -0,0 +1,569 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"xo-packs/core"
+	"xo-packs/db"
+	"xo-packs/model"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+)
+
+type AuctionRepository interface {
+	CreateAuction(context.Context, *model.Auction) (*uint64, error)
+	CancelAuction(context.Context, uint64, string) error
+	GetAuction(context.Context, uint64) (*model.AuctionExpanded, error)
+	GetAuctions(context.Context, string, string) ([]*model.AuctionExpanded, error)
+	PlaceBid(context.Context, uint64, string, float64, time.Duration) (*model.AuctionBidResp, *string, error)
+	CloseEndedAuctions(context.Context, int) ([]*model.ClosedAuction, error)
+}
+
+type AuctionRepoImpl struct {
+	db    *sqlx.DB
+	cache *redis.Client
+}
+
+func NewAuctionRepo(db *sqlx.DB, cache *redis.Client) AuctionRepository {
+	return &AuctionRepoImpl{db: db, cache: cache}
+}
+
+type AuctionError struct {
+	message string
+}
+
+func (e *AuctionError) Error() string {
+	return e.message
+}
+
+// opens an auction for one of the creators own active items. an item can only be up for auction once at a time
+func (r *AuctionRepoImpl) CreateAuction(c context.Context, auction *model.Auction) (*uint64, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("id").
+		From(db.SCHEMA_ITEMS).
+		Where(squirrel.Eq{"id": *auction.ItemId, "vendor_id": *auction.VendorId, "deleted_at": nil}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var itemId uint64
+	if err = tx.QueryRowContext(ctx, query, args...).Scan(&itemId); err != nil {
+		if err == sql.ErrNoRows {
+			err = &AuctionError{"auctions can only be created for the creators own active items"}
+		}
+		return nil, err
+	}
+
+	query, args, err = psql.
+		Select("count(*)").
+		From(db.SCHEMA_AUCTIONS).
+		Where(squirrel.Eq{"item_id": itemId, "status": db.AUCTION_ACTIVE}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	activeAuctions := 0
+	if err = tx.QueryRowContext(ctx, query, args...).Scan(&activeAuctions); err != nil {
+		return nil, err
+	}
+	if activeAuctions > 0 {
+		err = &AuctionError{"item is already up for auction"}
+		return nil, err
+	}
+
+	query, args, err = psql.
+		Insert(db.SCHEMA_AUCTIONS).
+		Columns(core.ModelColumns(auction)...).
+		Values(core.StructValues(auction)...).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var auctionId uint64
+	if err = tx.QueryRowContext(ctx, query, args...).Scan(&auctionId); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &auctionId, nil
+}
+
+// creators can only call off an auction nobody has bid on yet
+func (r *AuctionRepoImpl) CancelAuction(c context.Context, auctionId uint64, vendorId string) error {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	auction, err := lockAuction(ctx, tx, auctionId)
+	if err != nil {
+		return err
+	}
+	if *auction.VendorId != vendorId {
+		err = &AuctionError{"user is not authorized to perform this action"}
+		return err
+	}
+	if *auction.Status != db.AUCTION_ACTIVE {
+		err = &AuctionError{"auction is no longer active"}
+		return err
+	}
+	if auction.BidCount != nil && *auction.BidCount > 0 {
+		err = &AuctionError{"auctions with bids cannot be cancelled"}
+		return err
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	if err = setAuctionStatus(ctx, tx, auctionId, db.AUCTION_CANCELLED, nil, now); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func auctionSelect() squirrel.SelectBuilder {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	return psql.
+		Select(
+			"a.id",
+			"a.vendor_id",
+			"v.username as vendor_username",
+			"a.item_id",
+			"i.name as item_name",
+			"i.image_url as item_image_url",
+			"a.start_price",
+			"a.reserve_price",
+			"a.current_bid",
+			"a.current_bidder_uid",
+			"a.bid_count",
+			"a.status",
+			"a.user_item_id",
+			"a.starts_at",
+			"a.ends_at",
+			"a.created_at",
+			"a.closed_at",
+		).
+		From(db.SCHEMA_AUCTIONS + " a").
+		Join(db.SCHEMA_ITEMS + " i on i.id = a.item_id").
+		LeftJoin(db.SCHEMA_USERS + " v on v.uid = a.vendor_id")
+}
+
+// auctions change with every bid so they are read straight from the db
+func (r *AuctionRepoImpl) GetAuction(c context.Context, auctionId uint64) (*model.AuctionExpanded, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	query, args, err := auctionSelect().
+		Where(squirrel.Eq{"a.id": auctionId}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	auction := model.AuctionExpanded{}
+	if err = r.db.QueryRowxContext(ctx, query, args...).StructScan(&auction); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &AuctionError{fmt.Sprintf("auction %v does not exist", auctionId)}
+		}
+		return nil, err
+	}
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err = psql.
+		Select("id", "auction_id", "uid", "token_amount", "status", "created_at", "refunded_at").
+		From(db.SCHEMA_AUCTION_BIDS).
+		Where(squirrel.Eq{"auction_id": auctionId}).
+		OrderBy("token_amount desc", "id desc").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	auction.Bids = []*model.AuctionBid{}
+	defer rows.Close()
+	for rows.Next() {
+		bid := model.AuctionBid{}
+		if err = rows.StructScan(&bid); err != nil {
+			return nil, err
+		}
+		auction.Bids = append(auction.Bids, &bid)
+	}
+	return &auction, nil
+}
+
+// lists auctions by status, soonest ending first, optionally narrowed to one creator
+func (r *AuctionRepoImpl) GetAuctions(c context.Context, vendorId string, status string) ([]*model.AuctionExpanded, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	where := squirrel.Eq{"a.status": status}
+	if vendorId != "" {
+		where["a.vendor_id"] = vendorId
+	}
+	query, args, err := auctionSelect().
+		Where(where).
+		OrderBy("a.ends_at asc", "a.id asc").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	auctions := []*model.AuctionExpanded{}
+	defer rows.Close()
+	for rows.Next() {
+		auction := model.AuctionExpanded{}
+		if err = rows.StructScan(&auction); err != nil {
+			return nil, err
+		}
+		auctions = append(auctions, &auction)
+	}
+	return auctions, nil
+}
+
+// places a bid. the auction row is locked for the whole bid so concurrent bidders are applied one at a time,
+// each against the latest high bid. the bid is held from the bidders balance and the bid it beats is refunded
+// straight away. a bid within the snipe window of the end pushes the end out. returns the uid of the outbid
+// user when there was one
+func (r *AuctionRepoImpl) PlaceBid(c context.Context, auctionId uint64, uid string, tokenAmount float64, snipeWindow time.Duration) (*model.AuctionBidResp, *string, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	auction, err := lockAuction(ctx, tx, auctionId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bidAt := time.Now()
+	now := bidAt.Format("2006-01-02 15:04:05")
+	if *auction.Status != db.AUCTION_ACTIVE || core.TimeReached(*auction.EndsAt, bidAt) {
+		err = &AuctionError{"auction has ended"}
+		return nil, nil, err
+	}
+	if !core.TimeReached(*auction.StartsAt, bidAt) {
+		err = &AuctionError{"auction has not started yet"}
+		return nil, nil, err
+	}
+	if *auction.VendorId == uid {
+		err = &AuctionError{"creators cannot bid on their own auctions"}
+		return nil, nil, err
+	}
+	if minimumBid := core.MinimumBid(*auction.StartPrice, auction.CurrentBid); tokenAmount < minimumBid {
+		err = &AuctionError{fmt.Sprintf("bid must be at least %v tokens", minimumBid)}
+		return nil, nil, err
+	}
+
+	// refund the bid being beaten before holding the new one so a user raising their own bid only needs the difference
+	outbidUid, err := refundHeldBid(ctx, tx, auctionId, now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	held, err := debitTokenBalance(ctx, tx, uid, tokenAmount, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !held {
+		err = &core.ErrorResp{Message: "User does not have sufficient token balance to place this bid"}
+		return nil, nil, err
+	}
+
+	heldStatus := db.AUCTION_BID_HELD
+	bid := model.AuctionBid{AuctionId: &auctionId, Uid: &uid, TokenAmount: &tokenAmount, Status: &heldStatus, CreatedAt: &now}
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Insert(db.SCHEMA_AUCTION_BIDS).
+		Columns(core.ModelColumns(bid)...).
+		Values(core.StructValues(bid)...).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var bidId uint64
+	if err = tx.QueryRowContext(ctx, query, args...).Scan(&bidId); err != nil {
+		return nil, nil, err
+	}
+	bid.ID = &bidId
+
+	endsAt := core.AuctionEndAfterBid(*auction.EndsAt, bidAt, snipeWindow).Format("2006-01-02 15:04:05")
+	query, args, err = psql.
+		Update(db.SCHEMA_AUCTIONS).
+		SetMap(map[string]interface{}{
+			"current_bid":        tokenAmount,
+			"current_bidder_uid": uid,
+			"bid_count":          squirrel.Expr("bid_count + 1"),
+			"ends_at":            endsAt,
+		}).
+		Where(squirrel.Eq{"id": auctionId}).
+		ToSql()
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, nil, err
+	}
+
+	query, args, err = psql.
+		Select("balance").
+		From(db.SCHEMA_TOKEN_BALANCE).
+		Where(squirrel.Eq{"uid": uid}).
+		ToSql()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	newBalance := 0.0
+	if err = tx.QueryRowContext(ctx, query, args...).Scan(&newBalance); err != nil {
+		return nil, nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+	return &model.AuctionBidResp{Bid: &bid, EndsAt: endsAt, NewBalance: newBalance}, outbidUid, nil
+}
+
+// closes a batch of auctions whose end has passed. an auction whose high bid meets the reserve is sold, the
+// winner is minted the item and the creator is paid the bid less the platform fee. any other auction goes unsold
+// and its high bid is refunded. auctions being bid on are skipped and picked up by a later batch
+func (r *AuctionRepoImpl) CloseEndedAuctions(c context.Context, limit int) ([]*model.ClosedAuction, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("id", "vendor_id", "item_id", "reserve_price", "current_bid", "current_bidder_uid").
+		From(db.SCHEMA_AUCTIONS).
+		Where(squirrel.Eq{"status": db.AUCTION_ACTIVE}).
+		Where("ends_at <= now()").
+		OrderBy("ends_at asc").
+		Limit(uint64(limit)).
+		Suffix("FOR UPDATE SKIP LOCKED").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	auctions := []*model.Auction{}
+	defer rows.Close()
+	for rows.Next() {
+		auction := model.Auction{}
+		if err = rows.StructScan(&auction); err != nil {
+			return nil, err
+		}
+		auctions = append(auctions, &auction)
+	}
+	rows.Close()
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	closedAuctions := []*model.ClosedAuction{}
+	for _, auction := range auctions {
+		closed := model.ClosedAuction{AuctionId: *auction.ID, VendorId: *auction.VendorId, BidderUid: auction.CurrentBidderUid}
+		reserveMet := auction.CurrentBid != nil && (auction.ReservePrice == nil || *auction.CurrentBid >= *auction.ReservePrice)
+		if !reserveMet {
+			if _, err = refundHeldBid(ctx, tx, *auction.ID, now); err != nil {
+				return nil, err
+			}
+			if err = setAuctionStatus(ctx, tx, *auction.ID, db.AUCTION_UNSOLD, nil, now); err != nil {
+				return nil, err
+			}
+			closed.Status = db.AUCTION_UNSOLD
+			closedAuctions = append(closedAuctions, &closed)
+			continue
+		}
+
+		var userItemId *uint64
+		winnerUid := *auction.CurrentBidderUid
+		userItemId, err = mintUserItem(ctx, tx, winnerUid, *auction.ItemId, db.ITEM_EVENT_AUCTIONED, fmt.Sprintf("won auction %v", *auction.ID), now)
+		if err != nil {
+			return nil, err
+		}
+
+		query, args, err = psql.
+			Update(db.SCHEMA_AUCTION_BIDS).
+			Set("status", db.AUCTION_BID_WON).
+			Where(squirrel.Eq{"auction_id": *auction.ID, "status": db.AUCTION_BID_HELD}).
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+		if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+			return nil, err
+		}
+
+		_, creatorProceeds := core.AuctionSaleSplit(*auction.CurrentBid)
+		if err = creditTokenBalance(ctx, tx, *auction.VendorId, creatorProceeds, now); err != nil {
+			return nil, err
+		}
+		if err = setAuctionStatus(ctx, tx, *auction.ID, db.AUCTION_SOLD, userItemId, now); err != nil {
+			return nil, err
+		}
+		closed.Status = db.AUCTION_SOLD
+		closed.UserItemId = userItemId
+		closedAuctions = append(closedAuctions, &closed)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	for _, closed := range closedAuctions {
+		if closed.BidderUid == nil {
+			continue
+		}
+		if err = r.cache.Del(c, db.KEY_TOKEN_BALANCE+*closed.BidderUid).Err(); err != nil {
+			return nil, err
+		}
+		if closed.Status != db.AUCTION_SOLD {
+			continue
+		}
+		if err = r.cache.Del(c, db.KEY_TOKEN_BALANCE+closed.VendorId).Err(); err != nil {
+			return nil, err
+		}
+		if err = clearUserItemCache(c, r.cache, *closed.BidderUid); err != nil {
+			return nil, err
+		}
+	}
+	return closedAuctions, nil
+}
+
+func lockAuction(c context.Context, tx *sqlx.Tx, auctionId uint64) (*model.Auction, error) {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("id", "vendor_id", "item_id", "start_price", "reserve_price", "current_bid", "current_bidder_uid", "bid_count", "status", "starts_at", "ends_at").
+		From(db.SCHEMA_AUCTIONS).
+		Where(squirrel.Eq{"id": auctionId}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	auction := model.Auction{}
+	if err = tx.QueryRowxContext(c, query, args...).StructScan(&auction); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &AuctionError{fmt.Sprintf("auction %v does not exist", auctionId)}
+		}
+		return nil, err
+	}
+	return &auction, nil
+}
+
+// hands the held bid on an auction back to its bidder, returning the bidders uid or nil when no bid was held
+func refundHeldBid(c context.Context, tx *sqlx.Tx, auctionId uint64, now string) (*string, error) {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Update(db.SCHEMA_AUCTION_BIDS).
+		SetMap(map[string]interface{}{"status": db.AUCTION_BID_REFUNDED, "refunded_at": now}).
+		Where(squirrel.Eq{"auction_id": auctionId, "status": db.AUCTION_BID_HELD}).
+		Suffix("RETURNING uid, token_amount").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	bid := model.AuctionBid{}
+	if err = tx.QueryRowxContext(c, query, args...).StructScan(&bid); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err = creditTokenBalance(c, tx, *bid.Uid, *bid.TokenAmount, now); err != nil {
+		return nil, err
+	}
+	return bid.Uid, nil
+}
+
+func setAuctionStatus(c context.Context, tx *sqlx.Tx, auctionId uint64, status string, userItemId *uint64, now string) error {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Update(db.SCHEMA_AUCTIONS).
+		SetMap(map[string]interface{}{"status": status, "user_item_id": userItemId, "closed_at": now}).
+		Where(squirrel.Eq{"id": auctionId}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(c, query, args...)
+	return err
+}
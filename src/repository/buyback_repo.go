@@ -0,0 +1,291 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+	"xo-packs/core"
+	"xo-packs/db"
+	"xo-packs/model"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+)
+
+type BuybackRepository interface {
+	GetSetting(context.Context, string) (*model.BuybackSetting, error)
+	SetSetting(context.Context, string, bool) (*model.BuybackSetting, error)
+	GetTokensBoughtBackToday(context.Context, string) (float64, error)
+	BuybackUserItems(context.Context, string, []uint64, int, float64) (*model.BuybackResp, error)
+}
+
+type BuybackRepoImpl struct {
+	db    *sqlx.DB
+	cache *redis.Client
+}
+
+func NewBuybackRepo(db *sqlx.DB, cache *redis.Client) BuybackRepository {
+	return &BuybackRepoImpl{db: db, cache: cache}
+}
+
+type BuybackError struct {
+	message string
+}
+
+func (e *BuybackError) Error() string {
+	return e.message
+}
+
+// creators that never set up buybacks are opted out
+func (r *BuybackRepoImpl) GetSetting(c context.Context, vendorId string) (*model.BuybackSetting, error) {
+	val, err := r.cache.Get(c, db.KEY_BUYBACK_SETTING+vendorId).Result()
+	if err != nil {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+		query, args, err := psql.
+			Select("vendor_id", "enabled", "updated_at").
+			From(db.SCHEMA_BUYBACK_SETTINGS).
+			Where(squirrel.Eq{"vendor_id": vendorId}).
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+
+		setting := model.BuybackSetting{}
+		if err = r.db.QueryRowxContext(ctx, query, args...).StructScan(&setting); err != nil {
+			if err != sql.ErrNoRows {
+				return nil, err
+			}
+			enabled := false
+			setting = model.BuybackSetting{VendorId: &vendorId, Enabled: &enabled}
+		}
+
+		settingBytes, err := json.Marshal(setting)
+		if err != nil {
+			return nil, err
+		}
+		if err = r.cache.Set(c, db.KEY_BUYBACK_SETTING+vendorId, settingBytes, time.Duration(3600)*time.Second).Err(); err != nil {
+			return nil, err
+		}
+		return &setting, nil
+	} else {
+		setting := model.BuybackSetting{}
+		if err = json.Unmarshal([]byte(val), &setting); err != nil {
+			return nil, err
+		}
+		return &setting, nil
+	}
+}
+
+func (r *BuybackRepoImpl) SetSetting(c context.Context, vendorId string, enabled bool) (*model.BuybackSetting, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Insert(db.SCHEMA_BUYBACK_SETTINGS).
+		Columns("vendor_id", "enabled", "updated_at").
+		Values(vendorId, enabled, now).
+		Suffix("ON CONFLICT (vendor_id) DO UPDATE SET enabled = excluded.enabled, updated_at = excluded.updated_at RETURNING vendor_id, enabled, updated_at").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	setting := model.BuybackSetting{}
+	if err = r.db.QueryRowxContext(ctx, query, args...).StructScan(&setting); err != nil {
+		return nil, err
+	}
+
+	if err = r.cache.Del(c, db.KEY_BUYBACK_SETTING+vendorId).Err(); err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
+func (r *BuybackRepoImpl) GetTokensBoughtBackToday(c context.Context, uid string) (float64, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	return tokensBoughtBackToday(ctx, r.db, uid)
+}
+
+// sells a set of the users items back to the platform. every item must belong to a creator that opted in,
+// the items are removed from the collection and flagged as bought back, each sale is recorded in the buyback
+// ledger and the total is credited to the users token balance in the same transaction
+func (r *BuybackRepoImpl) BuybackUserItems(c context.Context, uid string, userItemIds []uint64, percent int, dailyCap float64) (*model.BuybackResp, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("ui.id as user_item_id", "i.id as item_id", "i.vendor_id", "i.value", "coalesce(bs.enabled, false) as buyback_enabled").
+		From(db.SCHEMA_USER_ITEMS+" ui").
+		Join(db.SCHEMA_ITEMS+" i on i.id = ui.item_id").
+		LeftJoin(db.SCHEMA_BUYBACK_SETTINGS+" bs on bs.vendor_id = i.vendor_id").
+		Where(squirrel.Eq{"ui.id": userItemIds, "ui.uid": uid, "ui.removed_at": nil, "ui.bought_back_at": nil}).
+		Where(unexpired("ui.expired_at")).
+		Where("not exists (select 1 from "+db.SCHEMA_ITEM_WITHDRAWALS+" w where w.user_item_id = ui.id and w.status <> ?)", db.WITHDRAWAL_CANCELLED).
+		Suffix("FOR UPDATE OF ui").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	buybackableItems := []*model.BuybackableItem{}
+	defer rows.Close()
+	for rows.Next() {
+		buybackableItem := model.BuybackableItem{}
+		if err = rows.StructScan(&buybackableItem); err != nil {
+			return nil, err
+		}
+		buybackableItems = append(buybackableItems, &buybackableItem)
+	}
+	if len(buybackableItems) != len(userItemIds) {
+		err = &BuybackError{"one or more items are no longer in the users collection"}
+		return nil, err
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	totalAmount := 0.0
+	boughtBackIds := make([]uint64, len(buybackableItems))
+	vendorIds := []string{}
+	buybackInsert := psql.
+		Insert(db.SCHEMA_ITEM_BUYBACKS).
+		Columns("user_item_id", "uid", "item_id", "vendor_id", "value", "amount", "bought_back_at")
+	for i, buybackableItem := range buybackableItems {
+		if buybackableItem.BuybackEnabled == nil || !*buybackableItem.BuybackEnabled {
+			err = &BuybackError{fmt.Sprintf("the creator of user item %v does not accept buybacks", *buybackableItem.UserItemId)}
+			return nil, err
+		}
+
+		value := 0.0
+		if buybackableItem.Value != nil {
+			value = *buybackableItem.Value
+		}
+		amount := core.BuybackAmount(value, percent)
+		if amount <= 0 {
+			err = &BuybackError{fmt.Sprintf("user item %v has no value to buy back", *buybackableItem.UserItemId)}
+			return nil, err
+		}
+
+		totalAmount += amount
+		boughtBackIds[i] = *buybackableItem.UserItemId
+		if !slices.Contains(vendorIds, *buybackableItem.VendorId) {
+			vendorIds = append(vendorIds, *buybackableItem.VendorId)
+		}
+		buybackInsert = buybackInsert.Values(*buybackableItem.UserItemId, uid, *buybackableItem.ItemId, *buybackableItem.VendorId, value, amount, now)
+	}
+	totalAmount = core.RoundTokenAmount(totalAmount)
+
+	// the balance update below conflicts with any concurrent buyback by the same user, so the sum read
+	// here cannot go stale before the transaction commits
+	usedToday, err := tokensBoughtBackToday(ctx, tx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if core.RoundTokenAmount(usedToday+totalAmount) > dailyCap {
+		err = &BuybackError{fmt.Sprintf("buyback of %v tokens exceeds the daily limit, %v tokens remain today", totalAmount, core.RoundTokenAmount(max(dailyCap-usedToday, 0)))}
+		return nil, err
+	}
+
+	query, args, err = psql.
+		Update(db.SCHEMA_USER_ITEMS).
+		SetMap(map[string]interface{}{"removed_at": now, "bought_back_at": now}).
+		Where(squirrel.Eq{"id": boughtBackIds, "uid": uid, "removed_at": nil}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, err
+	}
+
+	query, args, err = buybackInsert.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, err
+	}
+
+	notes := fmt.Sprintf("bought back at %v%% of value", percent)
+	events := make([]*model.ItemEvent, len(boughtBackIds))
+	for i := range boughtBackIds {
+		events[i] = &model.ItemEvent{UserItemId: &boughtBackIds[i], FromUid: &uid, ActorUid: &uid, Notes: &notes}
+	}
+	if err = addItemEvents(ctx, tx, db.ITEM_EVENT_BOUGHT_BACK, now, events); err != nil {
+		return nil, err
+	}
+
+	listingsRemoved, err := removeMarketListings(ctx, tx, squirrel.Eq{"user_item_id": boughtBackIds}, db.ITEM_EVENT_BOUGHT_BACK, now)
+	if err != nil {
+		return nil, err
+	}
+
+	newBalance, err := creditBalance(ctx, tx, db.SCHEMA_TOKEN_BALANCE, uid, totalAmount, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if listingsRemoved {
+		if err = clearMarketListingCache(c, r.cache); err != nil {
+			return nil, err
+		}
+	}
+	for _, vendorId := range vendorIds {
+		if err = r.cache.Del(c, db.KEY_ITEM_BUYBACKS+vendorId).Err(); err != nil {
+			return nil, err
+		}
+	}
+	return &model.BuybackResp{BoughtBackUserItemIds: boughtBackIds, Amount: totalAmount, NewBalance: newBalance}, nil
+}
+
+// the tokens the user was paid for buybacks since the start of the day
+func tokensBoughtBackToday(c context.Context, q sqlx.QueryerContext, uid string) (float64, error) {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("coalesce(sum(amount), 0)").
+		From(db.SCHEMA_ITEM_BUYBACKS).
+		Where(squirrel.Eq{"uid": uid}).
+		Where("bought_back_at >= date_trunc('day', now())").
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	usedToday := 0.0
+	if err = q.QueryRowxContext(c, query, args...).Scan(&usedToday); err != nil {
+		return 0, err
+	}
+	return usedToday, nil
+}
@@ -0,0 +1,639 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+	"xo-packs/core"
+	"xo-packs/db"
+	"xo-packs/model"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+)
+
+type CollectionRepository interface {
+	CreateSet(context.Context, *model.CollectionSet, []uint64) (*model.CollectionSetExpanded, error)
+	DeleteSet(context.Context, uint64, string) error
+	GetSet(context.Context, uint64) (*model.CollectionSetExpanded, error)
+	GetSets(context.Context, string, string) ([]*model.CollectionSetExpanded, error)
+	GetSetProgress(context.Context, uint64, string) (*model.CollectionSetProgress, error)
+	GetUserSetCompletions(context.Context, string) ([]*model.UserSetCompletionExpanded, error)
+	CompleteSets(context.Context, string) ([]*model.UserSetCompletionExpanded, error)
+}
+
+type CollectionRepoImpl struct {
+	db    *sqlx.DB
+	cache *redis.Client
+}
+
+func NewCollectionRepo(db *sqlx.DB, cache *redis.Client) CollectionRepository {
+	return &CollectionRepoImpl{db: db, cache: cache}
+}
+
+type CollectionError struct {
+	message string
+}
+
+func (e *CollectionError) Error() string {
+	return e.message
+}
+
+// saves a set and its items. the set items and the reward item must all be the creators own active items
+func (r *CollectionRepoImpl) CreateSet(c context.Context, set *model.CollectionSet, itemIds []uint64) (*model.CollectionSetExpanded, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	vendorItemIds := itemIds
+	if set.RewardItemId != nil {
+		vendorItemIds = append(append([]uint64{}, itemIds...), *set.RewardItemId)
+	}
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("count(distinct id)").
+		From(db.SCHEMA_ITEMS).
+		Where(squirrel.Eq{"id": vendorItemIds, "vendor_id": *set.VendorId, "deleted_at": nil}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	expectedCount := len(itemIds)
+	if set.RewardItemId != nil && !containsId(itemIds, *set.RewardItemId) {
+		expectedCount++
+	}
+	itemCount := 0
+	if err = tx.QueryRowContext(ctx, query, args...).Scan(&itemCount); err != nil {
+		return nil, err
+	}
+	if itemCount != expectedCount {
+		err = &CollectionError{"sets can only use the creators own active items"}
+		return nil, err
+	}
+
+	query, args, err = psql.
+		Insert(db.SCHEMA_COLLECTION_SETS).
+		Columns(core.ModelColumns(set)...).
+		Values(core.StructValues(set)...).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var setId uint64
+	if err = tx.QueryRowContext(ctx, query, args...).Scan(&setId); err != nil {
+		return nil, err
+	}
+
+	itemQuery := psql.
+		Insert(db.SCHEMA_COLLECTION_SET_ITEMS).
+		Columns("set_id", "item_id")
+	for _, itemId := range itemIds {
+		itemQuery = itemQuery.Values(setId, itemId)
+	}
+	query, args, err = itemQuery.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if err = clearCollectionSetCache(c, r.cache, setId); err != nil {
+		return nil, err
+	}
+	return r.GetSet(c, setId)
+}
+
+func (r *CollectionRepoImpl) DeleteSet(c context.Context, setId uint64, vendorId string) error {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Update(db.SCHEMA_COLLECTION_SETS).
+		SetMap(map[string]interface{}{"active": false, "deleted_at": now}).
+		Where(squirrel.Eq{"id": setId, "vendor_id": vendorId, "deleted_at": nil}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected <= 0 {
+		return &CollectionError{"set does not exist for this vendor"}
+	}
+	return clearCollectionSetCache(c, r.cache, setId)
+}
+
+func (r *CollectionRepoImpl) GetSet(c context.Context, setId uint64) (*model.CollectionSetExpanded, error) {
+	val, err := r.cache.Get(c, db.KEY_COLLECTION_SET+fmt.Sprintf("%v", setId)).Result()
+	if err != nil {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+		if err != nil {
+			return nil, err
+		}
+
+		defer func() {
+			if err = tx.Commit(); err != nil {
+				fmt.Println(err)
+			}
+		}()
+
+		sets, err := getCollectionSets(ctx, tx, squirrel.Eq{"cs.id": setId, "cs.deleted_at": nil})
+		if err != nil {
+			return nil, err
+		}
+		if len(sets) == 0 {
+			return nil, &CollectionError{fmt.Sprintf("set %v does not exist", setId)}
+		}
+
+		setBytes, err := json.Marshal(sets[0])
+		if err != nil {
+			return nil, err
+		}
+		if err = r.cache.Set(c, db.KEY_COLLECTION_SET+fmt.Sprintf("%v", setId), setBytes, time.Duration(3600)*time.Second).Err(); err != nil {
+			return nil, err
+		}
+		return sets[0], nil
+	} else {
+		set := model.CollectionSetExpanded{}
+		if err = json.Unmarshal([]byte(val), &set); err != nil {
+			return nil, err
+		}
+		return &set, nil
+	}
+}
+
+func (r *CollectionRepoImpl) GetSets(c context.Context, vendorId string, urlPath string) ([]*model.CollectionSetExpanded, error) {
+	val, err := r.cache.Get(c, urlPath).Result()
+	if err != nil {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+		if err != nil {
+			return nil, err
+		}
+
+		defer func() {
+			if err = tx.Commit(); err != nil {
+				fmt.Println(err)
+			}
+		}()
+
+		where := squirrel.Eq{"cs.active": true, "cs.deleted_at": nil}
+		if vendorId != "" {
+			where["cs.vendor_id"] = vendorId
+		}
+
+		sets, err := getCollectionSets(ctx, tx, where)
+		if err != nil {
+			return nil, err
+		}
+
+		setsBytes, err := json.Marshal(sets)
+		if err != nil {
+			return nil, err
+		}
+		if err = r.cache.Set(c, urlPath, setsBytes, time.Duration(3600)*time.Second).Err(); err != nil {
+			return nil, err
+		}
+		return sets, nil
+	} else {
+		sets := []*model.CollectionSetExpanded{}
+		if err = json.Unmarshal([]byte(val), &sets); err != nil {
+			return nil, err
+		}
+		return sets, nil
+	}
+}
+
+// gets a set with every item flagged by whether the user currently holds it
+func (r *CollectionRepoImpl) GetSetProgress(c context.Context, setId uint64, uid string) (*model.CollectionSetProgress, error) {
+	set, err := r.GetSet(c, setId)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err = tx.Commit(); err != nil {
+			fmt.Println(err)
+		}
+	}()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("distinct ui.item_id").
+		From(db.SCHEMA_USER_ITEMS + " ui").
+		Join(db.SCHEMA_COLLECTION_SET_ITEMS + " csi on csi.item_id = ui.item_id").
+		Where(squirrel.Eq{"csi.set_id": setId, "ui.uid": uid, "ui.removed_at": nil}).
+		Where(unexpired("ui.expired_at")).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	ownedIds, err := scanIds(ctx, tx, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := model.CollectionSetProgress{Set: set, TotalCount: len(set.Items)}
+	for _, item := range set.Items {
+		item.Owned = containsId(ownedIds, *item.ItemId)
+		if item.Owned {
+			progress.OwnedCount++
+		}
+	}
+
+	query, args, err = psql.
+		Select("completed_at").
+		From(db.SCHEMA_USER_SET_COMPLETIONS).
+		Where(squirrel.Eq{"set_id": setId, "uid": uid}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	completedAt := ""
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&completedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if err == nil {
+		progress.Completed = true
+		progress.CompletedAt = &completedAt
+	}
+	return &progress, nil
+}
+
+func (r *CollectionRepoImpl) GetUserSetCompletions(c context.Context, uid string) ([]*model.UserSetCompletionExpanded, error) {
+	val, err := r.cache.Get(c, db.KEY_USER_SET_COMPLETIONS+uid).Result()
+	if err != nil {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+		query, args, err := psql.
+			Select(
+				"usc.set_id",
+				"cs.name",
+				"cs.vendor_id",
+				"usc.reward_token_amount",
+				"usc.reward_user_item_id",
+				"cs.reward_badge_name",
+				"cs.reward_badge_image_url",
+				"usc.completed_at",
+			).
+			From(db.SCHEMA_USER_SET_COMPLETIONS + " usc").
+			Join(db.SCHEMA_COLLECTION_SETS + " cs on cs.id = usc.set_id").
+			Where(squirrel.Eq{"usc.uid": uid}).
+			OrderBy("usc.completed_at desc").
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+
+		rows, err := r.db.QueryxContext(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+
+		completions := []*model.UserSetCompletionExpanded{}
+		defer rows.Close()
+		for rows.Next() {
+			completion := model.UserSetCompletionExpanded{}
+			if err := rows.StructScan(&completion); err != nil {
+				return nil, err
+			}
+			completions = append(completions, &completion)
+		}
+
+		completionsBytes, err := json.Marshal(completions)
+		if err != nil {
+			return nil, err
+		}
+		if err = r.cache.Set(c, db.KEY_USER_SET_COMPLETIONS+uid, completionsBytes, time.Duration(3600)*time.Second).Err(); err != nil {
+			return nil, err
+		}
+		return completions, nil
+	} else {
+		completions := []*model.UserSetCompletionExpanded{}
+		if err = json.Unmarshal([]byte(val), &completions); err != nil {
+			return nil, err
+		}
+		return completions, nil
+	}
+}
+
+// finds every active set the user now holds all the items of and has not completed before, records the completion
+// and pays out its rewards. the unique set and uid pair on completions keeps a set from paying out twice
+func (r *CollectionRepoImpl) CompleteSets(c context.Context, uid string) ([]*model.UserSetCompletionExpanded, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("cs.id", "cs.vendor_id", "cs.name", "cs.reward_token_amount", "cs.reward_item_id", "cs.reward_badge_name", "cs.reward_badge_image_url").
+		From(db.SCHEMA_COLLECTION_SETS+" cs").
+		Where(squirrel.Eq{"cs.active": true, "cs.deleted_at": nil}).
+		Where("exists (select 1 from "+db.SCHEMA_COLLECTION_SET_ITEMS+" csi where csi.set_id = cs.id)").
+		Where("not exists (select 1 from "+db.SCHEMA_USER_SET_COMPLETIONS+" usc where usc.set_id = cs.id and usc.uid = ?)", uid).
+		Where("not exists (select 1 from "+db.SCHEMA_COLLECTION_SET_ITEMS+" csi where csi.set_id = cs.id and not exists ("+
+			"select 1 from "+db.SCHEMA_USER_ITEMS+" ui where ui.item_id = csi.item_id and ui.uid = ? and ui.removed_at is null and (ui.expired_at is null or ui.expired_at > now())))", uid).
+		OrderBy("cs.id asc").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	sets := []*model.CollectionSet{}
+	defer rows.Close()
+	for rows.Next() {
+		set := model.CollectionSet{}
+		if err = rows.StructScan(&set); err != nil {
+			return nil, err
+		}
+		sets = append(sets, &set)
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	completions := []*model.UserSetCompletionExpanded{}
+	for _, set := range sets {
+		rewardTokenAmount := 0.0
+		if set.RewardTokenAmount != nil {
+			rewardTokenAmount = *set.RewardTokenAmount
+		}
+
+		query, args, err = psql.
+			Insert(db.SCHEMA_USER_SET_COMPLETIONS).
+			Columns("set_id", "uid", "reward_token_amount", "completed_at").
+			Values(*set.ID, uid, rewardTokenAmount, now).
+			Suffix("ON CONFLICT (set_id, uid) DO NOTHING RETURNING id").
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+
+		var completionId uint64
+		if err = tx.QueryRowContext(ctx, query, args...).Scan(&completionId); err != nil {
+			if err == sql.ErrNoRows {
+				// completed by a concurrent request
+				err = nil
+				continue
+			}
+			return nil, err
+		}
+
+		if err = creditTokenBalance(ctx, tx, uid, rewardTokenAmount, now); err != nil {
+			return nil, err
+		}
+
+		var rewardUserItemId *uint64
+		if set.RewardItemId != nil {
+			if rewardUserItemId, err = grantRewardItem(ctx, tx, uid, *set.RewardItemId, fmt.Sprintf("reward for completing set %v", *set.ID), now); err != nil {
+				return nil, err
+			}
+
+			query, args, err = psql.
+				Update(db.SCHEMA_USER_SET_COMPLETIONS).
+				Set("reward_user_item_id", *rewardUserItemId).
+				Where(squirrel.Eq{"id": completionId}).
+				ToSql()
+			if err != nil {
+				return nil, err
+			}
+			if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+				return nil, err
+			}
+		}
+
+		completions = append(completions, &model.UserSetCompletionExpanded{
+			SetId:               set.ID,
+			Name:                set.Name,
+			VendorId:            set.VendorId,
+			RewardTokenAmount:   &rewardTokenAmount,
+			RewardUserItemId:    rewardUserItemId,
+			RewardBadgeName:     set.RewardBadgeName,
+			RewardBadgeImageUrl: set.RewardBadgeImageUrl,
+			CompletedAt:         &now,
+		})
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if len(completions) > 0 {
+		if err = r.cache.Del(c, db.KEY_USER_SET_COMPLETIONS+uid).Err(); err != nil {
+			return nil, err
+		}
+	}
+	return completions, nil
+}
+
+// mints a fresh copy of an item straight into a users collection as a reward
+func grantRewardItem(c context.Context, tx *sqlx.Tx, uid string, itemId uint64, notes string, now string) (*uint64, error) {
+	return mintUserItem(c, tx, uid, itemId, db.ITEM_EVENT_REWARDED, notes, now)
+}
+
+// mints the next serial of an item into a users collection outside of any pack, recording the given event
+func mintUserItem(c context.Context, tx *sqlx.Tx, uid string, itemId uint64, eventType string, notes string, now string) (*uint64, error) {
+	nextSerials, err := reserveItemSerials(c, tx, map[uint64]uint64{itemId: 1})
+	if err != nil {
+		return nil, err
+	}
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Insert(db.SCHEMA_USER_ITEMS).
+		Columns("uid", "item_id", "acquired_at", "serial_number").
+		Values(uid, itemId, now, nextSerials[itemId]).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var userItemId uint64
+	if err = tx.QueryRowContext(c, query, args...).Scan(&userItemId); err != nil {
+		return nil, err
+	}
+	if err = setUserItemExpiry(c, tx, []uint64{userItemId}); err != nil {
+		return nil, err
+	}
+
+	event := model.ItemEvent{UserItemId: &userItemId, ToUid: &uid, ActorUid: &uid, Notes: &notes}
+	if err = addItemEvents(c, tx, eventType, now, []*model.ItemEvent{&event}); err != nil {
+		return nil, err
+	}
+	return &userItemId, nil
+}
+
+// loads the sets matching the where clause along with their items
+func getCollectionSets(c context.Context, tx *sqlx.Tx, where squirrel.Sqlizer) ([]*model.CollectionSetExpanded, error) {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select(
+			"cs.id",
+			"cs.vendor_id",
+			"v.username as vendor_username",
+			"cs.name",
+			"cs.description",
+			"cs.reward_token_amount",
+			"cs.reward_item_id",
+			"ri.name as reward_item_name",
+			"ri.image_url as reward_item_image_url",
+			"cs.reward_badge_name",
+			"cs.reward_badge_image_url",
+			"cs.created_at",
+		).
+		From(db.SCHEMA_COLLECTION_SETS+" cs").
+		LeftJoin(db.SCHEMA_USERS+" v on v.uid = cs.vendor_id").
+		LeftJoin(db.SCHEMA_ITEMS+" ri on ri.id = cs.reward_item_id").
+		Where(where).
+		OrderBy("cs.created_at desc", "cs.id desc").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryxContext(c, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	sets := []*model.CollectionSetExpanded{}
+	defer rows.Close()
+	for rows.Next() {
+		set := model.CollectionSetExpanded{}
+		if err = rows.StructScan(&set); err != nil {
+			return nil, err
+		}
+		set.Items = []*model.CollectionSetItemExpanded{}
+		sets = append(sets, &set)
+	}
+	if len(sets) == 0 {
+		return sets, nil
+	}
+
+	setMap := map[uint64]*model.CollectionSetExpanded{}
+	setIds := make([]uint64, len(sets))
+	for i, set := range sets {
+		setMap[*set.ID] = set
+		setIds[i] = *set.ID
+	}
+
+	query, args, err = psql.
+		Select("csi.set_id", "csi.item_id", "i.name", "i.image_url", "i.rarity_id", "r.rarity").
+		From(db.SCHEMA_COLLECTION_SET_ITEMS + " csi").
+		Join(db.SCHEMA_ITEMS + " i on i.id = csi.item_id").
+		LeftJoin(db.SCHEMA_RARITY + " r on r.id = i.rarity_id").
+		Where(squirrel.Eq{"csi.set_id": setIds}).
+		OrderBy("csi.id asc").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.QueryxContext(c, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		item := model.CollectionSetItemExpanded{}
+		if err = rows.StructScan(&item); err != nil {
+			return nil, err
+		}
+		set := setMap[*item.SetId]
+		set.Items = append(set.Items, &item)
+	}
+	return sets, nil
+}
+
+func clearCollectionSetCache(c context.Context, cache *redis.Client, setId uint64) error {
+	keys, err := cache.Keys(c, "/collection/sets*").Result()
+	if err != nil {
+		return err
+	}
+	keys = append(keys, db.KEY_COLLECTION_SET+fmt.Sprintf("%v", setId))
+
+	for _, key := range keys {
+		if err := cache.Del(c, key).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func containsId(ids []uint64, id uint64) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
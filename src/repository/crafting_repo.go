@@ -0,0 +1,678 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+	"xo-packs/core"
+	"xo-packs/db"
+	"xo-packs/model"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+)
+
+type CraftingRepository interface {
+	CreateRecipe(context.Context, *model.CraftingRecipe, []*model.CraftingRecipeInput, []*model.CraftingRecipeOutcome) (*model.CraftingRecipeExpanded, error)
+	DeleteRecipe(context.Context, uint64, string) error
+	GetRecipe(context.Context, uint64) (*model.CraftingRecipeExpanded, error)
+	GetRecipes(context.Context, string, string) ([]*model.CraftingRecipeExpanded, error)
+	Craft(context.Context, uint64, string, []uint64) (*model.CraftResp, error)
+}
+
+type CraftingRepoImpl struct {
+	db    *sqlx.DB
+	cache *redis.Client
+}
+
+func NewCraftingRepo(db *sqlx.DB, cache *redis.Client) CraftingRepository {
+	return &CraftingRepoImpl{db: db, cache: cache}
+}
+
+type CraftingError struct {
+	message string
+}
+
+func (e *CraftingError) Error() string {
+	return e.message
+}
+
+// saves a recipe with its inputs and outcomes. every item the recipe names must be one of the creators active items
+func (r *CraftingRepoImpl) CreateRecipe(c context.Context, recipe *model.CraftingRecipe, inputs []*model.CraftingRecipeInput, outcomes []*model.CraftingRecipeOutcome) (*model.CraftingRecipeExpanded, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	seen := map[uint64]bool{}
+	itemIds := []uint64{}
+	for _, input := range inputs {
+		if input.ItemId != nil && !seen[*input.ItemId] {
+			seen[*input.ItemId] = true
+			itemIds = append(itemIds, *input.ItemId)
+		}
+	}
+	for _, outcome := range outcomes {
+		if !seen[*outcome.ItemId] {
+			seen[*outcome.ItemId] = true
+			itemIds = append(itemIds, *outcome.ItemId)
+		}
+	}
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("count(*)").
+		From(db.SCHEMA_ITEMS).
+		Where(squirrel.Eq{"id": itemIds, "vendor_id": *recipe.VendorId, "deleted_at": nil}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	itemCount := 0
+	if err = tx.QueryRowContext(ctx, query, args...).Scan(&itemCount); err != nil {
+		return nil, err
+	}
+	if itemCount != len(itemIds) {
+		err = &CraftingError{"recipes can only use the creators own active items"}
+		return nil, err
+	}
+
+	query, args, err = psql.
+		Insert(db.SCHEMA_CRAFTING_RECIPES).
+		Columns(core.ModelColumns(recipe)...).
+		Values(core.StructValues(recipe)...).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var recipeId uint64
+	if err = tx.QueryRowContext(ctx, query, args...).Scan(&recipeId); err != nil {
+		return nil, err
+	}
+
+	inputQuery := psql.
+		Insert(db.SCHEMA_CRAFTING_RECIPE_INPUTS).
+		Columns("recipe_id", "item_id", "rarity_id", "qty")
+	for _, input := range inputs {
+		inputQuery = inputQuery.Values(recipeId, input.ItemId, input.RarityId, *input.Qty)
+	}
+	query, args, err = inputQuery.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, err
+	}
+
+	outcomeQuery := psql.
+		Insert(db.SCHEMA_CRAFTING_RECIPE_OUTCOMES).
+		Columns("recipe_id", "item_id", "weight")
+	for _, outcome := range outcomes {
+		outcomeQuery = outcomeQuery.Values(recipeId, *outcome.ItemId, *outcome.Weight)
+	}
+	query, args, err = outcomeQuery.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if err = clearCraftingRecipeCache(c, r.cache, recipeId); err != nil {
+		return nil, err
+	}
+	return r.GetRecipe(c, recipeId)
+}
+
+func (r *CraftingRepoImpl) DeleteRecipe(c context.Context, recipeId uint64, vendorId string) error {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Update(db.SCHEMA_CRAFTING_RECIPES).
+		SetMap(map[string]interface{}{"active": false, "deleted_at": now}).
+		Where(squirrel.Eq{"id": recipeId, "vendor_id": vendorId, "deleted_at": nil}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected <= 0 {
+		return &CraftingError{"crafting recipe does not exist for this vendor"}
+	}
+	return clearCraftingRecipeCache(c, r.cache, recipeId)
+}
+
+func (r *CraftingRepoImpl) GetRecipe(c context.Context, recipeId uint64) (*model.CraftingRecipeExpanded, error) {
+	val, err := r.cache.Get(c, db.KEY_CRAFTING_RECIPE+fmt.Sprintf("%v", recipeId)).Result()
+	if err != nil {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+		if err != nil {
+			return nil, err
+		}
+
+		defer func() {
+			if err = tx.Commit(); err != nil {
+				fmt.Println(err)
+			}
+		}()
+
+		recipes, err := getCraftingRecipes(ctx, tx, squirrel.Eq{"cr.id": recipeId, "cr.deleted_at": nil})
+		if err != nil {
+			return nil, err
+		}
+		if len(recipes) == 0 {
+			return nil, &CraftingError{fmt.Sprintf("crafting recipe %v does not exist", recipeId)}
+		}
+
+		recipeBytes, err := json.Marshal(recipes[0])
+		if err != nil {
+			return nil, err
+		}
+		if err = r.cache.Set(c, db.KEY_CRAFTING_RECIPE+fmt.Sprintf("%v", recipeId), recipeBytes, time.Duration(3600)*time.Second).Err(); err != nil {
+			return nil, err
+		}
+		return recipes[0], nil
+	} else {
+		recipe := model.CraftingRecipeExpanded{}
+		if err = json.Unmarshal([]byte(val), &recipe); err != nil {
+			return nil, err
+		}
+		return &recipe, nil
+	}
+}
+
+// gets the active recipes that have not ended yet, optionally only those of one creator
+func (r *CraftingRepoImpl) GetRecipes(c context.Context, vendorId string, urlPath string) ([]*model.CraftingRecipeExpanded, error) {
+	val, err := r.cache.Get(c, urlPath).Result()
+	if err != nil {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+		if err != nil {
+			return nil, err
+		}
+
+		defer func() {
+			if err = tx.Commit(); err != nil {
+				fmt.Println(err)
+			}
+		}()
+
+		where := squirrel.And{
+			squirrel.Eq{"cr.active": true, "cr.deleted_at": nil},
+			squirrel.Or{squirrel.Eq{"cr.ends_at": nil}, squirrel.Expr("cr.ends_at > now()")},
+		}
+		if vendorId != "" {
+			where = append(where, squirrel.Eq{"cr.vendor_id": vendorId})
+		}
+
+		recipes, err := getCraftingRecipes(ctx, tx, where)
+		if err != nil {
+			return nil, err
+		}
+
+		recipesBytes, err := json.Marshal(recipes)
+		if err != nil {
+			return nil, err
+		}
+		if err = r.cache.Set(c, urlPath, recipesBytes, time.Duration(3600)*time.Second).Err(); err != nil {
+			return nil, err
+		}
+		return recipes, nil
+	} else {
+		recipes := []*model.CraftingRecipeExpanded{}
+		if err = json.Unmarshal([]byte(val), &recipes); err != nil {
+			return nil, err
+		}
+		return recipes, nil
+	}
+}
+
+// crafts a recipe. the submitted items are consumed, the crafting cost is taken from the users crafting balance
+// and a freshly minted outcome item is granted, all in one transaction. locking the recipe row serializes crafts
+// of the same recipe so per user limits hold
+func (r *CraftingRepoImpl) Craft(c context.Context, recipeId uint64, uid string, userItemIds []uint64) (*model.CraftResp, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("id", "vendor_id", "crafting_cost", "per_user_limit").
+		From(db.SCHEMA_CRAFTING_RECIPES).
+		Where(squirrel.Eq{"id": recipeId, "active": true, "deleted_at": nil}).
+		Where(squirrel.Or{squirrel.Eq{"starts_at": nil}, squirrel.Expr("starts_at <= now()")}).
+		Where(squirrel.Or{squirrel.Eq{"ends_at": nil}, squirrel.Expr("ends_at > now()")}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	recipe := model.CraftingRecipe{}
+	if err = tx.QueryRowxContext(ctx, query, args...).StructScan(&recipe); err != nil {
+		if err == sql.ErrNoRows {
+			err = &CraftingError{fmt.Sprintf("crafting recipe %v does not exist or is not available right now", recipeId)}
+		}
+		return nil, err
+	}
+
+	if recipe.PerUserLimit != nil && *recipe.PerUserLimit > 0 {
+		query, args, err = psql.
+			Select("count(*)").
+			From(db.SCHEMA_USER_CRAFTS).
+			Where(squirrel.Eq{"recipe_id": recipeId, "uid": uid}).
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+
+		craftCount := 0
+		if err = tx.QueryRowContext(ctx, query, args...).Scan(&craftCount); err != nil {
+			return nil, err
+		}
+		if craftCount >= *recipe.PerUserLimit {
+			err = &CraftingError{"the crafting limit for this recipe has been reached"}
+			return nil, err
+		}
+	}
+
+	query, args, err = psql.
+		Select("id", "recipe_id", "item_id", "rarity_id", "qty").
+		From(db.SCHEMA_CRAFTING_RECIPE_INPUTS).
+		Where(squirrel.Eq{"recipe_id": recipeId}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	inputs := []*model.CraftingRecipeInput{}
+	defer rows.Close()
+	for rows.Next() {
+		input := model.CraftingRecipeInput{}
+		if err = rows.StructScan(&input); err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, &input)
+	}
+
+	query, args, err = psql.
+		Select("ui.id as user_item_id", "i.id as item_id", "i.vendor_id", "i.rarity_id").
+		From(db.SCHEMA_USER_ITEMS+" ui").
+		Join(db.SCHEMA_ITEMS+" i on i.id = ui.item_id").
+		Where(squirrel.Eq{"ui.id": userItemIds, "ui.uid": uid, "ui.removed_at": nil}).
+		Where(unexpired("ui.expired_at")).
+		Where("not exists (select 1 from "+db.SCHEMA_ITEM_WITHDRAWALS+" w where w.user_item_id = ui.id and w.status <> ?)", db.WITHDRAWAL_CANCELLED).
+		OrderBy("ui.id asc").
+		Suffix("FOR UPDATE OF ui").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	craftableItems := []*model.CraftableItem{}
+	defer rows.Close()
+	for rows.Next() {
+		craftableItem := model.CraftableItem{}
+		if err = rows.StructScan(&craftableItem); err != nil {
+			return nil, err
+		}
+		craftableItems = append(craftableItems, &craftableItem)
+	}
+	if len(craftableItems) != len(userItemIds) {
+		err = &CraftingError{"one or more items are no longer in the users collection"}
+		return nil, err
+	}
+	if err = core.MatchRecipeInputs(inputs, craftableItems, *recipe.VendorId); err != nil {
+		return nil, err
+	}
+
+	query, args, err = psql.
+		Select("id", "recipe_id", "item_id", "weight").
+		From(db.SCHEMA_CRAFTING_RECIPE_OUTCOMES).
+		Where(squirrel.Eq{"recipe_id": recipeId}).
+		OrderBy("id asc").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	outcomes := []*model.CraftingRecipeOutcome{}
+	defer rows.Close()
+	for rows.Next() {
+		outcome := model.CraftingRecipeOutcome{}
+		if err = rows.StructScan(&outcome); err != nil {
+			return nil, err
+		}
+		outcomes = append(outcomes, &outcome)
+	}
+
+	outcome, err := core.RollCraftingOutcome(outcomes)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	craftingCost := 0.0
+	if recipe.CraftingCost != nil {
+		craftingCost = *recipe.CraftingCost
+	}
+	if craftingCost > 0 {
+		debited := false
+		if debited, err = debitBalance(ctx, tx, db.SCHEMA_CRAFTING_BALANCE, uid, craftingCost, now); err != nil {
+			return nil, err
+		}
+		if !debited {
+			err = &CraftingError{"crafting balance does not cover the cost of this recipe"}
+			return nil, err
+		}
+	}
+
+	query, args, err = psql.
+		Update(db.SCHEMA_USER_ITEMS).
+		Set("removed_at", now).
+		Where(squirrel.Eq{"id": userItemIds, "uid": uid, "removed_at": nil}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, err
+	}
+
+	// the crafted item is minted like any other item given outside of a pack
+	mintedUserItemId, err := mintUserItem(ctx, tx, uid, *outcome.ItemId, db.ITEM_EVENT_CRAFTED, fmt.Sprintf("crafted from recipe %v", recipeId), now)
+	if err != nil {
+		return nil, err
+	}
+	craftedUserItemId := *mintedUserItemId
+
+	query, args, err = psql.
+		Select("serial_number").
+		From(db.SCHEMA_USER_ITEMS).
+		Where(squirrel.Eq{"id": craftedUserItemId}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	serialNumber := uint64(0)
+	if err = tx.QueryRowContext(ctx, query, args...).Scan(&serialNumber); err != nil {
+		return nil, err
+	}
+
+	query, args, err = psql.
+		Insert(db.SCHEMA_USER_CRAFTS).
+		Columns("recipe_id", "uid", "output_user_item_id", "crafted_at").
+		Values(recipeId, uid, craftedUserItemId, now).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, err
+	}
+
+	consumedNotes := fmt.Sprintf("consumed by crafting recipe %v", recipeId)
+	events := make([]*model.ItemEvent, len(userItemIds))
+	for i := range userItemIds {
+		events[i] = &model.ItemEvent{UserItemId: &userItemIds[i], FromUid: &uid, ActorUid: &uid, Notes: &consumedNotes}
+	}
+	if err = addItemEvents(ctx, tx, db.ITEM_EVENT_CRAFTED, now, events); err != nil {
+		return nil, err
+	}
+
+	listingsRemoved, err := removeMarketListings(ctx, tx, squirrel.Eq{"user_item_id": userItemIds}, db.ITEM_EVENT_CRAFTED, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if craftingCost > 0 {
+		if err = r.cache.Del(c, db.KEY_CRAFTING_BALANCE+uid).Err(); err != nil {
+			return nil, err
+		}
+	}
+	if listingsRemoved {
+		if err = clearMarketListingCache(c, r.cache); err != nil {
+			return nil, err
+		}
+	}
+	return &model.CraftResp{
+		RecipeId:            &recipeId,
+		ConsumedUserItemIds: userItemIds,
+		UserItemId:          &craftedUserItemId,
+		ItemId:              outcome.ItemId,
+		SerialNumber:        &serialNumber,
+		CraftingCost:        craftingCost,
+	}, nil
+}
+
+// loads the recipes matching the where clause along with their inputs and outcomes, working out the
+// odds of each outcome from its weight
+func getCraftingRecipes(c context.Context, tx *sqlx.Tx, where squirrel.Sqlizer) ([]*model.CraftingRecipeExpanded, error) {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select(
+			"cr.id",
+			"cr.vendor_id",
+			"v.username as vendor_username",
+			"cr.name",
+			"cr.description",
+			"cr.crafting_cost",
+			"cr.per_user_limit",
+			"cr.starts_at",
+			"cr.ends_at",
+			"cr.created_at",
+		).
+		From(db.SCHEMA_CRAFTING_RECIPES+" cr").
+		LeftJoin(db.SCHEMA_USERS+" v on v.uid = cr.vendor_id").
+		Where(where).
+		OrderBy("cr.created_at desc", "cr.id desc").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryxContext(c, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	recipes := []*model.CraftingRecipeExpanded{}
+	defer rows.Close()
+	for rows.Next() {
+		recipe := model.CraftingRecipeExpanded{}
+		if err = rows.StructScan(&recipe); err != nil {
+			return nil, err
+		}
+		recipes = append(recipes, &recipe)
+	}
+	if len(recipes) == 0 {
+		return recipes, nil
+	}
+
+	recipeMap := map[uint64]*model.CraftingRecipeExpanded{}
+	recipeIds := make([]uint64, len(recipes))
+	for i, recipe := range recipes {
+		recipe.Inputs = []*model.CraftingRecipeInputExpanded{}
+		recipe.Outcomes = []*model.CraftingRecipeOutcomeExpanded{}
+		recipeMap[*recipe.ID] = recipe
+		recipeIds[i] = *recipe.ID
+	}
+
+	query, args, err = psql.
+		Select(
+			"ri.recipe_id",
+			"ri.item_id",
+			"i.name",
+			"i.image_url",
+			"coalesce(ri.rarity_id, i.rarity_id) as rarity_id",
+			"r.rarity",
+			"ri.qty",
+		).
+		From(db.SCHEMA_CRAFTING_RECIPE_INPUTS + " ri").
+		LeftJoin(db.SCHEMA_ITEMS + " i on i.id = ri.item_id").
+		LeftJoin(db.SCHEMA_RARITY + " r on r.id = coalesce(ri.rarity_id, i.rarity_id)").
+		Where(squirrel.Eq{"ri.recipe_id": recipeIds}).
+		OrderBy("ri.id asc").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.QueryxContext(c, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	inputs := []*model.CraftingRecipeInputExpanded{}
+	defer rows.Close()
+	for rows.Next() {
+		input := model.CraftingRecipeInputExpanded{}
+		if err = rows.StructScan(&input); err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, &input)
+	}
+	for _, input := range inputs {
+		recipe := recipeMap[*input.RecipeId]
+		recipe.Inputs = append(recipe.Inputs, input)
+	}
+
+	query, args, err = psql.
+		Select(
+			"ro.recipe_id",
+			"ro.item_id",
+			"i.name",
+			"i.image_url",
+			"i.rarity_id",
+			"r.rarity",
+			"ro.weight",
+		).
+		From(db.SCHEMA_CRAFTING_RECIPE_OUTCOMES + " ro").
+		Join(db.SCHEMA_ITEMS + " i on i.id = ro.item_id").
+		LeftJoin(db.SCHEMA_RARITY + " r on r.id = i.rarity_id").
+		Where(squirrel.Eq{"ro.recipe_id": recipeIds}).
+		OrderBy("ro.id asc").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.QueryxContext(c, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	outcomes := []*model.CraftingRecipeOutcomeExpanded{}
+	defer rows.Close()
+	for rows.Next() {
+		outcome := model.CraftingRecipeOutcomeExpanded{}
+		if err = rows.StructScan(&outcome); err != nil {
+			return nil, err
+		}
+		outcomes = append(outcomes, &outcome)
+	}
+	for _, outcome := range outcomes {
+		recipe := recipeMap[*outcome.RecipeId]
+		recipe.Outcomes = append(recipe.Outcomes, outcome)
+	}
+
+	for _, recipe := range recipes {
+		totalWeight := 0
+		for _, outcome := range recipe.Outcomes {
+			totalWeight += *outcome.Weight
+		}
+		if totalWeight <= 0 {
+			continue
+		}
+		for _, outcome := range recipe.Outcomes {
+			outcome.Odds = math.Round(float64(*outcome.Weight)/float64(totalWeight)*10000) / 10000
+		}
+	}
+	return recipes, nil
+}
+
+func clearCraftingRecipeCache(c context.Context, cache *redis.Client, recipeId uint64) error {
+	keys, err := cache.Keys(c, "/crafting/recipes*").Result()
+	if err != nil {
+		return err
+	}
+	keys = append(keys, db.KEY_CRAFTING_RECIPE+fmt.Sprintf("%v", recipeId))
+
+	for _, key := range keys {
+		if err := cache.Del(c, key).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
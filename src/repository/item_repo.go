@@ -6,6 +6,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 	"xo-packs/core"
 	"xo-packs/db"
@@ -22,7 +26,8 @@ type ItemRepository interface {
 	GetItems(context.Context, []uint64, string) ([]model.Item, error)
 	UserOwnsItem(context.Context, *string, uint64) (bool, error)
 	AddItemCategories(context.Context, []*model.ItemCategory) error
-	AddUserItems(context.Context, string, []uint64) error
+	AddUserItems(context.Context, string, uint64, []*model.Item) error
+	GetItemInstance(context.Context, uint64) (*model.ItemInstance, error)
 	PatchItem(context.Context, uint64, map[string]interface{}, string) (*model.Item, error)
 	DeleteUserItems(context.Context, []uint64, string) error
 	DeleteItems(context.Context, []uint64, string) error
@@ -31,11 +36,28 @@ type ItemRepository interface {
 	ClearVendorItemCache(context.Context, string) error
 	ClearItemCache(context.Context, []string) error
 	GetItemSignCreds(context.Context) (string, string, error)
+	GetItemSigner(context.Context) (*core.ContentSigner, error)
+	GetBurnRates(context.Context) ([]*model.BurnRate, error)
+	SetBurnRate(context.Context, *model.BurnRate) (*model.BurnRate, error)
+	GetRarities(context.Context) ([]*model.Rarity, error)
+	CreateRarity(context.Context, *model.Rarity) (*model.Rarity, error)
+	PatchRarity(context.Context, uint64, map[string]interface{}) (*model.Rarity, error)
+	DeleteRarity(context.Context, uint64) error
+	GetRarityOverrides(context.Context) ([]*model.RarityOverride, error)
+	SetRarityOverride(context.Context, *model.RarityOverride) (*model.RarityOverride, error)
+	DeleteRarityOverride(context.Context, string, uint64) error
+	GetCraftingBalance(context.Context, string) (*model.CraftingBalance, error)
+	BurnUserItems(context.Context, string, []uint64, string, []*model.BurnRate) (*model.BurnItemsResp, error)
+	GetExpiringUserItems(context.Context, string, int) ([]*model.ExpiringUserItem, error)
+	ExpireUserItems(context.Context, int) ([]string, error)
 }
 
 type ItemRepoImpl struct {
-	db    *sqlx.DB
-	cache *redis.Client
+	db          *sqlx.DB
+	cache       *redis.Client
+	signerMu    sync.Mutex
+	signer      *core.ContentSigner
+	signerCreds string
 }
 
 func NewItemRepo(db *sqlx.DB, cache *redis.Client) ItemRepository {
@@ -254,53 +276,249 @@ func (r *ItemRepoImpl) UserOwnsItem(c context.Context, uid *string, itemId uint6
 	return exists, nil
 }
 
-func (r *ItemRepoImpl) AddUserItems(c context.Context, uid string, itemIds []uint64) error {
+// credits the items pulled from a pack to the user, carrying over the serial number each item was minted with
+func (r *ItemRepoImpl) AddUserItems(c context.Context, uid string, packId uint64, items []*model.Item) error {
 	ctx, cancel := context.WithTimeout(c, 5*time.Second)
 	defer cancel()
 
-	if len(itemIds) <= 0 {
+	if len(items) <= 0 {
 		return nil
 	}
 
-	currentTime := time.Now()
-	now := currentTime.Format("2006-01-02 15:04:05")
-	userItems := make([]model.UserItem, len(itemIds))
-	for i, id := range itemIds {
-		userItemId := id
-		userItem := model.UserItem{
-			Uid:        &uid,
-			ItemId:     &userItemId,
-			AcquiredAt: &now,
-		}
-		userItems[i] = userItem
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return err
 	}
 
-	if len(userItems) != len(itemIds) {
-		return &core.ErrorResp{
-			Message: "critical error: unable to create user items corresponding to item ids",
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
 		}
+	}()
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	packIds := make([]uint64, len(items))
+	for i := range items {
+		packIds[i] = packId
+	}
+	if _, err = creditPulledItems(ctx, tx, uid, items, packIds, now); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// credits items pulled from packs to the user, starting their lifetimes and recording the pack each
+// one came from. packIds[i] is the pack items[i] was pulled from. shared by every way a pack is opened
+func creditPulledItems(c context.Context, tx *sqlx.Tx, uid string, items []*model.Item, packIds []uint64, now string) ([]uint64, error) {
+	if len(items) == 0 {
+		return []uint64{}, nil
 	}
 
 	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 	query := psql.
 		Insert(db.SCHEMA_USER_ITEMS).
-		Columns(core.ModelColumns(userItems[0])...)
-
-	for _, userItem := range userItems {
-		query = query.Values(core.StructValues(userItem)...)
+		Columns("uid", "item_id", "acquired_at", "serial_number").
+		Suffix("RETURNING id")
+	for _, item := range items {
+		if item.ID == nil {
+			return nil, &core.ErrorResp{
+				Message: "critical error: unable to create user items corresponding to item ids",
+			}
+		}
+		query = query.Values(uid, *item.ID, now, item.SerialNumber)
 	}
 
 	queryStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	userItemIds, err := scanIds(c, tx, queryStr, args)
+	if err != nil {
+		return nil, err
+	}
+	if err = setUserItemExpiry(c, tx, userItemIds); err != nil {
+		return nil, err
+	}
+
+	events := make([]*model.ItemEvent, len(userItemIds))
+	for i := range userItemIds {
+		events[i] = &model.ItemEvent{UserItemId: &userItemIds[i], PackId: &packIds[i], ToUid: &uid, ActorUid: &uid}
+	}
+	if err = addItemEvents(c, tx, db.ITEM_EVENT_PULLED, now, events); err != nil {
+		return nil, err
+	}
+	return userItemIds, nil
+}
+
+// runs an insert with a RETURNING id suffix and collects the ids in insertion order
+func scanIds(c context.Context, tx *sqlx.Tx, query string, args []interface{}) ([]uint64, error) {
+	rows, err := tx.QueryxContext(c, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []uint64{}
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// starts the clock on freshly credited user items. items with a lifetime expire that many days after they
+// were acquired, the rest keep a null expired_at and never expire
+func setUserItemExpiry(c context.Context, tx *sqlx.Tx, userItemIds []uint64) error {
+	if len(userItemIds) == 0 {
+		return nil
+	}
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Update(db.SCHEMA_USER_ITEMS+" ui").
+		Set("expired_at", squirrel.Expr("ui.acquired_at + make_interval(days => i.lifetime_days)")).
+		From(db.SCHEMA_ITEMS + " i").
+		Where("i.id = ui.item_id and i.lifetime_days is not null").
+		Where(squirrel.Eq{"ui.id": userItemIds}).
+		ToSql()
 	if err != nil {
 		return err
 	}
 
-	_, err = r.db.ExecContext(ctx, queryStr, args...)
+	_, err = tx.ExecContext(c, query, args...)
+	return err
+}
+
+// matches user items that have not run out their lifetime yet
+func unexpired(column string) squirrel.Sqlizer {
+	return squirrel.Or{squirrel.Eq{column: nil}, squirrel.Expr(column + " > now()")}
+}
+
+// records an ownership history event for every user item. shared by every repository that
+// moves an item instance in or out of a users collection
+func addItemEvents(c context.Context, tx *sqlx.Tx, eventType string, now string, events []*model.ItemEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query := psql.
+		Insert(db.SCHEMA_ITEM_EVENTS).
+		Columns("user_item_id", "event_type", "from_uid", "to_uid", "pack_id", "actor_uid", "notes", "created_at")
+	for _, event := range events {
+		query = query.Values(*event.UserItemId, eventType, event.FromUid, event.ToUid, event.PackId, event.ActorUid, event.Notes, now)
+	}
+
+	queryStr, args, err := query.ToSql()
 	if err != nil {
 		return err
 	}
 
-	return nil
+	_, err = tx.ExecContext(c, queryStr, args...)
+	return err
+}
+
+// gets an item instance along with its full ownership history
+func (r *ItemRepoImpl) GetItemInstance(c context.Context, userItemId uint64) (*model.ItemInstance, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err = tx.Commit(); err != nil {
+			fmt.Println(err)
+		}
+	}()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select(
+			"ui.id as user_item_id",
+			"i.id as item_id",
+			"i.name",
+			"i.description",
+			"i.image_url",
+			"i.vendor_id",
+			"ui.serial_number",
+			"mc.minted_qty as mint_count",
+			"ui.uid as owner_uid",
+			"u.username as owner_username",
+			"ui.acquired_at",
+			"ui.removed_at",
+			"ui.expired_at",
+		).
+		From(db.SCHEMA_USER_ITEMS + " ui").
+		Join(db.SCHEMA_ITEMS + " i on i.id = ui.item_id").
+		LeftJoin(db.SCHEMA_USERS + " u on u.uid = ui.uid").
+		LeftJoin(db.SCHEMA_ITEM_MINT_COUNTS + " mc on mc.item_id = i.id").
+		Where(squirrel.Eq{"ui.id": userItemId}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	itemInstance := model.ItemInstance{}
+	if err = tx.QueryRowxContext(ctx, query, args...).StructScan(&itemInstance); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &ItemError{fmt.Sprintf("item instance %v does not exist", userItemId)}
+		}
+		return nil, err
+	}
+
+	query, args, err = psql.
+		Select(
+			"e.id",
+			"e.event_type",
+			"e.from_uid",
+			"fu.username as from_username",
+			"e.to_uid",
+			"tu.username as to_username",
+			"e.pack_id",
+			"e.actor_uid",
+			"au.username as actor_username",
+			"e.notes",
+			"e.created_at",
+		).
+		From(db.SCHEMA_ITEM_EVENTS+" e").
+		LeftJoin(db.SCHEMA_USERS+" fu on fu.uid = e.from_uid").
+		LeftJoin(db.SCHEMA_USERS+" tu on tu.uid = e.to_uid").
+		LeftJoin(db.SCHEMA_USERS+" au on au.uid = e.actor_uid").
+		Where(squirrel.Eq{"e.user_item_id": userItemId}).
+		OrderBy("e.created_at asc", "e.id asc").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	itemInstance.OwnershipEvents = []*model.ItemEventExpanded{}
+	defer rows.Close()
+	for rows.Next() {
+		event := model.ItemEventExpanded{}
+		if err := rows.StructScan(&event); err != nil {
+			return nil, err
+		}
+		itemInstance.OwnershipEvents = append(itemInstance.OwnershipEvents, &event)
+	}
+	return &itemInstance, nil
 }
 
 func (r *ItemRepoImpl) PatchItem(c context.Context, itemId uint64, itemPatchMap map[string]interface{}, authorizedUid string) (*model.Item, error) {
@@ -367,31 +585,63 @@ func (r *ItemRepoImpl) DeleteUserItems(c context.Context, userItemIds []uint64,
 	ctx, cancel := context.WithTimeout(c, 5*time.Second)
 	defer cancel()
 
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
 	now := time.Now().Format("2006-01-02 15:04:05")
 	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 	query, args, err := psql.
 		Update(db.SCHEMA_USER_ITEMS).
 		Set("removed_at", now).
 		Where(squirrel.Eq{"id": userItemIds, "uid": uid}).
+		Where("removed_at is null").
+		Suffix("RETURNING id").
 		ToSql()
 	if err != nil {
 		return err
 	}
-	rows, err := r.db.ExecContext(ctx, query, args...)
+
+	deletedIds, err := scanIds(ctx, tx, query, args)
 	if err != nil {
 		return err
 	}
 
-	rowsAffected, err := rows.RowsAffected()
+	if len(deletedIds) <= 0 {
+		err = &core.ErrorResp{Message: "User items could not be deleted or do not exist"}
+		return err
+	}
+
+	events := make([]*model.ItemEvent, len(deletedIds))
+	for i := range deletedIds {
+		events[i] = &model.ItemEvent{UserItemId: &deletedIds[i], FromUid: &uid, ActorUid: &uid}
+	}
+	if err = addItemEvents(ctx, tx, db.ITEM_EVENT_DELETED, now, events); err != nil {
+		return err
+	}
+
+	listingsRemoved, err := removeMarketListings(ctx, tx, squirrel.Eq{"user_item_id": deletedIds}, db.ITEM_EVENT_DELETED, now)
 	if err != nil {
 		return err
 	}
 
-	if rowsAffected > 0 {
-		return nil
-	} else {
-		return &core.ErrorResp{Message: "User items could not be deleted or do not exist"}
+	if err = tx.Commit(); err != nil {
+		return err
 	}
+
+	if listingsRemoved {
+		return clearMarketListingCache(c, r.cache)
+	}
+	return nil
 }
 
 func (r *ItemRepoImpl) DeleteItems(c context.Context, itemIds []uint64, vendorId string) error {
@@ -429,80 +679,844 @@ func (r *ItemRepoImpl) DeleteItems(c context.Context, itemIds []uint64, vendorId
 	}
 }
 
-func (r *ItemRepoImpl) ClearItemCategoryCache(c context.Context) error {
-	return r.cache.Del(c, db.KEY_CATEGORY+"item_categories").Err()
-}
+func (r *ItemRepoImpl) GetBurnRates(c context.Context) ([]*model.BurnRate, error) {
+	val, err := r.cache.Get(c, db.KEY_BURN_RATES).Result()
+	if err != nil {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
 
-func (r *ItemRepoImpl) ClearUserItemCache(c context.Context, uid string) error {
-	keysToDelete := []string{
-		db.KEY_USER_ITEMS + uid,
-		db.KEY_USER_ITEM_AMOUNT + uid,
-		fmt.Sprintf("/user/items/%v*", uid),
-	}
+		psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+		query, args, err := psql.
+			Select("id", "vendor_id", "rarity_id", "token_rate", "crafting_rate", "created_at", "updated_at").
+			From(db.SCHEMA_BURN_RATES).
+			OrderBy("rarity_id asc", "id asc").
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
 
-	allKeys := []string{}
-	for _, key := range keysToDelete {
-		keys, err := r.cache.Keys(c, key).Result()
+		rows, err := r.db.QueryxContext(ctx, query, args...)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		allKeys = append(allKeys, keys...)
-	}
 
-	for _, key := range allKeys {
-		if err := r.cache.Del(c, key).Err(); err != nil {
-			return err
+		rates := []*model.BurnRate{}
+		defer rows.Close()
+		for rows.Next() {
+			rate := model.BurnRate{}
+			if err := rows.StructScan(&rate); err != nil {
+				return nil, err
+			}
+			rates = append(rates, &rate)
 		}
-	}
-	return nil
-}
 
-func (r *ItemRepoImpl) ClearVendorItemCache(c context.Context, vendorId string) error {
-	keys, err := r.cache.Keys(c, fmt.Sprintf("/vendor/items/%v*", vendorId)).Result()
-	if err != nil {
-		return err
-	}
-	for _, key := range keys {
-		if err = r.cache.Del(c, key).Err(); err != nil {
-			return err
+		ratesBytes, err := json.Marshal(rates)
+		if err != nil {
+			return nil, err
+		}
+		if err = r.cache.Set(c, db.KEY_BURN_RATES, ratesBytes, time.Duration(3600)*time.Second).Err(); err != nil {
+			return nil, err
+		}
+		return rates, nil
+	} else {
+		rates := []*model.BurnRate{}
+		if err = json.Unmarshal([]byte(val), &rates); err != nil {
+			return nil, err
 		}
+		return rates, nil
 	}
-	return nil
-}
-
-func (r *ItemRepoImpl) ClearItemCache(c context.Context, itemKeys []string) error {
-	return r.cache.Del(c, itemKeys...).Err()
 }
 
-func (r *ItemRepoImpl) GetItemSignCreds(c context.Context) (string, string, error) {
-	var pemKey string
-	var keyPairID string
+// sets the burn rate for a rarity. a nil vendor id sets the platform rate, otherwise the creators own rate
+// for their items of that rarity
+func (r *ItemRepoImpl) SetBurnRate(c context.Context, rate *model.BurnRate) (*model.BurnRate, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
 
-	pemKey, err := r.cache.Get(c, db.KEY_PEM).Result()
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
 	if err != nil {
-		pemKey = os.Getenv("PRIVATE_KEY")
-		pemKey, err = core.GetSecret(pemKey)
+		return nil, err
+	}
+
+	defer func() {
 		if err != nil {
-			return "", "", err
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
 		}
+	}()
 
-		if err = r.cache.Set(c, db.KEY_PEM, pemKey, time.Duration(3600*time.Second)).Err(); err != nil {
-			return "", "", err
+	now := time.Now().Format("2006-01-02 15:04:05")
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Update(db.SCHEMA_BURN_RATES).
+		SetMap(map[string]interface{}{"token_rate": *rate.TokenRate, "crafting_rate": *rate.CraftingRate, "updated_at": now}).
+		Where(squirrel.Eq{"vendor_id": rate.VendorId, "rarity_id": *rate.RarityId}).
+		Suffix("RETURNING id, vendor_id, rarity_id, token_rate, crafting_rate, created_at, updated_at").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	updatedRate := model.BurnRate{}
+	err = tx.QueryRowxContext(ctx, query, args...).StructScan(&updatedRate)
+	if err == sql.ErrNoRows {
+		rate.CreatedAt = &now
+		rate.UpdatedAt = &now
+		query, args, err = psql.
+			Insert(db.SCHEMA_BURN_RATES).
+			Columns(core.ModelColumns(rate)...).
+			Values(core.StructValues(rate)...).
+			Suffix("RETURNING id, vendor_id, rarity_id, token_rate, crafting_rate, created_at, updated_at").
+			ToSql()
+		if err != nil {
+			return nil, err
 		}
+		err = tx.QueryRowxContext(ctx, query, args...).StructScan(&updatedRate)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	keyPairID, err = r.cache.Get(c, db.KEY_KEY_PAIR_ID).Result()
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if err = r.cache.Del(c, db.KEY_BURN_RATES).Err(); err != nil {
+		return nil, err
+	}
+	return &updatedRate, nil
+}
+
+var rarityColumns = []string{"id", "rarity", "ranking", "label", "color", "created_at", "updated_at"}
+var rarityOverrideColumns = []string{"id", "vendor_id", "rarity_id", "label", "color", "created_at", "updated_at"}
+
+func (r *ItemRepoImpl) GetRarities(c context.Context) ([]*model.Rarity, error) {
+	val, err := r.cache.Get(c, db.KEY_RARITIES).Result()
 	if err != nil {
-		keyPairID = os.Getenv("KEY_PAIR_ID")
-		keyPairID, err = core.GetSecret(keyPairID)
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+		query, args, err := psql.
+			Select(rarityColumns...).
+			From(db.SCHEMA_RARITY).
+			OrderBy("ranking asc", "id asc").
+			ToSql()
 		if err != nil {
-			return "", "", err
+			return nil, err
 		}
 
-		if err = r.cache.Set(c, db.KEY_KEY_PAIR_ID, keyPairID, time.Duration(3600*time.Second)).Err(); err != nil {
-			return "", "", err
+		rows, err := r.db.QueryxContext(ctx, query, args...)
+		if err != nil {
+			return nil, err
 		}
-	}
 
-	return pemKey, keyPairID, nil
+		rarities := []*model.Rarity{}
+		defer rows.Close()
+		for rows.Next() {
+			rarity := model.Rarity{}
+			if err := rows.StructScan(&rarity); err != nil {
+				return nil, err
+			}
+			rarities = append(rarities, &rarity)
+		}
+
+		raritiesBytes, err := json.Marshal(rarities)
+		if err != nil {
+			return nil, err
+		}
+		if err = r.cache.Set(c, db.KEY_RARITIES, raritiesBytes, time.Duration(3600)*time.Second).Err(); err != nil {
+			return nil, err
+		}
+		return rarities, nil
+	} else {
+		rarities := []*model.Rarity{}
+		if err = json.Unmarshal([]byte(val), &rarities); err != nil {
+			return nil, err
+		}
+		return rarities, nil
+	}
+}
+
+func (r *ItemRepoImpl) CreateRarity(c context.Context, rarity *model.Rarity) (*model.Rarity, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	rarity.CreatedAt = &now
+	rarity.UpdatedAt = &now
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Insert(db.SCHEMA_RARITY).
+		Columns(core.ModelColumns(rarity)...).
+		Values(core.StructValues(rarity)...).
+		Suffix("RETURNING " + strings.Join(rarityColumns, ", ")).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	createdRarity := model.Rarity{}
+	if err = r.db.QueryRowxContext(ctx, query, args...).StructScan(&createdRarity); err != nil {
+		return nil, err
+	}
+
+	if err = r.cache.Del(c, db.KEY_RARITIES).Err(); err != nil {
+		return nil, err
+	}
+	return &createdRarity, nil
+}
+
+func (r *ItemRepoImpl) PatchRarity(c context.Context, rarityId uint64, rarityPatchMap map[string]interface{}) (*model.Rarity, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	rarityPatchMap["updated_at"] = time.Now().Format("2006-01-02 15:04:05")
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Update(db.SCHEMA_RARITY).
+		SetMap(rarityPatchMap).
+		Where(squirrel.Eq{"id": rarityId}).
+		Suffix("RETURNING " + strings.Join(rarityColumns, ", ")).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	updatedRarity := model.Rarity{}
+	if err = r.db.QueryRowxContext(ctx, query, args...).StructScan(&updatedRarity); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &ItemError{fmt.Sprintf("rarity %v does not exist", rarityId)}
+		}
+		return nil, err
+	}
+
+	if err = r.cache.Del(c, db.KEY_RARITIES).Err(); err != nil {
+		return nil, err
+	}
+	return &updatedRarity, nil
+}
+
+// removes a rarity no item uses anymore, along with the creator overrides of it
+func (r *ItemRepoImpl) DeleteRarity(c context.Context, rarityId uint64) error {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("count(*)").
+		From(db.SCHEMA_ITEMS).
+		Where(squirrel.Eq{"rarity_id": rarityId}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	var itemCount int
+	if err = tx.QueryRowxContext(ctx, query, args...).Scan(&itemCount); err != nil {
+		return err
+	}
+	if itemCount > 0 {
+		err = &ItemError{fmt.Sprintf("rarity %v is still used by %v items", rarityId, itemCount)}
+		return err
+	}
+
+	query, args, err = psql.
+		Delete(db.SCHEMA_RARITY_OVERRIDES).
+		Where(squirrel.Eq{"rarity_id": rarityId}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+
+	query, args, err = psql.
+		Delete(db.SCHEMA_RARITY).
+		Where(squirrel.Eq{"id": rarityId}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		err = &ItemError{fmt.Sprintf("rarity %v does not exist", rarityId)}
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	if err = r.cache.Del(c, db.KEY_RARITIES, db.KEY_RARITY_OVERRIDES).Err(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *ItemRepoImpl) GetRarityOverrides(c context.Context) ([]*model.RarityOverride, error) {
+	val, err := r.cache.Get(c, db.KEY_RARITY_OVERRIDES).Result()
+	if err != nil {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+		query, args, err := psql.
+			Select(rarityOverrideColumns...).
+			From(db.SCHEMA_RARITY_OVERRIDES).
+			OrderBy("vendor_id asc", "rarity_id asc").
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+
+		rows, err := r.db.QueryxContext(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+
+		overrides := []*model.RarityOverride{}
+		defer rows.Close()
+		for rows.Next() {
+			override := model.RarityOverride{}
+			if err := rows.StructScan(&override); err != nil {
+				return nil, err
+			}
+			overrides = append(overrides, &override)
+		}
+
+		overridesBytes, err := json.Marshal(overrides)
+		if err != nil {
+			return nil, err
+		}
+		if err = r.cache.Set(c, db.KEY_RARITY_OVERRIDES, overridesBytes, time.Duration(3600)*time.Second).Err(); err != nil {
+			return nil, err
+		}
+		return overrides, nil
+	} else {
+		overrides := []*model.RarityOverride{}
+		if err = json.Unmarshal([]byte(val), &overrides); err != nil {
+			return nil, err
+		}
+		return overrides, nil
+	}
+}
+
+// sets how a creator shows a rarity, replacing any override they had for it
+func (r *ItemRepoImpl) SetRarityOverride(c context.Context, override *model.RarityOverride) (*model.RarityOverride, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Insert(db.SCHEMA_RARITY_OVERRIDES).
+		Columns("vendor_id", "rarity_id", "label", "color", "created_at", "updated_at").
+		Values(*override.VendorId, *override.RarityId, override.Label, override.Color, now, now).
+		Suffix("ON CONFLICT (vendor_id, rarity_id) DO UPDATE SET label = excluded.label, color = excluded.color, updated_at = excluded.updated_at RETURNING " + strings.Join(rarityOverrideColumns, ", ")).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	updatedOverride := model.RarityOverride{}
+	if err = r.db.QueryRowxContext(ctx, query, args...).StructScan(&updatedOverride); err != nil {
+		return nil, err
+	}
+
+	if err = r.cache.Del(c, db.KEY_RARITY_OVERRIDES).Err(); err != nil {
+		return nil, err
+	}
+	return &updatedOverride, nil
+}
+
+func (r *ItemRepoImpl) DeleteRarityOverride(c context.Context, vendorId string, rarityId uint64) error {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Delete(db.SCHEMA_RARITY_OVERRIDES).
+		Where(squirrel.Eq{"vendor_id": vendorId, "rarity_id": rarityId}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err = r.db.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+	return r.cache.Del(c, db.KEY_RARITY_OVERRIDES).Err()
+}
+
+func (r *ItemRepoImpl) GetCraftingBalance(c context.Context, uid string) (*model.CraftingBalance, error) {
+	val, err := r.cache.Get(c, db.KEY_CRAFTING_BALANCE+uid).Result()
+	if err != nil {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+		query, args, err := psql.
+			Select("id", "uid", "balance", "updated_at").
+			From(db.SCHEMA_CRAFTING_BALANCE).
+			Where(squirrel.Eq{"uid": uid}).
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+
+		// users who have never burned an item have no balance row yet
+		zero := 0.0
+		craftingBalance := model.CraftingBalance{UID: &uid, Balance: &zero}
+		if err = r.db.QueryRowxContext(ctx, query, args...).StructScan(&craftingBalance); err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+
+		craftingBalanceBytes, err := json.Marshal(craftingBalance)
+		if err != nil {
+			return nil, err
+		}
+		if err = r.cache.Set(c, db.KEY_CRAFTING_BALANCE+uid, craftingBalanceBytes, time.Duration(3600)*time.Second).Err(); err != nil {
+			return nil, err
+		}
+		return &craftingBalance, nil
+	} else {
+		craftingBalance := model.CraftingBalance{}
+		if err = json.Unmarshal([]byte(val), &craftingBalance); err != nil {
+			return nil, err
+		}
+		return &craftingBalance, nil
+	}
+}
+
+// burns a set of the users items. every item is marked removed, the payout for each one is recorded and the
+// total is credited to the users token or crafting balance in the same transaction
+func (r *ItemRepoImpl) BurnUserItems(c context.Context, uid string, userItemIds []uint64, currency string, rates []*model.BurnRate) (*model.BurnItemsResp, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("ui.id as user_item_id", "i.id as item_id", "i.vendor_id", "i.rarity_id", "i.value").
+		From(db.SCHEMA_USER_ITEMS+" ui").
+		Join(db.SCHEMA_ITEMS+" i on i.id = ui.item_id").
+		Where(squirrel.Eq{"ui.id": userItemIds, "ui.uid": uid, "ui.removed_at": nil}).
+		Where(unexpired("ui.expired_at")).
+		Where("not exists (select 1 from "+db.SCHEMA_ITEM_WITHDRAWALS+" w where w.user_item_id = ui.id and w.status <> ?)", db.WITHDRAWAL_CANCELLED).
+		Suffix("FOR UPDATE OF ui").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	burnableItems := []*model.BurnableItem{}
+	defer rows.Close()
+	for rows.Next() {
+		burnableItem := model.BurnableItem{}
+		if err = rows.StructScan(&burnableItem); err != nil {
+			return nil, err
+		}
+		burnableItems = append(burnableItems, &burnableItem)
+	}
+	if len(burnableItems) != len(userItemIds) {
+		err = &ItemError{"one or more items are no longer in the users collection"}
+		return nil, err
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	totalAmount := 0.0
+	burnedIds := make([]uint64, len(burnableItems))
+	burnInsert := psql.
+		Insert(db.SCHEMA_ITEM_BURNS).
+		Columns("user_item_id", "uid", "currency", "amount", "burned_at")
+	for i, burnableItem := range burnableItems {
+		value := 0.0
+		if burnableItem.Value != nil {
+			value = *burnableItem.Value
+		}
+		var rarityId uint64
+		if burnableItem.RarityId != nil {
+			rarityId = *burnableItem.RarityId
+		}
+		vendorId := ""
+		if burnableItem.VendorId != nil {
+			vendorId = *burnableItem.VendorId
+		}
+
+		amount := 0.0
+		if rate := core.ResolveBurnRate(rates, vendorId, rarityId); rate != nil {
+			if currency == db.BURN_CURRENCY_CRAFTING && rate.CraftingRate != nil {
+				amount = core.BurnAmount(value, *rate.CraftingRate)
+			} else if currency == db.BURN_CURRENCY_TOKENS && rate.TokenRate != nil {
+				amount = core.BurnAmount(value, *rate.TokenRate)
+			}
+		}
+		if amount <= 0 {
+			err = &ItemError{fmt.Sprintf("user item %v cannot be burned for %v", *burnableItem.UserItemId, currency)}
+			return nil, err
+		}
+
+		totalAmount += amount
+		burnedIds[i] = *burnableItem.UserItemId
+		burnInsert = burnInsert.Values(*burnableItem.UserItemId, uid, currency, amount, now)
+	}
+	totalAmount = core.RoundTokenAmount(totalAmount)
+
+	query, args, err = psql.
+		Update(db.SCHEMA_USER_ITEMS).
+		Set("removed_at", now).
+		Where(squirrel.Eq{"id": burnedIds, "uid": uid, "removed_at": nil}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, err
+	}
+
+	query, args, err = burnInsert.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, err
+	}
+
+	notes := fmt.Sprintf("burned for %v", currency)
+	events := make([]*model.ItemEvent, len(burnedIds))
+	for i := range burnedIds {
+		events[i] = &model.ItemEvent{UserItemId: &burnedIds[i], FromUid: &uid, ActorUid: &uid, Notes: &notes}
+	}
+	if err = addItemEvents(ctx, tx, db.ITEM_EVENT_BURNED, now, events); err != nil {
+		return nil, err
+	}
+
+	listingsRemoved, err := removeMarketListings(ctx, tx, squirrel.Eq{"user_item_id": burnedIds}, db.ITEM_EVENT_BURNED, now)
+	if err != nil {
+		return nil, err
+	}
+
+	balanceTable := db.SCHEMA_TOKEN_BALANCE
+	if currency == db.BURN_CURRENCY_CRAFTING {
+		balanceTable = db.SCHEMA_CRAFTING_BALANCE
+	}
+	newBalance, err := creditBalance(ctx, tx, balanceTable, uid, totalAmount, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if currency == db.BURN_CURRENCY_CRAFTING {
+		if err = r.cache.Del(c, db.KEY_CRAFTING_BALANCE+uid).Err(); err != nil {
+			return nil, err
+		}
+	}
+	if listingsRemoved {
+		if err = clearMarketListingCache(c, r.cache); err != nil {
+			return nil, err
+		}
+	}
+	return &model.BurnItemsResp{BurnedUserItemIds: burnedIds, Currency: currency, Amount: totalAmount, NewBalance: newBalance}, nil
+}
+
+func (r *ItemRepoImpl) ClearItemCategoryCache(c context.Context) error {
+	return r.cache.Del(c, db.KEY_CATEGORY+"item_categories").Err()
+}
+
+func (r *ItemRepoImpl) ClearUserItemCache(c context.Context, uid string) error {
+	return clearUserItemCache(c, r.cache, uid)
+}
+
+func clearUserItemCache(c context.Context, cache *redis.Client, uid string) error {
+	keysToDelete := []string{
+		db.KEY_USER_ITEMS + uid,
+		db.KEY_USER_ITEM_AMOUNT + uid,
+		fmt.Sprintf("/user/items/%v*", uid),
+	}
+
+	allKeys := []string{}
+	for _, key := range keysToDelete {
+		keys, err := cache.Keys(c, key).Result()
+		if err != nil {
+			return err
+		}
+		allKeys = append(allKeys, keys...)
+	}
+
+	for _, key := range allKeys {
+		if err := cache.Del(c, key).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *ItemRepoImpl) ClearVendorItemCache(c context.Context, vendorId string) error {
+	keys, err := r.cache.Keys(c, fmt.Sprintf("/vendor/items/%v*", vendorId)).Result()
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err = r.cache.Del(c, key).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *ItemRepoImpl) ClearItemCache(c context.Context, itemKeys []string) error {
+	return r.cache.Del(c, itemKeys...).Err()
+}
+
+func (r *ItemRepoImpl) GetItemSignCreds(c context.Context) (string, string, error) {
+	var pemKey string
+	var keyPairID string
+
+	pemKey, err := r.cache.Get(c, db.KEY_PEM).Result()
+	if err != nil {
+		pemKey = os.Getenv("PRIVATE_KEY")
+		pemKey, err = core.GetSecret(pemKey)
+		if err != nil {
+			return "", "", err
+		}
+
+		if err = r.cache.Set(c, db.KEY_PEM, pemKey, time.Duration(3600*time.Second)).Err(); err != nil {
+			return "", "", err
+		}
+	}
+
+	keyPairID, err = r.cache.Get(c, db.KEY_KEY_PAIR_ID).Result()
+	if err != nil {
+		keyPairID = os.Getenv("KEY_PAIR_ID")
+		keyPairID, err = core.GetSecret(keyPairID)
+		if err != nil {
+			return "", "", err
+		}
+
+		if err = r.cache.Set(c, db.KEY_KEY_PAIR_ID, keyPairID, time.Duration(3600*time.Second)).Err(); err != nil {
+			return "", "", err
+		}
+	}
+
+	return pemKey, keyPairID, nil
+}
+
+// the content signer is kept for the life of the process so signed urls are reused across requests. it is
+// only rebuilt when the signing credentials change. URL_SIGNER=local signs with URL_SIGNING_SECRET instead of
+// the cloudfront key pair
+func (r *ItemRepoImpl) GetItemSigner(c context.Context) (*core.ContentSigner, error) {
+	if os.Getenv("URL_SIGNER") == core.SIGNER_LOCAL {
+		secret := os.Getenv("URL_SIGNING_SECRET")
+		return r.contentSigner(core.SIGNER_LOCAL+secret, func() (core.URLSigner, error) {
+			return core.NewHMACSigner(secret), nil
+		})
+	}
+
+	pemKey, keyPairID, err := r.GetItemSignCreds(c)
+	if err != nil {
+		return nil, err
+	}
+	return r.contentSigner(core.SIGNER_CLOUDFRONT+keyPairID+pemKey, func() (core.URLSigner, error) {
+		return core.NewCloudFrontSigner(pemKey, keyPairID)
+	})
+}
+
+func (r *ItemRepoImpl) contentSigner(creds string, build func() (core.URLSigner, error)) (*core.ContentSigner, error) {
+	r.signerMu.Lock()
+	defer r.signerMu.Unlock()
+
+	if r.signer != nil && r.signerCreds == creds {
+		return r.signer, nil
+	}
+	signer, err := build()
+	if err != nil {
+		return nil, err
+	}
+
+	ttls := map[string]time.Duration{
+		core.CONTENT_CLASS_IMAGE: envSeconds("SIGNED_URL_TTL_IMAGE", core.DEFAULT_SIGNED_URL_TTL_IMAGE),
+		core.CONTENT_CLASS_VIDEO: envSeconds("SIGNED_URL_TTL_VIDEO", core.DEFAULT_SIGNED_URL_TTL_VIDEO),
+		core.CONTENT_CLASS_THUMB: envSeconds("SIGNED_URL_TTL_THUMB", core.DEFAULT_SIGNED_URL_TTL_THUMB),
+	}
+	r.signer = core.NewContentSigner(signer, ttls, envSeconds("SIGNED_URL_REFRESH", core.DEFAULT_SIGNED_URL_REFRESH))
+	r.signerCreds = creds
+	return r.signer, nil
+}
+
+func envSeconds(name string, fallback int) time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || seconds <= 0 {
+		seconds = fallback
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// the users held items that run out their lifetime within the given number of days, soonest first. read straight
+// from the db since the window moves with the clock
+func (r *ItemRepoImpl) GetExpiringUserItems(c context.Context, uid string, days int) ([]*model.ExpiringUserItem, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select(
+			"ui.id as user_item_id",
+			"i.id as item_id",
+			"i.name",
+			"i.image_url",
+			"i.vendor_id",
+			"ui.serial_number",
+			"ui.acquired_at",
+			"ui.expired_at",
+		).
+		From(db.SCHEMA_USER_ITEMS+" ui").
+		Join(db.SCHEMA_ITEMS+" i on i.id = ui.item_id").
+		Where(squirrel.Eq{"ui.uid": uid, "ui.removed_at": nil}).
+		Where("ui.expired_at > now() and ui.expired_at <= now() + make_interval(days => ?)", days).
+		Where("not exists (select 1 from "+db.SCHEMA_ITEM_WITHDRAWALS+" w where w.user_item_id = ui.id and w.status <> ?)", db.WITHDRAWAL_CANCELLED).
+		OrderBy("ui.expired_at asc").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	expiringItems := []*model.ExpiringUserItem{}
+	defer rows.Close()
+	for rows.Next() {
+		expiringItem := model.ExpiringUserItem{}
+		if err := rows.StructScan(&expiringItem); err != nil {
+			return nil, err
+		}
+		expiringItems = append(expiringItems, &expiringItem)
+	}
+	return expiringItems, nil
+}
+
+// sweeps up to limit user items whose lifetime has passed. each gets an expired event, which also marks it as
+// swept, and any listing of it is taken down. returns the uids whose collections changed
+func (r *ItemRepoImpl) ExpireUserItems(c context.Context, limit int) ([]string, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("ui.id", "ui.uid").
+		From(db.SCHEMA_USER_ITEMS+" ui").
+		Where(squirrel.Eq{"ui.removed_at": nil}).
+		Where("ui.expired_at <= now()").
+		Where("not exists (select 1 from "+db.SCHEMA_ITEM_EVENTS+" e where e.user_item_id = ui.id and e.event_type = ?)", db.ITEM_EVENT_EXPIRED).
+		OrderBy("ui.expired_at asc").
+		Limit(uint64(limit)).
+		Suffix("FOR UPDATE SKIP LOCKED").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	userItemIds := []uint64{}
+	uids := []string{}
+	events := []*model.ItemEvent{}
+	defer rows.Close()
+	for rows.Next() {
+		userItem := model.UserItem{}
+		if err = rows.StructScan(&userItem); err != nil {
+			return nil, err
+		}
+		userItemIds = append(userItemIds, *userItem.ID)
+		if !slices.Contains(uids, *userItem.Uid) {
+			uids = append(uids, *userItem.Uid)
+		}
+		events = append(events, &model.ItemEvent{UserItemId: userItem.ID, FromUid: userItem.Uid})
+	}
+	rows.Close()
+	if len(userItemIds) == 0 {
+		err = tx.Commit()
+		return uids, err
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	if err = addItemEvents(ctx, tx, db.ITEM_EVENT_EXPIRED, now, events); err != nil {
+		return nil, err
+	}
+
+	listingsRemoved, err := removeMarketListings(ctx, tx, squirrel.Eq{"user_item_id": userItemIds}, db.ITEM_EVENT_EXPIRED, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if listingsRemoved {
+		if err := clearMarketListingCache(c, r.cache); err != nil {
+			return nil, err
+		}
+	}
+	return uids, nil
 }
@@ -0,0 +1,706 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+	"xo-packs/core"
+	"xo-packs/db"
+	"xo-packs/model"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+)
+
+type MarketRepository interface {
+	CreateListing(context.Context, string, uint64, float64) (*model.MarketListing, error)
+	CancelListing(context.Context, uint64, string) error
+	GetListing(context.Context, uint64) (*model.MarketListingExpanded, error)
+	SearchListings(context.Context, *model.MarketListingFilter, string, uint64, string) ([]*model.MarketListingExpanded, *uint64, error)
+	BuyListing(context.Context, uint64, string) (*model.MarketPurchaseResp, error)
+	ClearMarketListingCache(context.Context) error
+}
+
+type MarketRepoImpl struct {
+	db    *sqlx.DB
+	cache *redis.Client
+}
+
+func NewMarketRepo(db *sqlx.DB, cache *redis.Client) MarketRepository {
+	return &MarketRepoImpl{db: db, cache: cache}
+}
+
+type MarketError struct {
+	message string
+}
+
+func (e *MarketError) Error() string {
+	return e.message
+}
+
+// lists a user item on the marketplace. the item must still be in the sellers collection, must not be
+// withdrawn and can only have one active listing at a time
+func (r *MarketRepoImpl) CreateListing(c context.Context, uid string, userItemId uint64, tokenAmount float64) (*model.MarketListing, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("id", "uid", "item_id", "removed_at", "expired_at").
+		From(db.SCHEMA_USER_ITEMS).
+		Where(squirrel.Eq{"id": userItemId}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	userItem := model.UserItem{}
+	if err = tx.QueryRowxContext(ctx, query, args...).StructScan(&userItem); err != nil {
+		if err == sql.ErrNoRows {
+			err = &MarketError{"user item does not exist"}
+		}
+		return nil, err
+	}
+	if userItem.Uid == nil || *userItem.Uid != uid {
+		err = &MarketError{"user item does not exist for authorized user"}
+		return nil, err
+	}
+	if userItem.RemovedAt != nil || core.UserItemExpired(userItem.ExpiredAt, time.Now()) {
+		err = &MarketError{"user item is no longer in the users collection"}
+		return nil, err
+	}
+
+	query, args, err = psql.
+		Select("count(*)").
+		From(db.SCHEMA_ITEM_WITHDRAWALS).
+		Where(squirrel.Eq{"user_item_id": userItemId}).
+		Where(squirrel.NotEq{"status": db.WITHDRAWAL_CANCELLED}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	withdrawals := 0
+	if err = tx.QueryRowContext(ctx, query, args...).Scan(&withdrawals); err != nil {
+		return nil, err
+	}
+	if withdrawals > 0 {
+		err = &MarketError{"withdrawn items cannot be listed"}
+		return nil, err
+	}
+
+	query, args, err = psql.
+		Select("count(*)").
+		From(db.SCHEMA_MARKET_LISTINGS).
+		Where(squirrel.Eq{"user_item_id": userItemId, "status": db.MARKET_LISTING_ACTIVE}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	activeListings := 0
+	if err = tx.QueryRowContext(ctx, query, args...).Scan(&activeListings); err != nil {
+		return nil, err
+	}
+	if activeListings > 0 {
+		err = &MarketError{"user item is already listed"}
+		return nil, err
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	status := db.MARKET_LISTING_ACTIVE
+	listing := model.MarketListing{
+		UserItemId:  &userItemId,
+		ItemId:      userItem.ItemId,
+		SellerUid:   &uid,
+		TokenAmount: &tokenAmount,
+		Status:      &status,
+		CreatedAt:   &now,
+	}
+	query, args, err = psql.
+		Insert(db.SCHEMA_MARKET_LISTINGS).
+		Columns(core.ModelColumns(listing)...).
+		Values(core.StructValues(listing)...).
+		Suffix("RETURNING \"id\"").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	listingId := uint64(0)
+	if err = tx.QueryRowContext(ctx, query, args...).Scan(&listingId); err != nil {
+		return nil, err
+	}
+	listing.ID = &listingId
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if err := r.ClearMarketListingCache(c); err != nil {
+		return nil, err
+	}
+	return &listing, nil
+}
+
+func (r *MarketRepoImpl) CancelListing(c context.Context, listingId uint64, uid string) error {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Update(db.SCHEMA_MARKET_LISTINGS).
+		SetMap(map[string]interface{}{"status": db.MARKET_LISTING_CANCELLED, "cancelled_at": now}).
+		Where(squirrel.Eq{"id": listingId, "seller_uid": uid, "status": db.MARKET_LISTING_ACTIVE}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected <= 0 {
+		return &MarketError{"listing is not active or does not belong to the authorized user"}
+	}
+
+	return r.ClearMarketListingCache(c)
+}
+
+// base select for expanded listings, joined with the listed item, its owner and creator
+func marketListingSelect() squirrel.SelectBuilder {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	return psql.
+		Select(
+			"ml.id",
+			"ml.user_item_id",
+			"ml.item_id",
+			"ml.seller_uid",
+			"s.username as seller_username",
+			"ml.token_amount",
+			"ml.status",
+			"ml.created_at",
+			"i.name",
+			"i.description",
+			"i.image_url",
+			"i.vendor_id",
+			"v.username as vendor_username",
+			"i.rarity_id",
+			"r.rarity",
+			"ui.serial_number",
+			"mc.minted_qty as mint_count",
+			"count(*) over () as listing_amount",
+		).
+		From(db.SCHEMA_MARKET_LISTINGS + " ml").
+		Join(db.SCHEMA_USER_ITEMS + " ui on ui.id = ml.user_item_id").
+		Join(db.SCHEMA_ITEMS + " i on i.id = ml.item_id").
+		Join(db.SCHEMA_USERS + " s on s.uid = ml.seller_uid").
+		LeftJoin(db.SCHEMA_USERS + " v on v.uid = i.vendor_id").
+		LeftJoin(db.SCHEMA_RARITY + " r on r.id = i.rarity_id").
+		LeftJoin(db.SCHEMA_ITEM_MINT_COUNTS + " mc on mc.item_id = i.id")
+}
+
+func (r *MarketRepoImpl) GetListing(c context.Context, listingId uint64) (*model.MarketListingExpanded, error) {
+	val, err := r.cache.Get(c, db.KEY_MARKET_LISTING+fmt.Sprintf("%v", listingId)).Result()
+	if err != nil {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+		if err != nil {
+			return nil, err
+		}
+
+		defer func() {
+			if err = tx.Commit(); err != nil {
+				fmt.Println(err)
+			}
+		}()
+
+		query, args, err := marketListingSelect().
+			Where(squirrel.Eq{"ml.id": listingId}).
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+
+		listing := model.MarketListingExpanded{}
+		if err = tx.QueryRowxContext(ctx, query, args...).StructScan(&listing); err != nil {
+			if err == sql.ErrNoRows {
+				return nil, &MarketError{fmt.Sprintf("listing %v does not exist", listingId)}
+			}
+			return nil, err
+		}
+
+		listingBytes, err := json.Marshal(listing)
+		if err != nil {
+			return nil, err
+		}
+		if err = r.cache.Set(c, db.KEY_MARKET_LISTING+fmt.Sprintf("%v", listingId), listingBytes, time.Duration(3600)*time.Second).Err(); err != nil {
+			return nil, err
+		}
+		return &listing, nil
+	} else {
+		listing := model.MarketListingExpanded{}
+		if err = json.Unmarshal([]byte(val), &listing); err != nil {
+			return nil, err
+		}
+		return &listing, nil
+	}
+}
+
+// searches the active listings of active sellers. sort is one of priceAsc, priceDesc or newest (default)
+func (r *MarketRepoImpl) SearchListings(c context.Context, filter *model.MarketListingFilter, sort string, pageNumber uint64, urlPath string) ([]*model.MarketListingExpanded, *uint64, error) {
+	val, err := r.cache.Get(c, urlPath).Result()
+	if err != nil {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		defer func() {
+			if err = tx.Commit(); err != nil {
+				fmt.Println(err)
+			}
+		}()
+
+		pageSize, err := strconv.ParseUint(os.Getenv("MARKET_LISTINGS_PAGE_SIZE"), 10, 64)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		where := squirrel.And{
+			squirrel.Eq{"ml.status": db.MARKET_LISTING_ACTIVE, "s.active": true, "ui.removed_at": nil},
+		}
+		if filter.VendorId != nil {
+			where = append(where, squirrel.Eq{"i.vendor_id": *filter.VendorId})
+		}
+		if filter.ItemId != nil {
+			where = append(where, squirrel.Eq{"ml.item_id": *filter.ItemId})
+		}
+		if filter.RarityId != nil {
+			where = append(where, squirrel.Eq{"i.rarity_id": *filter.RarityId})
+		}
+		if filter.MinTokenAmount != nil {
+			where = append(where, squirrel.GtOrEq{"ml.token_amount": *filter.MinTokenAmount})
+		}
+		if filter.MaxTokenAmount != nil {
+			where = append(where, squirrel.LtOrEq{"ml.token_amount": *filter.MaxTokenAmount})
+		}
+
+		orderBy := "ml.created_at desc"
+		switch sort {
+		case "priceAsc":
+			orderBy = "ml.token_amount asc"
+		case "priceDesc":
+			orderBy = "ml.token_amount desc"
+		}
+
+		query, args, err := marketListingSelect().
+			Where(where).
+			OrderBy(orderBy, "ml.id desc").
+			Limit(pageSize).
+			Offset(pageSize * pageNumber).
+			ToSql()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		rows, err := tx.QueryxContext(ctx, query, args...)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		defer rows.Close()
+		listings := []*model.MarketListingExpanded{}
+		for rows.Next() {
+			listing := model.MarketListingExpanded{}
+			if err = rows.StructScan(&listing); err != nil {
+				return nil, nil, err
+			}
+			listings = append(listings, &listing)
+		}
+		listingAmount := new(uint64)
+		if len(listings) > 0 {
+			listingAmount = listings[len(listings)-1].ListingAmount
+		}
+
+		listingBytes, err := json.Marshal(listings)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err = r.cache.Set(c, urlPath, listingBytes, time.Duration(3600)*time.Second).Err(); err != nil {
+			return nil, nil, err
+		}
+		return listings, listingAmount, nil
+	} else {
+		listings := []*model.MarketListingExpanded{}
+		if err = json.Unmarshal([]byte(val), &listings); err != nil {
+			return nil, nil, err
+		}
+		listingAmount := new(uint64)
+		if len(listings) > 0 {
+			listingAmount = listings[len(listings)-1].ListingAmount
+		}
+		return listings, listingAmount, nil
+	}
+}
+
+// buys a listing. the buyer is debited, the item is transferred and the price is paid out as the platform fee,
+// the creator royalty and the seller proceeds in one transaction, so a failure at any step leaves the buyer
+// untouched. the settled sale and every token movement of it are recorded in the token ledger
+func (r *MarketRepoImpl) BuyListing(c context.Context, listingId uint64, buyerUid string) (*model.MarketPurchaseResp, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("ml.id", "ml.user_item_id", "ml.item_id", "ml.seller_uid", "ml.token_amount", "ml.status").
+		From(db.SCHEMA_MARKET_LISTINGS + " ml").
+		Where(squirrel.Eq{"ml.id": listingId}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	listing := model.MarketListing{}
+	if err = tx.QueryRowxContext(ctx, query, args...).StructScan(&listing); err != nil {
+		if err == sql.ErrNoRows {
+			err = &MarketError{"listing does not exist"}
+		}
+		return nil, err
+	}
+	if listing.Status == nil || *listing.Status != db.MARKET_LISTING_ACTIVE {
+		err = &MarketError{"listing is no longer available"}
+		return nil, err
+	}
+	sellerUid := *listing.SellerUid
+	if sellerUid == buyerUid {
+		err = &MarketError{"users cannot buy their own listings"}
+		return nil, err
+	}
+
+	// the seller must still be active
+	query, args, err = psql.
+		Select("active").
+		From(db.SCHEMA_USERS).
+		Where(squirrel.Eq{"uid": sellerUid}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	sellerActive := false
+	if err = tx.QueryRowContext(ctx, query, args...).Scan(&sellerActive); err != nil {
+		return nil, err
+	}
+	if !sellerActive {
+		err = &MarketError{"listing is no longer available"}
+		return nil, err
+	}
+
+	// the item creator receives the royalty
+	query, args, err = psql.
+		Select("vendor_id").
+		From(db.SCHEMA_ITEMS).
+		Where(squirrel.Eq{"id": *listing.ItemId}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	creatorUid := ""
+	if err = tx.QueryRowContext(ctx, query, args...).Scan(&creatorUid); err != nil {
+		return nil, err
+	}
+
+	// debit the price from the buyers balance
+	tokenAmount := *listing.TokenAmount
+	query, args, err = psql.
+		Update(db.SCHEMA_TOKEN_BALANCE).
+		Set("balance", squirrel.Expr("balance - ?", tokenAmount)).
+		Where(squirrel.And{squirrel.Eq{"uid": buyerUid}, squirrel.GtOrEq{"balance": tokenAmount}}).
+		Suffix("RETURNING balance").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	newBalance := 0.0
+	if err = tx.QueryRowContext(ctx, query, args...).Scan(&newBalance); err != nil {
+		if err == sql.ErrNoRows {
+			err = &core.ErrorResp{Message: "User does not have sufficient token balance to buy this listing"}
+		}
+		return nil, err
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	platformFee, creatorRoyalty, sellerProceeds := core.MarketSaleSplit(tokenAmount)
+	settled := db.MARKET_SALE_SETTLED
+	sale := model.MarketSale{
+		ListingId:      &listingId,
+		BuyerUid:       &buyerUid,
+		SellerUid:      &sellerUid,
+		CreatorUid:     &creatorUid,
+		TokenAmount:    &tokenAmount,
+		PlatformFee:    &platformFee,
+		CreatorRoyalty: &creatorRoyalty,
+		SellerProceeds: &sellerProceeds,
+		Status:         &settled,
+		SettledAt:      &now,
+	}
+	query, args, err = psql.
+		Insert(db.SCHEMA_MARKET_SALES).
+		Columns(core.ModelColumns(sale)...).
+		Values(core.StructValues(sale)...).
+		Suffix("RETURNING \"id\"").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	saleId := uint64(0)
+	if err = tx.QueryRowContext(ctx, query, args...).Scan(&saleId); err != nil {
+		return nil, err
+	}
+	sale.ID = &saleId
+
+	// transfer ownership of the item to the buyer
+	query, args, err = psql.
+		Update(db.SCHEMA_USER_ITEMS).
+		SetMap(map[string]interface{}{"uid": buyerUid, "acquired_at": now}).
+		Where(squirrel.Eq{"id": *listing.UserItemId, "uid": sellerUid, "removed_at": nil}).
+		Where(unexpired("expired_at")).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected != 1 {
+		err = &MarketError{"listed item is no longer owned by the seller"}
+		return nil, err
+	}
+
+	notes := fmt.Sprintf("market listing %v", listingId)
+	transferEvent := &model.ItemEvent{UserItemId: listing.UserItemId, FromUid: &sellerUid, ToUid: &buyerUid, ActorUid: &buyerUid, Notes: &notes}
+	if err = addItemEvents(ctx, tx, db.ITEM_EVENT_TRANSFERRED, now, []*model.ItemEvent{transferEvent}); err != nil {
+		return nil, err
+	}
+
+	// ownership has moved, pay out the price
+	if err = creditTokenBalance(ctx, tx, sellerUid, sellerProceeds, now); err != nil {
+		return nil, err
+	}
+	if err = creditTokenBalance(ctx, tx, creatorUid, creatorRoyalty, now); err != nil {
+		return nil, err
+	}
+
+	buyerDebit := -tokenAmount
+	ledgerPurchase, ledgerProceeds, ledgerRoyalty, ledgerFee := db.TOKEN_LEDGER_MARKET_PURCHASE, db.TOKEN_LEDGER_MARKET_PROCEEDS, db.TOKEN_LEDGER_MARKET_ROYALTY, db.TOKEN_LEDGER_MARKET_FEE
+	ledgerEntries := []*model.TokenLedgerEntry{
+		{Uid: &buyerUid, EntryType: &ledgerPurchase, TokenAmount: &buyerDebit},
+		{Uid: &sellerUid, EntryType: &ledgerProceeds, TokenAmount: &sellerProceeds},
+		{Uid: &creatorUid, EntryType: &ledgerRoyalty, TokenAmount: &creatorRoyalty},
+		{EntryType: &ledgerFee, TokenAmount: &platformFee},
+	}
+	if err = addMarketSaleLedgerEntries(ctx, tx, saleId, ledgerEntries, now); err != nil {
+		return nil, err
+	}
+
+	query, args, err = psql.
+		Update(db.SCHEMA_MARKET_LISTINGS).
+		SetMap(map[string]interface{}{"status": db.MARKET_LISTING_SOLD, "buyer_uid": buyerUid, "sold_at": now}).
+		Where(squirrel.Eq{"id": listingId}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if err := r.cache.Del(c, db.KEY_USER_ITEM+fmt.Sprintf("%v", *listing.UserItemId)).Err(); err != nil {
+		return nil, err
+	}
+	if err := r.ClearMarketListingCache(c); err != nil {
+		return nil, err
+	}
+	return &model.MarketPurchaseResp{Sale: &sale, UserItemId: listing.UserItemId, NewBalance: newBalance}, nil
+}
+
+// records the token movements of a marketplace sale in the token ledger. entries without a uid are kept by the platform
+func addMarketSaleLedgerEntries(c context.Context, tx *sqlx.Tx, saleId uint64, entries []*model.TokenLedgerEntry, now string) error {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	ledgerInsert := psql.
+		Insert(db.SCHEMA_TOKEN_LEDGER).
+		Columns("uid", "entry_type", "token_amount", "market_sale_id", "created_at")
+	for _, entry := range entries {
+		ledgerInsert = ledgerInsert.Values(entry.Uid, *entry.EntryType, *entry.TokenAmount, saleId, now)
+	}
+
+	query, args, err := ledgerInsert.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(c, query, args...)
+	return err
+}
+
+// adds tokens to a users balance, creating the balance for users that have never held tokens
+func creditTokenBalance(c context.Context, tx *sqlx.Tx, uid string, tokenAmount float64, now string) error {
+	if tokenAmount <= 0 {
+		return nil
+	}
+	_, err := creditBalance(c, tx, db.SCHEMA_TOKEN_BALANCE, uid, tokenAmount, now)
+	return err
+}
+
+// adds an amount to a users row of a balance table, creating the row when the user has none yet,
+// and returns the new balance
+func creditBalance(c context.Context, tx *sqlx.Tx, table string, uid string, amount float64, now string) (float64, error) {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Update(table).
+		SetMap(map[string]interface{}{"balance": squirrel.Expr("balance + ?", amount), "updated_at": now}).
+		Where(squirrel.Eq{"uid": uid}).
+		Suffix("RETURNING balance").
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	newBalance := 0.0
+	err = tx.QueryRowContext(c, query, args...).Scan(&newBalance)
+	if err == nil {
+		return newBalance, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	query, args, err = psql.
+		Insert(table).
+		Columns("uid", "balance", "updated_at").
+		Values(uid, amount, now).
+		Suffix("RETURNING balance").
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	if err = tx.QueryRowContext(c, query, args...).Scan(&newBalance); err != nil {
+		return 0, err
+	}
+	return newBalance, nil
+}
+
+// takes down the active listings matching the where clause. used when a listed item leaves its sellers
+// collection or the seller is banned, returns whether any listing was removed
+func removeMarketListings(c context.Context, tx *sqlx.Tx, where squirrel.Eq, reason string, now string) (bool, error) {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Update(db.SCHEMA_MARKET_LISTINGS).
+		SetMap(map[string]interface{}{"status": db.MARKET_LISTING_REMOVED, "removal_reason": reason, "cancelled_at": now}).
+		Where(where).
+		Where(squirrel.Eq{"status": db.MARKET_LISTING_ACTIVE}).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+
+	result, err := tx.ExecContext(c, query, args...)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+func (r *MarketRepoImpl) ClearMarketListingCache(c context.Context) error {
+	return clearMarketListingCache(c, r.cache)
+}
+
+func clearMarketListingCache(c context.Context, cache *redis.Client) error {
+	keysToDelete := []string{
+		db.KEY_MARKET_LISTING + "*",
+		"/market/listings*",
+	}
+
+	allKeys := []string{}
+	for _, key := range keysToDelete {
+		keys, err := cache.Keys(c, key).Result()
+		if err != nil {
+			return err
+		}
+		allKeys = append(allKeys, keys...)
+	}
+
+	for _, key := range allKeys {
+		if err := cache.Del(c, key).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,402 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"xo-packs/db"
+	"xo-packs/model"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+)
+
+type NotificationRepository interface {
+	CreateNotifications(context.Context, []*model.Notification) ([]*model.Notification, error)
+	GetNotifications(context.Context, string, bool, int) ([]*model.Notification, int, error)
+	ReadNotifications(context.Context, string, []uint64) error
+	GetBigPulls(context.Context, string, int) ([]*model.BigPull, error)
+	GetBigPullOptIn(context.Context, string) (bool, error)
+	SetBigPullOptIn(context.Context, string, bool) error
+	GetWebhook(context.Context, string) (*model.CreatorWebhook, error)
+	SetWebhook(context.Context, string, string, string) (*model.CreatorWebhook, error)
+	DeleteWebhook(context.Context, string) error
+}
+
+type NotificationRepoImpl struct {
+	db    *sqlx.DB
+	cache *redis.Client
+}
+
+func NewNotificationRepo(db *sqlx.DB, cache *redis.Client) NotificationRepository {
+	return &NotificationRepoImpl{db: db, cache: cache}
+}
+
+var notificationColumns = []string{"id", "uid", "type", "actor_uid", "item_id", "pack_id", "pack_config_id", "message", "read_at", "created_at"}
+var webhookColumns = []string{"vendor_id", "url", "secret", "created_at", "updated_at"}
+
+func (r *NotificationRepoImpl) CreateNotifications(c context.Context, notifications []*model.Notification) ([]*model.Notification, error) {
+	if len(notifications) == 0 {
+		return []*model.Notification{}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	insertQuery := psql.
+		Insert(db.SCHEMA_NOTIFICATIONS).
+		Columns("uid", "type", "actor_uid", "item_id", "pack_id", "pack_config_id", "message", "created_at")
+	for _, notification := range notifications {
+		insertQuery = insertQuery.Values(notification.Uid, notification.Type, notification.ActorUid, notification.ItemId, notification.PackId, notification.PackConfigId, notification.Message, now)
+	}
+	query, args, err := insertQuery.Suffix("RETURNING " + strings.Join(notificationColumns, ", ")).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	created := []*model.Notification{}
+	recipients := map[string]bool{}
+	defer rows.Close()
+	for rows.Next() {
+		notification := model.Notification{}
+		if err := rows.StructScan(&notification); err != nil {
+			return nil, err
+		}
+		created = append(created, &notification)
+		recipients[*notification.Uid] = true
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// new pulls show up on the recipients big pull feed
+	for uid := range recipients {
+		if err := r.cache.Del(c, db.KEY_BIG_PULLS+uid).Err(); err != nil {
+			return nil, err
+		}
+	}
+	return created, nil
+}
+
+// the newest notifications of a user along with how many they have not read yet
+func (r *NotificationRepoImpl) GetNotifications(c context.Context, uid string, unreadOnly bool, limit int) ([]*model.Notification, int, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	where := squirrel.And{squirrel.Eq{"uid": uid}}
+	if unreadOnly {
+		where = append(where, squirrel.Eq{"read_at": nil})
+	}
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select(notificationColumns...).
+		From(db.SCHEMA_NOTIFICATIONS).
+		Where(where).
+		OrderBy("created_at desc", "id desc").
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	notifications := []*model.Notification{}
+	defer rows.Close()
+	for rows.Next() {
+		notification := model.Notification{}
+		if err := rows.StructScan(&notification); err != nil {
+			return nil, 0, err
+		}
+		notifications = append(notifications, &notification)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	query, args, err = psql.
+		Select("count(*)").
+		From(db.SCHEMA_NOTIFICATIONS).
+		Where(squirrel.Eq{"uid": uid, "read_at": nil}).
+		ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var unreadAmount int
+	if err = r.db.QueryRowxContext(ctx, query, args...).Scan(&unreadAmount); err != nil {
+		return nil, 0, err
+	}
+	return notifications, unreadAmount, nil
+}
+
+// marks the given notifications of a user read, or all of them when no ids are given
+func (r *NotificationRepoImpl) ReadNotifications(c context.Context, uid string, notificationIds []uint64) error {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	where := squirrel.Eq{"uid": uid, "read_at": nil}
+	if len(notificationIds) > 0 {
+		where["id"] = notificationIds
+	}
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Update(db.SCHEMA_NOTIFICATIONS).
+		Set("read_at", time.Now().Format("2006-01-02 15:04:05")).
+		Where(where).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// the latest notify item pulls of a creators fans, only fans that opted in are shown
+func (r *NotificationRepoImpl) GetBigPulls(c context.Context, vendorId string, limit int) ([]*model.BigPull, error) {
+	val, err := r.cache.Get(c, db.KEY_BIG_PULLS+vendorId).Result()
+	if err != nil {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+		query, args, err := psql.
+			Select(
+				"n.id as notification_id",
+				"u.uid",
+				"u.username",
+				"u.display_name",
+				"u.image_url as user_image_url",
+				"i.id as item_id",
+				"i.name as item_name",
+				"i.image_url as item_image_url",
+				"i.rarity_id",
+				"r.rarity",
+				"n.created_at as pulled_at",
+			).
+			From(db.SCHEMA_NOTIFICATIONS+" n").
+			Join(db.SCHEMA_BIG_PULL_OPT_INS+" o on o.uid = n.actor_uid").
+			Join(db.SCHEMA_USERS+" u on u.uid = n.actor_uid").
+			Join(db.SCHEMA_ITEMS+" i on i.id = n.item_id").
+			LeftJoin(db.SCHEMA_RARITY+" r on r.id = i.rarity_id").
+			Where(squirrel.Eq{"n.uid": vendorId, "n.type": db.NOTIFICATION_NOTIFY_PULL, "u.deleted_at": nil}).
+			OrderBy("n.created_at desc", "n.id desc").
+			Limit(uint64(limit)).
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+
+		rows, err := r.db.QueryxContext(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+
+		bigPulls := []*model.BigPull{}
+		defer rows.Close()
+		for rows.Next() {
+			bigPull := model.BigPull{}
+			if err := rows.StructScan(&bigPull); err != nil {
+				return nil, err
+			}
+			bigPulls = append(bigPulls, &bigPull)
+		}
+
+		bigPullsBytes, err := json.Marshal(bigPulls)
+		if err != nil {
+			return nil, err
+		}
+		if err = r.cache.Set(c, db.KEY_BIG_PULLS+vendorId, bigPullsBytes, time.Duration(3600)*time.Second).Err(); err != nil {
+			return nil, err
+		}
+		return bigPulls, nil
+	} else {
+		bigPulls := []*model.BigPull{}
+		if err = json.Unmarshal([]byte(val), &bigPulls); err != nil {
+			return nil, err
+		}
+		return bigPulls, nil
+	}
+}
+
+func (r *NotificationRepoImpl) GetBigPullOptIn(c context.Context, uid string) (bool, error) {
+	val, err := r.cache.Get(c, db.KEY_BIG_PULL_OPT_IN+uid).Result()
+	if err == nil {
+		return val == "true", nil
+	}
+
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("count(*)").
+		From(db.SCHEMA_BIG_PULL_OPT_INS).
+		Where(squirrel.Eq{"uid": uid}).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+
+	var optIns int
+	if err = r.db.QueryRowxContext(ctx, query, args...).Scan(&optIns); err != nil {
+		return false, err
+	}
+
+	optedIn := optIns > 0
+	if err = r.cache.Set(c, db.KEY_BIG_PULL_OPT_IN+uid, fmt.Sprintf("%v", optedIn), time.Duration(3600)*time.Second).Err(); err != nil {
+		return false, err
+	}
+	return optedIn, nil
+}
+
+// opting in or out changes which pulls every creators feed shows, so all feeds are cleared
+func (r *NotificationRepoImpl) SetBigPullOptIn(c context.Context, uid string, optIn bool) error {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	var query string
+	var args []interface{}
+	var err error
+	if optIn {
+		query, args, err = psql.
+			Insert(db.SCHEMA_BIG_PULL_OPT_INS).
+			Columns("uid", "created_at").
+			Values(uid, time.Now().Format("2006-01-02 15:04:05")).
+			Suffix("ON CONFLICT (uid) DO NOTHING").
+			ToSql()
+	} else {
+		query, args, err = psql.
+			Delete(db.SCHEMA_BIG_PULL_OPT_INS).
+			Where(squirrel.Eq{"uid": uid}).
+			ToSql()
+	}
+	if err != nil {
+		return err
+	}
+	if _, err = r.db.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+
+	keys, err := r.cache.Keys(c, db.KEY_BIG_PULLS+"*").Result()
+	if err != nil {
+		return err
+	}
+	keys = append(keys, db.KEY_BIG_PULL_OPT_IN+uid)
+	for _, key := range keys {
+		if err := r.cache.Del(c, key).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// the webhook a creator is called on for notify item pulls, nil when they have not set one
+func (r *NotificationRepoImpl) GetWebhook(c context.Context, vendorId string) (*model.CreatorWebhook, error) {
+	val, err := r.cache.Get(c, db.KEY_CREATOR_WEBHOOK+vendorId).Result()
+	if err != nil {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+		query, args, err := psql.
+			Select(webhookColumns...).
+			From(db.SCHEMA_CREATOR_WEBHOOKS).
+			Where(squirrel.Eq{"vendor_id": vendorId}).
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+
+		webhook := model.CreatorWebhook{}
+		if err = r.db.QueryRowxContext(ctx, query, args...).StructScan(&webhook); err != nil {
+			if err != sql.ErrNoRows {
+				return nil, err
+			}
+		}
+
+		webhookBytes, err := json.Marshal(webhook)
+		if err != nil {
+			return nil, err
+		}
+		if err = r.cache.Set(c, db.KEY_CREATOR_WEBHOOK+vendorId, webhookBytes, time.Duration(3600)*time.Second).Err(); err != nil {
+			return nil, err
+		}
+		if webhook.Url == nil {
+			return nil, nil
+		}
+		return &webhook, nil
+	} else {
+		webhook := model.CreatorWebhook{}
+		if err = json.Unmarshal([]byte(val), &webhook); err != nil {
+			return nil, err
+		}
+		if webhook.Url == nil {
+			return nil, nil
+		}
+		return &webhook, nil
+	}
+}
+
+func (r *NotificationRepoImpl) SetWebhook(c context.Context, vendorId string, url string, secret string) (*model.CreatorWebhook, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Insert(db.SCHEMA_CREATOR_WEBHOOKS).
+		Columns("vendor_id", "url", "secret", "created_at", "updated_at").
+		Values(vendorId, url, secret, now, now).
+		Suffix("ON CONFLICT (vendor_id) DO UPDATE SET url = excluded.url, secret = excluded.secret, updated_at = excluded.updated_at RETURNING " + strings.Join(webhookColumns, ", ")).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	webhook := model.CreatorWebhook{}
+	if err = r.db.QueryRowxContext(ctx, query, args...).StructScan(&webhook); err != nil {
+		return nil, err
+	}
+
+	if err = r.cache.Del(c, db.KEY_CREATOR_WEBHOOK+vendorId).Err(); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (r *NotificationRepoImpl) DeleteWebhook(c context.Context, vendorId string) error {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Delete(db.SCHEMA_CREATOR_WEBHOOKS).
+		Where(squirrel.Eq{"vendor_id": vendorId}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err = r.db.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+	return r.cache.Del(c, db.KEY_CREATOR_WEBHOOK+vendorId).Err()
+}
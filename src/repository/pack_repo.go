@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"time"
 	"xo-packs/core"
 	"xo-packs/db"
@@ -15,24 +16,52 @@ import (
 
 	"github.com/Masterminds/squirrel"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type PackRepository interface {
 	CreatePackConfig(context.Context, *model.PackConfig) (*model.PackConfig, error)
 	AddPackItemConfigs(context.Context, []*model.PackItemConfig) error
-	BuyPacks(context.Context, string, *model.PackConfig, float64, *model.TokenCurrencyRate) (*model.PackBoughtResp, error)
+	ClonePackConfig(context.Context, uint64, int, *model.PackConfig) (*model.PackConfig, error)
+	BuyPacks(context.Context, string, *model.PackConfig, float64, *model.TokenCurrencyRate, *model.PackGift) (*model.PackBoughtResp, error)
+	GetReceivedGifts(context.Context, string) ([]*model.ReceivedGift, error)
+	JoinPackWaitlist(context.Context, string, uint64) (*model.PackWaitlistEntry, error)
+	LeavePackWaitlist(context.Context, string, uint64) error
+	GetPackWaitlist(context.Context, uint64) ([]*model.WaitlistedUser, error)
+	MarkPackWaitlistNotified(context.Context, uint64, []uint64) error
+	ClearPackWaitlistSizeCache(context.Context, string) error
+	ClearGiftCache(context.Context, string) error
 	OpenPack(context.Context, uint64, string) (*model.Pack, error)
+	OpenPacks(context.Context, string, []uint64, *uint64, uint64) ([]*model.Pack, error)
+	StartPackReveal(context.Context, uint64, string) (*model.PackRevealSession, error)
+	GetPackRevealSession(context.Context, uint64) (*model.PackRevealSession, error)
+	GetPackRevealSessionByPack(context.Context, uint64) (*model.PackRevealSession, error)
+	GetPackRevealSlots(context.Context, uint64) ([]*model.PackRevealSlotExpanded, error)
+	RevealPackSlot(context.Context, uint64, string, int) error
+	CompleteExpiredPackReveals(context.Context, string, uint64) ([]string, error)
 	GetPack(context.Context, uint64) (*model.Pack, error)
 	GetUserPackAmount(context.Context, string) (*uint64, error)
 	GetPackConfig(context.Context, uint64) (*model.PackConfig, error)
 	GetPackItems(context.Context, uint64) ([]*model.PackItemConfigExpanded, error)
 	AddPackCategories(context.Context, []*model.PackCategory) error
 	GetPackItemConfigs(context.Context, uint64) ([]*model.PackItemConfig, error)
+	GetPackPriceTiers(context.Context, uint64) ([]*model.PackPriceTier, error)
+	SetPackPriceTiers(context.Context, uint64, string, []*model.PackPriceTier) error
 	GetActivePackItems(context.Context, []uint64) ([]string, []string, error)
 	GetPacksContainingItems(context.Context, []uint64) ([]string, []string, error)
 	GetPackItemsPreview(context.Context, uint64) ([]*model.PackItemPreview, error)
 	UploadPacks(context.Context, []*model.PackFact, uint64) ([]uint64, error)
 	UploadPackItems(context.Context, []*model.PackItemFact) error
+	UploadPackChunk(context.Context, []*model.PackFact, [][]uint64) ([]uint64, error)
+	ActivateGeneratedPacks(context.Context, uint64) error
+	DeleteGeneratedPacks(context.Context, []uint64) error
+	CreatePackGenerationJob(context.Context, *model.PackGenerationJob) (*model.PackGenerationJob, error)
+	UpdatePackGenerationJob(context.Context, uint64, map[string]interface{}) error
+	GetPackGenerationJob(context.Context, uint64) (*model.PackGenerationJob, error)
+	GetActivePackGenerationJob(context.Context, uint64) (*model.PackGenerationJob, error)
+	ClaimPackGenerationJob(context.Context) (*model.PackGenerationJob, error)
+	GetStalePackGenerationJobs(context.Context, string) ([]*model.PackGenerationJob, error)
+	GetGeneratedPackIds(context.Context, uint64) ([]uint64, error)
 	UpdateVendorPackAmount(context.Context, string, int, *sqlx.Tx) error
 	PatchPackConfig(context.Context, uint64, map[string]interface{}, string) error
 	ClearPackConfigCache(context.Context, []uint64, string) error
@@ -42,9 +71,14 @@ type PackRepository interface {
 	ClearPackCache(context.Context, uint64) error
 	ClearPackShopCache(context.Context) error
 	ActivatePacks(context.Context, []uint64, string) error
+	ReleaseScheduledPackConfigs(context.Context, string) ([]*model.PackConfig, error)
+	SubmitPackConfigs(context.Context, []uint64, string) error
+	WithdrawPackReview(context.Context, uint64, string) (bool, error)
+	ReviewPackConfig(context.Context, uint64, string, *string, string) error
+	GetPackConfigsByReviewStatus(context.Context, string) ([]*model.PackConfig, error)
 	DeactivatePacks(context.Context, []uint64, string) error
 	DeletePackConfigs(context.Context, []uint64, string) error
-	GeneratePackItemOdds(context.Context, []model.Item, int) (map[uint64]int, error)
+	GeneratePackItemOdds(context.Context, []model.Item, int, map[uint64]int) (map[uint64]int, error)
 }
 
 type PackRepoImpl struct {
@@ -155,12 +189,15 @@ func (r *PackRepoImpl) AddPackItemConfigs(c context.Context, packItemConfigs []*
 	return err
 }
 
-// function that associates x amount of pack facts with a new owner and updates the current stock of packs
-func (r *PackRepoImpl) BuyPacks(c context.Context, uid string, packConfig *model.PackConfig, amount float64, activeTokenRate *model.TokenCurrencyRate) (*model.PackBoughtResp, error) {
+// inserts the given pack config and copies the item configs, categories and price tiers of the source pack config
+// onto it. every item referenced by the source pack must still belong to the vendor and not be deleted. item config
+// quantities are scaled from the sourceQty packs of the source to the qty of the copy, and the copied price tiers
+// must still be discounts on the price of the copy
+func (r *PackRepoImpl) ClonePackConfig(c context.Context, sourceId uint64, sourceQty int, packConfig *model.PackConfig) (*model.PackConfig, error) {
 	ctx, cancel := context.WithTimeout(c, 5*time.Second)
 	defer cancel()
 
-	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
 	if err != nil {
 		return nil, err
 	}
@@ -173,12 +210,11 @@ func (r *PackRepoImpl) BuyPacks(c context.Context, uid string, packConfig *model
 		}
 	}()
 
-	// validate user has enough tokens to purchase amount of packs
 	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 	query, args, err := psql.
-		Select(fmt.Sprintf("t.balance >= %v*p.token_amount, p.token_amount, t.balance", amount)).
-		From("financial.token_balance t").
-		Join(fmt.Sprintf("main.pack_configs p on t.uid = '%v' and p.id = %v", uid, *packConfig.ID)).
+		Select("item_id", "qty").
+		From(db.SCHEMA_PACK_ITEM_CONFIGS).
+		Where(squirrel.Eq{"pack_config_id": sourceId, "removed_at": nil}).
 		ToSql()
 	if err != nil {
 		return nil, err
@@ -189,33 +225,259 @@ func (r *PackRepoImpl) BuyPacks(c context.Context, uid string, packConfig *model
 		return nil, err
 	}
 
-	hasEnough := false
-	tokenAmount := 0.0
-	currBalance := 0.0
-	defer rows.Close()
+	packItemConfigs := []*model.PackItemConfig{}
+	itemIds := []uint64{}
+	for rows.Next() {
+		packItemConfig := model.PackItemConfig{}
+		if err = rows.StructScan(&packItemConfig); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		packItemConfigs = append(packItemConfigs, &packItemConfig)
+		itemIds = append(itemIds, *packItemConfig.ItemID)
+	}
+	rows.Close()
+
+	// checking the referenced items are still valid for the vendor
+	if len(itemIds) > 0 {
+		query, args, err = psql.
+			Select("id").
+			From(db.SCHEMA_ITEMS).
+			Where(squirrel.Eq{"id": itemIds, "vendor_id": *packConfig.VendorID, "deleted_at": nil}).
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+
+		rows, err = tx.QueryxContext(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+
+		validItemIds := map[uint64]bool{}
+		for rows.Next() {
+			var itemId uint64
+			if err = rows.Scan(&itemId); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			validItemIds[itemId] = true
+		}
+		rows.Close()
+
+		for _, itemId := range itemIds {
+			if !validItemIds[itemId] {
+				err = &PackError{fmt.Sprintf("item %v no longer belongs to the vendor or has been deleted", itemId)}
+				return nil, err
+			}
+		}
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	packConfig.CreatedAt = &now
+	query, args, err = psql.
+		Insert(db.SCHEMA_PACK_CONFIGS).
+		Columns(core.ModelColumns(packConfig)...).
+		Values(core.StructValues(packConfig)...).
+		Suffix("RETURNING \"id\"").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	insertedId := uint64(0)
+	if err = tx.QueryRowContext(ctx, query, args...).Scan(&insertedId); err != nil {
+		return nil, err
+	}
+
+	if len(packItemConfigs) > 0 {
+		itemConfigQuery := psql.
+			Insert(db.SCHEMA_PACK_ITEM_CONFIGS).
+			Columns("pack_config_id", "item_id", "qty", "created_at")
+		for _, packItemConfig := range packItemConfigs {
+			itemQty := packItemConfig.Qty
+			if itemQty != nil && packConfig.Qty != nil {
+				scaledQty := core.ScalePackItemQty(*itemQty, sourceQty, *packConfig.Qty)
+				itemQty = &scaledQty
+			}
+			itemConfigQuery = itemConfigQuery.Values(insertedId, *packItemConfig.ItemID, itemQty, now)
+		}
+		query, args, err = itemConfigQuery.ToSql()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+			return nil, err
+		}
+	}
+
+	categoryQuery := psql.
+		Select().
+		Column("CAST(? AS bigint)", insertedId).
+		Column("category_id").
+		From(db.SCHEMA_PACK_CATEGORIES).
+		Where(squirrel.Eq{"pack_config_id": sourceId})
+	query, args, err = psql.
+		Insert(db.SCHEMA_PACK_CATEGORIES).
+		Columns("pack_config_id", "category_id").
+		Select(categoryQuery).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, err
+	}
+
+	query, args, err = psql.
+		Select("min_qty", "pack_token_amount", "total_token_amount").
+		From(db.SCHEMA_PACK_PRICE_TIERS).
+		Where(squirrel.Eq{"pack_config_id": sourceId}).
+		OrderBy("min_qty").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	priceTiers := []*model.PackPriceTier{}
 	for rows.Next() {
-		if err := rows.Scan(&hasEnough, &tokenAmount, &currBalance); err != nil {
+		priceTier := model.PackPriceTier{}
+		if err = rows.StructScan(&priceTier); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		priceTiers = append(priceTiers, &priceTier)
+	}
+	rows.Close()
+
+	if len(priceTiers) > 0 {
+		if err = core.ValidatePackPriceTiers(priceTiers, *packConfig.TokenAmount); err != nil {
+			return nil, err
+		}
+
+		priceTierQuery := psql.
+			Insert(db.SCHEMA_PACK_PRICE_TIERS).
+			Columns("pack_config_id", "min_qty", "pack_token_amount", "total_token_amount", "created_at")
+		for _, priceTier := range priceTiers {
+			priceTierQuery = priceTierQuery.Values(insertedId, *priceTier.MinQty, priceTier.PackTokenAmount, priceTier.TotalTokenAmount, now)
+		}
+		query, args, err = priceTierQuery.ToSql()
+		if err != nil {
 			return nil, err
 		}
-		if !hasEnough {
-			err = &core.ErrorResp{Message: "User does not have sufficient token balance to buy this amount of packs"}
+
+		if _, err = tx.ExecContext(ctx, query, args...); err != nil {
 			return nil, err
 		}
 	}
 
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return r.GetPackConfig(c, insertedId)
+}
+
+// function that associates x amount of pack facts with a new owner and updates the current stock of packs.
+// when a gift is given the buyer pays and the packs are owned by the gift recipient
+func (r *PackRepoImpl) BuyPacks(c context.Context, uid string, packConfig *model.PackConfig, amount float64, activeTokenRate *model.TokenCurrencyRate, gift *model.PackGift) (*model.PackBoughtResp, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	// only approved packs that are on sale can be bought, and they are priced using the pack config price tiers
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("token_amount").
+		From(db.SCHEMA_PACK_CONFIGS).
+		Where(squirrel.Eq{"id": *packConfig.ID, "active": true, "review_status": db.PACK_REVIEW_APPROVED}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	baseTokenAmount := 0.0
+	if err = tx.QueryRowContext(ctx, query, args...).Scan(&baseTokenAmount); err != nil {
+		if err == sql.ErrNoRows {
+			err = &core.ErrorResp{Message: "this pack is not on sale"}
+		}
+		return nil, err
+	}
+
+	priceTiers, err := r.getPackPriceTiers(ctx, *packConfig.ID, tx)
+	if err != nil {
+		return nil, err
+	}
+	totalTokenAmount := core.TieredPackPrice(int(amount), baseTokenAmount, priceTiers)
+
+	// the buyer and the gift recipient must both stay within their daily spending limits
+	limitUids := []string{uid}
+	if gift != nil {
+		limitUids = append(limitUids, *gift.RecipientUid)
+	}
+	exceededUid, err := spendingLimitExceeded(ctx, tx, limitUids, totalTokenAmount)
+	if err != nil {
+		return nil, err
+	}
+	if exceededUid == uid {
+		err = &core.ErrorResp{Message: "purchase would exceed your daily spending limit"}
+		return nil, err
+	}
+	if exceededUid != "" {
+		err = &core.ErrorResp{Message: "gift would exceed the recipients daily spending limit"}
+		return nil, err
+	}
+
+	// validate user has enough tokens to purchase amount of packs
+	query, args, err = psql.
+		Select("balance").
+		From(db.SCHEMA_TOKEN_BALANCE).
+		Where(squirrel.Eq{"uid": uid}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	currBalance := 0.0
+	if err = tx.QueryRowContext(ctx, query, args...).Scan(&currBalance); err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if currBalance < totalTokenAmount {
+		err = &core.ErrorResp{Message: "User does not have sufficient token balance to buy this amount of packs"}
+		return nil, err
+	}
+
 	// get list of available packs
-	psql = squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 	query, args, err = psql.
 		Select("id").
 		From(db.SCHEMA_PACK_FACTS).
-		Where(squirrel.And{squirrel.Eq{"pack_config_id": *packConfig.ID}, squirrel.Eq{"owner_id": nil}}).
+		Where(squirrel.And{squirrel.Eq{"pack_config_id": *packConfig.ID}, squirrel.Eq{"owner_id": nil}, squirrel.Eq{"active": true}}).
 		Limit(uint64(amount)).
 		ToSql()
 	if err != nil {
 		return nil, err
 	}
 
-	rows, err = tx.QueryxContext(ctx, query, args...)
+	rows, err := tx.QueryxContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -240,9 +502,13 @@ func (r *PackRepoImpl) BuyPacks(c context.Context, uid string, packConfig *model
 		}
 	}
 
-	// associate packs with the user as the new owner
+	// associate packs with the user, or the gift recipient, as the new owner
+	ownerId := uid
+	if gift != nil {
+		ownerId = *gift.RecipientUid
+	}
 	now := time.Now().Format("2006-01-02 15:04:05")
-	purchaseMap := map[string]interface{}{"owner_id": uid, "purchased_at": now}
+	purchaseMap := map[string]interface{}{"owner_id": ownerId, "purchased_at": now}
 	psql = squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 	query, args, err = psql.
 		Update(db.SCHEMA_PACK_FACTS).
@@ -272,18 +538,8 @@ func (r *PackRepoImpl) BuyPacks(c context.Context, uid string, packConfig *model
 		}
 	}
 
-	packOrders := make([]model.PackOrder, len(packIds))
-	for i, id := range packIds {
-		packId := id
-		packOrder := model.PackOrder{
-			PackId:      &packId,
-			Uid:         &uid,
-			OrderedAt:   &now,
-			TokenAmount: &tokenAmount,
-			TokenRateId: activeTokenRate.ID,
-		}
-		packOrders[i] = packOrder
-	}
+	// every order records the per pack price and the orders add up to exactly what the user is charged
+	packOrders := core.NewPackOrders(uid, packIds, totalTokenAmount, activeTokenRate.ID, now)
 	packOrderQuery := psql.
 		Insert(db.SCHEMA_PACK_ORDERS).
 		Columns(core.ModelColumns(packOrders[0])...)
@@ -301,8 +557,32 @@ func (r *PackRepoImpl) BuyPacks(c context.Context, uid string, packConfig *model
 		return nil, err
 	}
 
+	// record the gift
+	if gift != nil {
+		packAmount := len(packIds)
+		gift.SenderUid = &uid
+		gift.PackConfigId = packConfig.ID
+		gift.PackAmount = &packAmount
+		gift.CreatedAt = &now
+		query, args, err = psql.
+			Insert(db.SCHEMA_PACK_GIFTS).
+			Columns(core.ModelColumns(gift)...).
+			Values(core.StructValues(gift)...).
+			Suffix("RETURNING \"id\"").
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+
+		giftId := uint64(0)
+		if err = tx.QueryRowContext(ctx, query, args...).Scan(&giftId); err != nil {
+			return nil, err
+		}
+		gift.ID = &giftId
+	}
+
 	// update the users token balance
-	newBalance := currBalance - (amount * tokenAmount)
+	newBalance := core.RoundTokenAmount(currBalance - totalTokenAmount)
 	psql = squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 	query, args, err = psql.
 		Update(db.SCHEMA_TOKEN_BALANCE).
@@ -318,53 +598,111 @@ func (r *PackRepoImpl) BuyPacks(c context.Context, uid string, packConfig *model
 		return nil, err
 	}
 
-	if err := tx.Commit(); err != nil {
+	// the new owner of the packs no longer needs to wait for this pack
+	query, args, err = psql.
+		Delete(db.SCHEMA_PACK_WAITLIST).
+		Where(squirrel.Eq{"uid": ownerId, "pack_config_id": *packConfig.ID}).
+		ToSql()
+	if err != nil {
 		return nil, err
 	}
-	return &model.PackBoughtResp{PackIds: packIds, NewBalance: newBalance}, nil
-}
 
-func (r *PackRepoImpl) AddPackOrder(c context.Context, now string, uid string, packConfig *model.PackConfig, packIds []uint64, tokenRateId uint64, tx *sqlx.Tx) error {
-	if packConfig.TokenAmount == nil {
-		return &core.ErrorResp{
-			Message: "critical error: pack config does not have token amount",
-		}
+	waitlistResult, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
 	}
-	packOrders := make([]model.PackOrder, len(packIds))
-	tokenAmount := *packConfig.TokenAmount
-	for i, id := range packIds {
-		packId := id
-		packOrder := model.PackOrder{
-			PackId:      &packId,
-			Uid:         &uid,
-			OrderedAt:   &now,
-			TokenAmount: &tokenAmount,
-			TokenRateId: &tokenRateId,
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if removed, _ := waitlistResult.RowsAffected(); removed > 0 && packConfig.VendorID != nil {
+		if err := r.ClearPackWaitlistSizeCache(c, *packConfig.VendorID); err != nil {
+			fmt.Println(err)
 		}
-		packOrders[i] = packOrder
 	}
-	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
-	query := psql.
-		Insert(db.SCHEMA_PACK_ORDERS).
-		Columns(core.ModelColumns(packOrders[0])...)
+	return &model.PackBoughtResp{PackIds: packIds, NewBalance: newBalance, TotalTokenAmount: totalTokenAmount, Gift: gift}, nil
+}
 
-	for _, packOrder := range packOrders {
-		query = query.Values(core.StructValues(packOrder)...)
+// returns the first of the uids whose daily spending limit the token amount would exceed. packs a user
+// ordered and packs gifted to them both count towards their limit. the limit rows are locked and stamped
+// so a concurrent purchase against the same limit fails instead of reading spend from before this one
+func spendingLimitExceeded(c context.Context, tx *sqlx.Tx, uids []string, tokenAmount float64) (string, error) {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("uid", "daily_token_limit").
+		From(db.SCHEMA_USER_SPENDING_LIMITS).
+		Where(squirrel.Eq{"uid": uids}).
+		OrderBy("uid").
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return "", err
 	}
-	queryStr, args, err := query.ToSql()
+
+	rows, err := tx.QueryxContext(c, query, args...)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	_, err = tx.ExecContext(c, queryStr, args...)
+	limits := map[string]float64{}
+	defer rows.Close()
+	for rows.Next() {
+		limitUid := ""
+		dailyTokenLimit := 0.0
+		if err := rows.Scan(&limitUid, &dailyTokenLimit); err != nil {
+			return "", err
+		}
+		limits[limitUid] = dailyTokenLimit
+	}
+	if len(limits) == 0 {
+		return "", nil
+	}
+
+	now := time.Now()
+	since := now.Add(-core.SPENDING_LIMIT_WINDOW_HOURS * time.Hour).Format("2006-01-02 15:04:05")
+	for _, limitUid := range uids {
+		dailyTokenLimit, ok := limits[limitUid]
+		if !ok {
+			continue
+		}
+
+		query, args, err = psql.
+			Select("coalesce(sum(o.token_amount + coalesce(o.rounding_adjustment, 0)), 0)").
+			From(db.SCHEMA_PACK_ORDERS + " o").
+			Join(db.SCHEMA_PACK_FACTS + " p ON p.id = o.pack_id").
+			Where(squirrel.GtOrEq{"o.ordered_at": since}).
+			Where(squirrel.Or{squirrel.Eq{"o.uid": limitUid}, squirrel.Eq{"p.owner_id": limitUid}}).
+			ToSql()
+		if err != nil {
+			return "", err
+		}
+
+		spent := 0.0
+		if err = tx.QueryRowContext(c, query, args...).Scan(&spent); err != nil {
+			return "", err
+		}
+		if spent+tokenAmount > dailyTokenLimit {
+			return limitUid, nil
+		}
+	}
+
+	query, args, err = psql.
+		Update(db.SCHEMA_USER_SPENDING_LIMITS).
+		Set("last_spent_at", now.Format("2006-01-02 15:04:05")).
+		Where(squirrel.Eq{"uid": uids}).
+		ToSql()
 	if err != nil {
-		return nil
+		return "", err
 	}
 
-	return nil
+	_, err = tx.ExecContext(c, query, args...)
+	return "", err
 }
 
-func (r *PackRepoImpl) OpenPack(c context.Context, packId uint64, uid string) (*model.Pack, error) {
+// adds the user to the waitlist of a sold out or upcoming pack. joining again after a
+// restock notification puts the user back on the list
+func (r *PackRepoImpl) JoinPackWaitlist(c context.Context, uid string, packConfigId uint64) (*model.PackWaitlistEntry, error) {
 	ctx, cancel := context.WithTimeout(c, 5*time.Second)
 	defer cancel()
 
@@ -381,307 +719,2336 @@ func (r *PackRepoImpl) OpenPack(c context.Context, packId uint64, uid string) (*
 		}
 	}()
 
-	now := time.Now().Format("2006-01-02 15:04:05")
 	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 	query, args, err := psql.
-		Update(db.SCHEMA_PACK_FACTS).
-		Set("opened_at", now).
-		Where(squirrel.Eq{"id": packId}).
+		Select("id", "vendor_id", "active", "current_stock", "deleted_at").
+		From(db.SCHEMA_PACK_CONFIGS).
+		Where(squirrel.Eq{"id": packConfigId}).
 		ToSql()
 	if err != nil {
 		return nil, err
 	}
 
-	rows, err := tx.ExecContext(ctx, query, args...)
-	if err != nil {
+	packConfig := model.PackConfig{}
+	if err = tx.QueryRowxContext(ctx, query, args...).StructScan(&packConfig); err != nil {
+		if err == sql.ErrNoRows {
+			err = &PackError{"pack does not exist"}
+		}
+		return nil, err
+	}
+	if packConfig.DeletedAt != nil {
+		err = &PackError{"this pack has been discontinued"}
 		return nil, err
 	}
 
-	rowsAffected, err := rows.RowsAffected()
-	if rowsAffected > 0 {
-		if err = tx.Commit(); err != nil {
-			return nil, err
-		}
+	soldOut := packConfig.CurrentStock == nil || *packConfig.CurrentStock <= 0
+	upcoming := packConfig.Active == nil || !*packConfig.Active
+	if !soldOut && !upcoming {
+		err = &PackError{"this pack is available to buy"}
+		return nil, err
+	}
 
-		// remove cached user packs
-		keys, err := r.cache.Keys(c, fmt.Sprintf("/user/packs/%v*", uid)).Result()
-		if err != nil {
-			return nil, err
-		}
-		for _, key := range keys {
-			if err = r.cache.Del(c, key).Err(); err != nil {
-				return nil, err
+	now := time.Now().Format("2006-01-02 15:04:05")
+	entry := model.PackWaitlistEntry{Uid: &uid, PackConfigId: &packConfigId, CreatedAt: &now}
+	query, args, err = psql.
+		Insert(db.SCHEMA_PACK_WAITLIST).
+		Columns(core.ModelColumns(entry)...).
+		Values(core.StructValues(entry)...).
+		Suffix("ON CONFLICT (uid, pack_config_id) DO UPDATE SET notified_at = NULL RETURNING \"id\"").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	entryId := uint64(0)
+	if err = tx.QueryRowContext(ctx, query, args...).Scan(&entryId); err != nil {
+		return nil, err
+	}
+	entry.ID = &entryId
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if err := r.ClearPackWaitlistSizeCache(c, *packConfig.VendorID); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *PackRepoImpl) LeavePackWaitlist(c context.Context, uid string, packConfigId uint64) error {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
 			}
 		}
+	}()
 
-		pack, err := r.GetPack(c, packId)
-		if err != nil {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Delete(db.SCHEMA_PACK_WAITLIST).
+		Where(squirrel.Eq{"uid": uid, "pack_config_id": packConfigId}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		err = &PackError{"user is not on the waitlist of this pack"}
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// gets the users on a pack waitlist that have not been notified yet
+func (r *PackRepoImpl) GetPackWaitlist(c context.Context, packConfigId uint64) ([]*model.WaitlistedUser, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err = tx.Commit(); err != nil {
+			fmt.Println(err)
+		}
+	}()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("w.id", "w.uid", "u.username", "u.email").
+		From(db.SCHEMA_PACK_WAITLIST + " w").
+		Join(db.SCHEMA_USERS + " u on u.uid = w.uid and u.active = true").
+		Where(squirrel.Eq{"w.pack_config_id": packConfigId, "w.notified_at": nil}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	waitlistedUsers := []*model.WaitlistedUser{}
+	defer rows.Close()
+	for rows.Next() {
+		waitlistedUser := model.WaitlistedUser{}
+		if err := rows.StructScan(&waitlistedUser); err != nil {
 			return nil, err
 		}
-		return pack, err
+		waitlistedUsers = append(waitlistedUsers, &waitlistedUser)
 	}
-	err = &core.ErrorResp{Message: "Pack fact record does not exist"}
-	return nil, err
+	return waitlistedUsers, nil
 }
 
-func (r *PackRepoImpl) GetPack(c context.Context, id uint64) (*model.Pack, error) {
-	val, err := r.cache.Get(c, db.KEY_PACK+fmt.Sprintf("%v", id)).Result()
+func (r *PackRepoImpl) MarkPackWaitlistNotified(c context.Context, packConfigId uint64, ids []uint64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
 	if err != nil {
-		ctx, cancel := context.WithTimeout(c, 5*time.Second)
-		defer cancel()
+		return err
+	}
 
-		tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	defer func() {
 		if err != nil {
-			return nil, err
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
 		}
+	}()
 
-		defer func() {
-			if err = tx.Commit(); err != nil {
+	now := time.Now().Format("2006-01-02 15:04:05")
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Update(db.SCHEMA_PACK_WAITLIST).
+		Set("notified_at", now).
+		Where(squirrel.Eq{"id": ids, "pack_config_id": packConfigId}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *PackRepoImpl) AddPackOrder(c context.Context, now string, uid string, packConfig *model.PackConfig, packIds []uint64, tokenRateId uint64, tx *sqlx.Tx) error {
+	if packConfig.TokenAmount == nil {
+		return &core.ErrorResp{
+			Message: "critical error: pack config does not have token amount",
+		}
+	}
+	packOrders := make([]model.PackOrder, len(packIds))
+	tokenAmount := *packConfig.TokenAmount
+	for i, id := range packIds {
+		packId := id
+		packOrder := model.PackOrder{
+			PackId:      &packId,
+			Uid:         &uid,
+			OrderedAt:   &now,
+			TokenAmount: &tokenAmount,
+			TokenRateId: &tokenRateId,
+		}
+		packOrders[i] = packOrder
+	}
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query := psql.
+		Insert(db.SCHEMA_PACK_ORDERS).
+		Columns(core.ModelColumns(packOrders[0])...)
+
+	for _, packOrder := range packOrders {
+		query = query.Values(core.StructValues(packOrder)...)
+	}
+	queryStr, args, err := query.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(c, queryStr, args...)
+	if err != nil {
+		return nil
+	}
+
+	return nil
+}
+
+func (r *PackRepoImpl) OpenPack(c context.Context, packId uint64, uid string) (*model.Pack, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
 				fmt.Println(err)
 			}
-		}()
+		}
+	}()
 
-		psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
-		query, args, err := psql.
-			Select(core.FlattenedPackFieldList...).
-			From("main.pack_configs pc").
-			Join("main.pack_facts p on pc.id = p.pack_config_id and p.active = true and p.opened_at is null").
-			Join("main.pack_item_facts item_facts on p.id = item_facts.pack_id").
-			Join("main.items i on item_facts.item_id = i.id").
-			Where(squirrel.Eq{"p.id": id}).
-			ToSql()
+	now := time.Now().Format("2006-01-02 15:04:05")
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Update(db.SCHEMA_PACK_FACTS).
+		Set("opened_at", now).
+		Where(squirrel.Eq{"id": packId}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsAffected, err := rows.RowsAffected()
+	if rowsAffected > 0 {
+		if err = tx.Commit(); err != nil {
+			return nil, err
+		}
+
+		// remove cached user packs
+		keys, err := r.cache.Keys(c, fmt.Sprintf("/user/packs/%v*", uid)).Result()
 		if err != nil {
 			return nil, err
 		}
-		fmt.Println(query)
-		rows, err := tx.QueryxContext(ctx, query, args...)
+		for _, key := range keys {
+			if err = r.cache.Del(c, key).Err(); err != nil {
+				return nil, err
+			}
+		}
+
+		pack, err := r.GetPack(c, packId)
 		if err != nil {
 			return nil, err
 		}
+		return pack, err
+	}
+	err = &core.ErrorResp{Message: "Pack fact record does not exist"}
+	return nil, err
+}
 
-		flattenedPacks := []model.PackFlatten{}
-		defer rows.Close()
-		for rows.Next() {
-			flatPack := model.PackFlatten{}
-			if err := rows.StructScan(&flatPack); err != nil {
-				return nil, err
+// opens a set of packs for a user and credits their items in a single transaction.
+// packs are either the given pack ids or, when no ids are given, up to limit unopened packs of the pack config
+func (r *PackRepoImpl) OpenPacks(c context.Context, uid string, packIds []uint64, packConfigId *uint64, limit uint64) ([]*model.Pack, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
 			}
-			flattenedPacks = append(flattenedPacks, flatPack)
 		}
+	}()
 
-		if len(flattenedPacks) <= 0 {
-			return nil, &core.ErrorResp{Message: fmt.Sprintf("Pack fact with id %v does not exist or has already been opened", id)}
-		} else {
-			flattenedPack := flattenedPacks[0]
-			items := []*model.Item{}
-			pack := model.Pack{
-				ID:           flattenedPack.PackFactId,
-				PackConfigId: flattenedPack.PackConfigId,
-				ImageUrl:     flattenedPack.ImageUrl,
-				PackItemQty:  flattenedPack.PackItemQty,
-				VendorId:     flattenedPack.VendorId,
-				CreatedAt:    flattenedPack.CreatedAt,
-				PurchasedAt:  flattenedPack.PurchasedAt,
-				OpenedAt:     flattenedPack.OpenedAt,
-				OwnerId:      flattenedPack.OwnerId,
-				Active:       flattenedPack.Active,
-				Description:  flattenedPack.Description,
-				Title:        flattenedPack.Title,
-				Items:        items,
+	// lock the pack facts being opened
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	packQuery := psql.
+		Select("id", "owner_id", "opened_at", "active").
+		From(db.SCHEMA_PACK_FACTS)
+	if len(packIds) > 0 {
+		packQuery = packQuery.Where(squirrel.Eq{"id": packIds})
+	} else if packConfigId != nil {
+		packQuery = packQuery.
+			Where(squirrel.Eq{"pack_config_id": *packConfigId, "owner_id": uid, "opened_at": nil, "active": true}).
+			OrderBy("purchased_at", "id").
+			Limit(limit)
+	} else {
+		err = &core.ErrorResp{Message: "pack ids or a pack config id must be given"}
+		return nil, err
+	}
+
+	query, args, err := packQuery.Suffix("FOR UPDATE").ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	packFacts := map[uint64]*model.PackFact{}
+	openIds := []uint64{}
+	for rows.Next() {
+		packFact := model.PackFact{}
+		if err = rows.StructScan(&packFact); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		packFacts[*packFact.ID] = &packFact
+		openIds = append(openIds, *packFact.ID)
+	}
+	rows.Close()
+
+	// same ownership and already opened checks as opening a single pack
+	if len(packIds) > 0 {
+		openIds = []uint64{}
+		for _, id := range packIds {
+			packFact, ok := packFacts[id]
+			if !ok || packFact.Active == nil || !*packFact.Active {
+				err = &core.ErrorResp{Message: fmt.Sprintf("Pack fact with id %v does not exist", id)}
+				return nil, err
 			}
-			for _, v := range flattenedPacks {
-				item := model.Item{
-					ID:              v.ItemId,
-					VendorId:        v.ItemVendorId,
-					ImageUrl:        v.ItemImageUrl,
-					CreatedAt:       v.ItemCreatedAt,
-					DeletedAt:       v.ItemDeletedAt,
-					UpdatedAt:       v.ItemUpdatedAt,
-					Description:     v.ItemDescription,
-					Name:            v.ItemName,
-					RarityId:        v.ItemRarityId,
-					ContentMainUrl:  v.ItemContentMainUrl,
-					ContentThumbUrl: v.ItemContentThumbUrl,
-					ContentType:     v.ItemContentType,
-					Active:          v.ItemActive,
-					Notify:          v.ItemNotify,
-				}
-				pack.Items = append(pack.Items, &item)
+			if packFact.OwnerID == nil {
+				err = &core.ErrorResp{Message: fmt.Sprintf("Error: pack %v has no owner associated", id)}
+				return nil, err
 			}
-
-			packBytes, err := json.Marshal(pack)
-			if err != nil {
+			if *packFact.OwnerID != uid {
+				err = &core.ErrorResp{Message: fmt.Sprintf("Error: user does not own pack %v", id)}
 				return nil, err
 			}
-			if err = r.cache.Set(c, db.KEY_PACK+fmt.Sprintf("%v", id), packBytes, time.Duration(3600)*time.Second).Err(); err != nil {
+			if packFact.OpenedAt != nil {
+				err = &core.ErrorResp{Message: fmt.Sprintf("Error: pack %v has already been opened", id)}
 				return nil, err
 			}
-			return &pack, nil
+			openIds = append(openIds, id)
 		}
-	} else {
-		pack := model.Pack{}
-		if err = json.Unmarshal([]byte(val), &pack); err != nil {
+	}
+	if len(openIds) == 0 {
+		err = &core.ErrorResp{Message: "There are no unopened packs to open"}
+		return nil, err
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	query, args, err = psql.
+		Update(db.SCHEMA_PACK_FACTS).
+		Set("opened_at", now).
+		Where(squirrel.Eq{"id": openIds}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	// get the contents of every opened pack
+	query, args, err = psql.
+		Select(core.FlattenedPackFieldList...).
+		From("main.pack_configs pc").
+		Join("main.pack_facts p on pc.id = p.pack_config_id").
+		Join("main.pack_item_facts item_facts on p.id = item_facts.pack_id").
+		Join("main.items i on item_facts.item_id = i.id").
+		LeftJoin(db.SCHEMA_ITEM_MINT_COUNTS+" mc on mc.item_id = i.id").
+		Where(squirrel.Eq{"p.id": openIds}).
+		OrderBy("p.id", "item_facts.id").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	packs := []*model.Pack{}
+	packMap := map[uint64]*model.Pack{}
+	pulledItems := []*model.Item{}
+	pulledPackIds := []uint64{}
+	for rows.Next() {
+		v := model.PackFlatten{}
+		if err = rows.StructScan(&v); err != nil {
+			rows.Close()
 			return nil, err
 		}
-		return &pack, nil
+
+		pack, ok := packMap[*v.PackFactId]
+		if !ok {
+			pack = &model.Pack{
+				ID:           v.PackFactId,
+				PackConfigId: v.PackConfigId,
+				ImageUrl:     v.ImageUrl,
+				PackItemQty:  v.PackItemQty,
+				VendorId:     v.VendorId,
+				CreatedAt:    v.CreatedAt,
+				PurchasedAt:  v.PurchasedAt,
+				OpenedAt:     v.OpenedAt,
+				OwnerId:      v.OwnerId,
+				Active:       v.Active,
+				Description:  v.Description,
+				Title:        v.Title,
+				Items:        []*model.Item{},
+			}
+			packMap[*v.PackFactId] = pack
+			packs = append(packs, pack)
+		}
+
+		item := model.Item{
+			ID:              v.ItemId,
+			VendorId:        v.ItemVendorId,
+			ImageUrl:        v.ItemImageUrl,
+			CreatedAt:       v.ItemCreatedAt,
+			DeletedAt:       v.ItemDeletedAt,
+			UpdatedAt:       v.ItemUpdatedAt,
+			Description:     v.ItemDescription,
+			Name:            v.ItemName,
+			RarityId:        v.ItemRarityId,
+			ContentMainUrl:  v.ItemContentMainUrl,
+			ContentThumbUrl: v.ItemContentThumbUrl,
+			ContentType:     v.ItemContentType,
+			Active:          v.ItemActive,
+			Notify:          v.ItemNotify,
+			SerialNumber:    v.ItemSerialNumber,
+			MintCount:       v.ItemMintCount,
+		}
+		pack.Items = append(pack.Items, &item)
+		pulledItems = append(pulledItems, &item)
+		pulledPackIds = append(pulledPackIds, *v.PackFactId)
+	}
+	rows.Close()
+
+	if len(packs) != len(openIds) {
+		err = &core.ErrorResp{Message: fmt.Sprintf("Critical server error: %v of the %v packs being opened are empty", len(openIds)-len(packs), len(openIds))}
+		return nil, err
+	}
+
+	// credit every pulled item to the user in one batch
+	if _, err = creditPulledItems(ctx, tx, uid, pulledItems, pulledPackIds, now); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	// remove cached user packs and opened packs
+	if err := r.ClearUserPackCache(c, uid); err != nil {
+		return nil, err
+	}
+	keys := []string{db.KEY_USER_PACK_AMOUNT + uid}
+	for _, id := range openIds {
+		keys = append(keys, db.KEY_PACK+fmt.Sprintf("%v", id))
+	}
+	if err := r.cache.Del(c, keys...).Err(); err != nil {
+		return nil, err
+	}
+	return packs, nil
+}
+
+// opens a pack into a reveal session. the pack items are stored as slots ordered from most common to rarest
+// so they can be revealed one at a time, each item is only credited to the user once its slot is revealed
+func (r *PackRepoImpl) StartPackReveal(c context.Context, packId uint64, uid string) (*model.PackRevealSession, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("id", "owner_id", "opened_at", "active").
+		From(db.SCHEMA_PACK_FACTS).
+		Where(squirrel.Eq{"id": packId}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	packFact := model.PackFact{}
+	err = tx.QueryRowxContext(ctx, query, args...).StructScan(&packFact)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = &core.ErrorResp{Message: fmt.Sprintf("Pack fact with id %v does not exist", packId)}
+		}
+		return nil, err
+	}
+	if packFact.Active == nil || !*packFact.Active {
+		err = &core.ErrorResp{Message: fmt.Sprintf("Pack fact with id %v does not exist", packId)}
+		return nil, err
+	}
+	if packFact.OwnerID == nil {
+		err = &core.ErrorResp{Message: "Error: pack has no owner associated"}
+		return nil, err
+	}
+	if *packFact.OwnerID != uid {
+		err = &core.ErrorResp{Message: "Error: user does not own this pack"}
+		return nil, err
+	}
+	if packFact.OpenedAt != nil {
+		err = &core.ErrorResp{Message: "Error: pack has already been opened"}
+		return nil, err
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	query, args, err = psql.
+		Update(db.SCHEMA_PACK_FACTS).
+		Set("opened_at", now).
+		Where(squirrel.Eq{"id": packId}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	// pack items ordered from most common to rarest
+	query, args, err = psql.
+		Select("item_facts.item_id", "i.rarity_id", "item_facts.serial_number").
+		From("main.pack_item_facts item_facts").
+		Join("main.items i on item_facts.item_id = i.id").
+		LeftJoin(db.SCHEMA_RARITY + " r on r.id = i.rarity_id").
+		Where(squirrel.Eq{"item_facts.pack_id": packId}).
+		OrderBy("r.ranking asc", "item_facts.id asc").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	slots := []*model.PackRevealSlot{}
+	for rows.Next() {
+		slot := len(slots)
+		revealSlot := model.PackRevealSlot{Slot: &slot}
+		if err = rows.Scan(&revealSlot.ItemId, &revealSlot.RarityId, &revealSlot.SerialNumber); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		slots = append(slots, &revealSlot)
+	}
+	rows.Close()
+
+	if len(slots) == 0 {
+		err = &core.ErrorResp{Message: fmt.Sprintf("Critical server error: pack %v is empty", packId)}
+		return nil, err
+	}
+
+	itemCount := len(slots)
+	revealedCount := 0
+	session := model.PackRevealSession{
+		PackId:        &packId,
+		Uid:           &uid,
+		ItemCount:     &itemCount,
+		RevealedCount: &revealedCount,
+		CreatedAt:     &now,
+	}
+	query, args, err = psql.
+		Insert(db.SCHEMA_PACK_REVEAL_SESSIONS).
+		Columns(core.ModelColumns(session)...).
+		Values(core.StructValues(session)...).
+		Suffix("RETURNING \"id\"").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	sessionId := uint64(0)
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&sessionId)
+	if err != nil {
+		return nil, err
+	}
+	session.ID = &sessionId
+
+	slotQuery := psql.
+		Insert(db.SCHEMA_PACK_REVEAL_SLOTS).
+		Columns("session_id", "slot", "item_id", "rarity_id", "serial_number")
+	for _, slot := range slots {
+		slotQuery = slotQuery.Values(sessionId, *slot.Slot, *slot.ItemId, slot.RarityId, slot.SerialNumber)
+	}
+
+	query, args, err = slotQuery.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	// remove cached user packs and the opened pack
+	if err := r.ClearUserPackCache(c, uid); err != nil {
+		return nil, err
+	}
+	if err := r.cache.Del(c, db.KEY_USER_PACK_AMOUNT+uid, db.KEY_PACK+fmt.Sprintf("%v", packId)).Err(); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *PackRepoImpl) GetPackRevealSession(c context.Context, sessionId uint64) (*model.PackRevealSession, error) {
+	session, err := r.getPackRevealSession(c, squirrel.Eq{"id": sessionId})
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, &core.ErrorResp{Message: fmt.Sprintf("reveal session %v does not exist", sessionId)}
+	}
+	return session, nil
+}
+
+// returns the reveal session a pack was opened into, or nil if the pack was not opened through a reveal
+func (r *PackRepoImpl) GetPackRevealSessionByPack(c context.Context, packId uint64) (*model.PackRevealSession, error) {
+	return r.getPackRevealSession(c, squirrel.Eq{"pack_id": packId})
+}
+
+func (r *PackRepoImpl) getPackRevealSession(c context.Context, where squirrel.Eq) (*model.PackRevealSession, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err = tx.Commit(); err != nil {
+			fmt.Println(err)
+		}
+	}()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("*").
+		From(db.SCHEMA_PACK_REVEAL_SESSIONS).
+		Where(where).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	session := model.PackRevealSession{}
+	defer rows.Close()
+	for rows.Next() {
+		if err := rows.StructScan(&session); err != nil {
+			return nil, err
+		}
+	}
+
+	if session.ID == nil {
+		return nil, nil
+	}
+	return &session, nil
+}
+
+func (r *PackRepoImpl) GetPackRevealSlots(c context.Context, sessionId uint64) ([]*model.PackRevealSlotExpanded, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err = tx.Commit(); err != nil {
+			fmt.Println(err)
+		}
+	}()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select(
+			"s.slot",
+			"s.revealed_at",
+			"s.rarity_id",
+			"i.id as item_id",
+			"i.name",
+			"i.description",
+			"i.image_url",
+			"i.content_main_url",
+			"i.content_thumb_url",
+			"i.content_type",
+			"i.vendor_id",
+			"i.notify",
+			"s.serial_number",
+			"mc.minted_qty as mint_count",
+		).
+		From(db.SCHEMA_PACK_REVEAL_SLOTS + " s").
+		Join("main.items i on s.item_id = i.id").
+		LeftJoin(db.SCHEMA_ITEM_MINT_COUNTS + " mc on mc.item_id = i.id").
+		Where(squirrel.Eq{"s.session_id": sessionId}).
+		OrderBy("s.slot asc").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	slots := []*model.PackRevealSlotExpanded{}
+	defer rows.Close()
+	for rows.Next() {
+		slot := model.PackRevealSlotExpanded{}
+		if err := rows.StructScan(&slot); err != nil {
+			return nil, err
+		}
+		slots = append(slots, &slot)
+	}
+	return slots, nil
+}
+
+// marks the next slot of a session as revealed and credits its item to the user. revealing a slot that
+// was already revealed is a no-op so clients can safely retry after a crash
+func (r *PackRepoImpl) RevealPackSlot(c context.Context, sessionId uint64, uid string, slot int) error {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("*").
+		From(db.SCHEMA_PACK_REVEAL_SESSIONS).
+		Where(squirrel.Eq{"id": sessionId, "uid": uid}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	session := model.PackRevealSession{}
+	err = tx.QueryRowxContext(ctx, query, args...).StructScan(&session)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = &core.ErrorResp{Message: fmt.Sprintf("reveal session %v does not exist for this user", sessionId)}
+		}
+		return err
+	}
+
+	if slot < 0 || slot >= *session.ItemCount {
+		err = &core.ErrorResp{Message: fmt.Sprintf("slot %v does not exist in this pack", slot)}
+		return err
+	}
+	if slot < *session.RevealedCount {
+		err = tx.Commit()
+		return err
+	}
+	if slot > *session.RevealedCount {
+		err = &core.ErrorResp{Message: fmt.Sprintf("slots must be revealed in order, the next slot is %v", *session.RevealedCount)}
+		return err
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	if err = revealPackSlots(ctx, tx, &session, slot+1, now); err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	return err
+}
+
+// reveals every slot of a locked session up to revealedCount, crediting the items of the newly revealed
+// slots to the session owner. the session is completed once all of its slots are revealed
+func revealPackSlots(c context.Context, tx *sqlx.Tx, session *model.PackRevealSession, revealedCount int, now string) error {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("id", "item_id", "serial_number").
+		From(db.SCHEMA_PACK_REVEAL_SLOTS).
+		Where(squirrel.Eq{"session_id": *session.ID, "revealed_at": nil}).
+		Where(squirrel.Lt{"slot": revealedCount}).
+		OrderBy("slot asc").
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.QueryxContext(c, query, args...)
+	if err != nil {
+		return err
+	}
+
+	slotIds := []uint64{}
+	items := []*model.Item{}
+	packIds := []uint64{}
+	for rows.Next() {
+		slotId := uint64(0)
+		item := model.Item{}
+		if err = rows.Scan(&slotId, &item.ID, &item.SerialNumber); err != nil {
+			rows.Close()
+			return err
+		}
+		slotIds = append(slotIds, slotId)
+		items = append(items, &item)
+		packIds = append(packIds, *session.PackId)
+	}
+	rows.Close()
+
+	userItemIds, err := creditPulledItems(c, tx, *session.Uid, items, packIds, now)
+	if err != nil {
+		return err
+	}
+	for i, slotId := range slotIds {
+		query, args, err = psql.
+			Update(db.SCHEMA_PACK_REVEAL_SLOTS).
+			SetMap(map[string]interface{}{"revealed_at": now, "user_item_id": userItemIds[i]}).
+			Where(squirrel.Eq{"id": slotId}).
+			ToSql()
+		if err != nil {
+			return err
+		}
+		if _, err = tx.ExecContext(c, query, args...); err != nil {
+			return err
+		}
+	}
+
+	sessionPatch := map[string]interface{}{"revealed_count": revealedCount}
+	if revealedCount == *session.ItemCount {
+		sessionPatch["completed_at"] = now
+	}
+	query, args, err = psql.
+		Update(db.SCHEMA_PACK_REVEAL_SESSIONS).
+		SetMap(sessionPatch).
+		Where(squirrel.Eq{"id": *session.ID}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(c, query, args...)
+	return err
+}
+
+// reveals the rest of every session that was started before createdBefore and never finished, so the items
+// of an abandoned reveal still end up with the user. returns the uids whose collections changed
+func (r *PackRepoImpl) CompleteExpiredPackReveals(c context.Context, createdBefore string, limit uint64) ([]string, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("*").
+		From(db.SCHEMA_PACK_REVEAL_SESSIONS).
+		Where(squirrel.Eq{"completed_at": nil}).
+		Where(squirrel.Lt{"created_at": createdBefore}).
+		OrderBy("created_at asc").
+		Limit(limit).
+		Suffix("FOR UPDATE SKIP LOCKED").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := []*model.PackRevealSession{}
+	for rows.Next() {
+		session := model.PackRevealSession{}
+		if err = rows.StructScan(&session); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		sessions = append(sessions, &session)
+	}
+	rows.Close()
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	uids := []string{}
+	for _, session := range sessions {
+		if err = revealPackSlots(ctx, tx, session, *session.ItemCount, now); err != nil {
+			return nil, err
+		}
+		uids = append(uids, *session.Uid)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	for _, uid := range uids {
+		if err := clearUserItemCache(c, r.cache, uid); err != nil {
+			fmt.Println(err)
+		}
+	}
+	return uids, nil
+}
+
+func (r *PackRepoImpl) GetPack(c context.Context, id uint64) (*model.Pack, error) {
+	val, err := r.cache.Get(c, db.KEY_PACK+fmt.Sprintf("%v", id)).Result()
+	if err != nil {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+		if err != nil {
+			return nil, err
+		}
+
+		defer func() {
+			if err = tx.Commit(); err != nil {
+				fmt.Println(err)
+			}
+		}()
+
+		psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+		query, args, err := psql.
+			Select(core.FlattenedPackFieldList...).
+			From("main.pack_configs pc").
+			Join("main.pack_facts p on pc.id = p.pack_config_id and p.active = true and p.opened_at is null").
+			Join("main.pack_item_facts item_facts on p.id = item_facts.pack_id").
+			Join("main.items i on item_facts.item_id = i.id").
+			LeftJoin(db.SCHEMA_ITEM_MINT_COUNTS + " mc on mc.item_id = i.id").
+			Where(squirrel.Eq{"p.id": id}).
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+		fmt.Println(query)
+		rows, err := tx.QueryxContext(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+
+		flattenedPacks := []model.PackFlatten{}
+		defer rows.Close()
+		for rows.Next() {
+			flatPack := model.PackFlatten{}
+			if err := rows.StructScan(&flatPack); err != nil {
+				return nil, err
+			}
+			flattenedPacks = append(flattenedPacks, flatPack)
+		}
+
+		if len(flattenedPacks) <= 0 {
+			return nil, &core.ErrorResp{Message: fmt.Sprintf("Pack fact with id %v does not exist or has already been opened", id)}
+		} else {
+			flattenedPack := flattenedPacks[0]
+			items := []*model.Item{}
+			pack := model.Pack{
+				ID:           flattenedPack.PackFactId,
+				PackConfigId: flattenedPack.PackConfigId,
+				ImageUrl:     flattenedPack.ImageUrl,
+				PackItemQty:  flattenedPack.PackItemQty,
+				VendorId:     flattenedPack.VendorId,
+				CreatedAt:    flattenedPack.CreatedAt,
+				PurchasedAt:  flattenedPack.PurchasedAt,
+				OpenedAt:     flattenedPack.OpenedAt,
+				OwnerId:      flattenedPack.OwnerId,
+				Active:       flattenedPack.Active,
+				Description:  flattenedPack.Description,
+				Title:        flattenedPack.Title,
+				Items:        items,
+			}
+			for _, v := range flattenedPacks {
+				item := model.Item{
+					ID:              v.ItemId,
+					VendorId:        v.ItemVendorId,
+					ImageUrl:        v.ItemImageUrl,
+					CreatedAt:       v.ItemCreatedAt,
+					DeletedAt:       v.ItemDeletedAt,
+					UpdatedAt:       v.ItemUpdatedAt,
+					Description:     v.ItemDescription,
+					Name:            v.ItemName,
+					RarityId:        v.ItemRarityId,
+					ContentMainUrl:  v.ItemContentMainUrl,
+					ContentThumbUrl: v.ItemContentThumbUrl,
+					ContentType:     v.ItemContentType,
+					Active:          v.ItemActive,
+					Notify:          v.ItemNotify,
+					SerialNumber:    v.ItemSerialNumber,
+					MintCount:       v.ItemMintCount,
+				}
+				pack.Items = append(pack.Items, &item)
+			}
+
+			packBytes, err := json.Marshal(pack)
+			if err != nil {
+				return nil, err
+			}
+			if err = r.cache.Set(c, db.KEY_PACK+fmt.Sprintf("%v", id), packBytes, time.Duration(3600)*time.Second).Err(); err != nil {
+				return nil, err
+			}
+			return &pack, nil
+		}
+	} else {
+		pack := model.Pack{}
+		if err = json.Unmarshal([]byte(val), &pack); err != nil {
+			return nil, err
+		}
+		return &pack, nil
+	}
+}
+
+// func (r *PackRepoImpl) GetPackSortMappings(c context.Context) (map[string]string, error) {
+// 	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+// 	defer cancel()
+
+// 	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+// 	if err != nil {
+// 		return nil, err
+// 	}
+
+// 	defer func() {
+// 		if err = tx.Commit(); err != nil {
+// 			fmt.Println(err)
+// 		}
+// 	}()
+
+// 	category := "packs"
+// 	return core.GetSortMappings(ctx, &category, tx, err)
+// }
+
+func (r *PackRepoImpl) GetPackConfig(c context.Context, packConfigId uint64) (*model.PackConfig, error) {
+	val, err := r.cache.Get(c, db.KEY_PACK_CONFIG+fmt.Sprintf("%v", packConfigId)).Result()
+	if err != nil {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+		if err != nil {
+			return nil, err
+		}
+
+		defer func() {
+			if err = tx.Commit(); err != nil {
+				fmt.Println(err)
+			}
+		}()
+
+		psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+		query, args, err := psql.
+			Select("*").
+			From(db.SCHEMA_PACK_CONFIGS).
+			Where(squirrel.Eq{"id": packConfigId}).
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+
+		rows, err := tx.QueryxContext(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+
+		packConfig := model.PackConfig{}
+		defer rows.Close()
+		for rows.Next() {
+			if err := rows.StructScan(&packConfig); err != nil {
+				return &packConfig, err
+			}
+		}
+
+		if packConfig.ID != nil {
+			packConfigBytes, err := json.Marshal(packConfig)
+			if err != nil {
+				return nil, err
+			}
+			if err = r.cache.Set(c, db.KEY_PACK_CONFIG+fmt.Sprintf("%v", packConfigId), packConfigBytes, time.Duration(3600)*time.Second).Err(); err != nil {
+				return nil, err
+			}
+		}
+		return &packConfig, nil
+	} else {
+		packConfig := model.PackConfig{}
+		if err = json.Unmarshal([]byte(val), &packConfig); err != nil {
+			return nil, err
+		}
+		return &packConfig, nil
+	}
+}
+
+func (r *PackRepoImpl) GetPackItems(c context.Context, packConfigId uint64) ([]*model.PackItemConfigExpanded, error) {
+	val, err := r.cache.Get(c, db.KEY_PACK_ITEMS+fmt.Sprintf("%v", packConfigId)).Result()
+	if err != nil {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+		if err != nil {
+			return nil, err
+		}
+
+		defer func() {
+			if err = tx.Commit(); err != nil {
+				fmt.Println(err)
+			}
+		}()
+
+		psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+		query, args, err := psql.
+			Select(
+				"pic.qty as item_qty",
+				"i.id",
+				"i.created_at",
+				"i.deleted_at",
+				"i.updated_at",
+				"i.description",
+				"i.vendor_id",
+				"i.name",
+				"i.content_main_url",
+				"i.content_thumb_url",
+				"i.content_type",
+				"i.value as item_value",
+				"r.rarity",
+			).
+			From("main.pack_item_configs pic").
+			Join("main.items i on i.id = pic.item_id").
+			Join("main.rarity r on i.rarity_id = r.id").
+			Where(squirrel.Eq{"pic.pack_config_id": packConfigId}).
+			OrderBy("item_qty asc").
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+
+		rows, err := tx.Queryx(query, args...)
+		if err != nil {
+			return nil, err
+		}
+
+		packItems := []*model.PackItemConfigExpanded{}
+		defer rows.Close()
+		for rows.Next() {
+			packItem := model.PackItemConfigExpanded{}
+			if err := rows.StructScan(&packItem); err != nil {
+				return nil, err
+			}
+			packItems = append(packItems, &packItem)
+		}
+
+		packItemBytes, err := json.Marshal(packItems)
+		if err != nil {
+			return nil, err
+		}
+		if err = r.cache.Set(c, db.KEY_PACK_ITEMS+fmt.Sprintf("%v", packConfigId), packItemBytes, time.Duration(3600)*time.Second).Err(); err != nil {
+			return nil, err
+		}
+		return packItems, nil
+	} else {
+		packItems := []*model.PackItemConfigExpanded{}
+		if err = json.Unmarshal([]byte(val), &packItems); err != nil {
+			return nil, err
+		}
+		return packItems, nil
+	}
+}
+
+func (r *PackRepoImpl) GetPackItemsPreview(c context.Context, packConfigId uint64) ([]*model.PackItemPreview, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err = tx.Commit(); err != nil {
+			fmt.Println(err)
+		}
+	}()
+
+	rows, err := tx.QueryxContext(ctx, fmt.Sprintf(query.PackItemPreview, packConfigId))
+	if err != nil {
+		return nil, err
+	}
+
+	itemPreviews := []*model.PackItemPreview{}
+	defer rows.Close()
+	for rows.Next() {
+		preview := model.PackItemPreview{}
+		if err = rows.StructScan(&preview); err != nil {
+			return nil, err
+		}
+		itemPreviews = append(itemPreviews, &preview)
+	}
+
+	return itemPreviews, nil
+}
+
+func (r *PackRepoImpl) AddPackCategories(c context.Context, categories []*model.PackCategory) error {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query := psql.
+		Insert(db.SCHEMA_PACK_CATEGORIES).
+		Columns("pack_config_id", "category_id")
+
+	for _, category := range categories {
+		query = query.Values(core.StructValues(category)...)
+	}
+	queryStr, args, err := query.ToSql()
+	if err != nil {
+		return nil
+	}
+	_, err = tx.ExecContext(ctx, queryStr, args...)
+	if err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	return err
+}
+
+func (r *PackRepoImpl) GetUserPackAmount(c context.Context, uid string) (*uint64, error) {
+	val, err := r.cache.Get(c, db.KEY_USER_PACK_AMOUNT+uid).Result()
+	if err != nil {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+		if err != nil {
+			return nil, err
+		}
+
+		defer func() {
+			if err = tx.Commit(); err != nil {
+				fmt.Println(err)
+			}
+		}()
+
+		psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+		query, args, err := psql.
+			Select("count(*)").
+			From("main.pack_facts").
+			Where(squirrel.Eq{"owner_id": uid, "opened_at": nil}).
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+
+		rows, err := tx.QueryxContext(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		count := new(uint64)
+		for rows.Next() {
+			if err := rows.Scan(count); err != nil {
+				return nil, err
+			}
+		}
+		if err = r.cache.Set(c, db.KEY_USER_PACK_AMOUNT+uid, fmt.Sprintf("%v", count), time.Duration(3600)*time.Second).Err(); err != nil {
+			return nil, err
+		}
+		return count, nil
+	} else {
+		count := new(uint64)
+		if err = json.Unmarshal([]byte(val), count); err != nil {
+			return nil, err
+		}
+		return count, nil
+	}
+}
+
+func (r *PackRepoImpl) GetPackItemConfigs(c context.Context, packConfigId uint64) ([]*model.PackItemConfig, error) {
+	val, err := r.cache.Get(c, db.KEY_PACK_ITEM_CONFIGS+fmt.Sprintf("%v", packConfigId)).Result()
+	if err != nil {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+		if err != nil {
+			return nil, err
+		}
+
+		defer func() {
+			if err = tx.Commit(); err != nil {
+				fmt.Println(err)
+			}
+		}()
+
+		psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+		query, args, err := psql.
+			Select("id", "pack_config_id", "item_id", "qty", "created_at", "removed_at").
+			From(db.SCHEMA_PACK_ITEM_CONFIGS).
+			Where(squirrel.Eq{"pack_config_id": packConfigId}).
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+
+		rows, err := tx.QueryxContext(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+
+		packItemConfigs := []*model.PackItemConfig{}
+		defer rows.Close()
+		for rows.Next() {
+			packItemConfig := model.PackItemConfig{}
+			if err := rows.StructScan(&packItemConfig); err != nil {
+				return nil, err
+			}
+			packItemConfigs = append(packItemConfigs, &packItemConfig)
+		}
+
+		packItemConfigBytes, err := json.Marshal(packItemConfigs)
+		if err != nil {
+			return nil, err
+		}
+		if err = r.cache.Set(c, db.KEY_PACK_ITEM_CONFIGS+fmt.Sprintf("%v", packConfigId), packItemConfigBytes, time.Duration(3600)*time.Second).Err(); err != nil {
+			return nil, err
+		}
+		return packItemConfigs, nil
+	} else {
+		packItemConfigs := []*model.PackItemConfig{}
+		if err = json.Unmarshal([]byte(val), &packItemConfigs); err != nil {
+			return nil, err
+		}
+		return packItemConfigs, nil
+	}
+}
+
+func (r *PackRepoImpl) GetPackPriceTiers(c context.Context, packConfigId uint64) ([]*model.PackPriceTier, error) {
+	val, err := r.cache.Get(c, db.KEY_PACK_PRICE_TIERS+fmt.Sprintf("%v", packConfigId)).Result()
+	if err != nil {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+		if err != nil {
+			return nil, err
+		}
+
+		defer func() {
+			if err = tx.Commit(); err != nil {
+				fmt.Println(err)
+			}
+		}()
+
+		priceTiers, err := r.getPackPriceTiers(ctx, packConfigId, tx)
+		if err != nil {
+			return nil, err
+		}
+
+		priceTierBytes, err := json.Marshal(priceTiers)
+		if err != nil {
+			return nil, err
+		}
+		if err = r.cache.Set(c, db.KEY_PACK_PRICE_TIERS+fmt.Sprintf("%v", packConfigId), priceTierBytes, time.Duration(3600)*time.Second).Err(); err != nil {
+			return nil, err
+		}
+		return priceTiers, nil
+	} else {
+		priceTiers := []*model.PackPriceTier{}
+		if err = json.Unmarshal([]byte(val), &priceTiers); err != nil {
+			return nil, err
+		}
+		return priceTiers, nil
+	}
+}
+
+func (r *PackRepoImpl) getPackPriceTiers(ctx context.Context, packConfigId uint64, tx *sqlx.Tx) ([]*model.PackPriceTier, error) {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("*").
+		From(db.SCHEMA_PACK_PRICE_TIERS).
+		Where(squirrel.Eq{"pack_config_id": packConfigId}).
+		OrderBy("min_qty asc").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	priceTiers := []*model.PackPriceTier{}
+	defer rows.Close()
+	for rows.Next() {
+		priceTier := model.PackPriceTier{}
+		if err := rows.StructScan(&priceTier); err != nil {
+			return nil, err
+		}
+		priceTiers = append(priceTiers, &priceTier)
+	}
+	return priceTiers, nil
+}
+
+// replaces the price tiers of a vendor's pack config
+func (r *PackRepoImpl) SetPackPriceTiers(c context.Context, packConfigId uint64, vendorId string, priceTiers []*model.PackPriceTier) error {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("count(*)").
+		From(db.SCHEMA_PACK_CONFIGS).
+		Where(squirrel.Eq{"id": packConfigId, "vendor_id": vendorId, "deleted_at": nil}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	if err = tx.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return err
+	}
+	if count == 0 {
+		err = &PackError{fmt.Sprintf("pack config %v does not exist for this vendor", packConfigId)}
+		return err
+	}
+
+	query, args, err = psql.
+		Delete(db.SCHEMA_PACK_PRICE_TIERS).
+		Where(squirrel.Eq{"pack_config_id": packConfigId}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	if len(priceTiers) > 0 {
+		now := time.Now().Format("2006-01-02 15:04:05")
+		insertQuery := psql.
+			Insert(db.SCHEMA_PACK_PRICE_TIERS).
+			Columns("pack_config_id", "min_qty", "pack_token_amount", "total_token_amount", "created_at")
+		for _, priceTier := range priceTiers {
+			insertQuery = insertQuery.Values(packConfigId, *priceTier.MinQty, priceTier.PackTokenAmount, priceTier.TotalTokenAmount, now)
+		}
+
+		query, args, err = insertQuery.ToSql()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	return r.cache.Del(c, db.KEY_PACK_PRICE_TIERS+fmt.Sprintf("%v", packConfigId)).Err()
+}
+
+func (r *PackRepoImpl) GetActivePackItems(c context.Context, itemIds []uint64) ([]string, []string, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer func() {
+		if err = tx.Commit(); err != nil {
+			fmt.Println(err)
+		}
+	}()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("pc.title", "pc.vendor_id", "i.name").
+		From("main.pack_configs pc").
+		Join("main.pack_item_configs pic on pc.id = pic.pack_config_id and pc.active = true").
+		Join("main.items i on i.id = pic.item_id").
+		Where(squirrel.Eq{"i.id": itemIds}).
+		ToSql()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := tx.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	itemNames := []string{}
+	packTitles := []string{}
+
+	defer rows.Close()
+	for rows.Next() {
+		packTitle := ""
+		itemName := ""
+		vendorId := ""
+		if err = rows.Scan(&packTitle, &vendorId, &itemName); err != nil {
+			return nil, nil, err
+		}
+		itemNames = append(itemNames, itemName)
+		packTitles = append(packTitles, packTitle)
+	}
+	return itemNames, packTitles, nil
+}
+
+func (r *PackRepoImpl) GetPacksContainingItems(c context.Context, itemIds []uint64) ([]string, []string, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer func() {
+		if err = tx.Commit(); err != nil {
+			fmt.Println(err)
+		}
+	}()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("pc.title", "pc.vendor_id", "i.name").
+		From("main.pack_configs pc").
+		Join("main.pack_item_configs pic on pc.id = pic.pack_config_id and pc.deleted_at is null").
+		Join("main.items i on i.id = pic.item_id").
+		Where(squirrel.Eq{"i.id": itemIds}).
+		ToSql()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := tx.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	itemNames := []string{}
+	packTitles := []string{}
+
+	defer rows.Close()
+	for rows.Next() {
+		packTitle := ""
+		itemName := ""
+		vendorId := ""
+		if err = rows.Scan(&packTitle, &vendorId, &itemName); err != nil {
+			return nil, nil, err
+		}
+		itemNames = append(itemNames, itemName)
+		packTitles = append(packTitles, packTitle)
+	}
+	return itemNames, packTitles, nil
+}
+
+func (r *PackRepoImpl) UploadPacks(c context.Context, packFacts []*model.PackFact, packConfigId uint64) ([]uint64, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query := psql.
+		Insert(db.SCHEMA_PACK_FACTS).
+		Columns(core.ModelColumns(packFacts[0])...).
+		Suffix("RETURNING id")
+
+	packIds := []uint64{}
+	for _, packFact := range packFacts {
+		query = query.Values(core.StructValues(packFact)...)
+	}
+
+	queryStr, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryxContext(ctx, queryStr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		packIds = append(packIds, id)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return packIds, nil
+}
+
+func (r *PackRepoImpl) UploadPackItems(c context.Context, packItemFacts []*model.PackItemFact) error {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query := psql.
+		Insert(db.SCHEMA_PACK_ITEM_FACTS).
+		Columns(core.ModelColumns(packItemFacts[0])...)
+
+	for _, packItemFact := range packItemFacts {
+		query = query.Values(core.StructValues(packItemFact)...)
+	}
+
+	queryStr, args, err := query.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, queryStr, args...)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	return err
+}
+
+// uploads a chunk of pack facts along with their pack item facts in a single transaction so a pack is never stored without its items
+func (r *PackRepoImpl) UploadPackChunk(c context.Context, packFacts []*model.PackFact, packItemIdBatch [][]uint64) ([]uint64, error) {
+	if len(packFacts) != len(packItemIdBatch) {
+		return nil, &PackError{fmt.Sprintf("pack chunk size %v does not match item id batch size %v", len(packFacts), len(packItemIdBatch))}
+	}
+	for _, itemIds := range packItemIdBatch {
+		if len(itemIds) == 0 {
+			return nil, &PackError{"a pack cannot be stored without items"}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	packQuery := psql.
+		Insert(db.SCHEMA_PACK_FACTS).
+		Columns(core.ModelColumns(packFacts[0])...).
+		Suffix("RETURNING id")
+
+	for _, packFact := range packFacts {
+		packQuery = packQuery.Values(core.StructValues(packFact)...)
+	}
+
+	queryStr, args, err := packQuery.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryxContext(ctx, queryStr, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	packIds := []uint64{}
+	for rows.Next() {
+		var id uint64
+		if err = rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		packIds = append(packIds, id)
+	}
+	rows.Close()
+
+	if len(packIds) != len(packItemIdBatch) {
+		err = &PackError{fmt.Sprintf("amount of packs uploaded %v and item id batches %v are not equal", len(packIds), len(packItemIdBatch))}
+		return nil, err
+	}
+
+	// serial numbers are only given out once the whole job is activated, so a failed job never uses any up
+	itemQuery := psql.
+		Insert(db.SCHEMA_PACK_ITEM_FACTS).
+		Columns("item_id", "pack_id")
+
+	itemAmount := 0
+	for i, itemIds := range packItemIdBatch {
+		for _, itemId := range itemIds {
+			itemQuery = itemQuery.Values(itemId, packIds[i])
+			itemAmount++
+		}
+	}
+
+	if itemAmount > 0 {
+		queryStr, args, err = itemQuery.ToSql()
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.ExecContext(ctx, queryStr, args...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return packIds, nil
+}
+
+// bumps the mint count of each item by the given amount and returns the first serial number
+// reserved for each item. the mint count row lock keeps serials unique across concurrent jobs and restocks
+func reserveItemSerials(c context.Context, tx *sqlx.Tx, mintAmounts map[uint64]uint64) (map[uint64]uint64, error) {
+	itemIds := make([]uint64, 0, len(mintAmounts))
+	for itemId := range mintAmounts {
+		itemIds = append(itemIds, itemId)
+	}
+	// lock rows in a stable order so concurrent jobs sharing items do not deadlock
+	sort.Slice(itemIds, func(i, j int) bool { return itemIds[i] < itemIds[j] })
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	nextSerials := map[uint64]uint64{}
+	for _, itemId := range itemIds {
+		amount := mintAmounts[itemId]
+		query, args, err := psql.
+			Insert(db.SCHEMA_ITEM_MINT_COUNTS).
+			Columns("item_id", "minted_qty").
+			Values(itemId, amount).
+			Suffix("ON CONFLICT (item_id) DO UPDATE SET minted_qty = item_mint_counts.minted_qty + excluded.minted_qty RETURNING minted_qty").
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+
+		mintedQty := uint64(0)
+		if err = tx.QueryRowContext(c, query, args...).Scan(&mintedQty); err != nil {
+			return nil, err
+		}
+		nextSerials[itemId] = mintedQty - amount + 1
+	}
+	return nextSerials, nil
+}
+
+// puts the packs of a finished generation job on sale and numbers the items minted into them. serials are
+// reserved here rather than per chunk so a job that fails part way never leaves gaps or an inflated mint count
+func (r *PackRepoImpl) ActivateGeneratedPacks(c context.Context, jobId uint64) error {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("pif.item_id", "count(*)").
+		From(db.SCHEMA_PACK_ITEM_FACTS + " pif").
+		Join(db.SCHEMA_PACK_FACTS + " pf on pf.id = pif.pack_id").
+		Where(squirrel.Eq{"pf.generation_job_id": jobId, "pif.serial_number": nil}).
+		GroupBy("pif.item_id").
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	mintAmounts := map[uint64]uint64{}
+	for rows.Next() {
+		var itemId, amount uint64
+		if err = rows.Scan(&itemId, &amount); err != nil {
+			rows.Close()
+			return err
+		}
+		mintAmounts[itemId] = amount
+	}
+	rows.Close()
+
+	if _, err = reserveItemSerials(ctx, tx, mintAmounts); err != nil {
+		return err
+	}
+
+	// the mint count rows stay locked until commit, so each item of the job is numbered within the range just
+	// reserved for it, which ends at the items new mint count
+	query = fmt.Sprintf(`
+		update %v pif set serial_number = mc.minted_qty - s.amount + s.position
+		from (
+			select pif2.id, pif2.item_id
+				, row_number() over (partition by pif2.item_id order by pif2.id) as position
+				, count(*) over (partition by pif2.item_id) as amount
+			from %v pif2
+			join %v pf on pf.id = pif2.pack_id
+			where pf.generation_job_id = $1 and pif2.serial_number is null
+		) s
+		join %v mc on mc.item_id = s.item_id
+		where pif.id = s.id`,
+		db.SCHEMA_PACK_ITEM_FACTS, db.SCHEMA_PACK_ITEM_FACTS, db.SCHEMA_PACK_FACTS, db.SCHEMA_ITEM_MINT_COUNTS)
+	if _, err = tx.ExecContext(ctx, query, jobId); err != nil {
+		return err
+	}
+
+	query, args, err = psql.
+		Update(db.SCHEMA_PACK_FACTS).
+		Set("active", true).
+		Where(squirrel.Eq{"generation_job_id": jobId, "owner_id": nil}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	return err
+}
+
+// removes the packs and pack items uploaded by a failed generation job
+func (r *PackRepoImpl) DeleteGeneratedPacks(c context.Context, packIds []uint64) error {
+	if len(packIds) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Delete(db.SCHEMA_PACK_ITEM_FACTS).
+		Where(squirrel.Eq{"pack_id": packIds}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	query, args, err = psql.
+		Delete(db.SCHEMA_PACK_FACTS).
+		Where(squirrel.Eq{"id": packIds, "owner_id": nil}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	return err
+}
+
+func (r *PackRepoImpl) CreatePackGenerationJob(c context.Context, job *model.PackGenerationJob) (*model.PackGenerationJob, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Insert(db.SCHEMA_PACK_GENERATION_JOBS).
+		Columns(core.ModelColumns(job)...).
+		Values(core.StructValues(job)...).
+		Suffix("RETURNING \"id\"").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	insertedId := uint64(0)
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&insertedId)
+	if err != nil {
+		// a concurrent request queued a job for the same pack config first
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" && pqErr.Constraint == db.INDEX_PACK_GENERATION_JOBS_ACTIVE {
+			return nil, &PackError{"packs are already being generated for this pack config"}
+		}
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	job.ID = &insertedId
+	return job, nil
+}
+
+func (r *PackRepoImpl) UpdatePackGenerationJob(c context.Context, jobId uint64, patchMap map[string]interface{}) error {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	// every update doubles as a heartbeat so jobs abandoned by a crashed worker can be told apart from slow ones
+	patchMap["updated_at"] = time.Now().Format("2006-01-02 15:04:05")
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Update(db.SCHEMA_PACK_GENERATION_JOBS).
+		SetMap(patchMap).
+		Where(squirrel.Eq{"id": jobId}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	return err
+}
+
+// job status is read straight from the DB since it changes while the job is running
+func (r *PackRepoImpl) GetPackGenerationJob(c context.Context, jobId uint64) (*model.PackGenerationJob, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err = tx.Commit(); err != nil {
+			fmt.Println(err)
+		}
+	}()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("*").
+		From(db.SCHEMA_PACK_GENERATION_JOBS).
+		Where(squirrel.Eq{"id": jobId}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	job := model.PackGenerationJob{}
+	defer rows.Close()
+	for rows.Next() {
+		if err := rows.StructScan(&job); err != nil {
+			return nil, err
+		}
+	}
+
+	if job.ID == nil {
+		return nil, &PackError{fmt.Sprintf("pack generation job %v does not exist", jobId)}
 	}
+	return &job, nil
 }
 
-// func (r *PackRepoImpl) GetPackSortMappings(c context.Context) (map[string]string, error) {
-// 	ctx, cancel := context.WithTimeout(c, 5*time.Second)
-// 	defer cancel()
+// returns the queued or running generation job for a pack config, or nil if there is none
+func (r *PackRepoImpl) GetActivePackGenerationJob(c context.Context, packConfigId uint64) (*model.PackGenerationJob, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
 
-// 	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
-// 	if err != nil {
-// 		return nil, err
-// 	}
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
 
-// 	defer func() {
-// 		if err = tx.Commit(); err != nil {
-// 			fmt.Println(err)
-// 		}
-// 	}()
+	defer func() {
+		if err = tx.Commit(); err != nil {
+			fmt.Println(err)
+		}
+	}()
 
-// 	category := "packs"
-// 	return core.GetSortMappings(ctx, &category, tx, err)
-// }
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("*").
+		From(db.SCHEMA_PACK_GENERATION_JOBS).
+		Where(squirrel.Eq{
+			"pack_config_id": packConfigId,
+			"status":         []string{db.JOB_STATUS_QUEUED, db.JOB_STATUS_RUNNING},
+		}).
+		OrderBy("id desc").
+		Limit(1).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
 
-func (r *PackRepoImpl) GetPackConfig(c context.Context, packConfigId uint64) (*model.PackConfig, error) {
-	val, err := r.cache.Get(c, db.KEY_PACK_CONFIG+fmt.Sprintf("%v", packConfigId)).Result()
+	rows, err := tx.QueryxContext(ctx, query, args...)
 	if err != nil {
-		ctx, cancel := context.WithTimeout(c, 5*time.Second)
-		defer cancel()
+		return nil, err
+	}
 
-		tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
-		if err != nil {
+	job := model.PackGenerationJob{}
+	defer rows.Close()
+	for rows.Next() {
+		if err := rows.StructScan(&job); err != nil {
 			return nil, err
 		}
+	}
 
-		defer func() {
-			if err = tx.Commit(); err != nil {
-				fmt.Println(err)
-			}
-		}()
+	if job.ID == nil {
+		return nil, nil
+	}
+	return &job, nil
+}
 
-		psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
-		query, args, err := psql.
-			Select("*").
-			From(db.SCHEMA_PACK_CONFIGS).
-			Where(squirrel.Eq{"id": packConfigId}).
-			ToSql()
-		if err != nil {
-			return nil, err
-		}
+// marks the oldest queued generation job as running and returns it, or nil if nothing is queued.
+// skipping locked rows lets several workers claim jobs from the same table without picking the same one
+func (r *PackRepoImpl) ClaimPackGenerationJob(c context.Context) (*model.PackGenerationJob, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
 
-		rows, err := tx.QueryxContext(ctx, query, args...)
-		if err != nil {
-			return nil, err
-		}
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
 
-		packConfig := model.PackConfig{}
-		defer rows.Close()
-		for rows.Next() {
-			if err := rows.StructScan(&packConfig); err != nil {
-				return &packConfig, err
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
 			}
 		}
+	}()
 
-		if packConfig.ID != nil {
-			packConfigBytes, err := json.Marshal(packConfig)
-			if err != nil {
-				return nil, err
-			}
-			if err = r.cache.Set(c, db.KEY_PACK_CONFIG+fmt.Sprintf("%v", packConfigId), packConfigBytes, time.Duration(3600)*time.Second).Err(); err != nil {
-				return nil, err
-			}
-		}
-		return &packConfig, nil
-	} else {
-		packConfig := model.PackConfig{}
-		if err = json.Unmarshal([]byte(val), &packConfig); err != nil {
-			return nil, err
-		}
-		return &packConfig, nil
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("*").
+		From(db.SCHEMA_PACK_GENERATION_JOBS).
+		Where(squirrel.Eq{"status": db.JOB_STATUS_QUEUED}).
+		OrderBy("id asc").
+		Limit(1).
+		Suffix("FOR UPDATE SKIP LOCKED").
+		ToSql()
+	if err != nil {
+		return nil, err
 	}
-}
 
-func (r *PackRepoImpl) GetPackItems(c context.Context, packConfigId uint64) ([]*model.PackItemConfigExpanded, error) {
-	val, err := r.cache.Get(c, db.KEY_PACK_ITEMS+fmt.Sprintf("%v", packConfigId)).Result()
+	rows, err := tx.QueryxContext(ctx, query, args...)
 	if err != nil {
-		ctx, cancel := context.WithTimeout(c, 5*time.Second)
-		defer cancel()
+		return nil, err
+	}
 
-		tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
-		if err != nil {
+	job := model.PackGenerationJob{}
+	for rows.Next() {
+		if err = rows.StructScan(&job); err != nil {
+			rows.Close()
 			return nil, err
 		}
+	}
+	rows.Close()
 
-		defer func() {
-			if err = tx.Commit(); err != nil {
-				fmt.Println(err)
-			}
-		}()
+	if job.ID == nil {
+		err = tx.Commit()
+		return nil, err
+	}
 
-		psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
-		query, args, err := psql.
-			Select(
-				"pic.qty as item_qty",
-				"i.id",
-				"i.created_at",
-				"i.deleted_at",
-				"i.updated_at",
-				"i.description",
-				"i.vendor_id",
-				"i.name",
-				"i.content_main_url",
-				"i.content_thumb_url",
-				"i.content_type",
-				"i.value as item_value",
-				"r.rarity",
-			).
-			From("main.pack_item_configs pic").
-			Join("main.items i on i.id = pic.item_id").
-			Join("main.rarity r on i.rarity_id = r.id").
-			Where(squirrel.Eq{"pic.pack_config_id": packConfigId}).
-			OrderBy("item_qty asc").
-			ToSql()
-		if err != nil {
-			return nil, err
-		}
+	now := time.Now().Format("2006-01-02 15:04:05")
+	query, args, err = psql.
+		Update(db.SCHEMA_PACK_GENERATION_JOBS).
+		Set("status", db.JOB_STATUS_RUNNING).
+		Set("started_at", now).
+		Set("updated_at", now).
+		Where(squirrel.Eq{"id": *job.ID}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
 
-		rows, err := tx.Queryx(query, args...)
-		if err != nil {
-			return nil, err
-		}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, err
+	}
 
-		packItems := []*model.PackItemConfigExpanded{}
-		defer rows.Close()
-		for rows.Next() {
-			packItem := model.PackItemConfigExpanded{}
-			if err := rows.StructScan(&packItem); err != nil {
-				return nil, err
-			}
-			packItems = append(packItems, &packItem)
-		}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	status := db.JOB_STATUS_RUNNING
+	job.Status = &status
+	job.StartedAt = &now
+	job.UpdatedAt = &now
+	return &job, nil
+}
 
-		packItemBytes, err := json.Marshal(packItems)
-		if err != nil {
-			return nil, err
-		}
-		if err = r.cache.Set(c, db.KEY_PACK_ITEMS+fmt.Sprintf("%v", packConfigId), packItemBytes, time.Duration(3600)*time.Second).Err(); err != nil {
-			return nil, err
+// returns the running generation jobs that have not recorded progress since the given time
+func (r *PackRepoImpl) GetStalePackGenerationJobs(c context.Context, updatedBefore string) ([]*model.PackGenerationJob, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err = tx.Commit(); err != nil {
+			fmt.Println(err)
 		}
-		return packItems, nil
-	} else {
-		packItems := []*model.PackItemConfigExpanded{}
-		if err = json.Unmarshal([]byte(val), &packItems); err != nil {
+	}()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("*").
+		From(db.SCHEMA_PACK_GENERATION_JOBS).
+		Where(squirrel.Eq{"status": db.JOB_STATUS_RUNNING}).
+		Where(squirrel.Or{
+			squirrel.Lt{"updated_at": updatedBefore},
+			squirrel.Eq{"updated_at": nil},
+		}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := []*model.PackGenerationJob{}
+	defer rows.Close()
+	for rows.Next() {
+		job := model.PackGenerationJob{}
+		if err := rows.StructScan(&job); err != nil {
 			return nil, err
 		}
-		return packItems, nil
+		jobs = append(jobs, &job)
 	}
+	return jobs, nil
 }
 
-func (r *PackRepoImpl) GetPackItemsPreview(c context.Context, packConfigId uint64) ([]*model.PackItemPreview, error) {
+// returns the packs a generation job uploaded that are not on sale yet
+func (r *PackRepoImpl) GetGeneratedPackIds(c context.Context, jobId uint64) ([]uint64, error) {
 	ctx, cancel := context.WithTimeout(c, 5*time.Second)
 	defer cancel()
 
@@ -696,25 +3063,70 @@ func (r *PackRepoImpl) GetPackItemsPreview(c context.Context, packConfigId uint6
 		}
 	}()
 
-	rows, err := tx.QueryxContext(ctx, fmt.Sprintf(query.PackItemPreview, packConfigId))
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("id").
+		From(db.SCHEMA_PACK_FACTS).
+		Where(squirrel.Eq{"generation_job_id": jobId, "active": false, "owner_id": nil}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	packIds := []uint64{}
+	defer rows.Close()
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		packIds = append(packIds, id)
+	}
+	return packIds, nil
+}
+
+func (r *PackRepoImpl) UpdatePackStock(c context.Context, packConfigId uint64, amount int, tx *sqlx.Tx) error {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Update(db.SCHEMA_PACK_CONFIGS).
+		Set("current_stock", squirrel.Expr("current_stock + ?", amount)).
+		Where(squirrel.Eq{"id": packConfigId}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(c, query, args...)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *PackRepoImpl) UpdatePackQtySold(c context.Context, packConfigId uint64, amount int, tx *sqlx.Tx) error {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Update(db.SCHEMA_PACK_CONFIGS).
+		Set("qty_sold", squirrel.Expr("qty_sold + ?", amount)).
+		Where(squirrel.Eq{"id": packConfigId}).
+		ToSql()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	itemPreviews := []*model.PackItemPreview{}
-	defer rows.Close()
-	for rows.Next() {
-		preview := model.PackItemPreview{}
-		if err = rows.StructScan(&preview); err != nil {
-			return nil, err
-		}
-		itemPreviews = append(itemPreviews, &preview)
+	_, err = tx.ExecContext(c, query, args...)
+	if err != nil {
+		return err
 	}
-
-	return itemPreviews, nil
+	return nil
 }
 
-func (r *PackRepoImpl) AddPackCategories(c context.Context, categories []*model.PackCategory) error {
+func (r *PackRepoImpl) PatchPackConfig(c context.Context, packConfigId uint64, patchMap map[string]interface{}, vendorId string) error {
 	ctx, cancel := context.WithTimeout(c, 5*time.Second)
 	defer cancel()
 
@@ -732,236 +3144,163 @@ func (r *PackRepoImpl) AddPackCategories(c context.Context, categories []*model.
 	}()
 
 	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
-	query := psql.
-		Insert(db.SCHEMA_PACK_CATEGORIES).
-		Columns("pack_config_id", "category_id")
-
-	for _, category := range categories {
-		query = query.Values(core.StructValues(category)...)
-	}
-	queryStr, args, err := query.ToSql()
-	if err != nil {
-		return nil
-	}
-	_, err = tx.ExecContext(ctx, queryStr, args...)
+	query, args, err := psql.
+		Update(db.SCHEMA_PACK_CONFIGS).
+		SetMap(patchMap).
+		Where(squirrel.Eq{"id": packConfigId, "vendor_id": vendorId}).
+		ToSql()
 	if err != nil {
 		return err
 	}
-	if err = tx.Commit(); err != nil {
-		return err
-	}
-	return err
-}
 
-func (r *PackRepoImpl) GetUserPackAmount(c context.Context, uid string) (*uint64, error) {
-	val, err := r.cache.Get(c, db.KEY_USER_PACK_AMOUNT+uid).Result()
+	result, err := tx.ExecContext(ctx, query, args...)
 	if err != nil {
-		ctx, cancel := context.WithTimeout(c, 5*time.Second)
-		defer cancel()
-
-		tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
-		if err != nil {
-			return nil, err
-		}
-
-		defer func() {
-			if err = tx.Commit(); err != nil {
-				fmt.Println(err)
-			}
-		}()
-
-		psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
-		query, args, err := psql.
-			Select("count(*)").
-			From("main.pack_facts").
-			Where(squirrel.Eq{"owner_id": uid, "opened_at": nil}).
-			ToSql()
-		if err != nil {
-			return nil, err
-		}
-
-		rows, err := tx.QueryxContext(ctx, query, args...)
-		if err != nil {
-			return nil, err
-		}
-		defer rows.Close()
-		count := new(uint64)
-		for rows.Next() {
-			if err := rows.Scan(count); err != nil {
-				return nil, err
-			}
-		}
-		if err = r.cache.Set(c, db.KEY_USER_PACK_AMOUNT+uid, fmt.Sprintf("%v", count), time.Duration(3600)*time.Second).Err(); err != nil {
-			return nil, err
-		}
-		return count, nil
-	} else {
-		count := new(uint64)
-		if err = json.Unmarshal([]byte(val), count); err != nil {
-			return nil, err
-		}
-		return count, nil
+		return err
 	}
-}
 
-func (r *PackRepoImpl) GetPackItemConfigs(c context.Context, packConfigId uint64) ([]*model.PackItemConfig, error) {
-	val, err := r.cache.Get(c, db.KEY_PACK_ITEM_CONFIGS+fmt.Sprintf("%v", packConfigId)).Result()
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		ctx, cancel := context.WithTimeout(c, 5*time.Second)
-		defer cancel()
-
-		tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
-		if err != nil {
-			return nil, err
-		}
-
-		defer func() {
-			if err = tx.Commit(); err != nil {
-				fmt.Println(err)
-			}
-		}()
-
-		psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
-		query, args, err := psql.
-			Select("id", "pack_config_id", "item_id", "qty", "created_at", "removed_at").
-			From(db.SCHEMA_PACK_ITEM_CONFIGS).
-			Where(squirrel.Eq{"pack_config_id": packConfigId}).
-			ToSql()
-		if err != nil {
-			return nil, err
-		}
-
-		rows, err := tx.QueryxContext(ctx, query, args...)
-		if err != nil {
-			return nil, err
-		}
-
-		packItemConfigs := []*model.PackItemConfig{}
-		defer rows.Close()
-		for rows.Next() {
-			packItemConfig := model.PackItemConfig{}
-			if err := rows.StructScan(&packItemConfig); err != nil {
-				return nil, err
-			}
-			packItemConfigs = append(packItemConfigs, &packItemConfig)
-		}
+		return err
+	}
 
-		packItemConfigBytes, err := json.Marshal(packItemConfigs)
-		if err != nil {
-			return nil, err
-		}
-		if err = r.cache.Set(c, db.KEY_PACK_ITEM_CONFIGS+fmt.Sprintf("%v", packConfigId), packItemConfigBytes, time.Duration(3600)*time.Second).Err(); err != nil {
-			return nil, err
+	if rowsAffected > 0 {
+		if err = tx.Commit(); err != nil {
+			return err
 		}
-		return packItemConfigs, nil
+		return nil
 	} else {
-		packItemConfigs := []*model.PackItemConfig{}
-		if err = json.Unmarshal([]byte(val), &packItemConfigs); err != nil {
-			return nil, err
-		}
-		return packItemConfigs, nil
+		err = &PackError{"Pack config either is not owned by the authorized user or could not be mapped using the given patch."}
+		return err
 	}
 }
 
-func (r *PackRepoImpl) GetActivePackItems(c context.Context, itemIds []uint64) ([]string, []string, error) {
+func (r *PackRepoImpl) PatchPackConfigs(c context.Context, packConfigIds []uint64, patchMap map[string]interface{}) error {
 	ctx, cancel := context.WithTimeout(c, 5*time.Second)
 	defer cancel()
 
 	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
 	if err != nil {
-		return nil, nil, err
+		return err
 	}
 
 	defer func() {
-		if err = tx.Commit(); err != nil {
-			fmt.Println(err)
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
 		}
 	}()
 
 	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 	query, args, err := psql.
-		Select("pc.title", "pc.vendor_id", "i.name").
-		From("main.pack_configs pc").
-		Join("main.pack_item_configs pic on pc.id = pic.pack_config_id and pc.active = true").
-		Join("main.items i on i.id = pic.item_id").
-		Where(squirrel.Eq{"i.id": itemIds}).
+		Update(db.SCHEMA_PACK_CONFIGS).
+		SetMap(patchMap).
+		Where(squirrel.Eq{"id": packConfigIds}).
 		ToSql()
 	if err != nil {
-		return nil, nil, err
+		return err
 	}
 
-	rows, err := tx.QueryxContext(ctx, query, args...)
+	result, err := r.db.ExecContext(ctx, query, args...)
 	if err != nil {
-		return nil, nil, err
+		return err
 	}
 
-	itemNames := []string{}
-	packTitles := []string{}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
 
-	defer rows.Close()
-	for rows.Next() {
-		packTitle := ""
-		itemName := ""
-		vendorId := ""
-		if err = rows.Scan(&packTitle, &vendorId, &itemName); err != nil {
-			return nil, nil, err
+	if rowsAffected > 0 {
+
+		return err
+	} else {
+		err = &PackError{
+			fmt.Sprintf("An error occurred during the update. PackConfig either is not active or could not be mapped using the given patch. %v", err.Error()),
 		}
-		itemNames = append(itemNames, itemName)
-		packTitles = append(packTitles, packTitle)
+		return err
 	}
-	return itemNames, packTitles, nil
 }
 
-func (r *PackRepoImpl) GetPacksContainingItems(c context.Context, itemIds []uint64) ([]string, []string, error) {
+func (r *PackRepoImpl) DeleteInactivePacks(c context.Context, packConfigIds []uint64, tx *sqlx.Tx) error {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Delete(db.SCHEMA_PACK_FACTS).
+		Where(squirrel.Eq{"pack_config_id": packConfigIds, "purchased_at": nil, "owner_id": nil}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(c, query, args...)
+	return err
+}
+
+func (r *PackRepoImpl) ActivatePacks(c context.Context, packConfigIds []uint64, vendorId string) error {
 	ctx, cancel := context.WithTimeout(c, 5*time.Second)
 	defer cancel()
 
 	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
 	if err != nil {
-		return nil, nil, err
+		return err
 	}
 
 	defer func() {
-		if err = tx.Commit(); err != nil {
-			fmt.Println(err)
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
 		}
 	}()
 
+	/* Activate packs only if they have not been deleted, previously inactive, approved by an admin, and have not been scheduled yet */
 	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 	query, args, err := psql.
-		Select("pc.title", "pc.vendor_id", "i.name").
-		From("main.pack_configs pc").
-		Join("main.pack_item_configs pic on pc.id = pic.pack_config_id and pc.deleted_at is null").
-		Join("main.items i on i.id = pic.item_id").
-		Where(squirrel.Eq{"i.id": itemIds}).
+		Update(db.SCHEMA_PACK_CONFIGS).
+		SetMap(map[string]interface{}{
+			"active": true,
+		}).
+		Where(squirrel.Eq{
+			"id":            packConfigIds,
+			"vendor_id":     vendorId,
+			"active":        false,
+			"deleted_at":    nil,
+			"review_status": db.PACK_REVIEW_APPROVED,
+		}).
 		ToSql()
 	if err != nil {
-		return nil, nil, err
+		return err
 	}
 
-	rows, err := tx.QueryxContext(ctx, query, args...)
+	result, err := tx.ExecContext(ctx, query, args...)
 	if err != nil {
-		return nil, nil, err
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected != int64(len(packConfigIds)) {
+		err = &PackError{"only inactive packs that have been approved by an admin can be activated"}
+		return err
 	}
 
-	itemNames := []string{}
-	packTitles := []string{}
+	// updating pack amount for all activated pack count
+	if err = r.UpdateVendorPackAmount(ctx, vendorId, len(packConfigIds), tx); err != nil {
+		return err
+	}
 
-	defer rows.Close()
-	for rows.Next() {
-		packTitle := ""
-		itemName := ""
-		vendorId := ""
-		if err = rows.Scan(&packTitle, &vendorId, &itemName); err != nil {
-			return nil, nil, err
-		}
-		itemNames = append(itemNames, itemName)
-		packTitles = append(packTitles, packTitle)
+	if err = tx.Commit(); err != nil {
+		return err
 	}
-	return itemNames, packTitles, nil
+	return nil
 }
 
-func (r *PackRepoImpl) UploadPacks(c context.Context, packFacts []*model.PackFact, packConfigId uint64) ([]uint64, error) {
+// activates the approved pack configs whose release date has passed and returns their ids and vendors.
+// the release date is kept, deactivating a pack clears it so a released pack is never put back on sale
+func (r *PackRepoImpl) ReleaseScheduledPackConfigs(c context.Context, releasedBefore string) ([]*model.PackConfig, error) {
 	ctx, cancel := context.WithTimeout(c, 5*time.Second)
 	defer cancel()
 
@@ -979,41 +3318,58 @@ func (r *PackRepoImpl) UploadPacks(c context.Context, packFacts []*model.PackFac
 	}()
 
 	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
-	query := psql.
-		Insert(db.SCHEMA_PACK_FACTS).
-		Columns(core.ModelColumns(packFacts[0])...).
-		Suffix("RETURNING id")
-
-	packIds := []uint64{}
-	for _, packFact := range packFacts {
-		query = query.Values(core.StructValues(packFact)...)
-	}
-
-	queryStr, args, err := query.ToSql()
+	query, args, err := psql.
+		Update(db.SCHEMA_PACK_CONFIGS).
+		SetMap(map[string]interface{}{
+			"active": true,
+		}).
+		Where(squirrel.Eq{
+			"active":        false,
+			"deleted_at":    nil,
+			"review_status": db.PACK_REVIEW_APPROVED,
+		}).
+		Where(squirrel.LtOrEq{"release_at": releasedBefore}).
+		Suffix("RETURNING id, vendor_id").
+		ToSql()
 	if err != nil {
 		return nil, err
 	}
 
-	rows, err := tx.QueryxContext(ctx, queryStr, args...)
+	rows, err := tx.QueryxContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
+
+	releasedConfigs := []*model.PackConfig{}
+	vendorPackAmounts := map[string]int{}
 	defer rows.Close()
 	for rows.Next() {
-		var id uint64
-		if err := rows.Scan(&id); err != nil {
+		packConfig := model.PackConfig{}
+		if err = rows.StructScan(&packConfig); err != nil {
+			return nil, err
+		}
+		releasedConfigs = append(releasedConfigs, &packConfig)
+		vendorPackAmounts[*packConfig.VendorID]++
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	// updating pack amount for every vendor with released packs
+	for vendorId, packAmount := range vendorPackAmounts {
+		if err = r.UpdateVendorPackAmount(ctx, vendorId, packAmount, tx); err != nil {
 			return nil, err
 		}
-		packIds = append(packIds, id)
 	}
 
 	if err = tx.Commit(); err != nil {
 		return nil, err
 	}
-	return packIds, nil
+	return releasedConfigs, nil
 }
 
-func (r *PackRepoImpl) UploadPackItems(c context.Context, packItemFacts []*model.PackItemFact) error {
+func (r *PackRepoImpl) SubmitPackConfigs(c context.Context, packConfigIds []uint64, vendorId string) error {
 	ctx, cancel := context.WithTimeout(c, 5*time.Second)
 	defer cancel()
 
@@ -1030,72 +3386,56 @@ func (r *PackRepoImpl) UploadPackItems(c context.Context, packItemFacts []*model
 		}
 	}()
 
-	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
-	query := psql.
-		Insert(db.SCHEMA_PACK_ITEM_FACTS).
-		Columns(core.ModelColumns(packItemFacts[0])...)
-
-	for _, packItemFact := range packItemFacts {
-		query = query.Values(core.StructValues(packItemFact)...)
-	}
-
-	queryStr, args, err := query.ToSql()
-	if err != nil {
-		return err
-	}
-
-	_, err = tx.ExecContext(ctx, queryStr, args...)
-	if err != nil {
-		return err
-	}
-
-	err = tx.Commit()
-	return err
-}
-
-func (r *PackRepoImpl) UpdatePackStock(c context.Context, packConfigId uint64, amount int, tx *sqlx.Tx) error {
+	/* Only drafts and previously rejected packs can be submitted for review */
+	now := time.Now().Format("2006-01-02 15:04:05")
 	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 	query, args, err := psql.
 		Update(db.SCHEMA_PACK_CONFIGS).
-		Set("current_stock", squirrel.Expr("current_stock + ?", amount)).
-		Where(squirrel.Eq{"id": packConfigId}).
+		SetMap(map[string]interface{}{
+			"review_status": db.PACK_REVIEW_SUBMITTED,
+			"submitted_at":  now,
+		}).
+		Where(squirrel.Eq{
+			"id":            packConfigIds,
+			"vendor_id":     vendorId,
+			"deleted_at":    nil,
+			"review_status": []string{db.PACK_REVIEW_DRAFT, db.PACK_REVIEW_REJECTED},
+		}).
 		ToSql()
 	if err != nil {
 		return err
 	}
 
-	_, err = tx.ExecContext(c, query, args...)
+	result, err := tx.ExecContext(ctx, query, args...)
 	if err != nil {
 		return err
 	}
-	return nil
-}
 
-func (r *PackRepoImpl) UpdatePackQtySold(c context.Context, packConfigId uint64, amount int, tx *sqlx.Tx) error {
-	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
-	query, args, err := psql.
-		Update(db.SCHEMA_PACK_CONFIGS).
-		Set("qty_sold", squirrel.Expr("qty_sold + ?", amount)).
-		Where(squirrel.Eq{"id": packConfigId}).
-		ToSql()
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return err
 	}
 
-	_, err = tx.ExecContext(c, query, args...)
-	if err != nil {
+	if rowsAffected != int64(len(packConfigIds)) {
+		err = &PackError{"only draft or rejected packs owned by the vendor can be submitted for review"}
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (r *PackRepoImpl) PatchPackConfig(c context.Context, packConfigId uint64, patchMap map[string]interface{}, vendorId string) error {
+// puts a submitted or approved pack config back to draft and takes it out of the shop. returns whether
+// the pack config was withdrawn, drafts and rejected packs are left as they are
+func (r *PackRepoImpl) WithdrawPackReview(c context.Context, packConfigId uint64, vendorId string) (bool, error) {
 	ctx, cancel := context.WithTimeout(c, 5*time.Second)
 	defer cancel()
 
 	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	defer func() {
@@ -1108,36 +3448,75 @@ func (r *PackRepoImpl) PatchPackConfig(c context.Context, packConfigId uint64, p
 
 	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 	query, args, err := psql.
-		Update(db.SCHEMA_PACK_CONFIGS).
-		SetMap(patchMap).
-		Where(squirrel.Eq{"id": packConfigId, "vendor_id": vendorId}).
+		Select("active").
+		From(db.SCHEMA_PACK_CONFIGS).
+		Where(squirrel.Eq{
+			"id":            packConfigId,
+			"vendor_id":     vendorId,
+			"review_status": []string{db.PACK_REVIEW_SUBMITTED, db.PACK_REVIEW_APPROVED},
+		}).
+		Suffix("FOR UPDATE").
 		ToSql()
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	result, err := tx.ExecContext(ctx, query, args...)
+	rows, err := tx.QueryxContext(ctx, query, args...)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	found := false
+	var active *bool
+	for rows.Next() {
+		found = true
+		if err = rows.Scan(&active); err != nil {
+			rows.Close()
+			return false, err
+		}
+	}
+	rows.Close()
+
+	if !found {
+		err = tx.Commit()
+		return false, err
+	}
+
+	/* Like deactivating, the release at date is removed so pack release scheduling does not put it back on sale */
+	query, args, err = psql.
+		Update(db.SCHEMA_PACK_CONFIGS).
+		SetMap(map[string]interface{}{
+			"review_status": db.PACK_REVIEW_DRAFT,
+			"review_notes":  nil,
+			"submitted_at":  nil,
+			"reviewed_at":   nil,
+			"reviewed_by":   nil,
+			"active":        false,
+			"release_at":    nil,
+		}).
+		Where(squirrel.Eq{"id": packConfigId}).
+		ToSql()
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	if rowsAffected > 0 {
-		if err = tx.Commit(); err != nil {
-			return err
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return false, err
+	}
+
+	if active != nil && *active {
+		if err = r.UpdateVendorPackAmount(ctx, vendorId, -1, tx); err != nil {
+			return false, err
 		}
-		return nil
-	} else {
-		err = &PackError{"Pack config either is not owned by the authorized user or could not be mapped using the given patch."}
-		return err
 	}
+
+	if err = tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
-func (r *PackRepoImpl) PatchPackConfigs(c context.Context, packConfigIds []uint64, patchMap map[string]interface{}) error {
+func (r *PackRepoImpl) ReviewPackConfig(c context.Context, packConfigId uint64, status string, notes *string, adminUid string) error {
 	ctx, cancel := context.WithTimeout(c, 5*time.Second)
 	defer cancel()
 
@@ -1154,17 +3533,27 @@ func (r *PackRepoImpl) PatchPackConfigs(c context.Context, packConfigIds []uint6
 		}
 	}()
 
+	now := time.Now().Format("2006-01-02 15:04:05")
 	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 	query, args, err := psql.
 		Update(db.SCHEMA_PACK_CONFIGS).
-		SetMap(patchMap).
-		Where(squirrel.Eq{"id": packConfigIds}).
+		SetMap(map[string]interface{}{
+			"review_status": status,
+			"review_notes":  notes,
+			"reviewed_at":   now,
+			"reviewed_by":   adminUid,
+		}).
+		Where(squirrel.Eq{
+			"id":            packConfigId,
+			"deleted_at":    nil,
+			"review_status": db.PACK_REVIEW_SUBMITTED,
+		}).
 		ToSql()
 	if err != nil {
 		return err
 	}
 
-	result, err := r.db.ExecContext(ctx, query, args...)
+	result, err := tx.ExecContext(ctx, query, args...)
 	if err != nil {
 		return err
 	}
@@ -1174,80 +3563,58 @@ func (r *PackRepoImpl) PatchPackConfigs(c context.Context, packConfigIds []uint6
 		return err
 	}
 
-	if rowsAffected > 0 {
-
-		return err
-	} else {
-		err = &PackError{
-			fmt.Sprintf("An error occurred during the update. PackConfig either is not active or could not be mapped using the given patch. %v", err.Error()),
-		}
+	if rowsAffected == 0 {
+		err = &PackError{"pack config does not exist or has not been submitted for review"}
 		return err
 	}
-}
 
-func (r *PackRepoImpl) DeleteInactivePacks(c context.Context, packConfigIds []uint64, tx *sqlx.Tx) error {
-	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
-	query, args, err := psql.
-		Delete(db.SCHEMA_PACK_FACTS).
-		Where(squirrel.Eq{"pack_config_id": packConfigIds, "purchased_at": nil, "owner_id": nil}).
-		ToSql()
-	if err != nil {
+	if err = tx.Commit(); err != nil {
 		return err
 	}
-
-	_, err = tx.ExecContext(c, query, args...)
-	return err
+	return nil
 }
 
-func (r *PackRepoImpl) ActivatePacks(c context.Context, packConfigIds []uint64, vendorId string) error {
+func (r *PackRepoImpl) GetPackConfigsByReviewStatus(c context.Context, status string) ([]*model.PackConfig, error) {
 	ctx, cancel := context.WithTimeout(c, 5*time.Second)
 	defer cancel()
 
 	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	defer func() {
-		if err != nil {
-			if err = tx.Rollback(); err != nil {
-				fmt.Println(err)
-			}
+		if err = tx.Commit(); err != nil {
+			fmt.Println(err)
 		}
 	}()
 
-	/* Activate packs only if they have not been deleted, previously inactive, and have not been scheduled yet */
 	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 	query, args, err := psql.
-		Update(db.SCHEMA_PACK_CONFIGS).
-		SetMap(map[string]interface{}{
-			"active": true,
-		}).
-		Where(squirrel.Eq{
-			"id":         packConfigIds,
-			"vendor_id":  vendorId,
-			"active":     false,
-			"deleted_at": nil,
-		}).
+		Select("*").
+		From(db.SCHEMA_PACK_CONFIGS).
+		Where(squirrel.Eq{"review_status": status, "deleted_at": nil}).
+		OrderBy("submitted_at asc").
 		ToSql()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	_, err = tx.ExecContext(ctx, query, args...)
+	rows, err := tx.QueryxContext(ctx, query, args...)
 	if err != nil {
-		return err
-	}
-
-	// updating pack amount for all activated pack count
-	if err = r.UpdateVendorPackAmount(ctx, vendorId, len(packConfigIds), tx); err != nil {
-		return err
+		return nil, err
 	}
 
-	if err = tx.Commit(); err != nil {
-		return err
+	packConfigs := []*model.PackConfig{}
+	defer rows.Close()
+	for rows.Next() {
+		packConfig := model.PackConfig{}
+		if err := rows.StructScan(&packConfig); err != nil {
+			return nil, err
+		}
+		packConfigs = append(packConfigs, &packConfig)
 	}
-	return nil
+	return packConfigs, nil
 }
 
 func (r *PackRepoImpl) DeactivatePacks(c context.Context, packConfigIds []uint64, vendorId string) error {
@@ -1378,6 +3745,10 @@ func (r *PackRepoImpl) UpdateVendorPackAmount(c context.Context, vendorId string
 	return nil
 }
 
+func (r *PackRepoImpl) ClearPackWaitlistSizeCache(c context.Context, vendorId string) error {
+	return r.cache.Del(c, db.KEY_PACK_WAITLIST_SIZE+vendorId).Err()
+}
+
 func (r *PackRepoImpl) ClearPackConfigCache(c context.Context, packConfigIds []uint64, vendorId string) error {
 	allKeys := []string{}
 
@@ -1445,6 +3816,83 @@ func (r *PackRepoImpl) ClearPackShopCache(c context.Context) error {
 	return nil
 }
 
+func (r *PackRepoImpl) GetReceivedGifts(c context.Context, uid string) ([]*model.ReceivedGift, error) {
+	val, err := r.cache.Get(c, db.KEY_GIFTS_RECEIVED+uid).Result()
+	if err != nil {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+		if err != nil {
+			return nil, err
+		}
+
+		defer func() {
+			if err = tx.Commit(); err != nil {
+				fmt.Println(err)
+			}
+		}()
+
+		psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+		query, args, err := psql.
+			Select(
+				"g.id",
+				"g.sender_uid",
+				"u.username as sender_username",
+				"g.pack_config_id",
+				"pc.title",
+				"pc.image_url",
+				"g.pack_amount",
+				"g.message",
+				"g.created_at",
+			).
+			From(db.SCHEMA_PACK_GIFTS + " g").
+			Join("main.pack_configs pc on g.pack_config_id = pc.id").
+			LeftJoin("main.users u on g.sender_uid = u.uid").
+			Where(squirrel.Eq{"g.recipient_uid": uid}).
+			OrderBy("g.created_at desc").
+			Limit(100).
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+
+		rows, err := tx.QueryxContext(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+
+		gifts := []*model.ReceivedGift{}
+		defer rows.Close()
+		for rows.Next() {
+			gift := model.ReceivedGift{}
+			if err := rows.StructScan(&gift); err != nil {
+				return nil, err
+			}
+			gifts = append(gifts, &gift)
+		}
+
+		giftBytes, err := json.Marshal(gifts)
+		if err != nil {
+			return nil, err
+		}
+		if err = r.cache.Set(c, db.KEY_GIFTS_RECEIVED+uid, giftBytes, time.Duration(3600)*time.Second).Err(); err != nil {
+			return nil, err
+		}
+		return gifts, nil
+	} else {
+		gifts := []*model.ReceivedGift{}
+		if err = json.Unmarshal([]byte(val), &gifts); err != nil {
+			return nil, err
+		}
+		return gifts, nil
+	}
+}
+
+func (r *PackRepoImpl) ClearGiftCache(c context.Context, uid string) error {
+	return r.cache.Del(c, db.KEY_GIFTS_RECEIVED+uid).Err()
+}
+
 func (r *PackRepoImpl) ClearUserPackCache(c context.Context, uid string) error {
 	keys, err := r.cache.Keys(c, fmt.Sprintf("/user/packs/%v*", uid)).Result()
 	if err != nil {
@@ -1483,8 +3931,8 @@ func (r *PackRepoImpl) ClearVendorPackCache(c context.Context, vendorId string)
 	return nil
 }
 
-func (r *PackRepoImpl) GeneratePackItemOdds(c context.Context, items []model.Item, totalItems int) (map[uint64]int, error) {
-	return core.GenerateOdds(items, totalItems)
+func (r *PackRepoImpl) GeneratePackItemOdds(c context.Context, items []model.Item, totalItems int, rarityTiers map[uint64]int) (map[uint64]int, error) {
+	return core.GenerateOdds(items, totalItems, rarityTiers)
 }
 
 func (r *PackRepoImpl) ClearPackCache(c context.Context, packId uint64) error {
@@ -2,8 +2,15 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+	"xo-packs/core"
+	"xo-packs/db"
 	"xo-packs/model"
 
+	"github.com/Masterminds/squirrel"
 	"github.com/jmoiron/sqlx"
 	"github.com/redis/go-redis/v9"
 )
@@ -11,7 +18,12 @@ import (
 type ShippingRepository interface {
 	GetShippingInfo(context.Context, string) (*model.ShippingInfo, error)
 	UploadShippingInfo(context.Context, string, *model.ShippingInfo) (*model.ShippingInfo, error)
-	UpdateShippingInfo(context.Context, string) error
+	UpdateShippingInfo(context.Context, string, map[string]interface{}) (*model.ShippingInfo, error)
+	RequestWithdrawal(context.Context, uint64, string) (*uint64, error)
+	GetWithdrawal(context.Context, uint64) (*model.ItemWithdrawalExpanded, error)
+	GetUserWithdrawals(context.Context, string) ([]*model.ItemWithdrawalExpanded, error)
+	GetWithdrawalQueue(context.Context, string, string) ([]*model.ItemWithdrawalExpanded, error)
+	UpdateWithdrawalStatus(context.Context, uint64, string, string, string, *model.WithdrawalStatusReq) (*model.ItemWithdrawal, error)
 }
 
 type ShippingRepoImpl struct {
@@ -23,14 +35,538 @@ func NewShippingRepo(db *sqlx.DB, cache *redis.Client) ShippingRepository {
 	return &ShippingRepoImpl{db: db, cache: cache}
 }
 
+type ShippingError struct {
+	message string
+}
+
+func (e *ShippingError) Error() string {
+	return e.message
+}
+
+var shippingInfoColumns = []string{"id", "uid", "name", "street_address1", "street_address2", "city", "state", "zip", "country", "created_at", "updated_at", "deleted_at"}
+
+var withdrawalColumns = []string{
+	"w.id",
+	"w.user_item_id",
+	"w.uid",
+	"u.username",
+	"w.status",
+	"w.name",
+	"w.street_address1",
+	"w.street_address2",
+	"w.city",
+	"w.state",
+	"w.zip",
+	"w.country",
+	"w.carrier",
+	"w.tracking_number",
+	"w.withdrawn_at",
+	"w.packed_at",
+	"w.shipped_at",
+	"w.fulfilled_at",
+	"w.cancelled_at",
+	"i.id as item_id",
+	"i.name as item_name",
+	"i.image_url",
+	"ui.serial_number",
+	"i.vendor_id",
+}
+
 func (r *ShippingRepoImpl) GetShippingInfo(c context.Context, uid string) (*model.ShippingInfo, error) {
-	return nil, nil
+	val, err := r.cache.Get(c, db.KEY_SHIPPING_INFO+uid).Result()
+	if err != nil {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+		query, args, err := psql.
+			Select(shippingInfoColumns...).
+			From(db.SCHEMA_SHIPPING_INFO).
+			Where(squirrel.Eq{"uid": uid, "deleted_at": nil}).
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+
+		shippingInfo := model.ShippingInfo{}
+		if err = r.db.QueryRowxContext(ctx, query, args...).StructScan(&shippingInfo); err != nil {
+			if err == sql.ErrNoRows {
+				return nil, &ShippingError{"user has no shipping info"}
+			}
+			return nil, err
+		}
+
+		shippingInfoBytes, err := json.Marshal(shippingInfo)
+		if err != nil {
+			return nil, err
+		}
+		if err = r.cache.Set(c, db.KEY_SHIPPING_INFO+uid, shippingInfoBytes, time.Duration(3600)*time.Second).Err(); err != nil {
+			return nil, err
+		}
+		return &shippingInfo, nil
+	} else {
+		shippingInfo := model.ShippingInfo{}
+		if err = json.Unmarshal([]byte(val), &shippingInfo); err != nil {
+			return nil, err
+		}
+		return &shippingInfo, nil
+	}
 }
 
+// replaces the users shipping address. the previous address is soft deleted rather than overwritten, withdrawals
+// keep their own snapshot of the address they were requested with
 func (r *ShippingRepoImpl) UploadShippingInfo(c context.Context, uid string, shippingInfo *model.ShippingInfo) (*model.ShippingInfo, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Update(db.SCHEMA_SHIPPING_INFO).
+		Set("deleted_at", now).
+		Where(squirrel.Eq{"uid": uid, "deleted_at": nil}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, err
+	}
+
+	shippingInfo.ID = nil
+	shippingInfo.Uid = &uid
+	shippingInfo.CreatedAt = &now
+	shippingInfo.UpdatedAt = nil
+	shippingInfo.DeletedAt = nil
+	query, args, err = psql.
+		Insert(db.SCHEMA_SHIPPING_INFO).
+		Columns(core.ModelColumns(*shippingInfo)...).
+		Values(core.StructValues(*shippingInfo)...).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	shippingInfoId := new(uint64)
+	if err = tx.QueryRowContext(ctx, query, args...).Scan(shippingInfoId); err != nil {
+		return nil, err
+	}
+	shippingInfo.ID = shippingInfoId
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if err := r.cache.Del(c, db.KEY_SHIPPING_INFO+uid).Err(); err != nil {
+		return nil, err
+	}
 	return shippingInfo, nil
 }
 
-func (r *ShippingRepoImpl) UpdateShippingInfo(c context.Context, uid string) error {
-	return nil
+// updates the users current shipping info with an already mapped patch of address columns
+func (r *ShippingRepoImpl) UpdateShippingInfo(c context.Context, uid string, shippingInfoPatchMap map[string]interface{}) (*model.ShippingInfo, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	shippingInfoPatchMap["updated_at"] = time.Now().Format("2006-01-02 15:04:05")
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Update(db.SCHEMA_SHIPPING_INFO).
+		SetMap(shippingInfoPatchMap).
+		Where(squirrel.Eq{"uid": uid, "deleted_at": nil}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, &ShippingError{"user has no shipping info"}
+	}
+
+	if err = r.cache.Del(c, db.KEY_SHIPPING_INFO+uid).Err(); err != nil {
+		return nil, err
+	}
+	return r.GetShippingInfo(c, uid)
+}
+
+// puts a hold on a user item for physical fulfillment. the users current shipping address is copied onto the
+// withdrawal so later address changes do not redirect an item that is already being worked
+func (r *ShippingRepoImpl) RequestWithdrawal(c context.Context, userItemId uint64, uid string) (*uint64, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("id", "uid", "item_id", "acquired_at", "removed_at", "expired_at", "serial_number").
+		From(db.SCHEMA_USER_ITEMS).
+		Where(squirrel.Eq{"id": userItemId}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	userItem := model.UserItem{}
+	if err = tx.QueryRowxContext(ctx, query, args...).StructScan(&userItem); err != nil {
+		if err == sql.ErrNoRows {
+			err = &ShippingError{"user item does not exist"}
+		}
+		return nil, err
+	}
+	if userItem.Uid == nil || *userItem.Uid != uid {
+		err = &ShippingError{"user item does not exist for authorized user"}
+		return nil, err
+	}
+	if userItem.RemovedAt != nil || core.UserItemExpired(userItem.ExpiredAt, time.Now()) {
+		err = &ShippingError{"user item is no longer in the users collection"}
+		return nil, err
+	}
+
+	query, args, err = psql.
+		Select("count(*)").
+		From(db.SCHEMA_ITEM_WITHDRAWALS).
+		Where(squirrel.Eq{"user_item_id": userItemId}).
+		Where(squirrel.NotEq{"status": db.WITHDRAWAL_CANCELLED}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	withdrawals := 0
+	if err = tx.QueryRowContext(ctx, query, args...).Scan(&withdrawals); err != nil {
+		return nil, err
+	}
+	if withdrawals > 0 {
+		err = &ShippingError{"user item has already been withdrawn"}
+		return nil, err
+	}
+
+	query, args, err = psql.
+		Select(shippingInfoColumns...).
+		From(db.SCHEMA_SHIPPING_INFO).
+		Where(squirrel.Eq{"uid": uid, "deleted_at": nil}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	shippingInfo := model.ShippingInfo{}
+	if err = tx.QueryRowxContext(ctx, query, args...).StructScan(&shippingInfo); err != nil {
+		if err == sql.ErrNoRows {
+			err = &ShippingError{"shipping info must be added before withdrawing an item"}
+		}
+		return nil, err
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	status := db.WITHDRAWAL_REQUESTED
+	itemWithdrawal := model.ItemWithdrawal{
+		UserItemId:     &userItemId,
+		Uid:            &uid,
+		Status:         &status,
+		Name:           shippingInfo.Name,
+		StreetAddress1: shippingInfo.StreetAddress1,
+		StreetAddress2: shippingInfo.StreetAddress2,
+		City:           shippingInfo.City,
+		State:          shippingInfo.State,
+		Zip:            shippingInfo.Zip,
+		Country:        shippingInfo.Country,
+		WithdrawnAt:    &now,
+	}
+	query, args, err = psql.
+		Insert(db.SCHEMA_ITEM_WITHDRAWALS).
+		Columns(core.ModelColumns(itemWithdrawal)...).
+		Values(core.StructValues(itemWithdrawal)...).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	withdrawalId := new(uint64)
+	if err = tx.QueryRowContext(ctx, query, args...).Scan(withdrawalId); err != nil {
+		return nil, err
+	}
+
+	notes := fmt.Sprintf("withdrawal %v", *withdrawalId)
+	withdrawnEvent := &model.ItemEvent{UserItemId: &userItemId, FromUid: &uid, ActorUid: &uid, Notes: &notes}
+	if err = addItemEvents(ctx, tx, db.ITEM_EVENT_WITHDRAWN, now, []*model.ItemEvent{withdrawnEvent}); err != nil {
+		return nil, err
+	}
+
+	listingsRemoved, err := removeMarketListings(ctx, tx, squirrel.Eq{"user_item_id": userItemId}, db.ITEM_EVENT_WITHDRAWN, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if listingsRemoved {
+		if err := clearMarketListingCache(c, r.cache); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.cache.Del(c, db.KEY_USER_WITHDRAWALS+uid).Err(); err != nil {
+		return nil, err
+	}
+	return withdrawalId, nil
+}
+
+func (r *ShippingRepoImpl) GetWithdrawal(c context.Context, withdrawalId uint64) (*model.ItemWithdrawalExpanded, error) {
+	val, err := r.cache.Get(c, db.KEY_ITEM_WITHDRAWALS+fmt.Sprintf("%v", withdrawalId)).Result()
+	if err != nil {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		withdrawals, err := r.getWithdrawals(ctx, squirrel.Eq{"w.id": withdrawalId})
+		if err != nil {
+			return nil, err
+		}
+		if len(withdrawals) == 0 {
+			return nil, &ShippingError{"withdrawal does not exist"}
+		}
+
+		withdrawalBytes, err := json.Marshal(withdrawals[0])
+		if err != nil {
+			return nil, err
+		}
+		if err = r.cache.Set(c, db.KEY_ITEM_WITHDRAWALS+fmt.Sprintf("%v", withdrawalId), withdrawalBytes, time.Duration(3600)*time.Second).Err(); err != nil {
+			return nil, err
+		}
+		return withdrawals[0], nil
+	} else {
+		withdrawal := model.ItemWithdrawalExpanded{}
+		if err = json.Unmarshal([]byte(val), &withdrawal); err != nil {
+			return nil, err
+		}
+		return &withdrawal, nil
+	}
+}
+
+func (r *ShippingRepoImpl) GetUserWithdrawals(c context.Context, uid string) ([]*model.ItemWithdrawalExpanded, error) {
+	val, err := r.cache.Get(c, db.KEY_USER_WITHDRAWALS+uid).Result()
+	if err != nil {
+		ctx, cancel := context.WithTimeout(c, 5*time.Second)
+		defer cancel()
+
+		withdrawals, err := r.getWithdrawals(ctx, squirrel.Eq{"w.uid": uid})
+		if err != nil {
+			return nil, err
+		}
+
+		withdrawalsBytes, err := json.Marshal(withdrawals)
+		if err != nil {
+			return nil, err
+		}
+		if err = r.cache.Set(c, db.KEY_USER_WITHDRAWALS+uid, withdrawalsBytes, time.Duration(3600)*time.Second).Err(); err != nil {
+			return nil, err
+		}
+		return withdrawals, nil
+	} else {
+		withdrawals := []*model.ItemWithdrawalExpanded{}
+		if err = json.Unmarshal([]byte(val), &withdrawals); err != nil {
+			return nil, err
+		}
+		return withdrawals, nil
+	}
+}
+
+// the fulfillment queue is read straight from the db since it is worked as statuses change. an empty vendorId
+// returns the queue across every creator and an empty status returns every status
+func (r *ShippingRepoImpl) GetWithdrawalQueue(c context.Context, vendorId string, status string) ([]*model.ItemWithdrawalExpanded, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	where := squirrel.Eq{}
+	if vendorId != "" {
+		where["i.vendor_id"] = vendorId
+	}
+	if status != "" {
+		where["w.status"] = status
+	}
+	return r.getWithdrawals(ctx, where)
+}
+
+// moves a withdrawal along its status machine. a set vendorId limits the change to withdrawals of that creators
+// items and a set uid to the owners own withdrawals. cancelling lifts the hold so the item is back in the users
+// collection, delivering marks the withdrawal fulfilled
+func (r *ShippingRepoImpl) UpdateWithdrawalStatus(c context.Context, withdrawalId uint64, vendorId string, uid string, actorUid string, req *model.WithdrawalStatusReq) (*model.ItemWithdrawal, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("w.id", "w.user_item_id", "w.uid", "w.status", "w.carrier", "w.tracking_number", "i.vendor_id").
+		From(db.SCHEMA_ITEM_WITHDRAWALS + " w").
+		Join(db.SCHEMA_USER_ITEMS + " ui on ui.id = w.user_item_id").
+		Join(db.SCHEMA_ITEMS + " i on i.id = ui.item_id").
+		Where(squirrel.Eq{"w.id": withdrawalId}).
+		Suffix("FOR UPDATE OF w").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	withdrawal := model.ItemWithdrawalExpanded{}
+	if err = tx.QueryRowxContext(ctx, query, args...).StructScan(&withdrawal); err != nil {
+		if err == sql.ErrNoRows {
+			err = &ShippingError{"withdrawal does not exist"}
+		}
+		return nil, err
+	}
+	if vendorId != "" && (withdrawal.VendorId == nil || *withdrawal.VendorId != vendorId) {
+		err = &ShippingError{"withdrawal does not exist for creator"}
+		return nil, err
+	}
+	if uid != "" && (withdrawal.Uid == nil || *withdrawal.Uid != uid) {
+		err = &ShippingError{"withdrawal does not exist for authorized user"}
+		return nil, err
+	}
+	if withdrawal.Status == nil || !core.WithdrawalTransitionAllowed(*withdrawal.Status, *req.Status) {
+		err = &ShippingError{fmt.Sprintf("withdrawal cannot move to %v from its current status", *req.Status)}
+		return nil, err
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	updates := map[string]interface{}{"status": *req.Status}
+	if req.Carrier != nil {
+		updates["carrier"] = *req.Carrier
+	}
+	if req.TrackingNumber != nil {
+		updates["tracking_number"] = *req.TrackingNumber
+	}
+
+	switch *req.Status {
+	case db.WITHDRAWAL_PACKED:
+		updates["packed_at"] = now
+	case db.WITHDRAWAL_SHIPPED:
+		if req.Carrier == nil && withdrawal.Carrier == nil || req.TrackingNumber == nil && withdrawal.TrackingNumber == nil {
+			err = &ShippingError{"a carrier and tracking number must be given to ship a withdrawal"}
+			return nil, err
+		}
+		updates["shipped_at"] = now
+	case db.WITHDRAWAL_DELIVERED:
+		updates["fulfilled_at"] = now
+	case db.WITHDRAWAL_CANCELLED:
+		updates["cancelled_at"] = now
+	}
+
+	query, args, err = psql.
+		Update(db.SCHEMA_ITEM_WITHDRAWALS).
+		SetMap(updates).
+		Where(squirrel.Eq{"id": withdrawalId}).
+		Suffix("RETURNING id, user_item_id, uid, status, name, street_address1, street_address2, city, state, zip, country, " +
+			"carrier, tracking_number, withdrawn_at, packed_at, shipped_at, fulfilled_at, cancelled_at").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	updated := model.ItemWithdrawal{}
+	if err = tx.QueryRowxContext(ctx, query, args...).StructScan(&updated); err != nil {
+		return nil, err
+	}
+
+	if *req.Status == db.WITHDRAWAL_CANCELLED {
+		notes := fmt.Sprintf("withdrawal %v cancelled", withdrawalId)
+		returnedEvent := &model.ItemEvent{UserItemId: withdrawal.UserItemId, ToUid: withdrawal.Uid, ActorUid: &actorUid, Notes: &notes}
+		if err = addItemEvents(ctx, tx, db.ITEM_EVENT_RETURNED, now, []*model.ItemEvent{returnedEvent}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	keys := []string{db.KEY_ITEM_WITHDRAWALS + fmt.Sprintf("%v", withdrawalId), db.KEY_USER_WITHDRAWALS + *withdrawal.Uid}
+	for _, key := range keys {
+		if err := r.cache.Del(c, key).Err(); err != nil {
+			return nil, err
+		}
+	}
+	return &updated, nil
+}
+
+func (r *ShippingRepoImpl) getWithdrawals(ctx context.Context, where squirrel.Eq) ([]*model.ItemWithdrawalExpanded, error) {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select(withdrawalColumns...).
+		From(db.SCHEMA_ITEM_WITHDRAWALS + " w").
+		Join(db.SCHEMA_USER_ITEMS + " ui on ui.id = w.user_item_id").
+		Join(db.SCHEMA_ITEMS + " i on i.id = ui.item_id").
+		LeftJoin(db.SCHEMA_USERS + " u on u.uid = w.uid").
+		Where(where).
+		OrderBy("w.withdrawn_at desc").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	withdrawals := []*model.ItemWithdrawalExpanded{}
+	defer rows.Close()
+	for rows.Next() {
+		withdrawal := model.ItemWithdrawalExpanded{}
+		if err := rows.StructScan(&withdrawal); err != nil {
+			return nil, err
+		}
+		withdrawals = append(withdrawals, &withdrawal)
+	}
+	return withdrawals, nil
 }
@@ -0,0 +1,613 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"xo-packs/core"
+	"xo-packs/db"
+	"xo-packs/model"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+)
+
+type TradeRepository interface {
+	CreateTradeOffer(context.Context, *model.TradeOffer, []uint64, []uint64) (*model.TradeOfferExpanded, error)
+	CounterTradeOffer(context.Context, uint64, *model.TradeOffer, []uint64, []uint64) (*model.TradeOfferExpanded, error)
+	AcceptTradeOffer(context.Context, uint64, string) (*model.TradeOfferExpanded, error)
+	CloseTradeOffer(context.Context, uint64, string, string) (*model.TradeOfferExpanded, error)
+	GetTradeOffer(context.Context, uint64) (*model.TradeOfferExpanded, error)
+	GetTradeHistory(context.Context, string, string) ([]*model.TradeOfferExpanded, error)
+}
+
+type TradeRepoImpl struct {
+	db    *sqlx.DB
+	cache *redis.Client
+}
+
+func NewTradeRepo(db *sqlx.DB, cache *redis.Client) TradeRepository {
+	return &TradeRepoImpl{db: db, cache: cache}
+}
+
+type TradeError struct {
+	message string
+}
+
+func (e *TradeError) Error() string {
+	return e.message
+}
+
+func (r *TradeRepoImpl) CreateTradeOffer(c context.Context, offer *model.TradeOffer, offeredIds []uint64, requestedIds []uint64) (*model.TradeOfferExpanded, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	offerId, err := insertTradeOffer(ctx, tx, offer, offeredIds, requestedIds)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetTradeOffer(c, offerId)
+}
+
+// answers a pending offer with a new offer going the other way. the original offer is closed as countered
+func (r *TradeRepoImpl) CounterTradeOffer(c context.Context, offerId uint64, counter *model.TradeOffer, offeredIds []uint64, requestedIds []uint64) (*model.TradeOfferExpanded, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	offer, err := lockPendingTradeOffer(ctx, tx, offerId, now)
+	if err != nil {
+		return nil, err
+	}
+	if *offer.RecipientUid != *counter.SenderUid {
+		err = &TradeError{"only the recipient of a trade offer can counter it"}
+		return nil, err
+	}
+
+	if err = setTradeOfferStatus(ctx, tx, offerId, db.TRADE_OFFER_COUNTERED, now); err != nil {
+		return nil, err
+	}
+
+	counter.RecipientUid = offer.SenderUid
+	counter.CounterOfId = &offerId
+	counterId, err := insertTradeOffer(ctx, tx, counter, offeredIds, requestedIds)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetTradeOffer(c, counterId)
+}
+
+// accepts a pending offer. ownership of every item is checked again and all items and tokens
+// change hands in one transaction, so the trade either completes in full or not at all
+func (r *TradeRepoImpl) AcceptTradeOffer(c context.Context, offerId uint64, uid string) (*model.TradeOfferExpanded, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	offer, err := lockPendingTradeOffer(ctx, tx, offerId, now)
+	if err != nil {
+		return nil, err
+	}
+	if *offer.RecipientUid != uid {
+		err = &TradeError{"only the recipient of a trade offer can accept it"}
+		return nil, err
+	}
+	senderUid := *offer.SenderUid
+
+	items, err := getTradeOfferItems(ctx, tx, []uint64{offerId})
+	if err != nil {
+		return nil, err
+	}
+
+	offeredIds := []uint64{}
+	requestedIds := []uint64{}
+	for _, item := range items {
+		if *item.OwnerUid == senderUid {
+			offeredIds = append(offeredIds, *item.UserItemId)
+		} else {
+			requestedIds = append(requestedIds, *item.UserItemId)
+		}
+	}
+
+	notes := fmt.Sprintf("trade offer %v", offerId)
+	if err = transferTradeItems(ctx, tx, offeredIds, senderUid, uid, notes, now); err != nil {
+		return nil, err
+	}
+	if err = transferTradeItems(ctx, tx, requestedIds, uid, senderUid, notes, now); err != nil {
+		return nil, err
+	}
+
+	if offer.SenderTokenAmount != nil && *offer.SenderTokenAmount > 0 {
+		debited := false
+		if debited, err = debitTokenBalance(ctx, tx, senderUid, *offer.SenderTokenAmount, now); err != nil {
+			return nil, err
+		}
+		if !debited {
+			err = &TradeError{"the sender no longer has enough tokens for this trade"}
+			return nil, err
+		}
+		if err = creditTokenBalance(ctx, tx, uid, *offer.SenderTokenAmount, now); err != nil {
+			return nil, err
+		}
+	}
+
+	// traded items leave their owners collection so any listing of them is taken down
+	tradedIds := append(append([]uint64{}, offeredIds...), requestedIds...)
+	listingsRemoved, err := removeMarketListings(ctx, tx, squirrel.Eq{"user_item_id": tradedIds}, "traded", now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = setTradeOfferStatus(ctx, tx, offerId, db.TRADE_OFFER_ACCEPTED, now); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	keys := []string{}
+	for _, id := range tradedIds {
+		keys = append(keys, db.KEY_USER_ITEM+fmt.Sprintf("%v", id))
+	}
+	if len(keys) > 0 {
+		if err := r.cache.Del(c, keys...).Err(); err != nil {
+			return nil, err
+		}
+	}
+	if listingsRemoved {
+		if err := clearMarketListingCache(c, r.cache); err != nil {
+			return nil, err
+		}
+	}
+	return r.GetTradeOffer(c, offerId)
+}
+
+// closes a pending offer without a trade. the recipient can decline an offer and the sender can cancel it
+func (r *TradeRepoImpl) CloseTradeOffer(c context.Context, offerId uint64, uid string, status string) (*model.TradeOfferExpanded, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	offer, err := lockPendingTradeOffer(ctx, tx, offerId, now)
+	if err != nil {
+		return nil, err
+	}
+	if status == db.TRADE_OFFER_DECLINED && *offer.RecipientUid != uid {
+		err = &TradeError{"only the recipient of a trade offer can decline it"}
+		return nil, err
+	}
+	if status == db.TRADE_OFFER_CANCELLED && *offer.SenderUid != uid {
+		err = &TradeError{"only the sender of a trade offer can cancel it"}
+		return nil, err
+	}
+
+	if err = setTradeOfferStatus(ctx, tx, offerId, status, now); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetTradeOffer(c, offerId)
+}
+
+func (r *TradeRepoImpl) GetTradeOffer(c context.Context, offerId uint64) (*model.TradeOfferExpanded, error) {
+	offers, err := r.getTradeOffers(c, squirrel.Eq{"t.id": offerId})
+	if err != nil {
+		return nil, err
+	}
+	if len(offers) == 0 {
+		return nil, &TradeError{fmt.Sprintf("trade offer %v does not exist", offerId)}
+	}
+	return offers[0], nil
+}
+
+// gets every offer the user sent or received, optionally only those with the given status
+func (r *TradeRepoImpl) GetTradeHistory(c context.Context, uid string, status string) ([]*model.TradeOfferExpanded, error) {
+	where := squirrel.And{squirrel.Or{squirrel.Eq{"t.sender_uid": uid}, squirrel.Eq{"t.recipient_uid": uid}}}
+	if status != "" {
+		where = append(where, squirrel.Eq{"t.status": status})
+	}
+	return r.getTradeOffers(c, where)
+}
+
+func (r *TradeRepoImpl) getTradeOffers(c context.Context, where squirrel.Sqlizer) ([]*model.TradeOfferExpanded, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	// offers past their expiry are closed before they are read
+	now := time.Now().Format("2006-01-02 15:04:05")
+	if err = expireTradeOffers(ctx, tx, now); err != nil {
+		return nil, err
+	}
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select(
+			"t.id",
+			"t.sender_uid",
+			"su.username as sender_username",
+			"t.recipient_uid",
+			"ru.username as recipient_username",
+			"t.sender_token_amount",
+			"t.message",
+			"t.status",
+			"t.counter_of_id",
+			"t.created_at",
+			"t.expires_at",
+			"t.responded_at",
+		).
+		From(db.SCHEMA_TRADE_OFFERS+" t").
+		LeftJoin(db.SCHEMA_USERS+" su on su.uid = t.sender_uid").
+		LeftJoin(db.SCHEMA_USERS+" ru on ru.uid = t.recipient_uid").
+		Where(where).
+		OrderBy("t.created_at desc", "t.id desc").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	offers := []*model.TradeOfferExpanded{}
+	offerMap := map[uint64]*model.TradeOfferExpanded{}
+	offerIds := []uint64{}
+	for rows.Next() {
+		offer := model.TradeOfferExpanded{
+			OfferedItems:   []*model.TradeOfferItemExpanded{},
+			RequestedItems: []*model.TradeOfferItemExpanded{},
+		}
+		if err = rows.StructScan(&offer); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		offers = append(offers, &offer)
+		offerMap[*offer.ID] = &offer
+		offerIds = append(offerIds, *offer.ID)
+	}
+	rows.Close()
+
+	if len(offerIds) > 0 {
+		items, err := getTradeOfferItems(ctx, tx, offerIds)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			offer := offerMap[*item.TradeOfferId]
+			if *item.OwnerUid == *offer.SenderUid {
+				offer.OfferedItems = append(offer.OfferedItems, item)
+			} else {
+				offer.RequestedItems = append(offer.RequestedItems, item)
+			}
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return offers, nil
+}
+
+// validates both sides of an offer and inserts it along with its items
+func insertTradeOffer(c context.Context, tx *sqlx.Tx, offer *model.TradeOffer, offeredIds []uint64, requestedIds []uint64) (uint64, error) {
+	if err := validateTradeItems(c, tx, *offer.SenderUid, offeredIds); err != nil {
+		return 0, err
+	}
+	if err := validateTradeItems(c, tx, *offer.RecipientUid, requestedIds); err != nil {
+		return 0, err
+	}
+
+	status := db.TRADE_OFFER_PENDING
+	offer.Status = &status
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Insert(db.SCHEMA_TRADE_OFFERS).
+		Columns(core.ModelColumns(offer)...).
+		Values(core.StructValues(offer)...).
+		Suffix("RETURNING \"id\"").
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	offerId := uint64(0)
+	if err = tx.QueryRowContext(c, query, args...).Scan(&offerId); err != nil {
+		return 0, err
+	}
+
+	itemQuery := psql.
+		Insert(db.SCHEMA_TRADE_OFFER_ITEMS).
+		Columns("trade_offer_id", "user_item_id", "owner_uid")
+	for _, id := range offeredIds {
+		itemQuery = itemQuery.Values(offerId, id, *offer.SenderUid)
+	}
+	for _, id := range requestedIds {
+		itemQuery = itemQuery.Values(offerId, id, *offer.RecipientUid)
+	}
+
+	query, args, err = itemQuery.ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err = tx.ExecContext(c, query, args...); err != nil {
+		return 0, err
+	}
+	return offerId, nil
+}
+
+// checks that every user item is still in the owners collection and has not been withdrawn
+func validateTradeItems(c context.Context, tx *sqlx.Tx, ownerUid string, userItemIds []uint64) error {
+	if len(userItemIds) == 0 {
+		return nil
+	}
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("count(*)").
+		From(db.SCHEMA_USER_ITEMS+" ui").
+		Where(squirrel.Eq{"ui.id": userItemIds, "ui.uid": ownerUid, "ui.removed_at": nil}).
+		Where(unexpired("ui.expired_at")).
+		Where("not exists (select 1 from "+db.SCHEMA_ITEM_WITHDRAWALS+" w where w.user_item_id = ui.id and w.status <> ?)", db.WITHDRAWAL_CANCELLED).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	if err = tx.QueryRowContext(c, query, args...).Scan(&count); err != nil {
+		return err
+	}
+	if count != len(userItemIds) {
+		return &TradeError{"one or more items in the trade are no longer owned by the trading user"}
+	}
+	return nil
+}
+
+// moves a set of user items from one user to another, failing if any of them changed hands in the meantime
+func transferTradeItems(c context.Context, tx *sqlx.Tx, userItemIds []uint64, fromUid string, toUid string, notes string, now string) error {
+	if len(userItemIds) == 0 {
+		return nil
+	}
+	if err := validateTradeItems(c, tx, fromUid, userItemIds); err != nil {
+		return err
+	}
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Update(db.SCHEMA_USER_ITEMS).
+		SetMap(map[string]interface{}{"uid": toUid, "acquired_at": now}).
+		Where(squirrel.Eq{"id": userItemIds, "uid": fromUid, "removed_at": nil}).
+		Where(unexpired("expired_at")).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(c, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected != int64(len(userItemIds)) {
+		return &TradeError{"one or more items in the trade are no longer owned by the trading user"}
+	}
+
+	events := make([]*model.ItemEvent, len(userItemIds))
+	for i := range userItemIds {
+		events[i] = &model.ItemEvent{UserItemId: &userItemIds[i], FromUid: &fromUid, ToUid: &toUid, ActorUid: &toUid, Notes: &notes}
+	}
+	return addItemEvents(c, tx, db.ITEM_EVENT_TRANSFERRED, now, events)
+}
+
+// takes tokens from a users balance, returns false when the balance does not cover the amount
+func debitTokenBalance(c context.Context, tx *sqlx.Tx, uid string, tokenAmount float64, now string) (bool, error) {
+	return debitBalance(c, tx, db.SCHEMA_TOKEN_BALANCE, uid, tokenAmount, now)
+}
+
+// takes an amount from a users row of a balance table, returns false when the balance does not cover it
+func debitBalance(c context.Context, tx *sqlx.Tx, table string, uid string, amount float64, now string) (bool, error) {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Update(table).
+		SetMap(map[string]interface{}{"balance": squirrel.Expr("balance - ?", amount), "updated_at": now}).
+		Where(squirrel.And{squirrel.Eq{"uid": uid}, squirrel.GtOrEq{"balance": amount}}).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+
+	result, err := tx.ExecContext(c, query, args...)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+func lockPendingTradeOffer(c context.Context, tx *sqlx.Tx, offerId uint64, now string) (*model.TradeOffer, error) {
+	if err := expireTradeOffers(c, tx, now); err != nil {
+		return nil, err
+	}
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("id", "sender_uid", "recipient_uid", "sender_token_amount", "status", "expires_at").
+		From(db.SCHEMA_TRADE_OFFERS).
+		Where(squirrel.Eq{"id": offerId}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	offer := model.TradeOffer{}
+	if err = tx.QueryRowxContext(c, query, args...).StructScan(&offer); err != nil {
+		if err == sql.ErrNoRows {
+			err = &TradeError{"trade offer does not exist"}
+		}
+		return nil, err
+	}
+	if offer.Status == nil || *offer.Status != db.TRADE_OFFER_PENDING {
+		return nil, &TradeError{"trade offer is no longer pending"}
+	}
+	return &offer, nil
+}
+
+func setTradeOfferStatus(c context.Context, tx *sqlx.Tx, offerId uint64, status string, now string) error {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Update(db.SCHEMA_TRADE_OFFERS).
+		SetMap(map[string]interface{}{"status": status, "responded_at": now}).
+		Where(squirrel.Eq{"id": offerId}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(c, query, args...)
+	return err
+}
+
+// closes every pending offer that has passed its expiry
+func expireTradeOffers(c context.Context, tx *sqlx.Tx, now string) error {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Update(db.SCHEMA_TRADE_OFFERS).
+		Set("status", db.TRADE_OFFER_EXPIRED).
+		Where(squirrel.And{squirrel.Eq{"status": db.TRADE_OFFER_PENDING}, squirrel.LtOrEq{"expires_at": now}}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(c, query, args...)
+	return err
+}
+
+func getTradeOfferItems(c context.Context, tx *sqlx.Tx, offerIds []uint64) ([]*model.TradeOfferItemExpanded, error) {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select(
+			"toi.trade_offer_id",
+			"toi.user_item_id",
+			"toi.owner_uid",
+			"i.id as item_id",
+			"i.name",
+			"i.image_url",
+			"i.vendor_id",
+			"ui.serial_number",
+		).
+		From(db.SCHEMA_TRADE_OFFER_ITEMS + " toi").
+		Join(db.SCHEMA_USER_ITEMS + " ui on ui.id = toi.user_item_id").
+		Join(db.SCHEMA_ITEMS + " i on i.id = ui.item_id").
+		Where(squirrel.Eq{"toi.trade_offer_id": offerIds}).
+		OrderBy("toi.id asc").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryxContext(c, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	items := []*model.TradeOfferItemExpanded{}
+	for rows.Next() {
+		item := model.TradeOfferItemExpanded{}
+		if err := rows.StructScan(&item); err != nil {
+			return nil, err
+		}
+		items = append(items, &item)
+	}
+	return items, rows.Err()
+}
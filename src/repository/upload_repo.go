@@ -0,0 +1,312 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"xo-packs/core"
+	"xo-packs/db"
+	"xo-packs/model"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+)
+
+type UploadRepository interface {
+	UploadItemContent(context.Context, string, uint64, *core.ValidatedUpload, *core.ValidatedUpload, []*core.Rendition) (*model.Item, []*model.ItemRendition, error)
+	UploadPackArt(context.Context, string, uint64, *core.ValidatedUpload) (*model.PackConfig, error)
+	UploadProfileImage(context.Context, string, *core.ValidatedUpload) (*model.User, error)
+	UploadBannerImage(context.Context, string, *core.ValidatedUpload) (*model.User, error)
+}
+
+type UploadRepoImpl struct {
+	db    *sqlx.DB
+	cache *redis.Client
+	store core.BlobStore
+}
+
+func NewUploadRepo(db *sqlx.DB, cache *redis.Client, store core.BlobStore) UploadRepository {
+	return &UploadRepoImpl{db: db, cache: cache, store: store}
+}
+
+type UploadError struct {
+	message string
+}
+
+func (e *UploadError) Error() string {
+	return e.message
+}
+
+// item content gets a fresh key on every upload so cdn copies of the old file are never served, the old
+// objects are removed once the item points at the new ones. the renditions derived from the upload replace
+// the items previous ones, and the largest thumbnail stands in for a thumbnail the creator did not upload
+func (r *UploadRepoImpl) UploadItemContent(c context.Context, vendorId string, itemId uint64, main *core.ValidatedUpload, thumb *core.ValidatedUpload, renditions []*core.Rendition) (*model.Item, []*model.ItemRendition, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("id", "vendor_id", "content_main_url", "content_thumb_url", "content_type").
+		From(db.SCHEMA_ITEMS).
+		Where(squirrel.Eq{"id": itemId, "vendor_id": vendorId}).
+		Where("deleted_at is null").
+		ToSql()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	previous := model.Item{}
+	if err := r.db.QueryRowxContext(ctx, query, args...).StructScan(&previous); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, &UploadError{message: fmt.Sprintf("item %v does not belong to vendor %v", itemId, vendorId)}
+		}
+		return nil, nil, err
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	version := time.Now().UnixNano()
+	updates := map[string]interface{}{"updated_at": now}
+	newKeys := []string{}
+	staleUrls := []*string{}
+	if main != nil {
+		key := fmt.Sprintf("items/%v/main-%v%v", itemId, version, main.Extension)
+		if err := r.store.Put(c, key, main.Body, main.MimeType); err != nil {
+			return nil, nil, err
+		}
+		newKeys = append(newKeys, key)
+		staleUrls = append(staleUrls, previous.ContentMainUrl)
+		updates["content_main_url"] = r.store.URL(key)
+		updates["content_type"] = core.ItemContentType(main.MimeType)
+	}
+	if thumb != nil {
+		key := fmt.Sprintf("items/%v/thumb-%v%v", itemId, version, thumb.Extension)
+		if err := r.store.Put(c, key, thumb.Body, thumb.MimeType); err != nil {
+			r.deleteBlobs(c, newKeys)
+			return nil, nil, err
+		}
+		newKeys = append(newKeys, key)
+		staleUrls = append(staleUrls, previous.ContentThumbUrl)
+		updates["content_thumb_url"] = r.store.URL(key)
+	}
+
+	itemRenditions := []*model.ItemRendition{}
+	for _, rendition := range renditions {
+		key := fmt.Sprintf("items/%v/%v-%v.jpg", itemId, rendition.Name, version)
+		if err := r.store.Put(c, key, bytes.NewReader(rendition.Data), "image/jpeg"); err != nil {
+			r.deleteBlobs(c, newKeys)
+			return nil, nil, err
+		}
+		newKeys = append(newKeys, key)
+
+		name, url, width, height := rendition.Name, r.store.URL(key), rendition.Width, rendition.Height
+		itemRenditions = append(itemRenditions, &model.ItemRendition{ItemId: &itemId, Name: &name, Url: &url, Width: &width, Height: &height, CreatedAt: &now})
+		if thumb == nil && name == core.LargestThumbnailName() {
+			staleUrls = append(staleUrls, previous.ContentThumbUrl)
+			updates["content_thumb_url"] = url
+		}
+	}
+
+	ctx, cancel = context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		r.deleteBlobs(c, newKeys)
+		return nil, nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if err = tx.Rollback(); err != nil {
+				fmt.Println(err)
+			}
+			r.deleteBlobs(c, newKeys)
+		}
+	}()
+
+	query, args, err = psql.
+		Update(db.SCHEMA_ITEMS).
+		SetMap(updates).
+		Where(squirrel.Eq{"id": itemId, "vendor_id": vendorId}).
+		Suffix("RETURNING id, vendor_id, content_main_url, content_thumb_url, content_type").
+		ToSql()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	item := model.Item{}
+	if err = tx.QueryRowxContext(ctx, query, args...).StructScan(&item); err != nil {
+		return nil, nil, err
+	}
+
+	// swap the renditions over
+	query, args, err = psql.
+		Delete(db.SCHEMA_ITEM_RENDITIONS).
+		Where(squirrel.Eq{"item_id": itemId}).
+		Suffix("RETURNING url").
+		ToSql()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := tx.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	for rows.Next() {
+		var url string
+		if err = rows.Scan(&url); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		staleUrls = append(staleUrls, &url)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(itemRenditions) > 0 {
+		insert := psql.
+			Insert(db.SCHEMA_ITEM_RENDITIONS).
+			Columns("item_id", "name", "url", "width", "height", "created_at")
+		for _, rendition := range itemRenditions {
+			insert = insert.Values(rendition.ItemId, rendition.Name, rendition.Url, rendition.Width, rendition.Height, rendition.CreatedAt)
+		}
+		query, args, err = insert.ToSql()
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+
+	r.deleteBlobs(c, r.ownedKeys(staleUrls))
+	return &item, itemRenditions, nil
+}
+
+func (r *UploadRepoImpl) UploadPackArt(c context.Context, vendorId string, packConfigId uint64, art *core.ValidatedUpload) (*model.PackConfig, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("id", "vendor_id", "image_url").
+		From(db.SCHEMA_PACK_CONFIGS).
+		Where(squirrel.Eq{"id": packConfigId, "vendor_id": vendorId}).
+		Where("deleted_at is null").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	previous := model.PackConfig{}
+	if err := r.db.QueryRowxContext(ctx, query, args...).StructScan(&previous); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &UploadError{message: fmt.Sprintf("pack %v does not belong to vendor %v", packConfigId, vendorId)}
+		}
+		return nil, err
+	}
+
+	key := fmt.Sprintf("packs/%v/art-%v%v", packConfigId, time.Now().UnixNano(), art.Extension)
+	if err := r.store.Put(c, key, art.Body, art.MimeType); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel = context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	query, args, err = psql.
+		Update(db.SCHEMA_PACK_CONFIGS).
+		Set("image_url", r.store.URL(key)).
+		Set("updated_at", time.Now().Format("2006-01-02 15:04:05")).
+		Where(squirrel.Eq{"id": packConfigId, "vendor_id": vendorId}).
+		Suffix("RETURNING id, vendor_id, image_url").
+		ToSql()
+	if err != nil {
+		r.deleteBlobs(c, []string{key})
+		return nil, err
+	}
+
+	packConfig := model.PackConfig{}
+	if err := r.db.QueryRowxContext(ctx, query, args...).StructScan(&packConfig); err != nil {
+		r.deleteBlobs(c, []string{key})
+		return nil, err
+	}
+
+	r.deleteBlobs(c, r.ownedKeys([]*string{previous.ImageUrl}))
+	return &packConfig, nil
+}
+
+// profile and banner images keep a stable key per user, clients bust their caches with the bumped version
+func (r *UploadRepoImpl) UploadProfileImage(c context.Context, uid string, image *core.ValidatedUpload) (*model.User, error) {
+	return r.uploadUserImage(c, uid, "profile", "image_url", "profile_img_version", image)
+}
+
+func (r *UploadRepoImpl) UploadBannerImage(c context.Context, uid string, image *core.ValidatedUpload) (*model.User, error) {
+	return r.uploadUserImage(c, uid, "banner", "banner_url", "banner_img_version", image)
+}
+
+func (r *UploadRepoImpl) uploadUserImage(c context.Context, uid string, name string, urlColumn string, versionColumn string, image *core.ValidatedUpload) (*model.User, error) {
+	key := fmt.Sprintf("users/%v/%v", uid, name)
+	if err := r.store.Put(c, key, image.Body, image.MimeType); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Update(db.SCHEMA_USERS).
+		Set(urlColumn, r.store.URL(key)).
+		Set(versionColumn, squirrel.Expr(fmt.Sprintf("coalesce(%v, 0) + 1", versionColumn))).
+		Set("updated_at", time.Now().Format("2006-01-02 15:04:05")).
+		Where(squirrel.Eq{"uid": uid}).
+		Where("deleted_at is null").
+		Suffix("RETURNING uid, username, image_url, banner_url, profile_img_version, banner_img_version").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	user := model.User{}
+	if err := r.db.QueryRowxContext(ctx, query, args...).StructScan(&user); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &UploadError{message: fmt.Sprintf("user %v does not exist", uid)}
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// keys of the urls that point into this store, urls set before uploads went through the api are left alone
+func (r *UploadRepoImpl) ownedKeys(urls []*string) []string {
+	keys := []string{}
+	for _, url := range urls {
+		if url == nil {
+			continue
+		}
+		if key, ok := core.BlobKey(r.store, *url); ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// cleanup is best effort, a leftover object is not worth failing the upload over
+func (r *UploadRepoImpl) deleteBlobs(c context.Context, keys []string) {
+	for _, key := range keys {
+		if err := r.store.Delete(c, key); err != nil {
+			fmt.Println("unable to delete blob ", key, ": ", err)
+		}
+	}
+}
@@ -25,10 +25,8 @@ type UserRepository interface {
 	CreateUser(context.Context, *model.User) (*model.User, error)
 	GetUser(context.Context, string, bool) (*model.User, error)
 	GetUserItem(context.Context, uint64) (*model.UserItem, error)
-	WithdrawalUserItem(context.Context, uint64) (*uint64, error)
-	GetUserItemWithdrawal(context.Context, uint64) (*model.ItemWithdrawal, error)
 	GetUserPackPage(context.Context, string, uint64, string, string, string, string) ([]*model.PageUserPack, *uint64, error)
-	GetUserItemPage(context.Context, string, uint64, string, string, string, string) ([]*model.PageUserItem, *uint64, error)
+	GetUserItemPage(context.Context, string, uint64, string, string, uint64, string, string) ([]*model.PageUserItem, *uint64, error)
 	GetUserFavoritesPage(*gin.Context, string, uint64, string, string) ([]*model.PageUserFavorite, *uint64, error)
 	GetUserByUsername(context.Context, string) (*model.User, error)
 	GetUserPackCategories(context.Context) ([]string, error)
@@ -43,6 +41,8 @@ type UserRepository interface {
 	GetFavorite(context.Context, string, string) (*model.Favorite, error)
 	AddFavorite(context.Context, string, string) (*model.Favorite, error)
 	RemoveFavorite(context.Context, string, string) error
+	GetSpendingLimit(context.Context, string) (*model.UserSpendingLimit, error)
+	SetSpendingLimit(context.Context, *model.UserSpendingLimit) error
 }
 
 type UserRepoImpl struct {
@@ -199,7 +199,7 @@ func (r *UserRepoImpl) GetUserItem(c context.Context, userItemId uint64) (*model
 		userItem := model.UserItem{}
 		psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 		query, args, err := psql.
-			Select("id", "uid", "item_id", "removed_at", "acquired_at", "expired_at").
+			Select("id", "uid", "item_id", "removed_at", "acquired_at", "expired_at", "serial_number").
 			From(db.SCHEMA_USER_ITEMS).
 			Where(squirrel.Eq{"id": userItemId}).
 			ToSql()
@@ -237,124 +237,6 @@ func (r *UserRepoImpl) GetUserItem(c context.Context, userItemId uint64) (*model
 	}
 }
 
-func (r *UserRepoImpl) WithdrawalUserItem(c context.Context, userItemId uint64) (*uint64, error) {
-	ctx, cancel := context.WithTimeout(c, 5*time.Second)
-	defer cancel()
-
-	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
-	if err != nil {
-		return nil, err
-	}
-
-	defer func() {
-		if err != nil {
-			if err = tx.Rollback(); err != nil {
-				fmt.Println(err)
-			}
-		}
-	}()
-
-	now := time.Now().Format("2006-01-02 15:04:05")
-	itemWithdrawal := model.ItemWithdrawal{
-		UserItemId:  &userItemId,
-		WithdrawnAt: &now,
-	}
-	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
-	query, args, err := psql.
-		Insert(db.SCHEMA_ITEM_WITHDRAWALS).
-		Columns(core.ModelColumns(itemWithdrawal)...).
-		Values(core.StructValues(itemWithdrawal)...).
-		Suffix("RETURNING ID").
-		ToSql()
-	if err != nil {
-		return nil, err
-	}
-
-	rows, err := tx.QueryContext(ctx, query, args...)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	withdrawalId := new(uint64)
-	for rows.Next() {
-		if err = rows.Scan(withdrawalId); err != nil {
-			return nil, err
-		}
-	}
-	if withdrawalId == nil || (withdrawalId != nil && *withdrawalId <= 0) {
-		return nil, &core.ErrorResp{
-			Message: "unable to withdrawal item at this time",
-		}
-	}
-
-	err = tx.Commit()
-	if err != nil {
-		return nil, err
-	}
-
-	return withdrawalId, nil
-}
-
-func (r *UserRepoImpl) GetUserItemWithdrawal(c context.Context, withdrawalId uint64) (*model.ItemWithdrawal, error) {
-	val, err := r.cache.Get(c, db.KEY_ITEM_WITHDRAWALS+fmt.Sprintf("%v", withdrawalId)).Result()
-	if err != nil {
-		ctx, cancel := context.WithTimeout(c, 5*time.Second)
-		defer cancel()
-
-		tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
-		if err != nil {
-			return nil, err
-		}
-
-		defer func() {
-			if err = tx.Commit(); err != nil {
-				fmt.Println(err)
-			}
-		}()
-
-		itemWithdrawal := model.ItemWithdrawal{}
-		psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
-		query, args, err := psql.
-			Select("id", "user_item_id", "withdrawn_at", "fulfilled_at").
-			From(db.SCHEMA_ITEM_WITHDRAWALS).
-			Where(squirrel.Eq{"id": withdrawalId}).
-			ToSql()
-		if err != nil {
-			return nil, err
-		}
-
-		rows, err := tx.QueryxContext(ctx, query, args...)
-		if err != nil {
-			return nil, err
-		}
-		defer rows.Close()
-
-		for rows.Next() {
-			if err = rows.StructScan(&itemWithdrawal); err != nil {
-				return nil, err
-			}
-		}
-
-		itemWithdrawalBytes, err := json.Marshal(itemWithdrawal)
-		if err != nil {
-			return nil, err
-		}
-
-		if err = r.cache.Set(ctx, db.KEY_ITEM_WITHDRAWALS+fmt.Sprintf("%v", withdrawalId), itemWithdrawalBytes, time.Duration(3600*time.Second)).Err(); err != nil {
-			return nil, err
-		}
-
-		return &itemWithdrawal, nil
-	} else {
-		itemWithdrawal := model.ItemWithdrawal{}
-		if err = json.Unmarshal([]byte(val), &itemWithdrawal); err != nil {
-			return nil, err
-		}
-		return &itemWithdrawal, nil
-	}
-}
-
 func (r *UserRepoImpl) GetUserPackPage(
 	c context.Context, uid string, pageNumber uint64, sortBy string, filterOn string, searchStr string, urlPath string) ([]*model.PageUserPack, *uint64, error) {
 
@@ -498,7 +380,7 @@ func (r *UserRepoImpl) GetUserPackPage(
 }
 
 func (r *UserRepoImpl) GetUserItemPage(
-	c context.Context, uid string, pageNumber uint64, sortBy string, filterOn string, searchStr string, urlPath string) ([]*model.PageUserItem, *uint64, error) {
+	c context.Context, uid string, pageNumber uint64, sortBy string, filterOn string, rarityId uint64, searchStr string, urlPath string) ([]*model.PageUserItem, *uint64, error) {
 
 	val, err := r.cache.Get(c, urlPath).Result()
 	if err != nil {
@@ -528,6 +410,8 @@ func (r *UserRepoImpl) GetUserItemPage(
 				rows, err = tx.QueryxContext(ctx, fmt.Sprintf(
 					query.UserItemPageSortByFilterOn,
 					uid,
+					rarityId,
+					rarityId,
 					filterOn,
 					searchStr,
 					searchStr,
@@ -539,10 +423,12 @@ func (r *UserRepoImpl) GetUserItemPage(
 					(pageSize)*(pageNumber)),
 				)
 			} else {
-				fmt.Printf(query.UserItemPageSortBy, uid, sortBy, pageSize, (pageSize)*(pageNumber))
+				fmt.Printf(query.UserItemPageSortBy, uid, rarityId, rarityId, sortBy, pageSize, (pageSize)*(pageNumber))
 				rows, err = tx.QueryxContext(ctx, fmt.Sprintf(
 					query.UserItemPageSortBy,
 					uid,
+					rarityId,
+					rarityId,
 					searchStr,
 					searchStr,
 					searchStr,
@@ -556,6 +442,8 @@ func (r *UserRepoImpl) GetUserItemPage(
 			rows, err = tx.QueryxContext(ctx, fmt.Sprintf(
 				query.UserItemPageFilterOn,
 				uid,
+				rarityId,
+				rarityId,
 				filterOn,
 				searchStr,
 				searchStr,
@@ -569,6 +457,8 @@ func (r *UserRepoImpl) GetUserItemPage(
 			rows, err = tx.QueryxContext(ctx, fmt.Sprintf(
 				query.UserItemPageDefault,
 				uid,
+				rarityId,
+				rarityId,
 				searchStr,
 				searchStr,
 				searchStr,
@@ -919,10 +809,20 @@ func (r *UserRepoImpl) DeleteUser(c context.Context, uid string, username string
 	}
 
 	if rowsAffected > 0 {
+		var listingsRemoved bool
+		listingsRemoved, err = removeMarketListings(ctx, tx, squirrel.Eq{"seller_uid": uid}, "account deleted", now)
+		if err != nil {
+			return err
+		}
+
 		err = tx.Commit()
 		if err != nil {
 			return err
 		}
+
+		if listingsRemoved {
+			return clearMarketListingCache(c, r.cache)
+		}
 		return nil
 	}
 	err = &UserError{message: fmt.Sprintf("User with UID: %v does not exist", uid)}
@@ -1179,3 +1079,55 @@ func (r *UserRepoImpl) RemoveFavorite(c context.Context, uid string, vendorId st
 		return nil
 	}
 }
+
+func (r *UserRepoImpl) GetSpendingLimit(c context.Context, uid string) (*model.UserSpendingLimit, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select("uid", "daily_token_limit", "last_spent_at", "updated_at").
+		From(db.SCHEMA_USER_SPENDING_LIMITS).
+		Where(squirrel.Eq{"uid": uid}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	spendingLimit := model.UserSpendingLimit{}
+	if err = r.db.QueryRowxContext(ctx, query, args...).StructScan(&spendingLimit); err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	spendingLimit.Uid = &uid
+	return &spendingLimit, nil
+}
+
+// a nil daily token limit removes the users limit
+func (r *UserRepoImpl) SetSpendingLimit(c context.Context, spendingLimit *model.UserSpendingLimit) error {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	var query string
+	var args []interface{}
+	var err error
+	if spendingLimit.DailyTokenLimit != nil {
+		query, args, err = psql.
+			Insert(db.SCHEMA_USER_SPENDING_LIMITS).
+			Columns(core.ModelColumns(*spendingLimit)...).
+			Values(core.StructValues(*spendingLimit)...).
+			Suffix("ON CONFLICT (uid) DO UPDATE SET daily_token_limit = EXCLUDED.daily_token_limit, updated_at = EXCLUDED.updated_at").
+			ToSql()
+	} else {
+		query, args, err = psql.
+			Delete(db.SCHEMA_USER_SPENDING_LIMITS).
+			Where(squirrel.Eq{"uid": *spendingLimit.Uid}).
+			ToSql()
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"strings"
+	"time"
+	"xo-packs/core"
+	"xo-packs/db"
+	"xo-packs/model"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+)
+
+type WatermarkRepository interface {
+	GetWatermark(context.Context, string, uint64, string) (*model.ContentWatermark, error)
+	GetWatermarkById(context.Context, uint64) (*model.ContentWatermark, error)
+	CreateWatermark(context.Context, string, uint64, string, uint64) (*model.ContentWatermark, error)
+}
+
+type WatermarkRepoImpl struct {
+	db    *sqlx.DB
+	cache *redis.Client
+	store core.BlobStore
+}
+
+func NewWatermarkRepo(db *sqlx.DB, cache *redis.Client, store core.BlobStore) WatermarkRepository {
+	return &WatermarkRepoImpl{db: db, cache: cache, store: store}
+}
+
+type WatermarkError struct {
+	message string
+}
+
+func (e *WatermarkError) Error() string {
+	return e.message
+}
+
+var watermarkColumns = []string{"id", "uid", "item_id", "content_url", "watermarked_url", "status", "created_at"}
+
+// the watermark issued to a user for an items current content, nil when none has been made yet. the cache
+// holds one watermark per user and item, a cached one for older content is ignored
+func (r *WatermarkRepoImpl) GetWatermark(c context.Context, uid string, itemId uint64, contentUrl string) (*model.ContentWatermark, error) {
+	cacheKey := fmt.Sprintf("%v%v_%v", db.KEY_CONTENT_WATERMARK, uid, itemId)
+	val, err := r.cache.Get(c, cacheKey).Result()
+	if err == nil {
+		watermark := model.ContentWatermark{}
+		if err = json.Unmarshal([]byte(val), &watermark); err != nil {
+			return nil, err
+		}
+		if watermark.ContentUrl != nil && *watermark.ContentUrl == contentUrl {
+			return &watermark, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select(watermarkColumns...).
+		From(db.SCHEMA_CONTENT_WATERMARKS).
+		Where(squirrel.Eq{"uid": uid, "item_id": itemId, "content_url": contentUrl}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	watermark := model.ContentWatermark{}
+	if err = r.db.QueryRowxContext(ctx, query, args...).StructScan(&watermark); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	// pending watermarks are still being made
+	if *watermark.Status != db.WATERMARK_PENDING {
+		watermarkBytes, err := json.Marshal(watermark)
+		if err != nil {
+			return nil, err
+		}
+		if err = r.cache.Set(c, cacheKey, watermarkBytes, time.Duration(3600)*time.Second).Err(); err != nil {
+			return nil, err
+		}
+	}
+	return &watermark, nil
+}
+
+func (r *WatermarkRepoImpl) GetWatermarkById(c context.Context, watermarkId uint64) (*model.ContentWatermark, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Select(watermarkColumns...).
+		From(db.SCHEMA_CONTENT_WATERMARKS).
+		Where(squirrel.Eq{"id": watermarkId}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	watermark := model.ContentWatermark{}
+	if err = r.db.QueryRowxContext(ctx, query, args...).StructScan(&watermark); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &WatermarkError{message: fmt.Sprintf("watermark %v was never issued", watermarkId)}
+		}
+		return nil, err
+	}
+	return &watermark, nil
+}
+
+// claims a watermark id for the user and content, then writes a copy of the content carrying that id to
+// the store. content that is not an image in the store, or too small to carry the payload, is marked
+// skipped so it is not fetched again. nil means another worker is already making the same watermark,
+// a watermark left pending by a worker that died is claimed again once it is stale
+func (r *WatermarkRepoImpl) CreateWatermark(c context.Context, uid string, itemId uint64, contentUrl string, seed uint64) (*model.ContentWatermark, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Insert(db.SCHEMA_CONTENT_WATERMARKS).
+		Columns("uid", "item_id", "content_url", "status", "created_at").
+		Values(uid, itemId, contentUrl, db.WATERMARK_PENDING, now).
+		Suffix(fmt.Sprintf("ON CONFLICT (uid, item_id, content_url) DO UPDATE SET created_at = excluded.created_at "+
+			"WHERE content_watermarks.status = '%v' AND content_watermarks.created_at < excluded.created_at - interval '%v seconds' RETURNING id",
+			db.WATERMARK_PENDING, core.WATERMARK_PENDING_STALE_SECONDS)).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var watermarkId uint64
+	if err = r.db.QueryRowxContext(ctx, query, args...).Scan(&watermarkId); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	status := db.WATERMARK_SKIPPED
+	var watermarkedUrl *string
+	data, err := r.watermarkContent(c, contentUrl, uint32(watermarkId), seed)
+	if err != nil {
+		// give the id back so the next request tries again
+		r.deleteWatermark(c, watermarkId)
+		return nil, err
+	}
+	if data != nil {
+		key := fmt.Sprintf("watermarks/%v/%v.jpg", itemId, watermarkId)
+		if err = r.store.Put(c, key, bytes.NewReader(data), "image/jpeg"); err != nil {
+			r.deleteWatermark(c, watermarkId)
+			return nil, err
+		}
+		url := r.store.URL(key)
+		watermarkedUrl = &url
+		status = db.WATERMARK_READY
+	}
+
+	ctx, cancel = context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	query, args, err = psql.
+		Update(db.SCHEMA_CONTENT_WATERMARKS).
+		Set("watermarked_url", watermarkedUrl).
+		Set("status", status).
+		Where(squirrel.Eq{"id": watermarkId}).
+		Suffix("RETURNING " + strings.Join(watermarkColumns, ", ")).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	watermark := model.ContentWatermark{}
+	if err = r.db.QueryRowxContext(ctx, query, args...).StructScan(&watermark); err != nil {
+		return nil, err
+	}
+	return &watermark, nil
+}
+
+// the watermarked jpeg, nil when the content cannot carry a watermark
+func (r *WatermarkRepoImpl) watermarkContent(c context.Context, contentUrl string, watermarkId uint32, seed uint64) ([]byte, error) {
+	key, ok := core.BlobKey(r.store, contentUrl)
+	if !ok {
+		return nil, nil
+	}
+
+	body, err := r.store.Get(c, key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	img, _, err := image.Decode(body)
+	if err != nil {
+		// videos and formats without a decoder
+		return nil, nil
+	}
+	if !core.CanWatermark(img.Bounds()) {
+		return nil, nil
+	}
+
+	buf := bytes.Buffer{}
+	if err := jpeg.Encode(&buf, core.EmbedWatermark(img, watermarkId, seed), &jpeg.Options{Quality: core.REENCODE_QUALITY}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (r *WatermarkRepoImpl) deleteWatermark(c context.Context, watermarkId uint64) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	query, args, err := psql.
+		Delete(db.SCHEMA_CONTENT_WATERMARKS).
+		Where(squirrel.Eq{"id": watermarkId}).
+		ToSql()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if _, err := r.db.ExecContext(ctx, query, args...); err != nil {
+		fmt.Println("unable to release watermark ", watermarkId, ": ", err)
+	}
+}
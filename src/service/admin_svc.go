@@ -10,6 +10,7 @@ type AdminService interface {
 	ApproveVendor(context.Context, string, string, ApplicationService, UserService) (*model.VendorApplication, error)
 	RejectVendor(context.Context, string, string, ApplicationService) (*model.VendorApplication, error)
 	RemoveVendor(context.Context, string, string, UserService) error
+	BanUser(context.Context, string) error
 	AddFaq(context.Context, *model.Faq) (*model.Faq, error)
 	EditFaq(context.Context, map[string]interface{}, uint64) error
 	RemoveFaq(context.Context, uint64) error
@@ -69,6 +70,15 @@ func (service *AdminSvcImpl) RemoveVendor(c context.Context, uid string, usernam
 	return service.FlushCache(c)
 }
 
+func (service *AdminSvcImpl) BanUser(c context.Context, uid string) error {
+	if err := service.adminRepo.BanUser(c, uid); err != nil {
+		return err
+	}
+
+	// flushing cache, the user shows up in listings, profiles and shop pages
+	return service.FlushCache(c)
+}
+
 func (service *AdminSvcImpl) AddFaq(c context.Context, faq *model.Faq) (*model.Faq, error) {
 	return service.adminRepo.AddFaq(c, faq)
 }
@@ -17,6 +17,8 @@ type AnalyticsService interface {
 	TopCustomers(context.Context, string) ([]*model.CustomerAnalytic, error)
 	PackSales(context.Context, string, string, int64, int64, string) (*model.PackAnalyticsResp, error)
 	PackQtySold(context.Context, string) ([]*model.PackQtySoldAnalytic, error)
+	PackWaitlistSize(context.Context, string) ([]*model.PackWaitlistAnalytic, error)
+	ItemBuybacks(context.Context, string) ([]*model.ItemBuybackAnalytic, error)
 }
 
 type AnalyticsSvcImpl struct {
@@ -79,3 +81,11 @@ func (analyticsService AnalyticsSvcImpl) PackSales(c context.Context, vendorId s
 func (analyticsService AnalyticsSvcImpl) PackQtySold(c context.Context, vendorId string) ([]*model.PackQtySoldAnalytic, error) {
 	return analyticsService.analyticsRepo.PackQtySold(c, vendorId)
 }
+
+func (analyticsService AnalyticsSvcImpl) PackWaitlistSize(c context.Context, vendorId string) ([]*model.PackWaitlistAnalytic, error) {
+	return analyticsService.analyticsRepo.PackWaitlistSize(c, vendorId)
+}
+
+func (analyticsService AnalyticsSvcImpl) ItemBuybacks(c context.Context, vendorId string) ([]*model.ItemBuybackAnalytic, error) {
+	return analyticsService.analyticsRepo.ItemBuybacks(c, vendorId)
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"xo-packs/core"
+	"xo-packs/db"
+	"xo-packs/model"
+	"xo-packs/repository"
+)
+
+const (
+	MAX_AUCTION_DAYS               = 30
+	AUCTION_CLOSE_BATCH_SIZE       = 100
+	DEFAULT_AUCTION_SNIPE_WINDOW   = 120
+	DEFAULT_AUCTION_CLOSE_INTERVAL = 15
+)
+
+var auctionStatuses = []string{db.AUCTION_ACTIVE, db.AUCTION_SOLD, db.AUCTION_UNSOLD, db.AUCTION_CANCELLED}
+
+type AuctionService interface {
+	CreateAuction(context.Context, string, *model.CreateAuctionReq) (*model.AuctionExpanded, error)
+	CancelAuction(context.Context, uint64, string) error
+	GetAuction(context.Context, uint64, string) (*model.AuctionExpanded, error)
+	GetAuctions(context.Context, string, string, string) ([]*model.AuctionExpanded, error)
+	PlaceBid(context.Context, uint64, string, *model.AuctionBidReq, TokenService) (*model.AuctionBidResp, error)
+}
+
+type AuctionSvcImpl struct {
+	auctionRepo repository.AuctionRepository
+}
+
+func NewAuctionService(auctionRepo repository.AuctionRepository) AuctionService {
+	auctionService := &AuctionSvcImpl{auctionRepo: auctionRepo}
+	go auctionService.runAuctionCloser()
+	return auctionService
+}
+
+func (service *AuctionSvcImpl) CreateAuction(c context.Context, vendorId string, req *model.CreateAuctionReq) (*model.AuctionExpanded, error) {
+	if req.ItemId == nil {
+		return nil, &core.ErrorResp{Message: "an item id must be given"}
+	}
+	if req.StartPrice == nil || core.RoundTokenAmount(*req.StartPrice) <= 0 {
+		return nil, &core.ErrorResp{Message: "start price must be greater than 0"}
+	}
+	startPrice := core.RoundTokenAmount(*req.StartPrice)
+	var reservePrice *float64
+	if req.ReservePrice != nil {
+		reserve := core.RoundTokenAmount(*req.ReservePrice)
+		if reserve < startPrice {
+			return nil, &core.ErrorResp{Message: "reserve price cannot be lower than the start price"}
+		}
+		reservePrice = &reserve
+	}
+
+	now := time.Now()
+	startsAt := now
+	if req.StartsAt != nil {
+		parsed, err := time.Parse(time.RFC3339, *req.StartsAt)
+		if err != nil {
+			return nil, &core.ErrorResp{Message: "startsAt must be an RFC3339 timestamp"}
+		}
+		startsAt = parsed.In(time.Local)
+	}
+	if req.EndsAt == nil {
+		return nil, &core.ErrorResp{Message: "endsAt must be given"}
+	}
+	endsAt, err := time.Parse(time.RFC3339, *req.EndsAt)
+	if err != nil {
+		return nil, &core.ErrorResp{Message: "endsAt must be an RFC3339 timestamp"}
+	}
+	endsAt = endsAt.In(time.Local)
+	if !endsAt.After(startsAt) || !endsAt.After(now) {
+		return nil, &core.ErrorResp{Message: "auction must end in the future and after it starts"}
+	}
+	if endsAt.Sub(startsAt) > MAX_AUCTION_DAYS*24*time.Hour {
+		return nil, &core.ErrorResp{Message: fmt.Sprintf("auctions can run for at most %v days", MAX_AUCTION_DAYS)}
+	}
+
+	status := db.AUCTION_ACTIVE
+	bidCount := uint64(0)
+	createdAt := now.Format("2006-01-02 15:04:05")
+	startsAtStr := startsAt.Format("2006-01-02 15:04:05")
+	endsAtStr := endsAt.Format("2006-01-02 15:04:05")
+	auction := model.Auction{
+		VendorId:     &vendorId,
+		ItemId:       req.ItemId,
+		StartPrice:   &startPrice,
+		ReservePrice: reservePrice,
+		BidCount:     &bidCount,
+		Status:       &status,
+		StartsAt:     &startsAtStr,
+		EndsAt:       &endsAtStr,
+		CreatedAt:    &createdAt,
+	}
+	auctionId, err := service.auctionRepo.CreateAuction(c, &auction)
+	if err != nil {
+		return nil, err
+	}
+	return service.GetAuction(c, *auctionId, vendorId)
+}
+
+func (service *AuctionSvcImpl) CancelAuction(c context.Context, auctionId uint64, vendorId string) error {
+	return service.auctionRepo.CancelAuction(c, auctionId, vendorId)
+}
+
+// the reserve price is only shown to the creator, bidders just see whether it has been met
+func (service *AuctionSvcImpl) GetAuction(c context.Context, auctionId uint64, uid string) (*model.AuctionExpanded, error) {
+	auction, err := service.auctionRepo.GetAuction(c, auctionId)
+	if err != nil {
+		return nil, err
+	}
+	presentAuction(auction, uid)
+	return auction, nil
+}
+
+func (service *AuctionSvcImpl) GetAuctions(c context.Context, vendorId string, status string, uid string) ([]*model.AuctionExpanded, error) {
+	if status == "" {
+		status = db.AUCTION_ACTIVE
+	}
+	if !slices.Contains(auctionStatuses, status) {
+		return nil, &core.ErrorResp{Message: "status param must be one of " + strings.Join(auctionStatuses, ", ")}
+	}
+
+	auctions, err := service.auctionRepo.GetAuctions(c, vendorId, status)
+	if err != nil {
+		return nil, err
+	}
+	for _, auction := range auctions {
+		presentAuction(auction, uid)
+	}
+	return auctions, nil
+}
+
+func (service *AuctionSvcImpl) PlaceBid(c context.Context, auctionId uint64, uid string, req *model.AuctionBidReq, tokenService TokenService) (*model.AuctionBidResp, error) {
+	if req.TokenAmount == nil || core.RoundTokenAmount(*req.TokenAmount) <= 0 {
+		return nil, &core.ErrorResp{Message: "bid token amount must be greater than 0"}
+	}
+
+	resp, outbidUid, err := service.auctionRepo.PlaceBid(c, auctionId, uid, core.RoundTokenAmount(*req.TokenAmount), auctionSnipeWindow())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tokenService.ClearUserTokenCache(c, uid); err != nil {
+		return nil, err
+	}
+	if outbidUid != nil && *outbidUid != uid {
+		if err := tokenService.ClearUserTokenCache(c, *outbidUid); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+func presentAuction(auction *model.AuctionExpanded, uid string) {
+	auction.ReserveMet = auction.CurrentBid != nil && (auction.ReservePrice == nil || *auction.CurrentBid >= *auction.ReservePrice)
+	if auction.StartPrice != nil {
+		auction.MinimumBid = core.MinimumBid(*auction.StartPrice, auction.CurrentBid)
+	}
+	if auction.VendorId == nil || *auction.VendorId != uid {
+		auction.ReservePrice = nil
+	}
+}
+
+func (service *AuctionSvcImpl) runAuctionCloser() {
+	ticker := time.NewTicker(auctionCloseInterval())
+	defer ticker.Stop()
+	for range ticker.C {
+		service.closeEndedAuctions(context.Background())
+	}
+}
+
+func (service *AuctionSvcImpl) closeEndedAuctions(c context.Context) {
+	for {
+		closedAuctions, err := service.auctionRepo.CloseEndedAuctions(c, AUCTION_CLOSE_BATCH_SIZE)
+		if err != nil {
+			fmt.Println("unable to close ended auctions: ", err)
+			return
+		}
+		if len(closedAuctions) == 0 {
+			return
+		}
+	}
+}
+
+// how long before the end a bid has to land to extend the auction, and how far it extends it
+func auctionSnipeWindow() time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv("AUCTION_SNIPE_WINDOW"))
+	if err != nil || seconds <= 0 {
+		seconds = DEFAULT_AUCTION_SNIPE_WINDOW
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func auctionCloseInterval() time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv("AUCTION_CLOSE_INTERVAL"))
+	if err != nil || seconds <= 0 {
+		seconds = DEFAULT_AUCTION_CLOSE_INTERVAL
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"xo-packs/core"
+	"xo-packs/model"
+	"xo-packs/repository"
+)
+
+const (
+	MAX_BUYBACK_ITEMS         = 50
+	DEFAULT_BUYBACK_PERCENT   = 50
+	DEFAULT_BUYBACK_DAILY_CAP = 500
+)
+
+type BuybackService interface {
+	GetSetting(context.Context, string) (*model.BuybackSetting, error)
+	SetSetting(context.Context, string, *model.BuybackSettingReq) (*model.BuybackSetting, error)
+	GetAllowance(context.Context, string) (*model.BuybackAllowance, error)
+	BuybackUserItems(context.Context, string, *model.BuybackReq, ItemService, TokenService) (*model.BuybackResp, error)
+}
+
+type BuybackSvcImpl struct {
+	buybackRepo repository.BuybackRepository
+}
+
+func NewBuybackService(buybackRepo repository.BuybackRepository) BuybackService {
+	return &BuybackSvcImpl{buybackRepo: buybackRepo}
+}
+
+func (service *BuybackSvcImpl) GetSetting(c context.Context, vendorId string) (*model.BuybackSetting, error) {
+	return service.buybackRepo.GetSetting(c, vendorId)
+}
+
+func (service *BuybackSvcImpl) SetSetting(c context.Context, vendorId string, req *model.BuybackSettingReq) (*model.BuybackSetting, error) {
+	if req.Enabled == nil {
+		return nil, &core.ErrorResp{Message: "enabled must be given"}
+	}
+	return service.buybackRepo.SetSetting(c, vendorId, *req.Enabled)
+}
+
+func (service *BuybackSvcImpl) GetAllowance(c context.Context, uid string) (*model.BuybackAllowance, error) {
+	usedToday, err := service.buybackRepo.GetTokensBoughtBackToday(c, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	dailyCap := buybackDailyCap()
+	return &model.BuybackAllowance{
+		Percent:   buybackPercent(),
+		DailyCap:  dailyCap,
+		UsedToday: usedToday,
+		Remaining: core.RoundTokenAmount(max(dailyCap-usedToday, 0)),
+	}, nil
+}
+
+func (service *BuybackSvcImpl) BuybackUserItems(c context.Context, uid string, req *model.BuybackReq, itemService ItemService, tokenService TokenService) (*model.BuybackResp, error) {
+	userItemIds := uniqueIds(req.UserItemIds)
+	if len(userItemIds) == 0 {
+		return nil, &core.ErrorResp{Message: "at least one user item id must be given"}
+	}
+	if len(userItemIds) > MAX_BUYBACK_ITEMS {
+		return nil, &core.ErrorResp{Message: fmt.Sprintf("at most %v items can be sold back at once", MAX_BUYBACK_ITEMS)}
+	}
+
+	resp, err := service.buybackRepo.BuybackUserItems(c, uid, userItemIds, buybackPercent(), buybackDailyCap())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := itemService.ClearUserItemCache(c, uid); err != nil {
+		return nil, err
+	}
+	if err := tokenService.ClearUserTokenCache(c, uid); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// the percentage of an items value the platform pays when buying it back
+func buybackPercent() int {
+	percent, err := strconv.Atoi(os.Getenv("BUYBACK_PERCENT"))
+	if err != nil || percent <= 0 || percent > 100 {
+		percent = DEFAULT_BUYBACK_PERCENT
+	}
+	return percent
+}
+
+// the most tokens a single user can be paid for buybacks in a day
+func buybackDailyCap() float64 {
+	dailyCap, err := strconv.Atoi(os.Getenv("BUYBACK_DAILY_CAP"))
+	if err != nil || dailyCap <= 0 {
+		dailyCap = DEFAULT_BUYBACK_DAILY_CAP
+	}
+	return float64(dailyCap)
+}
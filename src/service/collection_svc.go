@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"time"
+	"xo-packs/core"
+	"xo-packs/model"
+	"xo-packs/repository"
+)
+
+const MAX_SET_ITEMS = 100
+
+type CollectionService interface {
+	CreateSet(context.Context, string, *model.CollectionSetReq) (*model.CollectionSetExpanded, error)
+	DeleteSet(context.Context, uint64, string) error
+	GetSet(context.Context, uint64) (*model.CollectionSetExpanded, error)
+	GetSets(context.Context, string, string) ([]*model.CollectionSetExpanded, error)
+	GetSetProgress(context.Context, uint64, string) (*model.CollectionSetProgress, error)
+	GetUserSetCompletions(context.Context, string) ([]*model.UserSetCompletionExpanded, error)
+	CompleteSets(context.Context, string, ItemService, TokenService) ([]*model.UserSetCompletionExpanded, error)
+}
+
+type CollectionSvcImpl struct {
+	collectionRepo repository.CollectionRepository
+}
+
+func NewCollectionService(repo repository.CollectionRepository) CollectionService {
+	return &CollectionSvcImpl{collectionRepo: repo}
+}
+
+func (collectionService *CollectionSvcImpl) CreateSet(c context.Context, vendorId string, req *model.CollectionSetReq) (*model.CollectionSetExpanded, error) {
+	if req.Name == nil || *req.Name == "" {
+		return nil, &core.ErrorResp{Message: "a set name must be given"}
+	}
+
+	itemIds := uniqueIds(req.ItemIds)
+	if len(itemIds) == 0 {
+		return nil, &core.ErrorResp{Message: "a set must have at least one item"}
+	}
+	if len(itemIds) > MAX_SET_ITEMS {
+		return nil, &core.ErrorResp{Message: "too many items in set"}
+	}
+
+	rewardTokenAmount := 0.0
+	if req.RewardTokenAmount != nil {
+		rewardTokenAmount = core.RoundTokenAmount(*req.RewardTokenAmount)
+	}
+	if rewardTokenAmount < 0 {
+		return nil, &core.ErrorResp{Message: "set reward token amount cannot be negative"}
+	}
+
+	active := true
+	createdAt := time.Now().Format("2006-01-02 15:04:05")
+	set := model.CollectionSet{
+		VendorId:            &vendorId,
+		Name:                req.Name,
+		Description:         req.Description,
+		RewardTokenAmount:   &rewardTokenAmount,
+		RewardItemId:        req.RewardItemId,
+		RewardBadgeName:     req.RewardBadgeName,
+		RewardBadgeImageUrl: req.RewardBadgeImageUrl,
+		Active:              &active,
+		CreatedAt:           &createdAt,
+	}
+	return collectionService.collectionRepo.CreateSet(c, &set, itemIds)
+}
+
+func (collectionService *CollectionSvcImpl) DeleteSet(c context.Context, setId uint64, vendorId string) error {
+	return collectionService.collectionRepo.DeleteSet(c, setId, vendorId)
+}
+
+func (collectionService *CollectionSvcImpl) GetSet(c context.Context, setId uint64) (*model.CollectionSetExpanded, error) {
+	return collectionService.collectionRepo.GetSet(c, setId)
+}
+
+func (collectionService *CollectionSvcImpl) GetSets(c context.Context, vendorId string, urlPath string) ([]*model.CollectionSetExpanded, error) {
+	return collectionService.collectionRepo.GetSets(c, vendorId, urlPath)
+}
+
+func (collectionService *CollectionSvcImpl) GetSetProgress(c context.Context, setId uint64, uid string) (*model.CollectionSetProgress, error) {
+	return collectionService.collectionRepo.GetSetProgress(c, setId, uid)
+}
+
+func (collectionService *CollectionSvcImpl) GetUserSetCompletions(c context.Context, uid string) ([]*model.UserSetCompletionExpanded, error) {
+	return collectionService.collectionRepo.GetUserSetCompletions(c, uid)
+}
+
+// detects and rewards any sets the user has just completed
+func (collectionService *CollectionSvcImpl) CompleteSets(c context.Context, uid string, itemService ItemService, tokenService TokenService) ([]*model.UserSetCompletionExpanded, error) {
+	completions, err := collectionService.collectionRepo.CompleteSets(c, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, completion := range completions {
+		if completion.RewardTokenAmount != nil && *completion.RewardTokenAmount > 0 {
+			if err := tokenService.ClearUserTokenCache(c, uid); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	for _, completion := range completions {
+		if completion.RewardUserItemId != nil {
+			if err := itemService.ClearUserItemCache(c, uid); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	return completions, nil
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"time"
+	"xo-packs/core"
+	"xo-packs/model"
+	"xo-packs/repository"
+)
+
+const (
+	MAX_RECIPE_INPUT_ITEMS = 50
+	MAX_RECIPE_OUTCOMES    = 100
+)
+
+type CraftingService interface {
+	CreateRecipe(context.Context, string, *model.CraftingRecipeReq) (*model.CraftingRecipeExpanded, error)
+	DeleteRecipe(context.Context, uint64, string) error
+	GetRecipe(context.Context, uint64) (*model.CraftingRecipeExpanded, error)
+	GetRecipes(context.Context, string, string) ([]*model.CraftingRecipeExpanded, error)
+	Craft(context.Context, uint64, string, *model.CraftReq, ItemService) (*model.CraftResp, error)
+}
+
+type CraftingSvcImpl struct {
+	craftingRepo repository.CraftingRepository
+}
+
+func NewCraftingService(repo repository.CraftingRepository) CraftingService {
+	return &CraftingSvcImpl{craftingRepo: repo}
+}
+
+func (craftingService *CraftingSvcImpl) CreateRecipe(c context.Context, vendorId string, req *model.CraftingRecipeReq) (*model.CraftingRecipeExpanded, error) {
+	recipe, err := newCraftingRecipe(vendorId, req)
+	if err != nil {
+		return nil, err
+	}
+	return craftingService.craftingRepo.CreateRecipe(c, recipe, req.Inputs, req.Outcomes)
+}
+
+func (craftingService *CraftingSvcImpl) DeleteRecipe(c context.Context, recipeId uint64, vendorId string) error {
+	return craftingService.craftingRepo.DeleteRecipe(c, recipeId, vendorId)
+}
+
+func (craftingService *CraftingSvcImpl) GetRecipe(c context.Context, recipeId uint64) (*model.CraftingRecipeExpanded, error) {
+	return craftingService.craftingRepo.GetRecipe(c, recipeId)
+}
+
+func (craftingService *CraftingSvcImpl) GetRecipes(c context.Context, vendorId string, urlPath string) ([]*model.CraftingRecipeExpanded, error) {
+	return craftingService.craftingRepo.GetRecipes(c, vendorId, urlPath)
+}
+
+func (craftingService *CraftingSvcImpl) Craft(c context.Context, recipeId uint64, uid string, req *model.CraftReq, itemService ItemService) (*model.CraftResp, error) {
+	userItemIds := uniqueIds(req.UserItemIds)
+	if len(userItemIds) == 0 {
+		return nil, &core.ErrorResp{Message: "at least one user item id must be given"}
+	}
+	if len(userItemIds) > MAX_RECIPE_INPUT_ITEMS {
+		return nil, &core.ErrorResp{Message: "too many items given for a recipe"}
+	}
+
+	resp, err := craftingService.craftingRepo.Craft(c, recipeId, uid, userItemIds)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := itemService.ClearUserItemCache(c, uid); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// validates a recipe request and builds the recipe row for the creator. every input names either a specific
+// item or a rarity and every outcome needs a positive weight, the odds of an outcome are its share of the total weight
+func newCraftingRecipe(vendorId string, req *model.CraftingRecipeReq) (*model.CraftingRecipe, error) {
+	if req.Name == nil || *req.Name == "" {
+		return nil, &core.ErrorResp{Message: "a recipe name must be given"}
+	}
+	if len(req.Inputs) == 0 {
+		return nil, &core.ErrorResp{Message: "a recipe must have at least one input"}
+	}
+	if len(req.Outcomes) == 0 {
+		return nil, &core.ErrorResp{Message: "a recipe must have at least one outcome"}
+	}
+	if len(req.Outcomes) > MAX_RECIPE_OUTCOMES {
+		return nil, &core.ErrorResp{Message: "too many outcomes in recipe"}
+	}
+
+	inputItems := 0
+	for _, input := range req.Inputs {
+		if (input.ItemId == nil) == (input.RarityId == nil) {
+			return nil, &core.ErrorResp{Message: "a recipe input must name either an item or a rarity"}
+		}
+		if input.Qty == nil || *input.Qty <= 0 {
+			return nil, &core.ErrorResp{Message: "recipe input quantities must be greater than 0"}
+		}
+		inputItems += *input.Qty
+	}
+	if inputItems > MAX_RECIPE_INPUT_ITEMS {
+		return nil, &core.ErrorResp{Message: "too many input items in recipe"}
+	}
+
+	for _, outcome := range req.Outcomes {
+		if outcome.ItemId == nil {
+			return nil, &core.ErrorResp{Message: "a recipe outcome must name an item"}
+		}
+		if outcome.Weight == nil || *outcome.Weight <= 0 {
+			return nil, &core.ErrorResp{Message: "recipe outcome weights must be greater than 0"}
+		}
+	}
+
+	craftingCost := 0.0
+	if req.CraftingCost != nil {
+		craftingCost = core.RoundTokenAmount(*req.CraftingCost)
+	}
+	if craftingCost < 0 {
+		return nil, &core.ErrorResp{Message: "recipe crafting cost cannot be negative"}
+	}
+	if req.PerUserLimit != nil && *req.PerUserLimit < 0 {
+		return nil, &core.ErrorResp{Message: "recipe per user limit cannot be negative"}
+	}
+
+	var startsAt, endsAt time.Time
+	var err error
+	if req.StartsAt != nil {
+		if startsAt, err = time.Parse(time.RFC3339, *req.StartsAt); err != nil {
+			return nil, &core.ErrorResp{Message: "recipe start must be an RFC3339 timestamp"}
+		}
+	}
+	if req.EndsAt != nil {
+		if endsAt, err = time.Parse(time.RFC3339, *req.EndsAt); err != nil {
+			return nil, &core.ErrorResp{Message: "recipe end must be an RFC3339 timestamp"}
+		}
+		if req.StartsAt != nil && !endsAt.After(startsAt) {
+			return nil, &core.ErrorResp{Message: "recipe must end after it starts"}
+		}
+	}
+
+	active := true
+	createdAt := time.Now().Format("2006-01-02 15:04:05")
+	recipe := model.CraftingRecipe{
+		VendorId:     &vendorId,
+		Name:         req.Name,
+		Description:  req.Description,
+		CraftingCost: &craftingCost,
+		PerUserLimit: req.PerUserLimit,
+		StartsAt:     req.StartsAt,
+		EndsAt:       req.EndsAt,
+		Active:       &active,
+		CreatedAt:    &createdAt,
+	}
+	return &recipe, nil
+}
@@ -3,6 +3,10 @@ package service
 import (
 	"context"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 	"xo-packs/core"
 	"xo-packs/db"
 	"xo-packs/model"
@@ -14,7 +18,7 @@ type ItemService interface {
 	GetItem(context.Context, uint64) (*model.Item, error)
 	GetItems(context.Context, []uint64, string) ([]model.Item, error)
 	AddItemCategories(context.Context, []*model.ItemCategory) error
-	AddUserItems(context.Context, string, []uint64) error
+	AddUserItems(context.Context, string, uint64, []*model.Item) error
 	PatchItem(context.Context, uint64, map[string]interface{}, string) (*model.Item, error)
 	DeleteUserItems(context.Context, []uint64, string) error
 	DeleteItems(context.Context, []uint64, string, PackService) error
@@ -23,14 +27,36 @@ type ItemService interface {
 	ClearUserItemCache(context.Context, string) error
 	ClearItemCache(context.Context, []uint64) error
 	GetItemSignCreds(context.Context) (string, string, error)
+	GetItemSigner(context.Context) (*core.ContentSigner, error)
+	GetItemInstance(context.Context, uint64, bool) (*model.ItemInstance, error)
+	GetBurnRates(context.Context) ([]*model.BurnRate, error)
+	SetBurnRate(context.Context, *model.BurnRate) (*model.BurnRate, error)
+	GetRarities(context.Context, string) ([]*model.Rarity, error)
+	CreateRarity(context.Context, *model.Rarity) (*model.Rarity, error)
+	PatchRarity(context.Context, uint64, *model.Rarity) (*model.Rarity, error)
+	DeleteRarity(context.Context, uint64) error
+	SetRarityOverride(context.Context, *model.RarityOverride) (*model.RarityOverride, error)
+	DeleteRarityOverride(context.Context, string, uint64) error
+	GetCraftingBalance(context.Context, string) (*model.CraftingBalance, error)
+	BurnUserItems(context.Context, string, *model.BurnItemsReq, TokenService) (*model.BurnItemsResp, error)
+	GetExpiringUserItems(context.Context, string, int) ([]*model.ExpiringUserItem, error)
 }
 
+const (
+	MAX_BURN_ITEMS                     = 50
+	MAX_EXPIRING_ITEMS_DAYS            = 90
+	ITEM_EXPIRY_SWEEP_BATCH_SIZE       = 500
+	DEFAULT_ITEM_EXPIRY_SWEEP_INTERVAL = 60
+)
+
 type ItemSvcImpl struct {
 	itemRepo repository.ItemRepository
 }
 
 func NewItemService(repo repository.ItemRepository) ItemService {
-	return &ItemSvcImpl{itemRepo: repo}
+	itemService := &ItemSvcImpl{itemRepo: repo}
+	go itemService.runItemExpirySweeper()
+	return itemService
 }
 
 type ItemError struct {
@@ -42,6 +68,16 @@ func (e *ItemError) Error() string {
 }
 
 func (itemService *ItemSvcImpl) CreateItem(c context.Context, item *model.Item) (*model.Item, error) {
+	if item.LifetimeDays != nil && *item.LifetimeDays <= 0 {
+		return nil, &core.ErrorResp{Message: "item lifetime must be a positive number of days"}
+	}
+	if item.RarityId == nil {
+		return nil, &core.ErrorResp{Message: "a rarity id must be given"}
+	}
+	if err := itemService.validateRarityId(c, *item.RarityId); err != nil {
+		return nil, err
+	}
+
 	item, err := itemService.itemRepo.CreateItem(c, item)
 	if err != nil {
 		return nil, err
@@ -85,8 +121,8 @@ func (itemService *ItemSvcImpl) AddItemCategories(c context.Context, categories
 	return err
 }
 
-func (itemService *ItemSvcImpl) AddUserItems(c context.Context, uid string, itemIds []uint64) error {
-	err := itemService.itemRepo.AddUserItems(c, uid, itemIds)
+func (itemService *ItemSvcImpl) AddUserItems(c context.Context, uid string, packId uint64, items []*model.Item) error {
+	err := itemService.itemRepo.AddUserItems(c, uid, packId, items)
 	if err != nil {
 		return err
 	}
@@ -96,6 +132,16 @@ func (itemService *ItemSvcImpl) AddUserItems(c context.Context, uid string, item
 }
 
 func (itemService *ItemSvcImpl) PatchItem(c context.Context, itemId uint64, itemPatchMap map[string]interface{}, authorizedUid string) (*model.Item, error) {
+	if rawRarityId, ok := itemPatchMap["rarityId"]; ok {
+		rarityId, ok := rawRarityId.(float64)
+		if !ok || rarityId < 0 || rarityId != float64(uint64(rarityId)) {
+			return nil, &core.ErrorResp{Message: "rarityId must be a rarity id"}
+		}
+		if err := itemService.validateRarityId(c, uint64(rarityId)); err != nil {
+			return nil, err
+		}
+	}
+
 	dbPatchMap := core.ConvertJSONMapToDBMap(itemPatchMap, model.Item{})
 	updatedItem, err := itemService.itemRepo.PatchItem(c, itemId, dbPatchMap, authorizedUid)
 	if err != nil {
@@ -174,3 +220,260 @@ func (itemService *ItemSvcImpl) ClearItemCache(c context.Context, itemIds []uint
 func (itemService *ItemSvcImpl) GetItemSignCreds(c context.Context) (string, string, error) {
 	return itemService.itemRepo.GetItemSignCreds(c)
 }
+
+func (itemService *ItemSvcImpl) GetItemSigner(c context.Context) (*core.ContentSigner, error) {
+	return itemService.itemRepo.GetItemSigner(c)
+}
+
+// gets an item instance and its ownership history. the public view only exposes usernames,
+// admins also get the uids and event notes needed to settle disputes
+func (itemService *ItemSvcImpl) GetItemInstance(c context.Context, userItemId uint64, admin bool) (*model.ItemInstance, error) {
+	itemInstance, err := itemService.itemRepo.GetItemInstance(c, userItemId)
+	if err != nil {
+		return nil, err
+	}
+	if admin {
+		return itemInstance, nil
+	}
+
+	itemInstance.OwnerUid = nil
+	for _, event := range itemInstance.OwnershipEvents {
+		event.FromUid = nil
+		event.ToUid = nil
+		event.ActorUid = nil
+		event.Notes = nil
+	}
+	return itemInstance, nil
+}
+
+func (itemService *ItemSvcImpl) GetBurnRates(c context.Context) ([]*model.BurnRate, error) {
+	return itemService.itemRepo.GetBurnRates(c)
+}
+
+func (itemService *ItemSvcImpl) SetBurnRate(c context.Context, rate *model.BurnRate) (*model.BurnRate, error) {
+	if rate.RarityId == nil {
+		return nil, &core.ErrorResp{Message: "a rarity id must be given"}
+	}
+
+	zero := 0.0
+	if rate.TokenRate == nil {
+		rate.TokenRate = &zero
+	}
+	if rate.CraftingRate == nil {
+		rate.CraftingRate = &zero
+	}
+	if *rate.TokenRate < 0 || *rate.CraftingRate < 0 {
+		return nil, &core.ErrorResp{Message: "burn rates cannot be negative"}
+	}
+	rate.ID = nil
+	return itemService.itemRepo.SetBurnRate(c, rate)
+}
+
+// the rarities ordered from most common to rarest. given a vendor id, the rarities are labelled the way that
+// creator shows them
+func (itemService *ItemSvcImpl) GetRarities(c context.Context, vendorId string) ([]*model.Rarity, error) {
+	rarities, err := itemService.itemRepo.GetRarities(c)
+	if err != nil {
+		return nil, err
+	}
+	if vendorId == "" {
+		return rarities, nil
+	}
+
+	overrides, err := itemService.itemRepo.GetRarityOverrides(c)
+	if err != nil {
+		return nil, err
+	}
+	return core.ApplyRarityOverrides(rarities, overrides, vendorId), nil
+}
+
+func (itemService *ItemSvcImpl) CreateRarity(c context.Context, rarity *model.Rarity) (*model.Rarity, error) {
+	if rarity.Rarity == nil || strings.TrimSpace(*rarity.Rarity) == "" {
+		return nil, &core.ErrorResp{Message: "a rarity name must be given"}
+	}
+	if rarity.Ranking == nil {
+		return nil, &core.ErrorResp{Message: "a rarity ranking must be given"}
+	}
+	if err := itemService.validateRarity(c, 0, rarity); err != nil {
+		return nil, err
+	}
+	rarity.ID = nil
+	return itemService.itemRepo.CreateRarity(c, rarity)
+}
+
+func (itemService *ItemSvcImpl) PatchRarity(c context.Context, rarityId uint64, rarityPatch *model.Rarity) (*model.Rarity, error) {
+	if err := itemService.validateRarity(c, rarityId, rarityPatch); err != nil {
+		return nil, err
+	}
+
+	rarityPatchMap := map[string]interface{}{}
+	if rarityPatch.Rarity != nil {
+		if strings.TrimSpace(*rarityPatch.Rarity) == "" {
+			return nil, &core.ErrorResp{Message: "a rarity name cannot be empty"}
+		}
+		rarityPatchMap["rarity"] = *rarityPatch.Rarity
+	}
+	if rarityPatch.Ranking != nil {
+		rarityPatchMap["ranking"] = *rarityPatch.Ranking
+	}
+	if rarityPatch.Label != nil {
+		rarityPatchMap["label"] = *rarityPatch.Label
+	}
+	if rarityPatch.Color != nil {
+		rarityPatchMap["color"] = *rarityPatch.Color
+	}
+	if len(rarityPatchMap) == 0 {
+		return nil, &core.ErrorResp{Message: "nothing to update"}
+	}
+	return itemService.itemRepo.PatchRarity(c, rarityId, rarityPatchMap)
+}
+
+func (itemService *ItemSvcImpl) DeleteRarity(c context.Context, rarityId uint64) error {
+	return itemService.itemRepo.DeleteRarity(c, rarityId)
+}
+
+func (itemService *ItemSvcImpl) SetRarityOverride(c context.Context, override *model.RarityOverride) (*model.RarityOverride, error) {
+	if override.VendorId == nil || *override.VendorId == "" {
+		return nil, &core.ErrorResp{Message: "a vendor id must be given"}
+	}
+	if override.RarityId == nil {
+		return nil, &core.ErrorResp{Message: "a rarity id must be given"}
+	}
+	if override.Label == nil && override.Color == nil {
+		return nil, &core.ErrorResp{Message: "a label or color must be given"}
+	}
+	if override.Color != nil && !core.ValidRarityColor(*override.Color) {
+		return nil, &core.ErrorResp{Message: "rarity color must be given as #rrggbb"}
+	}
+	if err := itemService.validateRarityId(c, *override.RarityId); err != nil {
+		return nil, err
+	}
+	override.ID = nil
+	return itemService.itemRepo.SetRarityOverride(c, override)
+}
+
+func (itemService *ItemSvcImpl) DeleteRarityOverride(c context.Context, vendorId string, rarityId uint64) error {
+	return itemService.itemRepo.DeleteRarityOverride(c, vendorId, rarityId)
+}
+
+// checks a new or changed rarity against the others, rankings decide the order of rarities in odds so no two
+// rarities can share one
+func (itemService *ItemSvcImpl) validateRarity(c context.Context, rarityId uint64, rarity *model.Rarity) error {
+	if rarity.Color != nil && !core.ValidRarityColor(*rarity.Color) {
+		return &core.ErrorResp{Message: "rarity color must be given as #rrggbb"}
+	}
+	if rarity.Ranking == nil {
+		return nil
+	}
+
+	rarities, err := itemService.itemRepo.GetRarities(c)
+	if err != nil {
+		return err
+	}
+	for _, existing := range rarities {
+		if *existing.ID != rarityId && *existing.Ranking == *rarity.Ranking {
+			return &core.ErrorResp{Message: fmt.Sprintf("rarity %v already has ranking %v", *existing.Rarity, *rarity.Ranking)}
+		}
+	}
+	return nil
+}
+
+func (itemService *ItemSvcImpl) validateRarityId(c context.Context, rarityId uint64) error {
+	rarities, err := itemService.itemRepo.GetRarities(c)
+	if err != nil {
+		return err
+	}
+	for _, rarity := range rarities {
+		if *rarity.ID == rarityId {
+			return nil
+		}
+	}
+	return &core.ErrorResp{Message: fmt.Sprintf("rarity %v does not exist", rarityId)}
+}
+
+func (itemService *ItemSvcImpl) GetCraftingBalance(c context.Context, uid string) (*model.CraftingBalance, error) {
+	return itemService.itemRepo.GetCraftingBalance(c, uid)
+}
+
+// burns user items for tokens or crafting currency, tokens are paid out when no currency is given
+func (itemService *ItemSvcImpl) BurnUserItems(c context.Context, uid string, req *model.BurnItemsReq, tokenService TokenService) (*model.BurnItemsResp, error) {
+	currency := db.BURN_CURRENCY_TOKENS
+	if req.Currency != nil {
+		currency = strings.ToLower(*req.Currency)
+	}
+	if currency != db.BURN_CURRENCY_TOKENS && currency != db.BURN_CURRENCY_CRAFTING {
+		return nil, &core.ErrorResp{Message: fmt.Sprintf("currency must be %v or %v", db.BURN_CURRENCY_TOKENS, db.BURN_CURRENCY_CRAFTING)}
+	}
+
+	userItemIds := uniqueIds(req.UserItemIds)
+	if len(userItemIds) == 0 {
+		return nil, &core.ErrorResp{Message: "at least one user item id must be given"}
+	}
+	if len(userItemIds) > MAX_BURN_ITEMS {
+		return nil, &core.ErrorResp{Message: fmt.Sprintf("at most %v items can be burned at once", MAX_BURN_ITEMS)}
+	}
+
+	rates, err := itemService.itemRepo.GetBurnRates(c)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := itemService.itemRepo.BurnUserItems(c, uid, userItemIds, currency, rates)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := itemService.ClearUserItemCache(c, uid); err != nil {
+		return nil, err
+	}
+	if currency == db.BURN_CURRENCY_TOKENS {
+		if err := tokenService.ClearUserTokenCache(c, uid); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+func (itemService *ItemSvcImpl) GetExpiringUserItems(c context.Context, uid string, days int) ([]*model.ExpiringUserItem, error) {
+	if days <= 0 || days > MAX_EXPIRING_ITEMS_DAYS {
+		return nil, &core.ErrorResp{Message: fmt.Sprintf("days must be between 1 and %v", MAX_EXPIRING_ITEMS_DAYS)}
+	}
+	return itemService.itemRepo.GetExpiringUserItems(c, uid, days)
+}
+
+// periodically sweeps user items that have run out their lifetime. the ownership checks already treat them as
+// gone once expired_at passes, the sweep takes down their listings and clears the cached collections that still
+// show them
+func (itemService *ItemSvcImpl) runItemExpirySweeper() {
+	ticker := time.NewTicker(itemExpirySweepInterval())
+	defer ticker.Stop()
+	for range ticker.C {
+		itemService.sweepExpiredUserItems(context.Background())
+	}
+}
+
+func (itemService *ItemSvcImpl) sweepExpiredUserItems(c context.Context) {
+	for {
+		uids, err := itemService.itemRepo.ExpireUserItems(c, ITEM_EXPIRY_SWEEP_BATCH_SIZE)
+		if err != nil {
+			fmt.Println("unable to sweep expired user items: ", err)
+			return
+		}
+		for _, uid := range uids {
+			if err := itemService.ClearUserItemCache(c, uid); err != nil {
+				fmt.Println("unable to clear expired user item cache: ", err)
+			}
+		}
+		if len(uids) == 0 {
+			return
+		}
+	}
+}
+
+func itemExpirySweepInterval() time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv("ITEM_EXPIRY_SWEEP_INTERVAL"))
+	if err != nil || seconds <= 0 {
+		seconds = DEFAULT_ITEM_EXPIRY_SWEEP_INTERVAL
+	}
+	return time.Duration(seconds) * time.Second
+}
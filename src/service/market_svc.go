@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"xo-packs/core"
+	"xo-packs/model"
+	"xo-packs/repository"
+)
+
+type MarketService interface {
+	CreateListing(context.Context, string, *model.CreateMarketListingReq) (*model.MarketListing, error)
+	CancelListing(context.Context, uint64, string) error
+	GetListing(context.Context, uint64) (*model.MarketListingExpanded, error)
+	SearchListings(context.Context, *model.MarketListingFilter, string, uint64, string) (*model.MarketListingPage, error)
+	BuyListing(context.Context, uint64, string, ItemService, TokenService) (*model.MarketPurchaseResp, error)
+}
+
+type MarketSvcImpl struct {
+	marketRepo repository.MarketRepository
+}
+
+func NewMarketService(repo repository.MarketRepository) MarketService {
+	return &MarketSvcImpl{marketRepo: repo}
+}
+
+func (marketService *MarketSvcImpl) CreateListing(c context.Context, uid string, req *model.CreateMarketListingReq) (*model.MarketListing, error) {
+	if req.UserItemId == nil {
+		return nil, &core.ErrorResp{Message: "a user item id must be given"}
+	}
+	if req.TokenAmount == nil || core.RoundTokenAmount(*req.TokenAmount) <= 0 {
+		return nil, &core.ErrorResp{Message: "listing token amount must be greater than 0"}
+	}
+
+	return marketService.marketRepo.CreateListing(c, uid, *req.UserItemId, core.RoundTokenAmount(*req.TokenAmount))
+}
+
+func (marketService *MarketSvcImpl) CancelListing(c context.Context, listingId uint64, uid string) error {
+	return marketService.marketRepo.CancelListing(c, listingId, uid)
+}
+
+func (marketService *MarketSvcImpl) GetListing(c context.Context, listingId uint64) (*model.MarketListingExpanded, error) {
+	return marketService.marketRepo.GetListing(c, listingId)
+}
+
+func (marketService *MarketSvcImpl) SearchListings(c context.Context, filter *model.MarketListingFilter, sort string, pageNumber uint64, urlPath string) (*model.MarketListingPage, error) {
+	if filter.MinTokenAmount != nil && filter.MaxTokenAmount != nil && *filter.MinTokenAmount > *filter.MaxTokenAmount {
+		return nil, &core.ErrorResp{Message: "min price cannot be greater than max price"}
+	}
+
+	listings, listingAmount, err := marketService.marketRepo.SearchListings(c, filter, sort, pageNumber-1, urlPath)
+	if err != nil {
+		return nil, err
+	}
+	pageSize, err := strconv.ParseUint(os.Getenv("MARKET_LISTINGS_PAGE_SIZE"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	thisPageSize := uint64(len(listings))
+	nextPageNum := uint64(0)
+	nextPage := &nextPageNum
+	if thisPageSize < pageSize {
+		nextPage = nil
+	} else {
+		nextPageNum = (pageNumber + uint64(1))
+	}
+	return &model.MarketListingPage{ListingAmount: listingAmount, NextPage: nextPage, PageSize: &thisPageSize, Page: listings}, nil
+}
+
+func (marketService *MarketSvcImpl) BuyListing(c context.Context, listingId uint64, uid string, itemService ItemService, tokenService TokenService) (*model.MarketPurchaseResp, error) {
+	resp, err := marketService.marketRepo.BuyListing(c, listingId, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	// clearing the balances and collections of everyone paid or moved by the sale
+	for _, balanceUid := range []string{*resp.Sale.BuyerUid, *resp.Sale.SellerUid, *resp.Sale.CreatorUid} {
+		if err := tokenService.ClearUserTokenCache(c, balanceUid); err != nil {
+			return nil, err
+		}
+	}
+	for _, itemUid := range []string{*resp.Sale.BuyerUid, *resp.Sale.SellerUid} {
+		if err := itemService.ClearUserItemCache(c, itemUid); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+	"xo-packs/core"
+	"xo-packs/db"
+	"xo-packs/model"
+	"xo-packs/repository"
+)
+
+const (
+	MAX_NOTIFICATIONS      = 50
+	MAX_BIG_PULLS          = 20
+	WEBHOOK_TIMEOUT_SECOND = 5
+)
+
+type NotificationService interface {
+	NotifyPulls(context.Context, *model.User, *model.Vendor, *model.Pack) error
+	NotifyPackReview(context.Context, *model.PackConfig) error
+	NotifyPackWaitlist(context.Context, *model.PackConfig, []string) error
+	GetNotifications(context.Context, string, bool) (*model.NotificationsResp, error)
+	ReadNotifications(context.Context, string, *model.ReadNotificationsReq) error
+	GetBigPulls(context.Context, string) ([]*model.BigPull, error)
+	GetBigPullOptIn(context.Context, string) (*model.BigPullOptIn, error)
+	SetBigPullOptIn(context.Context, string, *model.BigPullOptIn) (*model.BigPullOptIn, error)
+	GetWebhook(context.Context, string) (*model.CreatorWebhook, error)
+	SetWebhook(context.Context, string, *model.CreatorWebhookReq) (*model.CreatorWebhook, error)
+	DeleteWebhook(context.Context, string) error
+}
+
+type NotificationSvcImpl struct {
+	notificationRepo repository.NotificationRepository
+}
+
+func NewNotificationService(notificationRepo repository.NotificationRepository) NotificationService {
+	return &NotificationSvcImpl{notificationRepo: notificationRepo}
+}
+
+// tells the creator of a pack about every notify item a fan pulled from it, in app and on their webhook
+// when they have one. the webhook is called in the background so a slow endpoint never holds up opening
+func (service *NotificationSvcImpl) NotifyPulls(c context.Context, fan *model.User, vendor *model.Vendor, pack *model.Pack) error {
+	if fan == nil || fan.Uid == nil || vendor == nil || vendor.UID == nil || pack == nil || pack.ID == nil {
+		return nil
+	}
+	username := ""
+	if fan.Username != nil {
+		username = *fan.Username
+	}
+
+	notificationType := db.NOTIFICATION_NOTIFY_PULL
+	notifications := []*model.Notification{}
+	for _, item := range pack.Items {
+		if item.Notify == nil || !*item.Notify || item.ID == nil {
+			continue
+		}
+		itemName := ""
+		if item.Name != nil {
+			itemName = *item.Name
+		}
+		message := core.NotifyPullMessage(username, itemName)
+		notifications = append(notifications, &model.Notification{
+			Uid:      vendor.UID,
+			Type:     &notificationType,
+			ActorUid: fan.Uid,
+			ItemId:   item.ID,
+			PackId:   pack.ID,
+			Message:  &message,
+		})
+	}
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	created, err := service.notificationRepo.CreateNotifications(c, notifications)
+	if err != nil {
+		return err
+	}
+
+	webhook, err := service.notificationRepo.GetWebhook(c, *vendor.UID)
+	if err != nil {
+		return err
+	}
+	if webhook == nil {
+		return nil
+	}
+
+	itemNames := map[uint64]string{}
+	for _, item := range pack.Items {
+		if item.ID != nil && item.Name != nil {
+			itemNames[*item.ID] = *item.Name
+		}
+	}
+	events := []*model.NotifyPullEvent{}
+	for _, notification := range created {
+		events = append(events, &model.NotifyPullEvent{
+			Type:        db.NOTIFICATION_NOTIFY_PULL,
+			VendorId:    *vendor.UID,
+			FanUid:      *fan.Uid,
+			FanUsername: username,
+			ItemId:      *notification.ItemId,
+			ItemName:    itemNames[*notification.ItemId],
+			PackId:      *notification.PackId,
+			PulledAt:    *notification.CreatedAt,
+		})
+	}
+	go deliverWebhook(*webhook, events)
+	return nil
+}
+
+// tells the creator of a reviewed pack whether it was approved, along with the admins notes when it was rejected
+func (service *NotificationSvcImpl) NotifyPackReview(c context.Context, packConfig *model.PackConfig) error {
+	if packConfig == nil || packConfig.ID == nil || packConfig.VendorID == nil || packConfig.ReviewStatus == nil {
+		return nil
+	}
+	packTitle := "Your pack"
+	if packConfig.Title != nil && *packConfig.Title != "" {
+		packTitle = *packConfig.Title
+	}
+
+	notificationType := db.NOTIFICATION_PACK_REVIEW
+	message := core.PackReviewMessage(packTitle, *packConfig.ReviewStatus == db.PACK_REVIEW_APPROVED, packConfig.ReviewNotes)
+	_, err := service.notificationRepo.CreateNotifications(c, []*model.Notification{{
+		Uid:          packConfig.VendorID,
+		Type:         &notificationType,
+		ActorUid:     packConfig.ReviewedBy,
+		PackConfigId: packConfig.ID,
+		Message:      &message,
+	}})
+	return err
+}
+
+// tells every waitlisted fan that the pack they are waiting on is live and in stock
+func (service *NotificationSvcImpl) NotifyPackWaitlist(c context.Context, packConfig *model.PackConfig, uids []string) error {
+	if packConfig == nil || packConfig.ID == nil || len(uids) == 0 {
+		return nil
+	}
+	packTitle := "A pack you are waiting on"
+	if packConfig.Title != nil && *packConfig.Title != "" {
+		packTitle = *packConfig.Title
+	}
+
+	notificationType := db.NOTIFICATION_PACK_WAITLIST
+	message := core.PackWaitlistMessage(packTitle)
+	notifications := make([]*model.Notification, len(uids))
+	for i := range uids {
+		notifications[i] = &model.Notification{
+			Uid:          &uids[i],
+			Type:         &notificationType,
+			ActorUid:     packConfig.VendorID,
+			PackConfigId: packConfig.ID,
+			Message:      &message,
+		}
+	}
+	_, err := service.notificationRepo.CreateNotifications(c, notifications)
+	return err
+}
+
+func deliverWebhook(webhook model.CreatorWebhook, events []*model.NotifyPullEvent) {
+	client := core.NewWebhookClient(WEBHOOK_TIMEOUT_SECOND * time.Second)
+	for _, event := range events {
+		body, err := json.Marshal(event)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+
+		req, err := http.NewRequest("POST", *webhook.Url, bytes.NewReader(body))
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(core.WEBHOOK_SIGNATURE_HEADER, core.SignWebhookPayload(*webhook.Secret, body))
+
+		resp, err := client.Do(req)
+		if err != nil {
+			fmt.Println("unable to call webhook for ", *webhook.VendorId, ": ", err)
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			fmt.Println("webhook for ", *webhook.VendorId, " responded with ", resp.StatusCode)
+		}
+	}
+}
+
+func (service *NotificationSvcImpl) GetNotifications(c context.Context, uid string, unreadOnly bool) (*model.NotificationsResp, error) {
+	notifications, unreadAmount, err := service.notificationRepo.GetNotifications(c, uid, unreadOnly, MAX_NOTIFICATIONS)
+	if err != nil {
+		return nil, err
+	}
+	return &model.NotificationsResp{UnreadAmount: unreadAmount, Notifications: notifications}, nil
+}
+
+func (service *NotificationSvcImpl) ReadNotifications(c context.Context, uid string, req *model.ReadNotificationsReq) error {
+	if len(req.NotificationIds) > MAX_NOTIFICATIONS {
+		return &core.ErrorResp{Message: fmt.Sprintf("cannot mark more than %v notifications read at once", MAX_NOTIFICATIONS)}
+	}
+	return service.notificationRepo.ReadNotifications(c, uid, req.NotificationIds)
+}
+
+func (service *NotificationSvcImpl) GetBigPulls(c context.Context, vendorId string) ([]*model.BigPull, error) {
+	return service.notificationRepo.GetBigPulls(c, vendorId, MAX_BIG_PULLS)
+}
+
+func (service *NotificationSvcImpl) GetBigPullOptIn(c context.Context, uid string) (*model.BigPullOptIn, error) {
+	optedIn, err := service.notificationRepo.GetBigPullOptIn(c, uid)
+	if err != nil {
+		return nil, err
+	}
+	return &model.BigPullOptIn{OptedIn: optedIn}, nil
+}
+
+func (service *NotificationSvcImpl) SetBigPullOptIn(c context.Context, uid string, optIn *model.BigPullOptIn) (*model.BigPullOptIn, error) {
+	if err := service.notificationRepo.SetBigPullOptIn(c, uid, optIn.OptedIn); err != nil {
+		return nil, err
+	}
+	return optIn, nil
+}
+
+func (service *NotificationSvcImpl) GetWebhook(c context.Context, vendorId string) (*model.CreatorWebhook, error) {
+	webhook, err := service.notificationRepo.GetWebhook(c, vendorId)
+	if err != nil {
+		return nil, err
+	}
+	if webhook == nil {
+		return nil, &core.ErrorResp{Message: "no webhook has been set"}
+	}
+	return webhook, nil
+}
+
+// every change of url comes with a new signing secret, the old one stops being used right away
+func (service *NotificationSvcImpl) SetWebhook(c context.Context, vendorId string, req *model.CreatorWebhookReq) (*model.CreatorWebhook, error) {
+	if req.Url == nil || !core.ValidWebhookUrl(*req.Url) {
+		return nil, &core.ErrorResp{Message: "webhook url must be a valid https url"}
+	}
+	secret, err := core.NewWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+	return service.notificationRepo.SetWebhook(c, vendorId, *req.Url, secret)
+}
+
+func (service *NotificationSvcImpl) DeleteWebhook(c context.Context, vendorId string) error {
+	return service.notificationRepo.DeleteWebhook(c, vendorId)
+}
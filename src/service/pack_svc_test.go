@@ -80,10 +80,62 @@ func TestGeneratePacks(t *testing.T) {
 		})
 	}
 
-	result, _ := GeneratePackItemIds(context.TODO(), packItemConfigs, &packConfig)
+	// 9 configs of 50 items cannot fill 100 packs of 5
+	if _, err := GeneratePackItemIds(context.TODO(), packItemConfigs, &packConfig); err == nil {
+		t.Errorf("expected an item pool smaller than the pack slots to be rejected")
+	}
+
+	qty = 90
+	result, err := GeneratePackItemIds(context.TODO(), packItemConfigs, &packConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, packItemIds := range result {
+		if len(packItemIds) != itemQty {
+			t.Errorf("expected every pack to hold %v items, got %v", itemQty, len(packItemIds))
+		}
+	}
 	fmt.Println("RESULT: ", result)
 }
 
+func TestPackGenerationChunkSize(t *testing.T) {
+	t.Setenv("PACK_GENERATION_CHUNK_SIZE", "")
+	if size := packGenerationChunkSize(); size != DEFAULT_PACK_GENERATION_CHUNK_SIZE {
+		t.Errorf("expected default chunk size %v, got %v", DEFAULT_PACK_GENERATION_CHUNK_SIZE, size)
+	}
+
+	t.Setenv("PACK_GENERATION_CHUNK_SIZE", "-1")
+	if size := packGenerationChunkSize(); size != DEFAULT_PACK_GENERATION_CHUNK_SIZE {
+		t.Errorf("expected default chunk size %v, got %v", DEFAULT_PACK_GENERATION_CHUNK_SIZE, size)
+	}
+
+	t.Setenv("PACK_GENERATION_CHUNK_SIZE", "250")
+	if size := packGenerationChunkSize(); size != 250 {
+		t.Errorf("expected chunk size 250, got %v", size)
+	}
+}
+
+func TestRaritySummary(t *testing.T) {
+	rarities := []*model.Rarity{}
+	for id, ranking := range map[uint64]uint64{1: 30, 2: 10, 3: 20} {
+		currId, currRanking := id, ranking
+		rarities = append(rarities, &model.Rarity{ID: &currId, Ranking: &currRanking})
+	}
+
+	summary := RaritySummary(map[uint64]int{3: 1, 1: 4, 2: 2, 9: 1}, rarities)
+	if len(summary) != 4 {
+		t.Fatalf("expected 4 rarities, got %v", len(summary))
+	}
+	for i, rarityId := range []uint64{2, 3, 1, 9} {
+		if summary[i].RarityId != rarityId {
+			t.Errorf("expected rarity %v at index %v, got %v", rarityId, i, summary[i].RarityId)
+		}
+	}
+	if summary[2].Amount != 4 {
+		t.Errorf("expected 4 items of rarity 1, got %v", summary[2].Amount)
+	}
+}
+
 // func TestUploadPacks(t *testing.T) {
 // 	packs := make([]*model.PackFact, 10)
 // 	for i := 0; i < 10; i++ {
@@ -130,7 +182,7 @@ func TestPackGeneration(t *testing.T) {
 
 	packService := NewPackService(repository.NewPackRepo(db, nil))
 	id := uint64(15)
-	err = packService.GeneratePacks(context.TODO(), id, "", nil)
+	_, err = packService.GeneratePacks(context.TODO(), id, "")
 	if err != nil {
 		fmt.Println(err)
 		t.Error(err)
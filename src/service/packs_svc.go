@@ -4,19 +4,38 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"os"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 	"xo-packs/core"
+	"xo-packs/db"
 	"xo-packs/model"
 	"xo-packs/repository"
+
+	"github.com/sirupsen/logrus"
 )
 
 type PackService interface {
 	CreatePackConfig(context.Context, *model.PackConfig, VendorService) (*model.PackConfig, error)
-	AddPackItemConfigs(context.Context, []*model.PackItemConfig) error
-	GeneratePacks(context.Context, uint64, string, VendorService) error
-	BuyPacks(context.Context, string, uint64, float64, TokenService) (*model.PackBoughtResp, error)
-	OpenPack(context.Context, uint64, string, ItemService) (*model.Pack, error)
+	ClonePackConfig(context.Context, uint64, string, *model.ClonePackConfigReq, VendorService) (*model.PackConfig, error)
+	AddPackItemConfigs(context.Context, []*model.PackItemConfig, VendorService) error
+	GeneratePacks(context.Context, uint64, string) (*model.PackGenerationJob, error)
+	GetPackGenerationJob(context.Context, uint64, string) (*model.PackGenerationJob, error)
+	RunPackGenerationWorker(VendorService, NotificationService)
+	BuyPacks(context.Context, string, uint64, float64, *model.PackGift, TokenService, UserService) (*model.PackBoughtResp, error)
+	GetReceivedGifts(context.Context, string) ([]*model.ReceivedGift, error)
+	JoinPackWaitlist(context.Context, string, uint64) (*model.PackWaitlistEntry, error)
+	LeavePackWaitlist(context.Context, string, uint64) error
+	QuotePacks(context.Context, uint64, int) (*model.PackQuote, error)
+	GetPackPriceTiers(context.Context, uint64) ([]*model.PackPriceTier, error)
+	SetPackPriceTiers(context.Context, uint64, string, []*model.PackPriceTier, VendorService) ([]*model.PackPriceTier, error)
+	OpenPack(context.Context, uint64, string, ItemService, WatermarkService) (*model.Pack, error)
+	OpenPacks(context.Context, string, []uint64, *uint64, ItemService, WatermarkService) (*model.OpenPacksResp, error)
+	StartPackReveal(context.Context, uint64, string, ItemService, WatermarkService) (*model.PackRevealResp, error)
+	GetPackReveal(context.Context, uint64, string, ItemService, WatermarkService) (*model.PackRevealResp, error)
+	RevealPackSlot(context.Context, uint64, int, string, ItemService, WatermarkService) (*model.PackRevealSlotExpanded, error)
 	GetPack(context.Context, uint64) (*model.Pack, error)
 	AddPackCategories(context.Context, []*model.PackCategory) error
 	GetUserPackAmount(context.Context, string) (*uint64, error)
@@ -26,9 +45,13 @@ type PackService interface {
 	GetPackItemsPreview2(context.Context, uint64) ([]*model.PackItemPreview, error)
 	GetActivePackItems(context.Context, []uint64) ([]string, []string, error)
 	GetPacksContainingItems(context.Context, []uint64) ([]string, []string, error)
-	PatchPackConfig(context.Context, uint64, map[string]interface{}, string) (*model.PackConfig, error)
+	PatchPackConfig(context.Context, uint64, map[string]interface{}, string, VendorService) (*model.PackConfig, error)
 	RemoveUserPacks(context.Context, []uint64) error
-	ActivatePacks(context.Context, []uint64, string, VendorService) error
+	ActivatePacks(context.Context, []uint64, string, VendorService, NotificationService) error
+	SubmitPackConfigs(context.Context, []uint64, string) error
+	GetSubmittedPackConfigs(context.Context) ([]*model.PackConfig, error)
+	GetPackReview(context.Context, uint64, ItemService) (*model.PackReview, error)
+	ReviewPackConfig(context.Context, uint64, bool, *string, string) (*model.PackConfig, error)
 	DeactivatePacks(context.Context, []uint64, string, VendorService) error
 	DeletePackConfigs(context.Context, []uint64, string, VendorService) error
 	ClearPackCategoryCache(context.Context) error
@@ -38,33 +61,36 @@ type PackService interface {
 	GeneratePackItemOdds(context.Context, string, []model.PackItemConfig, int, ItemService) ([]int, error)
 }
 
+const (
+	DEFAULT_PACK_GENERATION_CHUNK_SIZE    = 500
+	DEFAULT_PACK_GENERATION_POLL_INTERVAL = 10
+	PACK_GENERATION_STALE_AFTER_SECONDS   = 15 * 60
+	MAX_BULK_OPEN_PACKS                   = 100
+	PACK_REVEAL_EXPIRY_SECONDS            = 60 * 60
+	PACK_REVEAL_SWEEP_BATCH_SIZE          = 100
+	DEFAULT_PACK_REVEAL_SWEEP_INTERVAL    = 60
+	MIN_GIFT_RECIPIENT_AGE                = 18
+	MAX_GIFT_MESSAGE_LENGTH               = 280
+)
+
 type PackSvcImpl struct {
-	packRepo repository.PackRepository
+	packRepo       repository.PackRepository
+	generationWake chan struct{}
 }
 
 func NewPackService(repo repository.PackRepository) PackService {
-	return &PackSvcImpl{packRepo: repo}
+	packService := &PackSvcImpl{
+		packRepo:       repo,
+		generationWake: make(chan struct{}, 1),
+	}
+	go packService.runPackRevealSweeper()
+	return packService
 }
 
 // @service: create-pack-config
 func (packService *PackSvcImpl) CreatePackConfig(c context.Context, packConfig *model.PackConfig, vendorService VendorService) (*model.PackConfig, error) {
-	if packConfig.Qty == nil || packConfig.ItemQty == nil || packConfig.TokenAmount == nil {
-		return nil, &core.ErrorResp{
-			Message: "pack qty, item qty, and token amount need to be populated in the pack config",
-		}
-	}
-
-	if (*packConfig.Qty * *packConfig.ItemQty) > 10000 {
-		return nil, &core.ErrorResp{
-			Message: "total pack items cannot exceed 10,000",
-		}
-	}
-
-	// checking token amount is high enough
-	if *packConfig.TokenAmount < 5 {
-		return nil, &core.ErrorResp{
-			Message: "pack must cost at least 5 tokens",
-		}
+	if err := validatePackConfig(packConfig); err != nil {
+		return nil, err
 	}
 
 	if packConfig.VendorID == nil {
@@ -74,6 +100,16 @@ func (packService *PackSvcImpl) CreatePackConfig(c context.Context, packConfig *
 	}
 	vendorId := *packConfig.VendorID
 
+	// every pack config starts as a draft and must be approved by an admin before it can go live
+	reviewStatus := db.PACK_REVIEW_DRAFT
+	packConfig.ReviewStatus = &reviewStatus
+	packConfig.ReviewNotes = nil
+	packConfig.SubmittedAt = nil
+	packConfig.ReviewedAt = nil
+	packConfig.ReviewedBy = nil
+	active := false
+	packConfig.Active = &active
+
 	// converting release date time to UTC for scheduler job
 	// if packConfig.ReleaseAt != nil {
 	// 	parsedTime, err := time.Parse(time.RFC3339, *packConfig.ReleaseAt)
@@ -103,105 +139,540 @@ func (packService *PackSvcImpl) CreatePackConfig(c context.Context, packConfig *
 	return packConfig, nil
 }
 
-func (packService *PackSvcImpl) AddPackItemConfigs(c context.Context, packItemConfigs []*model.PackItemConfig) error {
-	if len(packItemConfigs) > 0 {
-		return packService.packRepo.AddPackItemConfigs(c, packItemConfigs)
+func validatePackConfig(packConfig *model.PackConfig) error {
+	if packConfig.Qty == nil || packConfig.ItemQty == nil || packConfig.TokenAmount == nil {
+		return &core.ErrorResp{
+			Message: "pack qty, item qty, and token amount need to be populated in the pack config",
+		}
+	}
+
+	if (*packConfig.Qty * *packConfig.ItemQty) > 10000 {
+		return &core.ErrorResp{
+			Message: "total pack items cannot exceed 10,000",
+		}
+	}
+
+	// checking token amount is high enough
+	if *packConfig.TokenAmount < 5 {
+		return &core.ErrorResp{
+			Message: "pack must cost at least 5 tokens",
+		}
 	}
 	return nil
 }
 
-func (packService *PackSvcImpl) GeneratePacks(c context.Context, packConfigId uint64, vendorId string, vendorService VendorService) error {
+// copies a pack config with its item configs and categories into a new draft owned by the same vendor
+func (packService *PackSvcImpl) ClonePackConfig(c context.Context, packConfigId uint64, vendorId string, overrides *model.ClonePackConfigReq, vendorService VendorService) (*model.PackConfig, error) {
+	source, err := packService.packRepo.GetPackConfig(c, packConfigId)
+	if err != nil {
+		return nil, err
+	}
+	if source.ID == nil || source.VendorID == nil || source.DeletedAt != nil {
+		return nil, &core.ErrorResp{Message: "pack config does not exist"}
+	}
+	if *source.VendorID != vendorId {
+		return nil, &core.ErrorResp{Message: "vendor does not have access to this pack"}
+	}
+
+	reviewStatus := db.PACK_REVIEW_DRAFT
+	active := false
+	packConfig := &model.PackConfig{
+		ImageUrl:        source.ImageUrl,
+		VendorID:        source.VendorID,
+		Description:     source.Description,
+		Title:           source.Title,
+		TokenAmount:     source.TokenAmount,
+		Qty:             source.Qty,
+		ItemQty:         source.ItemQty,
+		ContentMainUrl:  source.ContentMainUrl,
+		ContentThumbUrl: source.ContentThumbUrl,
+		Active:          &active,
+		ReviewStatus:    &reviewStatus,
+	}
+	if overrides != nil {
+		if overrides.Title != nil {
+			packConfig.Title = overrides.Title
+		}
+		if overrides.TokenAmount != nil {
+			packConfig.TokenAmount = overrides.TokenAmount
+		}
+		if overrides.Qty != nil {
+			packConfig.Qty = overrides.Qty
+		}
+	}
+
+	if err := validatePackConfig(packConfig); err != nil {
+		return nil, err
+	}
+
+	// a qty override scales the copied item configs so every new pack is still filled
+	sourceQty := 0
+	if source.Qty != nil {
+		sourceQty = *source.Qty
+	}
+	packConfig, err = packService.packRepo.ClonePackConfig(c, packConfigId, sourceQty, packConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// clearing vendor pack cache
+	if err := packService.ClearVendorPackCache(c, vendorId); err != nil {
+		return nil, err
+	}
+
+	// clear vendor cache
+	if err := vendorService.ClearVendorCache(c, vendorId); err != nil {
+		return nil, err
+	}
+	return packConfig, nil
+}
+
+func (packService *PackSvcImpl) AddPackItemConfigs(c context.Context, packItemConfigs []*model.PackItemConfig, vendorService VendorService) error {
+	if len(packItemConfigs) == 0 {
+		return nil
+	}
+	if err := packService.packRepo.AddPackItemConfigs(c, packItemConfigs); err != nil {
+		return err
+	}
+
+	// the items of a pack are part of what was reviewed
+	withdrawn := map[uint64]bool{}
+	for _, itemConfig := range packItemConfigs {
+		if itemConfig.PackConfigID == nil || withdrawn[*itemConfig.PackConfigID] {
+			continue
+		}
+		withdrawn[*itemConfig.PackConfigID] = true
+
+		packConfig, err := packService.packRepo.GetPackConfig(c, *itemConfig.PackConfigID)
+		if err != nil {
+			return err
+		}
+		if packConfig.VendorID == nil {
+			continue
+		}
+		if err := packService.withdrawPackReview(c, *itemConfig.PackConfigID, *packConfig.VendorID, vendorService); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// any change to what a submitted or approved pack sells puts it back to draft and takes it out of the shop,
+// so nothing goes on sale without an admin having seen it
+func (packService *PackSvcImpl) withdrawPackReview(c context.Context, packConfigId uint64, vendorId string, vendorService VendorService) error {
+	withdrawn, err := packService.packRepo.WithdrawPackReview(c, packConfigId, vendorId)
+	if err != nil {
+		return err
+	}
+	if !withdrawn {
+		return nil
+	}
+
+	if err := packService.ClearPackConfigCache(c, []uint64{packConfigId}, vendorId); err != nil {
+		return err
+	}
+	if err := packService.ClearVendorPackCache(c, vendorId); err != nil {
+		return err
+	}
+	if err := packService.ClearPackShopCache(c); err != nil {
+		return err
+	}
+	return vendorService.ClearVendorCache(c, vendorId)
+}
+
+// validates the pack config and queues a background job that generates and uploads its stock
+func (packService *PackSvcImpl) GeneratePacks(c context.Context, packConfigId uint64, vendorId string) (*model.PackGenerationJob, error) {
 	// 1. get the pack config
 	packConfig, err := packService.packRepo.GetPackConfig(c, packConfigId)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if packConfig == nil {
-		return &core.SvcError{Message: "This pack has been discontinued"}
+		return nil, &core.SvcError{Message: "This pack has been discontinued"}
 	} else if packConfig.DeletedAt != nil {
-		return &core.SvcError{Message: "This pack has been discontinued"}
+		return nil, &core.SvcError{Message: "This pack has been discontinued"}
 	}
 
 	if packConfig.CurrentStock == nil {
-		return &core.SvcError{
+		return nil, &core.SvcError{
 			Message: "Data quality error; no current stock associated with pack",
 		}
 	} else if *packConfig.CurrentStock > 0 {
-		return &core.SvcError{
+		return nil, &core.SvcError{
 			Message: fmt.Sprintf("There are currently %v packs available in the market", *packConfig.CurrentStock),
 		}
 	}
 
 	if packConfig.VendorID == nil {
-		return &core.ErrorResp{Message: "this pack does not exist"}
+		return nil, &core.ErrorResp{Message: "this pack does not exist"}
 	}
 	if *packConfig.VendorID != vendorId {
-		return &core.ErrorResp{Message: "vendor does not have access to this pack"}
+		return nil, &core.ErrorResp{Message: "vendor does not have access to this pack"}
+	}
+	if packConfig.Qty == nil || packConfig.ItemQty == nil {
+		return nil, &core.SvcError{Message: "Data quality error; pack qty and item qty must be populated"}
 	}
 
 	// 2. get the pack item configs
 	packItemConfigs, err := packService.packRepo.GetPackItemConfigs(c, packConfigId)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if len(packItemConfigs) == 0 {
-		return &core.SvcError{Message: "This pack has no items associated with it"}
+		return nil, &core.SvcError{Message: "This pack has no items associated with it"}
+	}
+	if err := validatePackItemPool(packItemConfigs, packConfig); err != nil {
+		return nil, err
 	}
 
-	// 3. generate list of item ids for a new pack based on the configs
-	packItemIdBatch, err := GeneratePackItemIds(c, packItemConfigs, packConfig)
+	// 3. only one generation job can run for a pack config at a time
+	activeJob, err := packService.packRepo.GetActivePackGenerationJob(c, packConfigId)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if activeJob != nil {
+		return nil, &core.SvcError{
+			Message: fmt.Sprintf("Packs are already being generated for this pack config. Job ID: %v", *activeJob.ID),
+		}
+	}
+
+	// 4. record the job, the generation worker claims it from the DB
+	now := time.Now().Format("2006-01-02 15:04:05")
+	status := db.JOB_STATUS_QUEUED
+	totalPacks := *packConfig.Qty
+	packsUploaded := 0
+	job := &model.PackGenerationJob{
+		PackConfigID:  &packConfigId,
+		VendorID:      &vendorId,
+		Status:        &status,
+		TotalPacks:    &totalPacks,
+		PacksUploaded: &packsUploaded,
+		CreatedAt:     &now,
+		UpdatedAt:     &now,
+	}
+	job, err = packService.packRepo.CreatePackGenerationJob(c, job)
+	if err != nil {
+		return nil, err
 	}
-	if len(packItemIdBatch) == 0 {
-		return &core.SvcError{Message: "An error occurred generating the item ids list"}
+
+	// start the job now instead of on the next poll, the worker is already awake if this is full
+	select {
+	case packService.generationWake <- struct{}{}:
+	default:
 	}
 
-	// 4. build and upload pack fact records
-	packs := make([]*model.PackFact, *packConfig.Qty)
-	for i := 0; i < *packConfig.Qty; i++ {
-		active := true
-		pack := model.PackFact{PackConfigID: &packConfigId, Active: &active}
-		packs[i] = &pack
+	return job, nil
+}
+
+func (packService *PackSvcImpl) GetPackGenerationJob(c context.Context, jobId uint64, vendorId string) (*model.PackGenerationJob, error) {
+	job, err := packService.packRepo.GetPackGenerationJob(c, jobId)
+	if err != nil {
+		return nil, err
+	}
+	if job.VendorID == nil || *job.VendorID != vendorId {
+		return nil, &core.ErrorResp{Message: "vendor does not have access to this job"}
+	}
+	return job, nil
+}
+
+// claims queued generation jobs from the DB one at a time. the queue lives in the DB so queued jobs survive a restart
+// and several instances can share it, jobs left running by a crashed worker are failed and their packs removed
+func (packService *PackSvcImpl) RunPackGenerationWorker(vendorService VendorService, notificationService NotificationService) {
+	ticker := time.NewTicker(packGenerationPollInterval())
+	defer ticker.Stop()
+	for {
+		c := context.Background()
+		packService.releaseScheduledPacks(c, vendorService, notificationService)
+		packService.failStalePackGenerationJobs(c)
+		for {
+			job, err := packService.packRepo.ClaimPackGenerationJob(c)
+			if err != nil {
+				core.AddBackgroundLog(logrus.Fields{"Error": err.Error()}, db.LOG_PACK_GENERATION_ERROR)
+				break
+			}
+			if job == nil {
+				break
+			}
+			packService.runPackGenerationJob(c, job, vendorService, notificationService)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-packService.generationWake:
+		}
 	}
-	packIds, err := packService.packRepo.UploadPacks(c, packs, packConfigId)
+}
+
+// puts approved packs on sale once their release date has passed and lets their waitlists know
+func (packService *PackSvcImpl) releaseScheduledPacks(c context.Context, vendorService VendorService, notificationService NotificationService) {
+	releasedBefore := time.Now().Format("2006-01-02 15:04:05")
+	releasedConfigs, err := packService.packRepo.ReleaseScheduledPackConfigs(c, releasedBefore)
 	if err != nil {
-		return &core.SvcError{Message: "An error occurred uploading packs to document store"}
+		fmt.Println("unable to release scheduled packs: ", err)
+		return
 	}
 
-	if len(packIds) != len(packItemIdBatch) {
-		return &core.SvcError{Message: fmt.Sprintf("Critical error: amount of packs uploaded and item id batches are not equal. Pack Config ID: %v", *packConfig.ID)}
+	vendorPackConfigIds := map[string][]uint64{}
+	for _, packConfig := range releasedConfigs {
+		vendorPackConfigIds[*packConfig.VendorID] = append(vendorPackConfigIds[*packConfig.VendorID], *packConfig.ID)
 	}
+	for vendorId, packConfigIds := range vendorPackConfigIds {
+		core.AddBackgroundLog(logrus.Fields{"CreatorId": vendorId, "PackConfigIds": packConfigIds}, db.LOG_PACK_RELEASE)
+
+		// clear pack config, vendor pack and vendor caches so the released packs show up
+		if err := packService.ClearPackConfigCache(c, packConfigIds, vendorId); err != nil {
+			fmt.Println(err)
+		}
+		if err := packService.ClearVendorPackCache(c, vendorId); err != nil {
+			fmt.Println(err)
+		}
+		if err := vendorService.ClearVendorCache(c, vendorId); err != nil {
+			fmt.Println(err)
+		}
 
-	// 5. build and upload pack item fact records
-	items := []*model.PackItemFact{}
-	for i, itemIds := range packItemIdBatch {
-		for _, v := range itemIds {
-			id := v
-			item := model.PackItemFact{PackID: &packIds[i], ItemID: &id}
-			items = append(items, &item)
+		for _, packConfigId := range packConfigIds {
+			packService.notifyPackWaitlist(c, packConfigId, notificationService)
 		}
 	}
-	err = packService.packRepo.UploadPackItems(c, items)
+}
+
+// fails running jobs that stopped recording progress, their worker died before it could clean up after itself
+func (packService *PackSvcImpl) failStalePackGenerationJobs(c context.Context) {
+	updatedBefore := time.Now().Add(-PACK_GENERATION_STALE_AFTER_SECONDS * time.Second).Format("2006-01-02 15:04:05")
+	staleJobs, err := packService.packRepo.GetStalePackGenerationJobs(c, updatedBefore)
 	if err != nil {
-		return err
+		core.AddBackgroundLog(logrus.Fields{"Error": err.Error()}, db.LOG_PACK_GENERATION_ERROR)
+		return
 	}
 
-	// clear vendor pack cache
-	err = packService.ClearVendorPackCache(c, vendorId)
+	for _, job := range staleJobs {
+		message := "generation was interrupted before it finished"
+		packIds, err := packService.packRepo.GetGeneratedPackIds(c, *job.ID)
+		if err != nil {
+			message = fmt.Sprintf("%v; its inactive packs could not be looked up: %v", message, err)
+		}
+		packService.failPackGenerationJob(c, job, packIds, message)
+	}
+}
+
+// generates the pack stock for a job in chunks, recording progress after every chunk.
+// packs are uploaded inactive and only activated once every chunk has been stored,
+// so a failed job is rolled back without any of its packs having been on sale
+func (packService *PackSvcImpl) runPackGenerationJob(c context.Context, job *model.PackGenerationJob, vendorService VendorService, notificationService NotificationService) {
+	jobId := *job.ID
+	packConfigId := *job.PackConfigID
+	vendorId := *job.VendorID
+
+	packConfig, err := packService.packRepo.GetPackConfig(c, packConfigId)
 	if err != nil {
-		return err
+		packService.failPackGenerationJob(c, job, nil, err.Error())
+		return
+	}
+	packItemConfigs, err := packService.packRepo.GetPackItemConfigs(c, packConfigId)
+	if err != nil {
+		packService.failPackGenerationJob(c, job, nil, err.Error())
+		return
 	}
 
-	// clear vendor cache
-	err = vendorService.ClearVendorCache(c, vendorId)
+	// generate list of item ids for every new pack based on the configs
+	packItemIdBatch, err := GeneratePackItemIds(c, packItemConfigs, packConfig)
 	if err != nil {
+		packService.failPackGenerationJob(c, job, nil, err.Error())
+		return
+	}
+	if len(packItemIdBatch) != *job.TotalPacks {
+		packService.failPackGenerationJob(c, job, nil, "An error occurred generating the item ids list")
+		return
+	}
+
+	// build and upload pack and pack item fact records chunk by chunk
+	chunkSize := packGenerationChunkSize()
+	packIds := []uint64{}
+	for start := 0; start < len(packItemIdBatch); start += chunkSize {
+		end := start + chunkSize
+		if end > len(packItemIdBatch) {
+			end = len(packItemIdBatch)
+		}
+
+		packs := make([]*model.PackFact, end-start)
+		for i := range packs {
+			active := false
+			pack := model.PackFact{PackConfigID: &packConfigId, Active: &active, GenerationJobID: &jobId}
+			packs[i] = &pack
+		}
+
+		chunkPackIds, err := packService.packRepo.UploadPackChunk(c, packs, packItemIdBatch[start:end])
+		if err != nil {
+			packService.failPackGenerationJob(c, job, packIds, err.Error())
+			return
+		}
+		packIds = append(packIds, chunkPackIds...)
+
+		if err := packService.packRepo.UpdatePackGenerationJob(c, *job.ID, map[string]interface{}{
+			"packs_uploaded": len(packIds),
+		}); err != nil {
+			packService.failPackGenerationJob(c, job, packIds, err.Error())
+			return
+		}
+	}
+
+	// every chunk is stored, put the packs on sale
+	if err := packService.packRepo.ActivateGeneratedPacks(c, jobId); err != nil {
+		packService.failPackGenerationJob(c, job, packIds, err.Error())
+		return
+	}
+
+	// clear pack config, vendor pack and vendor caches so the new stock shows up. the packs are already on sale,
+	// so a cache that could not be cleared is recorded on the finished job instead of failing it
+	cacheErrs := []string{}
+	if err := packService.ClearPackConfigCache(c, []uint64{packConfigId}, vendorId); err != nil {
+		cacheErrs = append(cacheErrs, err.Error())
+	}
+	if err := packService.ClearVendorPackCache(c, vendorId); err != nil {
+		cacheErrs = append(cacheErrs, err.Error())
+	}
+	if vendorService != nil {
+		if err := vendorService.ClearVendorCache(c, vendorId); err != nil {
+			cacheErrs = append(cacheErrs, err.Error())
+		}
+	}
+
+	finishedAt := time.Now().Format("2006-01-02 15:04:05")
+	doneUpdate := map[string]interface{}{
+		"status":      db.JOB_STATUS_DONE,
+		"finished_at": finishedAt,
+	}
+	if len(cacheErrs) > 0 {
+		message := "packs are on sale but caches could not be cleared: " + strings.Join(cacheErrs, "; ")
+		doneUpdate["error_message"] = message
+		logPackGenerationError(job, message)
+	}
+	if err := packService.packRepo.UpdatePackGenerationJob(c, *job.ID, doneUpdate); err != nil {
+		logPackGenerationError(job, fmt.Sprintf("packs are on sale but the job could not be marked as done: %v", err))
+	}
+
+	// let waitlisted users know the pack is back in stock
+	packService.notifyPackWaitlist(c, packConfigId, notificationService)
+}
+
+func (packService *PackSvcImpl) JoinPackWaitlist(c context.Context, uid string, packConfigId uint64) (*model.PackWaitlistEntry, error) {
+	return packService.packRepo.JoinPackWaitlist(c, uid, packConfigId)
+}
+
+func (packService *PackSvcImpl) LeavePackWaitlist(c context.Context, uid string, packConfigId uint64) error {
+	if err := packService.packRepo.LeavePackWaitlist(c, uid, packConfigId); err != nil {
 		return err
 	}
 
+	packConfig, err := packService.packRepo.GetPackConfig(c, packConfigId)
+	if err != nil {
+		return err
+	}
+	if packConfig.VendorID != nil {
+		return packService.packRepo.ClearPackWaitlistSizeCache(c, *packConfig.VendorID)
+	}
 	return nil
 }
 
+// notifies every waitlisted user once the pack is live and in stock.
+// errors are only printed since notifying never blocks a restock or release
+func (packService *PackSvcImpl) notifyPackWaitlist(c context.Context, packConfigId uint64, notificationService NotificationService) {
+	packConfig, err := packService.packRepo.GetPackConfig(c, packConfigId)
+	if err != nil {
+		fmt.Printf("error getting pack config %v for waitlist notifications: %v\n", packConfigId, err)
+		return
+	}
+	if packConfig.ID == nil ||
+		packConfig.DeletedAt != nil ||
+		packConfig.Active == nil || !*packConfig.Active ||
+		packConfig.CurrentStock == nil || *packConfig.CurrentStock <= 0 {
+		return
+	}
+
+	waitlistedUsers, err := packService.packRepo.GetPackWaitlist(c, packConfigId)
+	if err != nil {
+		fmt.Printf("error getting waitlist of pack config %v: %v\n", packConfigId, err)
+		return
+	}
+	if len(waitlistedUsers) == 0 {
+		return
+	}
+
+	notifiedIds := make([]uint64, 0, len(waitlistedUsers))
+	notifiedUids := make([]string, 0, len(waitlistedUsers))
+	for _, waitlistedUser := range waitlistedUsers {
+		if waitlistedUser.Uid == nil {
+			continue
+		}
+		notifiedIds = append(notifiedIds, *waitlistedUser.ID)
+		notifiedUids = append(notifiedUids, *waitlistedUser.Uid)
+	}
+
+	if err := notificationService.NotifyPackWaitlist(c, packConfig, notifiedUids); err != nil {
+		fmt.Printf("error notifying waitlist of pack config %v: %v\n", packConfigId, err)
+		return
+	}
+	core.AddBackgroundLog(logrus.Fields{
+		"PackConfigId":  packConfigId,
+		"CreatorId":     *packConfig.VendorID,
+		"NotifiedUsers": len(notifiedUids),
+	}, db.LOG_PACK_WAITLIST_RESTOCK)
+
+	if err := packService.packRepo.MarkPackWaitlistNotified(c, packConfigId, notifiedIds); err != nil {
+		fmt.Printf("error marking waitlist of pack config %v as notified: %v\n", packConfigId, err)
+		return
+	}
+	if err := packService.packRepo.ClearPackWaitlistSizeCache(c, *packConfig.VendorID); err != nil {
+		fmt.Println(err)
+	}
+}
+
+// removes any packs a job uploaded and marks the job as failed
+func (packService *PackSvcImpl) failPackGenerationJob(c context.Context, job *model.PackGenerationJob, packIds []uint64, message string) {
+	if err := packService.packRepo.DeleteGeneratedPacks(c, packIds); err != nil {
+		message = fmt.Sprintf("%v; %v inactive packs could not be removed: %v", message, len(packIds), err)
+	}
+	logPackGenerationError(job, message)
+
+	finishedAt := time.Now().Format("2006-01-02 15:04:05")
+	if err := packService.packRepo.UpdatePackGenerationJob(c, *job.ID, map[string]interface{}{
+		"status":         db.JOB_STATUS_FAILED,
+		"error_message":  message,
+		"packs_uploaded": 0,
+		"finished_at":    finishedAt,
+	}); err != nil {
+		logPackGenerationError(job, fmt.Sprintf("the job could not be marked as failed: %v", err))
+	}
+}
+
+// records a generation job error in the background logs, next to the error message kept on the job row
+func logPackGenerationError(job *model.PackGenerationJob, message string) {
+	fields := logrus.Fields{"JobId": *job.ID, "Error": message}
+	if job.PackConfigID != nil {
+		fields["PackConfigId"] = *job.PackConfigID
+	}
+	if job.VendorID != nil {
+		fields["CreatorId"] = *job.VendorID
+	}
+	core.AddBackgroundLog(fields, db.LOG_PACK_GENERATION_ERROR)
+}
+
+func packGenerationPollInterval() time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv("PACK_GENERATION_POLL_INTERVAL"))
+	if err != nil || seconds <= 0 {
+		seconds = DEFAULT_PACK_GENERATION_POLL_INTERVAL
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func packGenerationChunkSize() int {
+	chunkSize, err := strconv.Atoi(os.Getenv("PACK_GENERATION_CHUNK_SIZE"))
+	if err != nil || chunkSize <= 0 {
+		return DEFAULT_PACK_GENERATION_CHUNK_SIZE
+	}
+	return chunkSize
+}
+
 func (packService *PackSvcImpl) AddPackCategories(c context.Context, categories []*model.PackCategory) error {
 	if err := packService.ClearPackCategoryCache(c); err != nil {
 		return err
@@ -215,12 +686,18 @@ func (packService *PackSvcImpl) GetUserPackAmount(c context.Context, uid string)
 }
 
 // function that associates a new pack fact with a user (updates owner ID of next available pack)
-func (packService *PackSvcImpl) BuyPacks(c context.Context, uid string, packConfigId uint64, amount float64, tokenService TokenService) (*model.PackBoughtResp, error) {
+func (packService *PackSvcImpl) BuyPacks(c context.Context, uid string, packConfigId uint64, amount float64, gift *model.PackGift, tokenService TokenService, userService UserService) (*model.PackBoughtResp, error) {
 	if amount <= 0 {
 		err := &core.ErrorResp{Message: "cannot purchase 0 packs"}
 		return nil, err
 	}
 
+	if gift != nil {
+		if err := validatePackGift(c, uid, gift, userService); err != nil {
+			return nil, err
+		}
+	}
+
 	packConfig, err := packService.GetPackConfig(c, packConfigId)
 	if err != nil {
 		return nil, err
@@ -239,11 +716,21 @@ func (packService *PackSvcImpl) BuyPacks(c context.Context, uid string, packConf
 		return nil, err
 	}
 
-	resp, err := packService.packRepo.BuyPacks(c, uid, packConfig, amount, activeTokenRate)
+	resp, err := packService.packRepo.BuyPacks(c, uid, packConfig, amount, activeTokenRate, gift)
 	if err != nil {
 		return nil, err
 	}
 
+	// remove the gift recipient pack and gift caches
+	if gift != nil {
+		if err := packService.ClearUserPackCache(c, *gift.RecipientUid); err != nil {
+			return nil, err
+		}
+		if err := packService.packRepo.ClearGiftCache(c, *gift.RecipientUid); err != nil {
+			return nil, err
+		}
+	}
+
 	// invalidate user token balance cache
 	if err := tokenService.ClearUserTokenCache(c, uid); err != nil {
 		return nil, err
@@ -267,11 +754,113 @@ func (packService *PackSvcImpl) BuyPacks(c context.Context, uid string, packConf
 	return resp, nil
 }
 
+// returns the exact token cost of buying an amount of packs with the pack config price tiers applied
+func (packService *PackSvcImpl) QuotePacks(c context.Context, packConfigId uint64, amount int) (*model.PackQuote, error) {
+	if amount <= 0 {
+		return nil, &core.ErrorResp{Message: "cannot quote 0 packs"}
+	}
+
+	packConfig, err := packService.GetPackConfig(c, packConfigId)
+	if err != nil {
+		return nil, err
+	}
+	if packConfig.TokenAmount == nil {
+		return nil, &core.ErrorResp{Message: "critical error; pack config has null token amount"}
+	}
+
+	priceTiers, err := packService.packRepo.GetPackPriceTiers(c, packConfigId)
+	if err != nil {
+		return nil, err
+	}
+
+	baseTotal := core.RoundTokenAmount(float64(amount) * *packConfig.TokenAmount)
+	totalTokenAmount := core.TieredPackPrice(amount, *packConfig.TokenAmount, priceTiers)
+	return &model.PackQuote{
+		PackConfigId:     packConfigId,
+		Amount:           amount,
+		BaseTokenAmount:  *packConfig.TokenAmount,
+		PackTokenAmount:  core.RoundTokenAmount(totalTokenAmount / float64(amount)),
+		TotalTokenAmount: totalTokenAmount,
+		Savings:          core.RoundTokenAmount(baseTotal - totalTokenAmount),
+	}, nil
+}
+
+func (packService *PackSvcImpl) GetPackPriceTiers(c context.Context, packConfigId uint64) ([]*model.PackPriceTier, error) {
+	return packService.packRepo.GetPackPriceTiers(c, packConfigId)
+}
+
+func (packService *PackSvcImpl) SetPackPriceTiers(c context.Context, packConfigId uint64, vendorId string, priceTiers []*model.PackPriceTier, vendorService VendorService) ([]*model.PackPriceTier, error) {
+	packConfig, err := packService.packRepo.GetPackConfig(c, packConfigId)
+	if err != nil {
+		return nil, err
+	}
+	if packConfig.TokenAmount == nil {
+		return nil, &core.SvcError{Message: "Data quality error; no token amount associated with pack"}
+	}
+	if err := core.ValidatePackPriceTiers(priceTiers, *packConfig.TokenAmount); err != nil {
+		return nil, err
+	}
+
+	if err := packService.packRepo.SetPackPriceTiers(c, packConfigId, vendorId, priceTiers); err != nil {
+		return nil, err
+	}
+
+	if err := packService.withdrawPackReview(c, packConfigId, vendorId, vendorService); err != nil {
+		return nil, err
+	}
+
+	// remove pack config cache
+	if err := packService.ClearPackConfigCache(c, []uint64{packConfigId}, vendorId); err != nil {
+		return nil, err
+	}
+
+	return packService.packRepo.GetPackPriceTiers(c, packConfigId)
+}
+
+// checks the gift recipient can receive packs from the buyer
+func validatePackGift(c context.Context, uid string, gift *model.PackGift, userService UserService) error {
+	if gift.RecipientUid == nil || *gift.RecipientUid == "" {
+		return &core.ErrorResp{Message: "gift recipient must be given"}
+	}
+	if *gift.RecipientUid == uid {
+		return &core.ErrorResp{Message: "cannot gift packs to yourself"}
+	}
+	if gift.Message != nil && len(*gift.Message) > MAX_GIFT_MESSAGE_LENGTH {
+		return &core.ErrorResp{Message: fmt.Sprintf("gift message cannot be longer than %v characters", MAX_GIFT_MESSAGE_LENGTH)}
+	}
+
+	recipient, err := userService.GetUser(c, *gift.RecipientUid, false)
+	if err != nil {
+		return err
+	}
+	// only active users are returned, so banned recipients cannot be gifted to
+	if recipient.Uid == nil {
+		return &core.ErrorResp{Message: "gift recipient does not exist or has been banned"}
+	}
+
+	// recipient must meet the age requirement
+	if recipient.Birthday == nil {
+		return &core.ErrorResp{Message: "gift recipient has not verified their age"}
+	}
+	age, err := core.AgeOn(*recipient.Birthday, time.Now())
+	if err != nil {
+		return &core.ErrorResp{Message: "gift recipient has not verified their age"}
+	}
+	if age < MIN_GIFT_RECIPIENT_AGE {
+		return &core.ErrorResp{Message: "gift recipient does not meet the age requirement"}
+	}
+	return nil
+}
+
+func (packService *PackSvcImpl) GetReceivedGifts(c context.Context, uid string) ([]*model.ReceivedGift, error) {
+	return packService.packRepo.GetReceivedGifts(c, uid)
+}
+
 func (packService *PackSvcImpl) GetPack(c context.Context, id uint64) (*model.Pack, error) {
 	return packService.packRepo.GetPack(c, id)
 }
 
-func (packService *PackSvcImpl) OpenPack(c context.Context, id uint64, uid string, itemService ItemService) (*model.Pack, error) {
+func (packService *PackSvcImpl) OpenPack(c context.Context, id uint64, uid string, itemService ItemService, watermarkService WatermarkService) (*model.Pack, error) {
 	if err := packService.ClearPackCache(c, id); err != nil {
 		return nil, err
 	}
@@ -308,11 +897,14 @@ func (packService *PackSvcImpl) OpenPack(c context.Context, id uint64, uid strin
 		}
 	}
 
-	pemKey, keyPairID, err := itemService.GetItemSignCreds(c)
+	// owners are served a copy of the content watermarked to them
+	watermarkService.WatermarkUrlBatch(c, uid, urlBatch)
+
+	signer, err := itemService.GetItemSigner(c)
 	if err != nil {
 		return nil, err
 	}
-	signedUrlBatch, err := core.SignUrlBatch(urlBatch, pemKey, keyPairID)
+	signedUrlBatch, err := core.SignUrlBatch(urlBatch, signer)
 	if err != nil {
 		return nil, err
 	}
@@ -322,20 +914,300 @@ func (packService *PackSvcImpl) OpenPack(c context.Context, id uint64, uid strin
 		item.ContentThumbUrl = signedUrlBatch[int(*item.ID)]["contentThumbUrl"]
 	}
 
-	itemIds := []uint64{}
-	for _, v := range pack.Items {
-		itemIds = append(itemIds, *v.ID)
-	}
-	err = itemService.AddUserItems(c, uid, itemIds)
+	err = itemService.AddUserItems(c, uid, id, pack.Items)
 	if err != nil {
 		return nil, err
 	}
 	return pack, err
 }
 
+// opens a list of packs, or every unopened pack of a config up to MAX_BULK_OPEN_PACKS, in one transaction
+func (packService *PackSvcImpl) OpenPacks(c context.Context, uid string, packIds []uint64, packConfigId *uint64, itemService ItemService, watermarkService WatermarkService) (*model.OpenPacksResp, error) {
+	if len(packIds) == 0 && packConfigId == nil {
+		return nil, &core.ErrorResp{Message: "pack ids or a pack config id must be given"}
+	}
+
+	// dedupe the requested pack ids
+	uniquePackIds := []uint64{}
+	seen := map[uint64]bool{}
+	for _, id := range packIds {
+		if !seen[id] {
+			seen[id] = true
+			uniquePackIds = append(uniquePackIds, id)
+		}
+	}
+	if len(uniquePackIds) > MAX_BULK_OPEN_PACKS {
+		return nil, &core.ErrorResp{Message: fmt.Sprintf("cannot open more than %v packs at once", MAX_BULK_OPEN_PACKS)}
+	}
+
+	packs, err := packService.packRepo.OpenPacks(c, uid, uniquePackIds, packConfigId, MAX_BULK_OPEN_PACKS)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := itemService.ClearUserItemCache(c, uid); err != nil {
+		return nil, err
+	}
+
+	// build one batch url sign object for every opened pack
+	urlBatch := map[int]map[string]*string{}
+	for _, pack := range packs {
+		for _, item := range pack.Items {
+			if _, ok := urlBatch[int(*item.ID)]; !ok {
+				urlBatch[int(*item.ID)] = map[string]*string{
+					"contentMainUrl":  item.ContentMainUrl,
+					"contentThumbUrl": item.ContentThumbUrl,
+				}
+			}
+		}
+	}
+
+	// owners are served a copy of the content watermarked to them
+	watermarkService.WatermarkUrlBatch(c, uid, urlBatch)
+
+	signer, err := itemService.GetItemSigner(c)
+	if err != nil {
+		return nil, err
+	}
+	signedUrlBatch, err := core.SignUrlBatch(urlBatch, signer)
+	if err != nil {
+		return nil, err
+	}
+
+	rarities, err := itemService.GetRarities(c, "")
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &model.OpenPacksResp{Packs: []*model.OpenedPack{}}
+	totalRarityCounts := map[uint64]int{}
+	for _, pack := range packs {
+		packRarityCounts := map[uint64]int{}
+		for _, item := range pack.Items {
+			item.ContentMainUrl = signedUrlBatch[int(*item.ID)]["contentMainUrl"]
+			item.ContentThumbUrl = signedUrlBatch[int(*item.ID)]["contentThumbUrl"]
+			if item.RarityId != nil {
+				packRarityCounts[*item.RarityId]++
+				totalRarityCounts[*item.RarityId]++
+			}
+		}
+		resp.Packs = append(resp.Packs, &model.OpenedPack{Pack: pack, RaritySummary: RaritySummary(packRarityCounts, rarities)})
+		resp.ItemAmount += len(pack.Items)
+	}
+	resp.PackAmount = len(packs)
+	resp.RaritySummary = RaritySummary(totalRarityCounts, rarities)
+
+	return resp, nil
+}
+
+// converts rarity id counts into a list ordered from most common to rarest, rarities missing from the table
+// go last
+func RaritySummary(rarityCounts map[uint64]int, rarities []*model.Rarity) []*model.RarityCount {
+	rarityMap := map[uint64]*model.Rarity{}
+	for _, rarity := range rarities {
+		rarityMap[*rarity.ID] = rarity
+	}
+
+	summary := []*model.RarityCount{}
+	for rarityId, amount := range rarityCounts {
+		rarityCount := &model.RarityCount{RarityId: rarityId, Amount: amount}
+		if rarity, ok := rarityMap[rarityId]; ok {
+			rarityCount.Rarity = rarity.Rarity
+			rarityCount.Label = rarity.Label
+			rarityCount.Color = rarity.Color
+		}
+		summary = append(summary, rarityCount)
+	}
+
+	tiers := core.RarityTiers(rarities)
+	sort.Slice(summary, func(i, j int) bool {
+		tierI, okI := tiers[summary[i].RarityId]
+		tierJ, okJ := tiers[summary[j].RarityId]
+		if okI != okJ {
+			return okI
+		}
+		if tierI != tierJ {
+			return tierI < tierJ
+		}
+		return summary[i].RarityId < summary[j].RarityId
+	})
+	return summary
+}
+
+// opens a pack into a reveal session, or resumes the session the pack was already opened into
+func (packService *PackSvcImpl) StartPackReveal(c context.Context, packId uint64, uid string, itemService ItemService, watermarkService WatermarkService) (*model.PackRevealResp, error) {
+	session, err := packService.packRepo.GetPackRevealSessionByPack(c, packId)
+	if err != nil {
+		return nil, err
+	}
+
+	if session == nil {
+		session, err = packService.packRepo.StartPackReveal(c, packId, uid)
+		if err != nil {
+			return nil, err
+		}
+	} else if session.Uid == nil || *session.Uid != uid {
+		return nil, &core.ErrorResp{Message: "Error: user does not own this pack"}
+	}
+
+	return packService.buildPackRevealResp(c, session, uid, itemService, watermarkService)
+}
+
+// returns a reveal session with every slot revealed so far, used to resume a reveal
+func (packService *PackSvcImpl) GetPackReveal(c context.Context, sessionId uint64, uid string, itemService ItemService, watermarkService WatermarkService) (*model.PackRevealResp, error) {
+	session, err := packService.packRepo.GetPackRevealSession(c, sessionId)
+	if err != nil {
+		return nil, err
+	}
+	if session.Uid == nil || *session.Uid != uid {
+		return nil, &core.ErrorResp{Message: "user is not authorized to view this reveal session"}
+	}
+
+	return packService.buildPackRevealResp(c, session, uid, itemService, watermarkService)
+}
+
+// reveals the next slot of a session, crediting its item to the user and releasing its signed content
+func (packService *PackSvcImpl) RevealPackSlot(c context.Context, sessionId uint64, slot int, uid string, itemService ItemService, watermarkService WatermarkService) (*model.PackRevealSlotExpanded, error) {
+	if err := packService.packRepo.RevealPackSlot(c, sessionId, uid, slot); err != nil {
+		return nil, err
+	}
+	if err := itemService.ClearUserItemCache(c, uid); err != nil {
+		return nil, err
+	}
+
+	slots, err := packService.packRepo.GetPackRevealSlots(c, sessionId)
+	if err != nil {
+		return nil, err
+	}
+	if slot >= len(slots) {
+		return nil, &core.ErrorResp{Message: fmt.Sprintf("slot %v does not exist in this pack", slot)}
+	}
+
+	revealedSlot := []*model.PackRevealSlotExpanded{slots[slot]}
+	if err := signPackRevealSlots(c, uid, revealedSlot, itemService, watermarkService); err != nil {
+		return nil, err
+	}
+	return revealedSlot[0], nil
+}
+
+// periodically finishes reveal sessions that were abandoned part way through, crediting the items
+// that were never revealed
+func (packService *PackSvcImpl) runPackRevealSweeper() {
+	ticker := time.NewTicker(packRevealSweepInterval())
+	defer ticker.Stop()
+	for range ticker.C {
+		packService.completeExpiredPackReveals(context.Background())
+	}
+}
+
+func (packService *PackSvcImpl) completeExpiredPackReveals(c context.Context) {
+	createdBefore := time.Now().Add(-PACK_REVEAL_EXPIRY_SECONDS * time.Second).Format("2006-01-02 15:04:05")
+	for {
+		uids, err := packService.packRepo.CompleteExpiredPackReveals(c, createdBefore, PACK_REVEAL_SWEEP_BATCH_SIZE)
+		if err != nil {
+			fmt.Println("unable to complete expired pack reveals: ", err)
+			return
+		}
+		if len(uids) == 0 {
+			return
+		}
+	}
+}
+
+func packRevealSweepInterval() time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv("PACK_REVEAL_SWEEP_INTERVAL"))
+	if err != nil || seconds <= 0 {
+		seconds = DEFAULT_PACK_REVEAL_SWEEP_INTERVAL
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// builds the reveal response; only rarity hints are given for slots that have not been revealed yet
+func (packService *PackSvcImpl) buildPackRevealResp(c context.Context, session *model.PackRevealSession, uid string, itemService ItemService, watermarkService WatermarkService) (*model.PackRevealResp, error) {
+	slots, err := packService.packRepo.GetPackRevealSlots(c, *session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &model.PackRevealResp{
+		Session:       session,
+		RarityHints:   []*uint64{},
+		RevealedItems: []*model.PackRevealSlotExpanded{},
+	}
+	for _, slot := range slots {
+		resp.RarityHints = append(resp.RarityHints, slot.RarityId)
+		if slot.RevealedAt != nil {
+			resp.RevealedItems = append(resp.RevealedItems, slot)
+		}
+	}
+
+	if err := signPackRevealSlots(c, uid, resp.RevealedItems, itemService, watermarkService); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// revealed items are served to their owner, so like opened packs they carry the owners watermark
+func signPackRevealSlots(c context.Context, uid string, slots []*model.PackRevealSlotExpanded, itemService ItemService, watermarkService WatermarkService) error {
+	if len(slots) == 0 {
+		return nil
+	}
+
+	urlBatch := map[int]map[string]*string{}
+	for _, slot := range slots {
+		if _, ok := urlBatch[int(*slot.ItemId)]; !ok {
+			urlBatch[int(*slot.ItemId)] = map[string]*string{
+				"contentMainUrl":  slot.ContentMainUrl,
+				"contentThumbUrl": slot.ContentThumbUrl,
+			}
+		}
+	}
+
+	watermarkService.WatermarkUrlBatch(c, uid, urlBatch)
+
+	signer, err := itemService.GetItemSigner(c)
+	if err != nil {
+		return err
+	}
+	signedUrlBatch, err := core.SignUrlBatch(urlBatch, signer)
+	if err != nil {
+		return err
+	}
+
+	for _, slot := range slots {
+		slot.ContentMainUrl = signedUrlBatch[int(*slot.ItemId)]["contentMainUrl"]
+		slot.ContentThumbUrl = signedUrlBatch[int(*slot.ItemId)]["contentThumbUrl"]
+	}
+	return nil
+}
+
+// the item configs must hold at least enough items to fill every slot of every pack, otherwise the last packs
+// would be generated and sold empty
+func validatePackItemPool(packItemConfigs []*model.PackItemConfig, packConfig *model.PackConfig) error {
+	if packConfig.Qty == nil || packConfig.ItemQty == nil {
+		return &core.SvcError{Message: "Data quality error; pack qty and item qty must be populated"}
+	}
+	poolSize := 0
+	for _, v := range packItemConfigs {
+		if v.Qty != nil {
+			poolSize += *v.Qty
+		}
+	}
+	if slots := *packConfig.Qty * *packConfig.ItemQty; poolSize < slots {
+		return &core.SvcError{
+			Message: fmt.Sprintf("The pack items only fill %v of the %v item slots needed for %v packs", poolSize, slots, *packConfig.Qty),
+		}
+	}
+	return nil
+}
+
 // function to randomly generate item ids to associate with a new pack instance that a customer purchases
 // runtime = O(N*M) where N = pack config item qty, M = possible items in pack
 func GeneratePackItemIds(c context.Context, packItemConfigs []*model.PackItemConfig, packConfig *model.PackConfig) ([][]uint64, error) {
+	if err := validatePackItemPool(packItemConfigs, packConfig); err != nil {
+		return nil, err
+	}
+
 	packItemIdBatch := [][]uint64{}
 	itemIdPool := []uint64{}
 
@@ -352,12 +1224,10 @@ func GeneratePackItemIds(c context.Context, packItemConfigs []*model.PackItemCon
 	for i := 0; i < *packConfig.Qty; i++ {
 		packItemIds := []uint64{}
 		for j := 0; j < *packConfig.ItemQty; j++ {
-			if len(itemIdPool) != 0 {
-				rng := rand.New(rand.NewSource(time.Now().UnixNano()))
-				randNum := rng.Intn(len(itemIdPool))
-				packItemIds = append(packItemIds, itemIdPool[randNum])
-				itemIdPool = append(itemIdPool[:randNum], itemIdPool[randNum+1:]...)
-			}
+			rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+			randNum := rng.Intn(len(itemIdPool))
+			packItemIds = append(packItemIds, itemIdPool[randNum])
+			itemIdPool = append(itemIdPool[:randNum], itemIdPool[randNum+1:]...)
 		}
 		packItemIdBatch = append(packItemIdBatch, packItemIds)
 	}
@@ -395,11 +1265,11 @@ func (packService *PackSvcImpl) GetPackItems(c context.Context, id uint64, itemS
 		}
 	}
 
-	pemKey, keyPairID, err := itemService.GetItemSignCreds(c)
+	signer, err := itemService.GetItemSigner(c)
 	if err != nil {
 		return nil, err
 	}
-	signedUrlBatch, err := core.SignUrlBatch(urlBatch, pemKey, keyPairID)
+	signedUrlBatch, err := core.SignUrlBatch(urlBatch, signer)
 	if err != nil {
 		return nil, err
 	}
@@ -412,8 +1282,20 @@ func (packService *PackSvcImpl) GetPackItems(c context.Context, id uint64, itemS
 	return packItems, nil
 }
 
+// locked items show their pixelated teaser, items uploaded before teasers existed fall back to the generic preview
 func (packService *PackSvcImpl) GetPackItemsPreview2(c context.Context, packConfigId uint64) ([]*model.PackItemPreview, error) {
-	return packService.packRepo.GetPackItemsPreview(c, packConfigId)
+	previews, err := packService.packRepo.GetPackItemsPreview(c, packConfigId)
+	if err != nil {
+		return nil, err
+	}
+
+	genericPreview := os.Getenv("ITEM_PREVIEW_THUMB_URL")
+	for _, preview := range previews {
+		if preview.PreviewUrl == nil {
+			preview.PreviewUrl = &genericPreview
+		}
+	}
+	return previews, nil
 }
 
 func (packService *PackSvcImpl) GetPackItemsPreview(c context.Context, id uint64, itemService ItemService) ([]*model.PackItemConfigExpanded, error) {
@@ -433,11 +1315,11 @@ func (packService *PackSvcImpl) GetPackItemsPreview(c context.Context, id uint64
 		}
 	}
 
-	pemKey, keyPairID, err := itemService.GetItemSignCreds(c)
+	signer, err := itemService.GetItemSigner(c)
 	if err != nil {
 		return nil, err
 	}
-	signedUrlBatch, err := core.SignUrlBatch(urlBatch, pemKey, keyPairID)
+	signedUrlBatch, err := core.SignUrlBatch(urlBatch, signer)
 	if err != nil {
 		return nil, err
 	}
@@ -458,9 +1340,31 @@ func (packService *PackSvcImpl) GetPacksContainingItems(c context.Context, itemI
 	return packService.packRepo.GetPacksContainingItems(c, itemIds)
 }
 
-func (packService *PackSvcImpl) PatchPackConfig(c context.Context, packConfigId uint64, packConfigPatchMap map[string]interface{}, vendorId string) (*model.PackConfig, error) {
+func (packService *PackSvcImpl) PatchPackConfig(c context.Context, packConfigId uint64, packConfigPatchMap map[string]interface{}, vendorId string, vendorService VendorService) (*model.PackConfig, error) {
 	packConfigPatchMap = core.ConvertJSONMapToDBMap(packConfigPatchMap, model.PackConfig{})
 
+	// review state and activation are only changed through the moderation workflow
+	for _, key := range []string{"active", "review_status", "review_notes", "submitted_at", "reviewed_at", "reviewed_by"} {
+		if _, ok := packConfigPatchMap[key]; ok {
+			return nil, &core.ErrorResp{Message: fmt.Sprintf("%v cannot be patched directly", key)}
+		}
+	}
+
+	// a lower pack price cannot leave the price tiers costing more than buying packs one at a time
+	if rawTokenAmount, ok := packConfigPatchMap["token_amount"]; ok {
+		tokenAmount, ok := rawTokenAmount.(float64)
+		if !ok {
+			return nil, &core.ErrorResp{Message: "token amount must be a number"}
+		}
+		priceTiers, err := packService.packRepo.GetPackPriceTiers(c, packConfigId)
+		if err != nil {
+			return nil, err
+		}
+		if err := core.ValidatePackPriceTiers(priceTiers, tokenAmount); err != nil {
+			return nil, err
+		}
+	}
+
 	// // converting release date time to UTC for scheduler job
 	// if packConfigPatchMap["releaseAt"] != nil {
 	// 	parsedTime, err := time.Parse(time.RFC3339, packConfigPatchMap["releaseAt"].(string))
@@ -476,6 +1380,10 @@ func (packService *PackSvcImpl) PatchPackConfig(c context.Context, packConfigId
 		return nil, err
 	}
 
+	if err := packService.withdrawPackReview(c, packConfigId, vendorId, vendorService); err != nil {
+		return nil, err
+	}
+
 	// clear pack config
 	err = packService.ClearPackConfigCache(c, []uint64{packConfigId}, vendorId)
 	if err != nil {
@@ -494,7 +1402,7 @@ func (packService *PackSvcImpl) RemoveUserPacks(c context.Context, packIds []uin
 	return nil
 }
 
-func (packService *PackSvcImpl) ActivatePacks(c context.Context, packConfigIds []uint64, vendorId string, vendorService VendorService) error {
+func (packService *PackSvcImpl) ActivatePacks(c context.Context, packConfigIds []uint64, vendorId string, vendorService VendorService, notificationService NotificationService) error {
 	err := packService.packRepo.ActivatePacks(c, packConfigIds, vendorId)
 	if err != nil {
 		return err
@@ -509,9 +1417,81 @@ func (packService *PackSvcImpl) ActivatePacks(c context.Context, packConfigIds [
 	if err := vendorService.ClearVendorCache(c, vendorId); err != nil {
 		return err
 	}
+
+	// clear pack config cache so the waitlist sees the activated packs
+	if err := packService.ClearPackConfigCache(c, packConfigIds, vendorId); err != nil {
+		return err
+	}
+
+	// let waitlisted users know the packs went live
+	for _, packConfigId := range packConfigIds {
+		packService.notifyPackWaitlist(c, packConfigId, notificationService)
+	}
 	return nil
 }
 
+func (packService *PackSvcImpl) SubmitPackConfigs(c context.Context, packConfigIds []uint64, vendorId string) error {
+	if err := packService.packRepo.SubmitPackConfigs(c, packConfigIds, vendorId); err != nil {
+		return err
+	}
+	return packService.ClearPackConfigCache(c, packConfigIds, vendorId)
+}
+
+func (packService *PackSvcImpl) GetSubmittedPackConfigs(c context.Context) ([]*model.PackConfig, error) {
+	return packService.packRepo.GetPackConfigsByReviewStatus(c, db.PACK_REVIEW_SUBMITTED)
+}
+
+// gathers everything an admin needs to review a pack: the config, its items and the pull odds
+func (packService *PackSvcImpl) GetPackReview(c context.Context, packConfigId uint64, itemService ItemService) (*model.PackReview, error) {
+	packConfig, err := packService.packRepo.GetPackConfig(c, packConfigId)
+	if err != nil {
+		return nil, err
+	}
+	if packConfig.ID == nil {
+		return nil, &core.ErrorResp{Message: "pack config does not exist"}
+	}
+
+	items, err := packService.GetPackItems(c, packConfigId, itemService)
+	if err != nil {
+		return nil, err
+	}
+
+	odds, err := packService.packRepo.GetPackItemsPreview(c, packConfigId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.PackReview{PackConfig: packConfig, Items: items, Odds: odds}, nil
+}
+
+func (packService *PackSvcImpl) ReviewPackConfig(c context.Context, packConfigId uint64, approve bool, notes *string, adminUid string) (*model.PackConfig, error) {
+	status := db.PACK_REVIEW_APPROVED
+	if !approve {
+		status = db.PACK_REVIEW_REJECTED
+		if notes == nil || *notes == "" {
+			return nil, &core.ErrorResp{Message: "notes are required when rejecting a pack"}
+		}
+	}
+
+	packConfig, err := packService.packRepo.GetPackConfig(c, packConfigId)
+	if err != nil {
+		return nil, err
+	}
+	if packConfig.ID == nil || packConfig.VendorID == nil {
+		return nil, &core.ErrorResp{Message: "pack config does not exist"}
+	}
+
+	if err := packService.packRepo.ReviewPackConfig(c, packConfigId, status, notes, adminUid); err != nil {
+		return nil, err
+	}
+
+	if err := packService.ClearPackConfigCache(c, []uint64{packConfigId}, *packConfig.VendorID); err != nil {
+		return nil, err
+	}
+
+	return packService.packRepo.GetPackConfig(c, packConfigId)
+}
+
 func (packService *PackSvcImpl) DeactivatePacks(c context.Context, packConfigIds []uint64, vendorId string, vendorService VendorService) error {
 	err := packService.packRepo.DeactivatePacks(c, packConfigIds, vendorId)
 	if err != nil {
@@ -594,13 +1574,24 @@ func (packService *PackSvcImpl) GeneratePackItemOdds(c context.Context, vendorId
 		return nil, err
 	}
 
-	// sort the items in asc order on rarity ids
-	sort.Slice(items, func(i, j int) bool {
-		return *items[i].RarityId < *items[j].RarityId
+	rarities, err := itemService.GetRarities(c, "")
+	if err != nil {
+		return nil, err
+	}
+	rarityTiers := core.RarityTiers(rarities)
+	for _, item := range items {
+		if item.RarityId == nil {
+			return nil, &core.ErrorResp{Message: fmt.Sprintf("item %v has no rarity", *item.ID)}
+		}
+	}
+
+	// sort the items from most common to rarest
+	sort.SliceStable(items, func(i, j int) bool {
+		return rarityTiers[*items[i].RarityId] < rarityTiers[*items[j].RarityId]
 	})
 
 	// generate odds for items
-	itemOddsMap, err := packService.packRepo.GeneratePackItemOdds(c, items, totalItems)
+	itemOddsMap, err := packService.packRepo.GeneratePackItemOdds(c, items, totalItems, rarityTiers)
 	if err != nil {
 		return nil, err
 	}
@@ -2,14 +2,27 @@ package service
 
 import (
 	"context"
+	"slices"
+	"strings"
+	"xo-packs/core"
+	"xo-packs/db"
 	"xo-packs/model"
 	"xo-packs/repository"
 )
 
+var shippingInfoPatchColumns = []string{"name", "street_address1", "street_address2", "city", "state", "zip", "country"}
+
+var withdrawalStatuses = []string{db.WITHDRAWAL_REQUESTED, db.WITHDRAWAL_PACKED, db.WITHDRAWAL_SHIPPED, db.WITHDRAWAL_DELIVERED, db.WITHDRAWAL_CANCELLED}
+
 type ShippingService interface {
 	GetShippingInfo(context.Context, string) (*model.ShippingInfo, error)
 	UploadShippingInfo(context.Context, string, *model.ShippingInfo) (*model.ShippingInfo, error)
-	UpdateShippingInfo(context.Context, string) error
+	UpdateShippingInfo(context.Context, string, map[string]interface{}) (*model.ShippingInfo, error)
+	RequestWithdrawal(context.Context, uint64, string) (*model.ItemWithdrawalExpanded, error)
+	GetWithdrawal(context.Context, uint64) (*model.ItemWithdrawalExpanded, error)
+	GetUserWithdrawals(context.Context, string) ([]*model.ItemWithdrawalExpanded, error)
+	GetWithdrawalQueue(context.Context, string, string) ([]*model.ItemWithdrawalExpanded, error)
+	UpdateWithdrawalStatus(context.Context, uint64, string, string, string, *model.WithdrawalStatusReq) (*model.ItemWithdrawal, error)
 }
 
 type ShippingSvcImpl struct {
@@ -25,9 +38,71 @@ func (service *ShippingSvcImpl) GetShippingInfo(c context.Context, uid string) (
 }
 
 func (service *ShippingSvcImpl) UploadShippingInfo(c context.Context, uid string, shippingInfo *model.ShippingInfo) (*model.ShippingInfo, error) {
+	required := []*string{shippingInfo.Name, shippingInfo.StreetAddress1, shippingInfo.City, shippingInfo.State, shippingInfo.Zip}
+	for _, field := range required {
+		if field == nil || strings.TrimSpace(*field) == "" {
+			return nil, &core.ErrorResp{Message: "name, street address, city, state and zip must be given"}
+		}
+	}
 	return service.shippingRepo.UploadShippingInfo(c, uid, shippingInfo)
 }
 
-func (service *ShippingSvcImpl) UpdateShippingInfo(c context.Context, uid string) error {
-	return service.shippingRepo.UpdateShippingInfo(c, uid)
+func (service *ShippingSvcImpl) UpdateShippingInfo(c context.Context, uid string, shippingInfoPatchMap map[string]interface{}) (*model.ShippingInfo, error) {
+	dbPatchMap := core.ConvertJSONMapToDBMap(shippingInfoPatchMap, model.ShippingInfo{})
+	if len(dbPatchMap) == 0 {
+		return nil, &core.ErrorResp{Message: "at least one shipping info field must be given"}
+	}
+	for column, value := range dbPatchMap {
+		if !slices.Contains(shippingInfoPatchColumns, column) {
+			return nil, &core.ErrorResp{Message: "shipping info field " + column + " cannot be updated"}
+		}
+		if column == "street_address2" {
+			continue
+		}
+		if str, ok := value.(string); !ok || strings.TrimSpace(str) == "" {
+			return nil, &core.ErrorResp{Message: "shipping info field " + column + " cannot be empty"}
+		}
+	}
+	return service.shippingRepo.UpdateShippingInfo(c, uid, dbPatchMap)
+}
+
+func (service *ShippingSvcImpl) RequestWithdrawal(c context.Context, userItemId uint64, uid string) (*model.ItemWithdrawalExpanded, error) {
+	withdrawalId, err := service.shippingRepo.RequestWithdrawal(c, userItemId, uid)
+	if err != nil {
+		return nil, err
+	}
+	return service.shippingRepo.GetWithdrawal(c, *withdrawalId)
+}
+
+func (service *ShippingSvcImpl) GetWithdrawal(c context.Context, withdrawalId uint64) (*model.ItemWithdrawalExpanded, error) {
+	return service.shippingRepo.GetWithdrawal(c, withdrawalId)
+}
+
+func (service *ShippingSvcImpl) GetUserWithdrawals(c context.Context, uid string) ([]*model.ItemWithdrawalExpanded, error) {
+	return service.shippingRepo.GetUserWithdrawals(c, uid)
+}
+
+func (service *ShippingSvcImpl) GetWithdrawalQueue(c context.Context, vendorId string, status string) ([]*model.ItemWithdrawalExpanded, error) {
+	if status != "" && !slices.Contains(withdrawalStatuses, status) {
+		return nil, &core.ErrorResp{Message: "status param must be one of " + strings.Join(withdrawalStatuses, ", ")}
+	}
+	return service.shippingRepo.GetWithdrawalQueue(c, vendorId, status)
+}
+
+func (service *ShippingSvcImpl) UpdateWithdrawalStatus(c context.Context, withdrawalId uint64, vendorId string, uid string, actorUid string, req *model.WithdrawalStatusReq) (*model.ItemWithdrawal, error) {
+	if req.Status == nil || !slices.Contains(withdrawalStatuses, *req.Status) {
+		return nil, &core.ErrorResp{Message: "status must be one of " + strings.Join(withdrawalStatuses, ", ")}
+	}
+	if req.Carrier != nil {
+		carrier := strings.TrimSpace(*req.Carrier)
+		req.Carrier = &carrier
+	}
+	if req.TrackingNumber != nil {
+		trackingNumber := strings.TrimSpace(*req.TrackingNumber)
+		req.TrackingNumber = &trackingNumber
+	}
+	if (req.Carrier != nil && *req.Carrier == "") || (req.TrackingNumber != nil && *req.TrackingNumber == "") {
+		return nil, &core.ErrorResp{Message: "carrier and tracking number cannot be empty"}
+	}
+	return service.shippingRepo.UpdateWithdrawalStatus(c, withdrawalId, vendorId, uid, actorUid, req)
 }
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"time"
+	"xo-packs/core"
+	"xo-packs/db"
+	"xo-packs/model"
+	"xo-packs/repository"
+)
+
+const (
+	TRADE_OFFER_EXPIRY_HOURS = 72
+	MAX_TRADE_OFFER_ITEMS    = 20
+)
+
+type TradeService interface {
+	CreateTradeOffer(context.Context, string, *model.TradeOfferReq, UserService) (*model.TradeOfferExpanded, error)
+	CounterTradeOffer(context.Context, uint64, string, *model.TradeOfferReq) (*model.TradeOfferExpanded, error)
+	AcceptTradeOffer(context.Context, uint64, string, ItemService, TokenService) (*model.TradeOfferExpanded, error)
+	DeclineTradeOffer(context.Context, uint64, string) (*model.TradeOfferExpanded, error)
+	CancelTradeOffer(context.Context, uint64, string) (*model.TradeOfferExpanded, error)
+	GetTradeOffer(context.Context, uint64, string) (*model.TradeOfferExpanded, error)
+	GetTradeHistory(context.Context, string, string) ([]*model.TradeOfferExpanded, error)
+}
+
+type TradeSvcImpl struct {
+	tradeRepo repository.TradeRepository
+}
+
+func NewTradeService(repo repository.TradeRepository) TradeService {
+	return &TradeSvcImpl{tradeRepo: repo}
+}
+
+func (tradeService *TradeSvcImpl) CreateTradeOffer(c context.Context, uid string, req *model.TradeOfferReq, userService UserService) (*model.TradeOfferExpanded, error) {
+	if req.RecipientUid == nil || *req.RecipientUid == "" {
+		return nil, &core.ErrorResp{Message: "a recipient uid must be given"}
+	}
+	if *req.RecipientUid == uid {
+		return nil, &core.ErrorResp{Message: "users cannot trade with themselves"}
+	}
+
+	recipient, err := userService.GetUser(c, *req.RecipientUid, false)
+	if err != nil {
+		return nil, err
+	}
+	if recipient.Uid == nil {
+		return nil, &core.ErrorResp{Message: "trade recipient does not exist"}
+	}
+
+	offer, offeredIds, requestedIds, err := newTradeOffer(uid, req)
+	if err != nil {
+		return nil, err
+	}
+	offer.RecipientUid = req.RecipientUid
+	return tradeService.tradeRepo.CreateTradeOffer(c, offer, offeredIds, requestedIds)
+}
+
+// counters an offer. the counter offer goes back to the original sender, so its offered items are the
+// countering users items and its requested items belong to the original sender
+func (tradeService *TradeSvcImpl) CounterTradeOffer(c context.Context, offerId uint64, uid string, req *model.TradeOfferReq) (*model.TradeOfferExpanded, error) {
+	counter, offeredIds, requestedIds, err := newTradeOffer(uid, req)
+	if err != nil {
+		return nil, err
+	}
+	return tradeService.tradeRepo.CounterTradeOffer(c, offerId, counter, offeredIds, requestedIds)
+}
+
+func (tradeService *TradeSvcImpl) AcceptTradeOffer(c context.Context, offerId uint64, uid string, itemService ItemService, tokenService TokenService) (*model.TradeOfferExpanded, error) {
+	offer, err := tradeService.tradeRepo.AcceptTradeOffer(c, offerId, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	// clearing the collections and balances of both users
+	for _, tradeUid := range []string{*offer.SenderUid, *offer.RecipientUid} {
+		if err := itemService.ClearUserItemCache(c, tradeUid); err != nil {
+			return nil, err
+		}
+		if err := tokenService.ClearUserTokenCache(c, tradeUid); err != nil {
+			return nil, err
+		}
+	}
+	return offer, nil
+}
+
+func (tradeService *TradeSvcImpl) DeclineTradeOffer(c context.Context, offerId uint64, uid string) (*model.TradeOfferExpanded, error) {
+	return tradeService.tradeRepo.CloseTradeOffer(c, offerId, uid, db.TRADE_OFFER_DECLINED)
+}
+
+func (tradeService *TradeSvcImpl) CancelTradeOffer(c context.Context, offerId uint64, uid string) (*model.TradeOfferExpanded, error) {
+	return tradeService.tradeRepo.CloseTradeOffer(c, offerId, uid, db.TRADE_OFFER_CANCELLED)
+}
+
+func (tradeService *TradeSvcImpl) GetTradeOffer(c context.Context, offerId uint64, uid string) (*model.TradeOfferExpanded, error) {
+	offer, err := tradeService.tradeRepo.GetTradeOffer(c, offerId)
+	if err != nil {
+		return nil, err
+	}
+	if *offer.SenderUid != uid && *offer.RecipientUid != uid {
+		return nil, &core.ErrorResp{Message: "trade offer does not exist for authorized user"}
+	}
+	return offer, nil
+}
+
+func (tradeService *TradeSvcImpl) GetTradeHistory(c context.Context, uid string, status string) ([]*model.TradeOfferExpanded, error) {
+	return tradeService.tradeRepo.GetTradeHistory(c, uid, status)
+}
+
+// builds a pending offer from the sender with deduplicated item ids and its expiry set
+func newTradeOffer(uid string, req *model.TradeOfferReq) (*model.TradeOffer, []uint64, []uint64, error) {
+	offeredIds := uniqueIds(req.OfferedUserItemIds)
+	requestedIds := uniqueIds(req.RequestedUserItemIds)
+	if len(offeredIds) == 0 && len(requestedIds) == 0 {
+		return nil, nil, nil, &core.ErrorResp{Message: "a trade offer must include at least one item"}
+	}
+	if len(offeredIds)+len(requestedIds) > MAX_TRADE_OFFER_ITEMS {
+		return nil, nil, nil, &core.ErrorResp{Message: "too many items in trade offer"}
+	}
+
+	tokenAmount := 0.0
+	if req.TokenAmount != nil {
+		tokenAmount = core.RoundTokenAmount(*req.TokenAmount)
+	}
+	if tokenAmount < 0 {
+		return nil, nil, nil, &core.ErrorResp{Message: "trade offer token amount cannot be negative"}
+	}
+
+	now := time.Now()
+	createdAt := now.Format("2006-01-02 15:04:05")
+	expiresAt := now.Add(TRADE_OFFER_EXPIRY_HOURS * time.Hour).Format("2006-01-02 15:04:05")
+	offer := model.TradeOffer{
+		SenderUid:         &uid,
+		SenderTokenAmount: &tokenAmount,
+		Message:           req.Message,
+		CreatedAt:         &createdAt,
+		ExpiresAt:         &expiresAt,
+	}
+	return &offer, offeredIds, requestedIds, nil
+}
+
+func uniqueIds(ids []uint64) []uint64 {
+	seen := map[uint64]bool{}
+	unique := []uint64{}
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"testing"
+	"xo-packs/model"
+)
+
+func TestNewTradeOffer(t *testing.T) {
+	tokens := 12.345
+	offer, offeredIds, requestedIds, err := newTradeOffer("sender", &model.TradeOfferReq{
+		OfferedUserItemIds:   []uint64{1, 2, 2, 3},
+		RequestedUserItemIds: []uint64{4, 4},
+		TokenAmount:          &tokens,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(offeredIds) != 3 || len(requestedIds) != 1 {
+		t.Errorf("expected duplicate item ids to be dropped, got %v and %v", offeredIds, requestedIds)
+	}
+	if *offer.SenderTokenAmount != 12.35 {
+		t.Errorf("expected token amount to be rounded to 12.35, got %v", *offer.SenderTokenAmount)
+	}
+	if offer.ExpiresAt == nil || *offer.ExpiresAt <= *offer.CreatedAt {
+		t.Errorf("expected the offer to expire after it was created")
+	}
+
+	if _, _, _, err := newTradeOffer("sender", &model.TradeOfferReq{}); err == nil {
+		t.Errorf("expected an offer without items to be rejected")
+	}
+
+	negative := -1.0
+	if _, _, _, err := newTradeOffer("sender", &model.TradeOfferReq{OfferedUserItemIds: []uint64{1}, TokenAmount: &negative}); err == nil {
+		t.Errorf("expected an offer with negative tokens to be rejected")
+	}
+}
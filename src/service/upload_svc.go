@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"mime/multipart"
+	"xo-packs/core"
+	"xo-packs/model"
+	"xo-packs/repository"
+)
+
+type UploadService interface {
+	UploadItemContent(context.Context, string, uint64, *multipart.FileHeader, *multipart.FileHeader, ItemService, PackService) (*model.UploadResp, error)
+	UploadPackArt(context.Context, string, uint64, *multipart.FileHeader, PackService) (*model.UploadResp, error)
+	UploadProfileImage(context.Context, string, *multipart.FileHeader, UserService, VendorService) (*model.UploadResp, error)
+	UploadBannerImage(context.Context, string, *multipart.FileHeader, UserService, VendorService) (*model.UploadResp, error)
+}
+
+type UploadSvcImpl struct {
+	uploadRepo repository.UploadRepository
+}
+
+func NewUploadService(uploadRepo repository.UploadRepository) UploadService {
+	return &UploadSvcImpl{uploadRepo: uploadRepo}
+}
+
+func (service *UploadSvcImpl) UploadItemContent(c context.Context, vendorId string, itemId uint64, mainFile *multipart.FileHeader, thumbFile *multipart.FileHeader, itemService ItemService, packService PackService) (*model.UploadResp, error) {
+	if mainFile == nil && thumbFile == nil {
+		return nil, &core.ErrorResp{Message: "a contentMain or contentThumb file must be given"}
+	}
+
+	var main, thumb *core.ValidatedUpload
+	if mainFile != nil {
+		upload, closeFile, err := openUpload(mainFile, core.UPLOAD_ITEM_CONTENT)
+		if err != nil {
+			return nil, err
+		}
+		defer closeFile()
+		main = upload
+	}
+	if thumbFile != nil {
+		upload, closeFile, err := openUpload(thumbFile, core.UPLOAD_ITEM_THUMB)
+		if err != nil {
+			return nil, err
+		}
+		defer closeFile()
+		thumb = upload
+	}
+
+	// derive thumbnails and the locked teaser from the creators thumbnail, or from the main content when it is
+	// an image. a video without a thumbnail leaves nothing to derive from
+	source := thumb
+	if source == nil && main != nil && core.ItemContentType(main.MimeType) == core.ITEM_CONTENT_IMAGE {
+		source = main
+	}
+	renditions := []*core.Rendition{}
+	if source != nil {
+		generated, err := core.GenerateRenditions(source)
+		if err != nil {
+			return nil, err
+		}
+		renditions = generated
+	}
+
+	item, itemRenditions, err := service.uploadRepo.UploadItemContent(c, vendorId, itemId, main, thumb, renditions)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := itemService.ClearItemCache(c, []uint64{itemId}); err != nil {
+		return nil, err
+	}
+	if err := itemService.ClearVendorItemCache(c, vendorId); err != nil {
+		return nil, err
+	}
+	if err := packService.ClearVendorPackCache(c, vendorId); err != nil {
+		return nil, err
+	}
+	return &model.UploadResp{ContentMainUrl: item.ContentMainUrl, ContentThumbUrl: item.ContentThumbUrl, ContentType: item.ContentType, Renditions: itemRenditions}, nil
+}
+
+func (service *UploadSvcImpl) UploadPackArt(c context.Context, vendorId string, packConfigId uint64, artFile *multipart.FileHeader, packService PackService) (*model.UploadResp, error) {
+	art, closeFile, err := openUpload(artFile, core.UPLOAD_PACK_ART)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFile()
+
+	packConfig, err := service.uploadRepo.UploadPackArt(c, vendorId, packConfigId, art)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := packService.ClearPackConfigCache(c, []uint64{packConfigId}, vendorId); err != nil {
+		return nil, err
+	}
+	if err := packService.ClearPackShopCache(c); err != nil {
+		return nil, err
+	}
+	return &model.UploadResp{ImageUrl: packConfig.ImageUrl}, nil
+}
+
+func (service *UploadSvcImpl) UploadProfileImage(c context.Context, uid string, imageFile *multipart.FileHeader, userService UserService, vendorService VendorService) (*model.UploadResp, error) {
+	image, closeFile, err := openUpload(imageFile, core.UPLOAD_PROFILE)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFile()
+
+	user, err := service.uploadRepo.UploadProfileImage(c, uid, image)
+	if err != nil {
+		return nil, err
+	}
+	if err := clearUploadedUserCache(c, user, userService, vendorService); err != nil {
+		return nil, err
+	}
+	return &model.UploadResp{ImageUrl: user.ImageUrl, Version: user.ProfileImgVersion}, nil
+}
+
+func (service *UploadSvcImpl) UploadBannerImage(c context.Context, uid string, imageFile *multipart.FileHeader, userService UserService, vendorService VendorService) (*model.UploadResp, error) {
+	image, closeFile, err := openUpload(imageFile, core.UPLOAD_BANNER)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFile()
+
+	user, err := service.uploadRepo.UploadBannerImage(c, uid, image)
+	if err != nil {
+		return nil, err
+	}
+	if err := clearUploadedUserCache(c, user, userService, vendorService); err != nil {
+		return nil, err
+	}
+	return &model.UploadResp{BannerUrl: user.BannerUrl, Version: user.BannerImgVersion}, nil
+}
+
+// opens, validates and strips the metadata of a single required upload, the returned func closes the file
+func openUpload(fileHeader *multipart.FileHeader, kind string) (*core.ValidatedUpload, func(), error) {
+	if fileHeader == nil {
+		return nil, nil, &core.ErrorResp{Message: "a file must be given"}
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, nil, err
+	}
+	upload, err := core.ValidateUpload(file, fileHeader.Size, kind)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	if err := core.StripMetadata(upload); err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	return upload, func() { file.Close() }, nil
+}
+
+// profile images show up on the vendor page too
+func clearUploadedUserCache(c context.Context, user *model.User, userService UserService, vendorService VendorService) error {
+	username := ""
+	if user.Username != nil {
+		username = *user.Username
+	}
+	if err := userService.ClearUserCache(c, *user.Uid, username); err != nil {
+		return err
+	}
+	return vendorService.ClearVendorCache(c, *user.Uid)
+}
@@ -22,11 +22,10 @@ type UserService interface {
 	GetUserByUid(context.Context, string) (*model.User, error)
 	GetUserByUsername(context.Context, string) (*model.User, error)
 	GetUserItem(context.Context, uint64) (*model.UserItem, error)
-	WithdrawalUserItem(context.Context, uint64, string) (*model.ItemWithdrawal, error)
 	PatchUser(context.Context, string, string, map[string]interface{}) (*model.User, error)
 	DeleteUser(context.Context, string, string) (*model.User, error)
 	GetUserPackPage(context.Context, string, uint64, string, string, string, string, string) (*model.UserPackPage, error)
-	GetUserItemPage(context.Context, string, uint64, string, string, string, string, string, ItemService) (*model.UserItemPage, error)
+	GetUserItemPage(context.Context, string, uint64, string, string, string, uint64, string, string, ItemService, WatermarkService) (*model.UserItemPage, error)
 	GetUserFavoritesPage(*gin.Context, string, uint64, string, string) (*model.UserFavoritePage, error)
 	ClearUserCache(context.Context, string, string) error
 	ClearFavoriteCache(context.Context, string, string) error
@@ -34,6 +33,8 @@ type UserService interface {
 	AddFavorite(context.Context, string, string, VendorService) (*model.Favorite, error)
 	RemoveFavorite(context.Context, string, string, VendorService) error
 	FlushCache(context.Context) error
+	GetSpendingLimit(context.Context, string) (*model.UserSpendingLimit, error)
+	SetSpendingLimit(context.Context, string, *model.UserSpendingLimit) (*model.UserSpendingLimit, error)
 }
 
 type UserSvcImpl struct {
@@ -162,38 +163,6 @@ func (userService *UserSvcImpl) GetUserItem(c context.Context, userItemId uint64
 	return userService.userRepo.GetUserItem(c, userItemId)
 }
 
-func (userService *UserSvcImpl) WithdrawalUserItem(c context.Context, userItemId uint64, uid string) (*model.ItemWithdrawal, error) {
-	userItem, err := userService.GetUserItem(c, userItemId)
-	if err != nil {
-		return nil, err
-	}
-
-	// validate authorized user is the owner of item
-	if userItem.Uid == nil || (userItem.Uid != nil && *userItem.Uid != uid) {
-		return nil, &core.ErrorResp{
-			Message: "user item does not exist for authorized user",
-		}
-	}
-
-	// create new withdrawal for user item
-	withdrawalId, err := userService.userRepo.WithdrawalUserItem(c, userItemId)
-	if err != nil {
-		return nil, err
-	}
-	if withdrawalId == nil {
-		return nil, &core.ErrorResp{
-			Message: "unable to withdrawal item at this time",
-		}
-	}
-
-	// get newly created withdrawal
-	itemWithdrawal, err := userService.userRepo.GetUserItemWithdrawal(c, *withdrawalId)
-	if err != nil {
-		return nil, err
-	}
-	return itemWithdrawal, nil
-}
-
 func (userService *UserSvcImpl) GetUserPackPage(
 	c context.Context, uid string, pageNumber uint64, sortBy string, sortDir string, filterOn string, searchStr string, urlPath string) (*model.UserPackPage, error) {
 
@@ -244,7 +213,7 @@ func (userService *UserSvcImpl) GetUserPackPage(
 }
 
 func (userService *UserSvcImpl) GetUserItemPage(
-	c context.Context, uid string, pageNumber uint64, sortBy string, sortDir string, filterOn string, searchStr string, urlPath string, itemService ItemService) (*model.UserItemPage, error) {
+	c context.Context, uid string, pageNumber uint64, sortBy string, sortDir string, filterOn string, rarityId uint64, searchStr string, urlPath string, itemService ItemService, watermarkService WatermarkService) (*model.UserItemPage, error) {
 
 	if filterOn != "" {
 		categories, err := userService.userRepo.GetUserItemCategories(c)
@@ -270,8 +239,18 @@ func (userService *UserSvcImpl) GetUserItemPage(
 		sortBy = sortMapping[sortBy] + " " + sortDir
 	}
 
+	if rarityId != 0 {
+		rarities, err := itemService.GetRarities(c, "")
+		if err != nil {
+			return nil, err
+		}
+		if !slices.ContainsFunc(rarities, func(rarity *model.Rarity) bool { return *rarity.ID == rarityId }) {
+			return nil, &core.ErrorResp{Message: "Rarity filter not valid"}
+		}
+	}
+
 	adjustedPageNum := pageNumber - 1
-	userItemPage, userItemAmount, err := userService.userRepo.GetUserItemPage(c, uid, adjustedPageNum, sortBy, filterOn, searchStr, urlPath)
+	userItemPage, userItemAmount, err := userService.userRepo.GetUserItemPage(c, uid, adjustedPageNum, sortBy, filterOn, rarityId, searchStr, urlPath)
 	if err != nil {
 		return nil, err
 	}
@@ -287,11 +266,14 @@ func (userService *UserSvcImpl) GetUserItemPage(
 		}
 	}
 
-	pemKey, keyPairID, err := itemService.GetItemSignCreds(c)
+	// owners are served a copy of the content watermarked to them
+	watermarkService.WatermarkUrlBatch(c, uid, urlBatch)
+
+	signer, err := itemService.GetItemSigner(c)
 	if err != nil {
 		return nil, err
 	}
-	signedUrlBatch, err := core.SignUrlBatch(urlBatch, pemKey, keyPairID)
+	signedUrlBatch, err := core.SignUrlBatch(urlBatch, signer)
 	if err != nil {
 		return nil, err
 	}
@@ -423,3 +405,23 @@ func (userService *UserSvcImpl) RemoveFavorite(c context.Context, uid string, ve
 func (userService *UserSvcImpl) FlushCache(c context.Context) error {
 	return userService.userRepo.FlushCache(c)
 }
+
+func (userService *UserSvcImpl) GetSpendingLimit(c context.Context, uid string) (*model.UserSpendingLimit, error) {
+	return userService.userRepo.GetSpendingLimit(c, uid)
+}
+
+// spending limits are set by admins so users cannot raise or remove their own
+func (userService *UserSvcImpl) SetSpendingLimit(c context.Context, uid string, spendingLimit *model.UserSpendingLimit) (*model.UserSpendingLimit, error) {
+	if spendingLimit.DailyTokenLimit != nil && *spendingLimit.DailyTokenLimit < 0 {
+		return nil, &core.ErrorResp{Message: "daily token limit cannot be negative"}
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	spendingLimit.Uid = &uid
+	spendingLimit.LastSpentAt = nil
+	spendingLimit.UpdatedAt = &now
+	if err := userService.userRepo.SetSpendingLimit(c, spendingLimit); err != nil {
+		return nil, err
+	}
+	return userService.userRepo.GetSpendingLimit(c, uid)
+}
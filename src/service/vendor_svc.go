@@ -286,11 +286,11 @@ func (vendorService *VendorSvcImpl) GetVendorItemListPage(
 		}
 	}
 
-	pemKey, keyPairID, err := itemService.GetItemSignCreds(c)
+	signer, err := itemService.GetItemSigner(c)
 	if err != nil {
 		return nil, err
 	}
-	signedUrlBatch, err := core.SignUrlBatch(urlBatch, pemKey, keyPairID)
+	signedUrlBatch, err := core.SignUrlBatch(urlBatch, signer)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"mime/multipart"
+	"os"
+	"sync"
+	"xo-packs/core"
+	"xo-packs/db"
+	"xo-packs/model"
+	"xo-packs/repository"
+)
+
+type WatermarkService interface {
+	WatermarkUrlBatch(context.Context, string, map[int]map[string]*string)
+	IdentifyLeak(context.Context, *multipart.FileHeader) (*model.ContentWatermark, error)
+}
+
+const (
+	WATERMARK_WORKERS    = 4
+	WATERMARK_QUEUE_SIZE = 512
+)
+
+type WatermarkSvcImpl struct {
+	watermarkRepo repository.WatermarkRepository
+	queue         chan watermarkTask
+	queued        sync.Map
+}
+
+type watermarkTask struct {
+	uid        string
+	itemId     uint64
+	contentUrl string
+}
+
+func NewWatermarkService(watermarkRepo repository.WatermarkRepository) WatermarkService {
+	service := &WatermarkSvcImpl{
+		watermarkRepo: watermarkRepo,
+		queue:         make(chan watermarkTask, WATERMARK_QUEUE_SIZE),
+	}
+	for i := 0; i < WATERMARK_WORKERS; i++ {
+		go service.runWatermarkWorker()
+	}
+	return service
+}
+
+// swaps the main content of each item in a url sign batch for the copy watermarked with the owners uid.
+// watermarks are made in the background, until one is ready the owner is served the thumbnail instead so
+// the clean original never leaves for owner-served content. content that cannot carry a watermark, like
+// videos, is marked skipped and served as is
+func (service *WatermarkSvcImpl) WatermarkUrlBatch(c context.Context, uid string, urlBatch map[int]map[string]*string) {
+	for itemId, urls := range urlBatch {
+		contentUrl := urls["contentMainUrl"]
+		if contentUrl == nil || *contentUrl == "" {
+			continue
+		}
+
+		watermark, err := service.watermarkRepo.GetWatermark(c, uid, uint64(itemId), *contentUrl)
+		if err != nil {
+			fmt.Println("unable to get watermark of item ", itemId, " for ", uid, ": ", err)
+		}
+		if watermark != nil && *watermark.Status == db.WATERMARK_READY {
+			urls["contentMainUrl"] = watermark.WatermarkedUrl
+			continue
+		}
+		if watermark != nil && *watermark.Status == db.WATERMARK_SKIPPED {
+			continue
+		}
+
+		urls["contentMainUrl"] = urls["contentThumbUrl"]
+		service.queueWatermark(watermarkTask{uid: uid, itemId: uint64(itemId), contentUrl: *contentUrl})
+	}
+}
+
+// a full queue or a watermark that is already queued is left for the next time the content is served
+func (service *WatermarkSvcImpl) queueWatermark(task watermarkTask) {
+	if _, queued := service.queued.LoadOrStore(task, true); queued {
+		return
+	}
+	select {
+	case service.queue <- task:
+	default:
+		service.queued.Delete(task)
+	}
+}
+
+func (service *WatermarkSvcImpl) runWatermarkWorker() {
+	for task := range service.queue {
+		if _, err := service.watermarkRepo.CreateWatermark(context.Background(), task.uid, task.itemId, task.contentUrl, watermarkSeed()); err != nil {
+			fmt.Println("unable to watermark item ", task.itemId, " for ", task.uid, ": ", err)
+		}
+		service.queued.Delete(task)
+	}
+}
+
+// reads the watermark out of a leaked image and looks up who it was issued to
+func (service *WatermarkSvcImpl) IdentifyLeak(c context.Context, imageFile *multipart.FileHeader) (*model.ContentWatermark, error) {
+	if imageFile == nil {
+		return nil, &core.ErrorResp{Message: "an image must be given"}
+	}
+	file, err := imageFile.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	upload, err := core.ValidateUpload(file, imageFile.Size, core.UPLOAD_LEAKED_IMAGE)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(upload.Body)
+	if err != nil {
+		return nil, &core.ErrorResp{Message: "image could not be decoded: " + err.Error()}
+	}
+
+	watermarkId, ok := core.ExtractWatermark(img, watermarkSeed())
+	if !ok {
+		return nil, &core.ErrorResp{Message: "no watermark could be read from the image, it may have been cropped or resized"}
+	}
+	return service.watermarkRepo.GetWatermarkById(c, uint64(watermarkId))
+}
+
+func watermarkSeed() uint64 {
+	return core.WatermarkSeed(os.Getenv("WATERMARK_SECRET"))
+}